@@ -0,0 +1,144 @@
+package dbresolver
+
+import (
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// breakerState is the state of the circuit breaker of a readable database.
+type breakerState int
+
+const (
+	// breakerClosed lets the reads through.
+	breakerClosed breakerState = iota
+	// breakerOpen keeps the reads away until the cooldown elapses.
+	breakerOpen
+	// breakerHalfOpen lets a single probe read through, whose result decides whether the breaker closes.
+	breakerHalfOpen
+)
+
+// circuitBreaker keeps the readable databases failing with the consecutive connection errors out of the reads.
+// A breaker of a database opens after the given number of the consecutive connection errors,
+// and it lets a probe read through once the cooldown elapses. The breaker closes if the probe succeeds,
+// and opens again otherwise.
+type circuitBreaker struct {
+	failures int
+	cooldown time.Duration
+	now      func() time.Time
+
+	mu       sync.Mutex
+	breakers map[*sqlx.DB]*dbBreaker
+}
+
+type dbBreaker struct {
+	state    breakerState
+	failures int
+	openedAt time.Time
+}
+
+func newCircuitBreaker(failures int, cooldown time.Duration, now func() time.Time) *circuitBreaker {
+	return &circuitBreaker{
+		failures: failures,
+		cooldown: cooldown,
+		now:      now,
+		breakers: make(map[*sqlx.DB]*dbBreaker),
+	}
+}
+
+// allowedDBs returns the given databases whose breaker lets the reads through.
+// The databases whose breaker is open within the cooldown or waiting for the result of the probe are excluded.
+func (b *circuitBreaker) allowedDBs(dbs []*sqlx.DB) []*sqlx.DB {
+	if b == nil {
+		return dbs
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(b.breakers) == 0 {
+		return dbs
+	}
+	now := b.now()
+	allowed := make([]*sqlx.DB, 0, len(dbs))
+	for _, db := range dbs {
+		if b.allows(b.breakers[db], now) {
+			allowed = append(allowed, db)
+		}
+	}
+	return allowed
+}
+
+func (b *circuitBreaker) allows(breaker *dbBreaker, now time.Time) bool {
+	if breaker == nil {
+		return true
+	}
+	switch breaker.state {
+	case breakerOpen:
+		return now.Sub(breaker.openedAt) >= b.cooldown
+	case breakerHalfOpen:
+		return false
+	default:
+		return true
+	}
+}
+
+// begin marks the read with the given database as the probe if the cooldown of its open breaker elapsed.
+func (b *circuitBreaker) begin(db *sqlx.DB) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	breaker := b.breakers[db]
+	if breaker != nil && breaker.state == breakerOpen && b.now().Sub(breaker.openedAt) >= b.cooldown {
+		breaker.state = breakerHalfOpen
+	}
+}
+
+// abort gives up the probe with the given database if the read was canceled before its result,
+// so that the next read becomes the probe.
+func (b *circuitBreaker) abort(db *sqlx.DB) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if breaker := b.breakers[db]; breaker != nil && breaker.state == breakerHalfOpen {
+		breaker.state = breakerOpen
+	}
+}
+
+// record records the result of the read with the given database.
+func (b *circuitBreaker) record(db *sqlx.DB, connErr bool) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	breaker := b.breakers[db]
+	if !connErr {
+		if breaker != nil {
+			// The closed breakers are removed to skip filtering while all the databases are fine.
+			delete(b.breakers, db)
+		}
+		return
+	}
+
+	if breaker == nil {
+		breaker = &dbBreaker{}
+		b.breakers[db] = breaker
+	}
+	breaker.failures++
+	if breaker.state == breakerHalfOpen || breaker.failures >= b.failures {
+		breaker.state = breakerOpen
+		breaker.openedAt = b.now()
+	}
+}
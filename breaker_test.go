@@ -0,0 +1,162 @@
+package dbresolver
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	dbs := newMockDBs(t, 2)
+	now := time.Now()
+	newBreaker := func() *circuitBreaker {
+		return newCircuitBreaker(2, time.Minute, func() time.Time {
+			return now
+		})
+	}
+
+	t.Run("open after consecutive failures", func(t *testing.T) {
+		b := newBreaker()
+
+		b.record(dbs[0], true)
+		assert.Equal(t, dbs, b.allowedDBs(dbs))
+		b.record(dbs[0], true)
+		assert.Equal(t, []*sqlx.DB{dbs[1]}, b.allowedDBs(dbs))
+	})
+
+	t.Run("reset failures on success", func(t *testing.T) {
+		b := newBreaker()
+
+		b.record(dbs[0], true)
+		b.record(dbs[0], false)
+		b.record(dbs[0], true)
+
+		assert.Equal(t, dbs, b.allowedDBs(dbs))
+	})
+
+	t.Run("let single probe through after cooldown", func(t *testing.T) {
+		b := newBreaker()
+		b.record(dbs[0], true)
+		b.record(dbs[0], true)
+		now = now.Add(time.Minute)
+
+		assert.Equal(t, dbs, b.allowedDBs(dbs))
+		b.begin(dbs[0])
+		assert.Equal(t, []*sqlx.DB{dbs[1]}, b.allowedDBs(dbs))
+		b.record(dbs[0], false)
+		assert.Equal(t, dbs, b.allowedDBs(dbs))
+	})
+
+	t.Run("open again when probe fails", func(t *testing.T) {
+		b := newBreaker()
+		b.record(dbs[0], true)
+		b.record(dbs[0], true)
+		now = now.Add(time.Minute)
+
+		b.begin(dbs[0])
+		b.record(dbs[0], true)
+		assert.Equal(t, []*sqlx.DB{dbs[1]}, b.allowedDBs(dbs))
+		now = now.Add(time.Minute)
+		assert.Equal(t, dbs, b.allowedDBs(dbs))
+	})
+
+	t.Run("let next probe through when probe is aborted", func(t *testing.T) {
+		b := newBreaker()
+		b.record(dbs[0], true)
+		b.record(dbs[0], true)
+		now = now.Add(time.Minute)
+
+		b.begin(dbs[0])
+		b.abort(dbs[0])
+
+		assert.Equal(t, dbs, b.allowedDBs(dbs))
+	})
+}
+
+func TestWithReadCircuitBreaker(t *testing.T) {
+	connErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+	newResolver := func(t *testing.T, now *time.Time) (DBResolver, sqlmock.Sqlmock, sqlmock.Sqlmock, sqlmock.Sqlmock) {
+		t.Helper()
+
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		mockDB3, sqlMock3, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		r := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{sqlx.NewDb(mockDB1, "primary")}, ReadWritePolicy: WriteOnly},
+			WithSecondaryDBs(sqlx.NewDb(mockDB2, "secondary1"), sqlx.NewDb(mockDB3, "secondary2")),
+			WithLoadBalancer(&firstLoadBalancer{}),
+			WithReadCircuitBreaker(2, time.Minute),
+			WithClock(func() time.Time {
+				return *now
+			}),
+		)
+		return r, sqlMock1, sqlMock2, sqlMock3
+	}
+	get := func(t *testing.T, r DBResolver) string {
+		t.Helper()
+
+		var firstName string
+		err := r.GetContext(context.Background(), &firstName, `SELECT first_name FROM person`)
+		assert.NoError(t, err)
+		return firstName
+	}
+	expectRow := func(sqlMock sqlmock.Sqlmock, firstName string) {
+		sqlMock.ExpectQuery(`SELECT first_name FROM person`).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow(firstName))
+	}
+	expectConnErr := func(sqlMock sqlmock.Sqlmock) {
+		sqlMock.ExpectQuery(`SELECT first_name FROM person`).
+			WillReturnError(connErr)
+	}
+
+	t.Run("skip secondary until cooldown elapses", func(t *testing.T) {
+		now := time.Now()
+		r, primaryMock, secondaryMock1, secondaryMock2 := newResolver(t, &now)
+		expectConnErr(secondaryMock1)
+		expectRow(primaryMock, "primary")
+		expectConnErr(secondaryMock1)
+		expectRow(primaryMock, "primary")
+		expectRow(secondaryMock2, "secondary2")
+		expectRow(secondaryMock1, "secondary1")
+		expectRow(secondaryMock1, "secondary1")
+
+		assert.Equal(t, "primary", get(t, r))
+		assert.Equal(t, "primary", get(t, r))
+		assert.Equal(t, "secondary2", get(t, r))
+		now = now.Add(time.Minute)
+		assert.Equal(t, "secondary1", get(t, r))
+		assert.Equal(t, "secondary1", get(t, r))
+
+		assert.NoError(t, primaryMock.ExpectationsWereMet())
+		assert.NoError(t, secondaryMock1.ExpectationsWereMet())
+		assert.NoError(t, secondaryMock2.ExpectationsWereMet())
+	})
+
+	t.Run("skip secondary again when probe fails", func(t *testing.T) {
+		now := time.Now()
+		r, primaryMock, secondaryMock1, secondaryMock2 := newResolver(t, &now)
+		expectConnErr(secondaryMock1)
+		expectRow(primaryMock, "primary")
+		expectConnErr(secondaryMock1)
+		expectRow(primaryMock, "primary")
+		expectConnErr(secondaryMock1)
+		expectRow(primaryMock, "primary")
+		expectRow(secondaryMock2, "secondary2")
+
+		assert.Equal(t, "primary", get(t, r))
+		assert.Equal(t, "primary", get(t, r))
+		now = now.Add(time.Minute)
+		assert.Equal(t, "primary", get(t, r))
+		assert.Equal(t, "secondary2", get(t, r))
+
+		assert.NoError(t, primaryMock.ExpectationsWereMet())
+		assert.NoError(t, secondaryMock1.ExpectationsWereMet())
+		assert.NoError(t, secondaryMock2.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,28 @@
+package dbresolver
+
+import (
+	"math/rand"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// canaryPrimary routes a fraction of the writes to a primary database being validated.
+type canaryPrimary struct {
+	db       *sqlx.DB
+	fraction float64
+	random   *rand.Rand
+}
+
+var _ randomized = (*canaryPrimary)(nil)
+
+// chosen reports whether a write is routed to the canary.
+func (c *canaryPrimary) chosen() bool {
+	if c == nil {
+		return false
+	}
+	return randFloat64(c.random) < c.fraction
+}
+
+func (c *canaryPrimary) setRandom(random *rand.Rand) {
+	c.random = random
+}
@@ -0,0 +1,113 @@
+package dbresolver
+
+import "strings"
+
+// stmtKind is the kind of a SQL statement.
+type stmtKind int
+
+const (
+	stmtKindUnknown stmtKind = iota
+	stmtKindRead
+	stmtKindWrite
+)
+
+// readKeywords are the first keywords of the statements which only read.
+var readKeywords = map[string]struct{}{
+	"SELECT":   {},
+	"SHOW":     {},
+	"DESCRIBE": {},
+	"DESC":     {},
+	"EXPLAIN":  {},
+	"VALUES":   {},
+	"TABLE":    {},
+}
+
+// writeKeywords are the keywords of the statements which write.
+var writeKeywords = map[string]struct{}{
+	"INSERT":   {},
+	"UPDATE":   {},
+	"DELETE":   {},
+	"MERGE":    {},
+	"REPLACE":  {},
+	"UPSERT":   {},
+	"CREATE":   {},
+	"ALTER":    {},
+	"DROP":     {},
+	"TRUNCATE": {},
+	"GRANT":    {},
+	"REVOKE":   {},
+}
+
+// classifyStatement classifies the statement by its keywords, skipping the comments and the quoted strings.
+// A statement with a common table expression, i.e. WITH ..., is a write if any of its keywords is a write.
+// A SELECT locking the rows, e.g. SELECT ... FOR UPDATE, is a write as it needs a primary database.
+// It is conservative, so the statements which are not known to be reads are not classified as reads.
+func classifyStatement(query string) stmtKind {
+	words := sqlWords(query)
+	if len(words) == 0 {
+		return stmtKindUnknown
+	}
+
+	first := words[0]
+	if _, ok := writeKeywords[first]; ok {
+		return stmtKindWrite
+	}
+	_, read := readKeywords[first]
+	if !read && first != "WITH" {
+		return stmtKindUnknown
+	}
+	for i, word := range words {
+		if _, ok := writeKeywords[word]; ok && first == "WITH" {
+			return stmtKindWrite
+		}
+		if word == "FOR" && i+1 < len(words) && (words[i+1] == "UPDATE" || words[i+1] == "SHARE") {
+			return stmtKindWrite
+		}
+		if word == "INTO" && first == "SELECT" {
+			// SELECT ... INTO creates a table or writes variables.
+			return stmtKindWrite
+		}
+	}
+	return stmtKindRead
+}
+
+// sqlWords returns the upper-cased words of the query outside the comments and the quoted strings and identifiers.
+func sqlWords(query string) []string {
+	var words []string
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case c == '-' && strings.HasPrefix(query[i:], "--"):
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				return words
+			}
+			i += end + 1
+		case c == '/' && strings.HasPrefix(query[i:], "/*"):
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				return words
+			}
+			i += end + 4
+		case c == '\'' || c == '"' || c == '`':
+			end := strings.IndexByte(query[i+1:], c)
+			if end < 0 {
+				return words
+			}
+			i += end + 2
+		case isWordByte(c):
+			start := i
+			for i < len(query) && isWordByte(query[i]) {
+				i++
+			}
+			words = append(words, strings.ToUpper(query[start:i]))
+		default:
+			i++
+		}
+	}
+	return words
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c == '$' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9'
+}
@@ -0,0 +1,35 @@
+package dbresolver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassifyStatement(t *testing.T) {
+	testCases := map[string]stmtKind{
+		`SELECT * FROM person WHERE first_name = :first_name`:                                      stmtKindRead,
+		"  \n\tselect first_name FROM person":                                                      stmtKindRead,
+		`WITH p AS (SELECT * FROM person) SELECT first_name FROM p`:                                stmtKindRead,
+		`(SELECT first_name FROM person) UNION (SELECT first_name FROM place)`:                     stmtKindRead,
+		`SELECT 'INSERT' FROM person -- DELETE`:                                                    stmtKindRead,
+		`SELECT * FROM person FOR UPDATE`:                                                          stmtKindWrite,
+		`SELECT first_name INTO backup FROM person`:                                                stmtKindWrite,
+		`INSERT INTO person (first_name) VALUES (:first_name) RETURNING id`:                        stmtKindWrite,
+		"  \n\tinsert into person (first_name) VALUES (:first_name)":                               stmtKindWrite,
+		`Update person SET first_name = :first_name`:                                               stmtKindWrite,
+		`DELETE FROM person WHERE id = :id`:                                                        stmtKindWrite,
+		"/* app: api */ INSERT INTO person (first_name) VALUES (?) RETURNING id":                   stmtKindWrite,
+		"-- app: api\nINSERT INTO person (first_name) VALUES (?)":                                  stmtKindWrite,
+		`WITH p AS (SELECT id FROM person) DELETE FROM place USING p`:                              stmtKindWrite,
+		`WITH p AS (INSERT INTO person (first_name) VALUES ('foo') RETURNING id) SELECT id FROM p`: stmtKindWrite,
+		`INSERTED`:            stmtKindUnknown,
+		`update_person`:       stmtKindUnknown,
+		`CALL do_something()`: stmtKindUnknown,
+		`/* SELECT */`:        stmtKindUnknown,
+		``:                    stmtKindUnknown,
+	}
+	for query, expected := range testCases {
+		assert.Equal(t, expected, classifyStatement(query), query)
+	}
+}
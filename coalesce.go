@@ -0,0 +1,93 @@
+package dbresolver
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// coalescer collapses the identical concurrent reads into one read.
+type coalescer struct {
+	group singleflight.Group
+}
+
+// coalesce runs fn with a fresh destination once among the concurrent callers of the same key
+// and copies the result into the destination of each caller.
+// fn runs with a context detached from the cancellation of the caller, because the result is shared by the callers,
+// and each caller returns the error of its own context if it is done before the result.
+// Values referenced by pointers in the result are shared by the callers, so they must be treated as read-only.
+func (c *coalescer) coalesce(
+	ctx context.Context,
+	op string,
+	dest interface{},
+	query string,
+	args []interface{},
+	fn func(ctx context.Context, dest interface{}) error,
+) error {
+	destValue := reflect.ValueOf(dest)
+	if destValue.Kind() != reflect.Ptr || destValue.IsNil() {
+		return fn(ctx, dest)
+	}
+
+	ch := c.group.DoChan(coalesceKey(op, dest, query, args), func() (interface{}, error) {
+		fresh := reflect.New(destValue.Type().Elem())
+		if err := fn(detachedContext{ctx}, fresh.Interface()); err != nil {
+			return nil, err
+		}
+		return fresh.Elem(), nil
+	})
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case result := <-ch:
+		if result.Err != nil {
+			return result.Err
+		}
+		copyCoalescedValue(destValue.Elem(), result.Val.(reflect.Value))
+		return nil
+	}
+}
+
+// detachedContext is a context which has the values of the given context but is never canceled.
+type detachedContext struct {
+	context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (detachedContext) Err() error {
+	return nil
+}
+
+// coalesceKey returns the key identifying the identical reads.
+func coalesceKey(op string, dest interface{}, query string, args []interface{}) string {
+	var b strings.Builder
+	_, _ = fmt.Fprintf(&b, "%s\x00%T\x00%s", op, dest, query)
+	for _, arg := range args {
+		_, _ = fmt.Fprintf(&b, "\x00%T:%#v", arg, arg)
+	}
+	return b.String()
+}
+
+// copyCoalescedValue copies the shared result into the destination.
+// A slice is appended with copied elements as sqlx does, so the callers do not share the backing array.
+func copyCoalescedValue(dst, src reflect.Value) {
+	if src.Kind() != reflect.Slice {
+		dst.Set(src)
+		return
+	}
+
+	elems := reflect.MakeSlice(src.Type(), src.Len(), src.Len())
+	reflect.Copy(elems, src)
+	dst.Set(reflect.AppendSlice(dst, elems))
+}
@@ -0,0 +1,258 @@
+package dbresolver
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCoalesceKey(t *testing.T) {
+	var firstName string
+	var firstNames []string
+
+	assert.Equal(t,
+		coalesceKey("Get", &firstName, `SELECT * FROM person WHERE id=?`, []interface{}{1}),
+		coalesceKey("Get", &firstName, `SELECT * FROM person WHERE id=?`, []interface{}{1}),
+	)
+	assert.NotEqual(t,
+		coalesceKey("Get", &firstName, `SELECT * FROM person WHERE id=?`, []interface{}{1}),
+		coalesceKey("Get", &firstName, `SELECT * FROM person WHERE id=?`, []interface{}{2}),
+	)
+	assert.NotEqual(t,
+		coalesceKey("Get", &firstName, `SELECT * FROM person WHERE id=?`, []interface{}{1}),
+		coalesceKey("Get", &firstName, `SELECT * FROM person WHERE id=?`, []interface{}{"1"}),
+	)
+	assert.NotEqual(t,
+		coalesceKey("Get", &firstName, `SELECT * FROM person WHERE id=?`, []interface{}{1}),
+		coalesceKey("Select", &firstNames, `SELECT * FROM person WHERE id=?`, []interface{}{1}),
+	)
+}
+
+func TestDBResolver_WithReadCoalescing(t *testing.T) {
+	type Person struct {
+		FirstName string `db:"first_name"`
+		LastName  string `db:"last_name"`
+	}
+
+	t.Run("concurrent identical gets share one query", func(t *testing.T) {
+		mockDB, sqlMock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock.ExpectQuery(`SELECT * FROM person WHERE first_name=?`).
+			WithArgs("foo").
+			WillDelayFor(200 * time.Millisecond).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name", "last_name"}).AddRow("foo", "bar"))
+		mockReadDB := sqlx.NewDb(mockDB, "mock")
+		r := &dbResolver{
+			primaries:    []*sqlx.DB{mockReadDB},
+			reads:        []*sqlx.DB{mockReadDB},
+			loadBalancer: &injectedLoadBalancer{db: mockReadDB},
+			coalescer:    &coalescer{},
+		}
+
+		const callers = 10
+		results := make([]*Person, callers)
+		errs := make([]error, callers)
+		var wg sync.WaitGroup
+		for i := 0; i < callers; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				results[i] = &Person{}
+				errs[i] = r.GetContext(context.Background(), results[i], `SELECT * FROM person WHERE first_name=?`, "foo")
+			}(i)
+		}
+		wg.Wait()
+
+		for i := 0; i < callers; i++ {
+			assert.NoError(t, errs[i])
+			assert.Equal(t, &Person{FirstName: "foo", LastName: "bar"}, results[i])
+		}
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("concurrent identical selects do not share slices", func(t *testing.T) {
+		mockDB, sqlMock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock.ExpectQuery(`SELECT * FROM person`).
+			WillDelayFor(200 * time.Millisecond).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name", "last_name"}).
+				AddRow("foo", "bar").
+				AddRow("baz", "qux"))
+		mockReadDB := sqlx.NewDb(mockDB, "mock")
+		r := &dbResolver{
+			primaries:    []*sqlx.DB{mockReadDB},
+			reads:        []*sqlx.DB{mockReadDB},
+			loadBalancer: &injectedLoadBalancer{db: mockReadDB},
+			coalescer:    &coalescer{},
+		}
+
+		results := make([][]Person, 2)
+		var wg sync.WaitGroup
+		for i := range results {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				err := r.Select(&results[i], `SELECT * FROM person`)
+				assert.NoError(t, err)
+			}(i)
+		}
+		wg.Wait()
+		results[0][0].FirstName = "changed"
+
+		expected := []Person{{FirstName: "foo", LastName: "bar"}, {FirstName: "baz", LastName: "qux"}}
+		assert.Equal(t, expected, results[1])
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("not share read routed by context", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock1.ExpectQuery(`SELECT * FROM person WHERE first_name=?`).
+			WithArgs("foo").
+			WillDelayFor(200 * time.Millisecond).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name", "last_name"}).AddRow("foo", "primary"))
+		mockPrimaryDB := sqlx.NewDb(mockDB1, "mock")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock2.ExpectQuery(`SELECT * FROM person WHERE first_name=?`).
+			WithArgs("foo").
+			WillDelayFor(200 * time.Millisecond).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name", "last_name"}).AddRow("foo", "replica"))
+		mockReadDB := sqlx.NewDb(mockDB2, "mock")
+		r := &dbResolver{
+			primaries:    []*sqlx.DB{mockPrimaryDB},
+			reads:        []*sqlx.DB{mockReadDB},
+			loadBalancer: &firstLoadBalancer{},
+			coalescer:    &coalescer{},
+		}
+
+		var (
+			wg              sync.WaitGroup
+			fromReplica     Person
+			fromPrimary     Person
+			errReplica      error
+			errForcePrimary error
+		)
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			errReplica = r.GetContext(context.Background(), &fromReplica, `SELECT * FROM person WHERE first_name=?`, "foo")
+		}()
+		go func() {
+			defer wg.Done()
+			time.Sleep(50 * time.Millisecond)
+			errForcePrimary = r.GetContext(
+				WithForcePrimary(context.Background()), &fromPrimary, `SELECT * FROM person WHERE first_name=?`, "foo",
+			)
+		}()
+		wg.Wait()
+
+		assert.NoError(t, errReplica)
+		assert.NoError(t, errForcePrimary)
+		assert.Equal(t, "replica", fromReplica.LastName)
+		assert.Equal(t, "primary", fromPrimary.LastName)
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
+
+	t.Run("not share read tagged differently", func(t *testing.T) {
+		type tagKey struct{}
+		mockDB, sqlMock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock.MatchExpectationsInOrder(false)
+		sqlMock.ExpectQuery(`/* req-1 */ SELECT * FROM person WHERE first_name=?`).
+			WithArgs("foo").
+			WillDelayFor(200 * time.Millisecond).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name", "last_name"}).AddRow("foo", "bar"))
+		sqlMock.ExpectQuery(`/* req-2 */ SELECT * FROM person WHERE first_name=?`).
+			WithArgs("foo").
+			WillDelayFor(200 * time.Millisecond).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name", "last_name"}).AddRow("foo", "bar"))
+		mockReadDB := sqlx.NewDb(mockDB, "mock")
+		r := &dbResolver{
+			primaries:    []*sqlx.DB{mockReadDB},
+			reads:        []*sqlx.DB{mockReadDB},
+			loadBalancer: &injectedLoadBalancer{db: mockReadDB},
+			coalescer:    &coalescer{},
+			queryTag: func(ctx context.Context) string {
+				tag, _ := ctx.Value(tagKey{}).(string)
+				return tag
+			},
+		}
+
+		results := make([]Person, 2)
+		errs := make([]error, 2)
+		var wg sync.WaitGroup
+		for i, tag := range []string{"req-1", "req-2"} {
+			wg.Add(1)
+			go func(i int, tag string) {
+				defer wg.Done()
+				ctx := context.WithValue(context.Background(), tagKey{}, tag)
+				errs[i] = r.GetContext(ctx, &results[i], `SELECT * FROM person WHERE first_name=?`, "foo")
+			}(i, tag)
+		}
+		wg.Wait()
+
+		for i := range results {
+			assert.NoError(t, errs[i])
+			assert.Equal(t, Person{FirstName: "foo", LastName: "bar"}, results[i])
+		}
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("canceled caller does not fail the others", func(t *testing.T) {
+		mockDB, sqlMock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock.ExpectQuery(`SELECT * FROM person WHERE first_name=?`).
+			WithArgs("foo").
+			WillDelayFor(200 * time.Millisecond).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name", "last_name"}).AddRow("foo", "bar"))
+		mockReadDB := sqlx.NewDb(mockDB, "mock")
+		r := &dbResolver{
+			primaries:    []*sqlx.DB{mockReadDB},
+			reads:        []*sqlx.DB{mockReadDB},
+			loadBalancer: &injectedLoadBalancer{db: mockReadDB},
+			coalescer:    &coalescer{},
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		var (
+			wg          sync.WaitGroup
+			canceled    Person
+			waited      Person
+			errCanceled error
+			errWaited   error
+		)
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			errCanceled = r.GetContext(ctx, &canceled, `SELECT * FROM person WHERE first_name=?`, "foo")
+		}()
+		go func() {
+			defer wg.Done()
+			time.Sleep(50 * time.Millisecond)
+			errWaited = r.GetContext(context.Background(), &waited, `SELECT * FROM person WHERE first_name=?`, "foo")
+		}()
+		time.Sleep(100 * time.Millisecond)
+		cancel()
+		wg.Wait()
+
+		assert.ErrorIs(t, errCanceled, context.Canceled)
+		assert.Equal(t, Person{}, canceled)
+		assert.NoError(t, errWaited)
+		assert.Equal(t, Person{FirstName: "foo", LastName: "bar"}, waited)
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("enabled by option", func(t *testing.T) {
+		mockDB, _, _ := sqlmock.New()
+		primaryDBsCfg := &PrimaryDBsConfig{
+			DBs: []*sqlx.DB{sqlx.NewDb(mockDB, "primary")},
+		}
+
+		r, err := NewDBResolver(primaryDBsCfg, WithReadCoalescing())
+
+		assert.NoError(t, err)
+		assert.NotNil(t, r.(*dbResolver).coalescer)
+	})
+}
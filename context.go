@@ -0,0 +1,139 @@
+package dbresolver
+
+import (
+	"context"
+	"sync"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// contextKey is the type of the context keys of this package.
+type contextKey int
+
+// contextKeys.
+const (
+	readSubsetKey contextKey = iota
+	loadBalancerKey
+	forcePrimaryKey
+	resolverKey
+	withoutHedgingKey
+	stickyReadKey
+	regionKey
+	noFallbackKey
+)
+
+// WithReadSubset returns a copy of the context which narrows the readable databases
+// to a stable hashed subset of the given fraction for the reads using the returned context.
+// The subset always has at least one database. If the fraction is not in (0, 1), it has no effect.
+// It is useful to roll out a query change gradually to a subset of the replicas.
+func WithReadSubset(ctx context.Context, fraction float64) context.Context {
+	return context.WithValue(ctx, readSubsetKey, fraction)
+}
+
+func readSubsetFromContext(ctx context.Context) (float64, bool) {
+	fraction, ok := ctx.Value(readSubsetKey).(float64)
+	return fraction, ok
+}
+
+// WithLoadBalancerInstance returns a copy of the context which makes the methods using the returned context
+// choose a database with the given load balancer instead of the configured load balancer.
+// It is useful to use a different load balancer for a specific query.
+func WithLoadBalancerInstance(ctx context.Context, lb LoadBalancer) context.Context {
+	return context.WithValue(ctx, loadBalancerKey, lb)
+}
+
+func loadBalancerFromContext(ctx context.Context) (LoadBalancer, bool) {
+	lb, ok := ctx.Value(loadBalancerKey).(LoadBalancer)
+	return lb, ok && lb != nil
+}
+
+// WithForcePrimary returns a copy of the context which makes the read methods using the returned context
+// read from a primary database instead of the readable databases.
+// It is useful to read the rows written just before without being affected by the replication lag.
+func WithForcePrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, forcePrimaryKey, true)
+}
+
+func forcePrimaryFromContext(ctx context.Context) bool {
+	force, _ := ctx.Value(forcePrimaryKey).(bool)
+	return force
+}
+
+// NewContext returns a copy of the context which carries the given resolver.
+// It is useful for the libraries built on top of the resolver to retrieve the resolver without passing it explicitly.
+func NewContext(ctx context.Context, r DBResolver) context.Context {
+	return context.WithValue(ctx, resolverKey, r)
+}
+
+// FromContext returns the resolver carried by the context if it exists.
+func FromContext(ctx context.Context) (DBResolver, bool) {
+	r, ok := ctx.Value(resolverKey).(DBResolver)
+	return r, ok && r != nil
+}
+
+// WithoutHedging returns a copy of the context which disables the hedged reads for the reads using the returned context.
+// It is useful not to duplicate an expensive query.
+func WithoutHedging(ctx context.Context) context.Context {
+	return context.WithValue(ctx, withoutHedgingKey, true)
+}
+
+func withoutHedgingFromContext(ctx context.Context) bool {
+	without, _ := ctx.Value(withoutHedgingKey).(bool)
+	return without
+}
+
+// stickyRead holds the readable database chosen by the first read sharing a context.
+type stickyRead struct {
+	mu sync.Mutex
+	db *sqlx.DB
+}
+
+// WithStickyRead returns a copy of the context which makes the reads using the returned context
+// stick to the readable database chosen by the first of them instead of choosing with the load balancer.
+// If the database is no longer readable, e.g. it is removed or fails, another one is chosen and stuck to.
+// The hedged reads are disabled for the reads using the returned context.
+// It is useful not to read the inconsistent snapshots of the different replicas within a request.
+func WithStickyRead(ctx context.Context) context.Context {
+	return context.WithValue(ctx, stickyReadKey, &stickyRead{})
+}
+
+func stickyReadFromContext(ctx context.Context) (*stickyRead, bool) {
+	sticky, ok := ctx.Value(stickyReadKey).(*stickyRead)
+	return sticky, ok && sticky != nil
+}
+
+// WithRegion returns a copy of the context which carries the region of the caller.
+// The RegionAwareLoadBalancer prefers the databases in the region for the reads using the returned context.
+func WithRegion(ctx context.Context, region string) context.Context {
+	return context.WithValue(ctx, regionKey, region)
+}
+
+// RegionFromContext returns the region of the caller carried by the context if it exists.
+func RegionFromContext(ctx context.Context) (string, bool) {
+	region, ok := ctx.Value(regionKey).(string)
+	return region, ok && region != ""
+}
+
+// WithNoFallback returns a copy of the context which makes the reads using the returned context
+// return the connection errors of the readable databases instead of falling back to a primary database,
+// like WithStrictReadSeparation but only for the reads using the returned context.
+// It is useful for the queries tolerating the unavailable replicas like the analytics
+// not to put their load on the primary database.
+func WithNoFallback(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noFallbackKey, true)
+}
+
+func noFallbackFromContext(ctx context.Context) bool {
+	noFallback, _ := ctx.Value(noFallbackKey).(bool)
+	return noFallback
+}
+
+// routedByContext reports whether the context changes how the reads using it are routed,
+// e.g. by WithForcePrimary or WithStickyRead, so they may read from the other databases than the others.
+func routedByContext(ctx context.Context) bool {
+	_, subset := readSubsetFromContext(ctx)
+	_, lb := loadBalancerFromContext(ctx)
+	_, sticky := stickyReadFromContext(ctx)
+	_, region := RegionFromContext(ctx)
+	return subset || lb || sticky || region || forcePrimaryFromContext(ctx) || noFallbackFromContext(ctx)
+}
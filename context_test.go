@@ -0,0 +1,264 @@
+package dbresolver
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithLoadBalancerInstance(t *testing.T) {
+	t.Run("read with load balancer in context", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		mockReadDB1 := sqlx.NewDb(mockDB1, "read1")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock2.ExpectQuery(`SELECT first_name FROM person`).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("foo"))
+		mockReadDB2 := sqlx.NewDb(mockDB2, "read2")
+		r := &dbResolver{
+			primaries: []*sqlx.DB{mockReadDB1},
+			reads:     []*sqlx.DB{mockReadDB1, mockReadDB2},
+			loadBalancer: &injectedLoadBalancer{
+				db: mockReadDB1,
+			},
+		}
+
+		ctx := WithLoadBalancerInstance(context.Background(), NewFixedLoadBalancer(mockReadDB2))
+		var firstName string
+		err := r.GetContext(ctx, &firstName, `SELECT first_name FROM person`)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "foo", firstName)
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
+
+	t.Run("write with load balancer in context", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		mockPrimaryDB1 := sqlx.NewDb(mockDB1, "primary1")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock2.ExpectExec(`DELETE FROM person`).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mockPrimaryDB2 := sqlx.NewDb(mockDB2, "primary2")
+		r := &dbResolver{
+			primaries: []*sqlx.DB{mockPrimaryDB1, mockPrimaryDB2},
+			loadBalancer: &injectedLoadBalancer{
+				db: mockPrimaryDB1,
+			},
+		}
+
+		ctx := WithLoadBalancerInstance(context.Background(), NewFixedLoadBalancer(mockPrimaryDB2))
+		_, err := r.NamedExecContext(ctx, `DELETE FROM person`, map[string]interface{}{})
+
+		assert.NoError(t, err)
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
+
+	t.Run("nil load balancer in context", func(t *testing.T) {
+		r := &dbResolver{
+			loadBalancer: NewRandomLoadBalancer(),
+		}
+
+		ctx := WithLoadBalancerInstance(context.Background(), nil)
+
+		assert.Equal(t, r.loadBalancer, r.balancer(ctx))
+	})
+}
+
+func TestWithForcePrimary(t *testing.T) {
+	t.Run("read from primary", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock1.ExpectQuery(`SELECT first_name FROM person`).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("foo"))
+		mockPrimaryDB := sqlx.NewDb(mockDB1, "primary")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		mockSecondaryDB := sqlx.NewDb(mockDB2, "secondary")
+		resolver := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB}, ReadWritePolicy: WriteOnly},
+			WithSecondaryDBs(mockSecondaryDB),
+		)
+
+		var firstName string
+		err := resolver.GetContext(WithForcePrimary(context.Background()), &firstName, `SELECT first_name FROM person`)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "foo", firstName)
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
+
+	t.Run("read from secondary without force", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		mockPrimaryDB := sqlx.NewDb(mockDB1, "primary")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock2.ExpectQuery(`SELECT first_name FROM person`).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("bar"))
+		mockSecondaryDB := sqlx.NewDb(mockDB2, "secondary")
+		resolver := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB}, ReadWritePolicy: WriteOnly},
+			WithSecondaryDBs(mockSecondaryDB),
+		)
+
+		var firstName string
+		err := resolver.GetContext(context.Background(), &firstName, `SELECT first_name FROM person`)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "bar", firstName)
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
+}
+
+func TestFromContext(t *testing.T) {
+	t.Run("resolver in context", func(t *testing.T) {
+		mockDB, _, _ := sqlmock.New()
+		resolver := MustNewDBResolver(&PrimaryDBsConfig{
+			DBs:             []*sqlx.DB{sqlx.NewDb(mockDB, "primary")},
+			ReadWritePolicy: ReadWrite,
+		})
+
+		got, ok := FromContext(NewContext(context.Background(), resolver))
+
+		assert.True(t, ok)
+		assert.Same(t, resolver, got)
+	})
+
+	t.Run("no resolver in context", func(t *testing.T) {
+		got, ok := FromContext(context.Background())
+
+		assert.False(t, ok)
+		assert.Nil(t, got)
+	})
+
+	t.Run("nil resolver in context", func(t *testing.T) {
+		got, ok := FromContext(NewContext(context.Background(), nil))
+
+		assert.False(t, ok)
+		assert.Nil(t, got)
+	})
+}
+
+func TestWithStickyRead(t *testing.T) {
+	t.Run("stick to first chosen database", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock1.ExpectQuery(`SELECT first_name FROM person`).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("foo"))
+		sqlMock1.ExpectQuery(`SELECT last_name FROM person`).
+			WillReturnRows(sqlmock.NewRows([]string{"last_name"}).AddRow("bar"))
+		mockSecondaryDB1 := sqlx.NewDb(mockDB1, "secondary1")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		mockSecondaryDB2 := sqlx.NewDb(mockDB2, "secondary2")
+		resolver := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: newMockDBs(t, 1), ReadWritePolicy: WriteOnly},
+			WithSecondaryDBs(mockSecondaryDB1, mockSecondaryDB2),
+			WithLoadBalancer(NewRoundRobinLoadBalancer()),
+		)
+
+		ctx := WithStickyRead(context.Background())
+		var firstName, lastName string
+		err := resolver.GetContext(ctx, &firstName, `SELECT first_name FROM person`)
+		assert.NoError(t, err)
+		err = resolver.GetContext(ctx, &lastName, `SELECT last_name FROM person`)
+		assert.NoError(t, err)
+
+		assert.Equal(t, "foo", firstName)
+		assert.Equal(t, "bar", lastName)
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
+
+	t.Run("stick to another database when stuck one is removed", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock1.ExpectQuery(`SELECT first_name FROM person`).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("foo"))
+		mockSecondaryDB1 := sqlx.NewDb(mockDB1, "secondary1")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock2.ExpectQuery(`SELECT first_name FROM person`).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("bar"))
+		sqlMock2.ExpectQuery(`SELECT first_name FROM person`).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("baz"))
+		mockSecondaryDB2 := sqlx.NewDb(mockDB2, "secondary2")
+		mockDB3, sqlMock3, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		mockSecondaryDB3 := sqlx.NewDb(mockDB3, "secondary3")
+		resolver := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: newMockDBs(t, 1), ReadWritePolicy: WriteOnly},
+			WithSecondaryDBs(mockSecondaryDB1, mockSecondaryDB2, mockSecondaryDB3),
+			WithLoadBalancer(&firstLoadBalancer{}),
+		)
+
+		ctx := WithStickyRead(context.Background())
+		var firstNames [3]string
+		assert.NoError(t, resolver.GetContext(ctx, &firstNames[0], `SELECT first_name FROM person`))
+		assert.NoError(t, resolver.RemoveSecondary(mockSecondaryDB1))
+		assert.NoError(t, resolver.GetContext(ctx, &firstNames[1], `SELECT first_name FROM person`))
+		resolver.AddSecondary(mockSecondaryDB1)
+		assert.NoError(t, resolver.GetContext(ctx, &firstNames[2], `SELECT first_name FROM person`))
+
+		assert.Equal(t, [3]string{"foo", "bar", "baz"}, firstNames)
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+		assert.NoError(t, sqlMock3.ExpectationsWereMet())
+	})
+}
+
+func TestWithNoFallback(t *testing.T) {
+	connErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+	newResolver := func(t *testing.T) (DBResolver, sqlmock.Sqlmock, sqlmock.Sqlmock) {
+		t.Helper()
+
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		r := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{sqlx.NewDb(mockDB1, "primary")}, ReadWritePolicy: WriteOnly},
+			WithSecondaryDBs(sqlx.NewDb(mockDB2, "secondary")),
+		)
+		return r, sqlMock1, sqlMock2
+	}
+
+	t.Run("return connection error without fallback", func(t *testing.T) {
+		r, primaryMock, secondaryMock := newResolver(t)
+		secondaryMock.ExpectQuery(`SELECT first_name FROM person`).
+			WillReturnError(connErr)
+
+		rows, err := r.QueryContext(WithNoFallback(context.Background()), `SELECT first_name FROM person`)
+
+		assert.Nil(t, rows)
+		assert.ErrorIs(t, err, connErr)
+		assert.Equal(t, RoutingStats{ReadQueries: 1}, r.RoutingStats())
+		assert.NoError(t, primaryMock.ExpectationsWereMet())
+		assert.NoError(t, secondaryMock.ExpectationsWereMet())
+	})
+
+	t.Run("return scan connection error without fallback", func(t *testing.T) {
+		r, primaryMock, secondaryMock := newResolver(t)
+		secondaryMock.ExpectQuery(`SELECT first_name FROM person`).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("foo").RowError(0, connErr))
+
+		var firstName string
+		err := r.QueryRowFallback(WithNoFallback(context.Background()), `SELECT first_name FROM person`).Scan(&firstName)
+
+		assert.ErrorIs(t, err, connErr)
+		assert.NoError(t, primaryMock.ExpectationsWereMet())
+		assert.NoError(t, secondaryMock.ExpectationsWereMet())
+	})
+
+	t.Run("fall back without flag", func(t *testing.T) {
+		r, primaryMock, secondaryMock := newResolver(t)
+		secondaryMock.ExpectQuery(`SELECT first_name FROM person`).
+			WillReturnError(connErr)
+		primaryMock.ExpectQuery(`SELECT first_name FROM person`).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("foo"))
+
+		rows, err := r.QueryContext(context.Background(), `SELECT first_name FROM person`)
+
+		assert.NoError(t, err)
+		assert.NoError(t, rows.Close())
+		assert.NoError(t, primaryMock.ExpectationsWereMet())
+		assert.NoError(t, secondaryMock.ExpectationsWereMet())
+	})
+}
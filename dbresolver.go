@@ -4,6 +4,9 @@ import (
 	"context"
 	"database/sql"
 	"database/sql/driver"
+	"math/rand"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/hashicorp/go-multierror"
@@ -16,8 +19,22 @@ var (
 	errNoPrimaryDB            = errors.New("dbresolver: no primary database")
 	errInvalidReadWritePolicy = errors.New("dbresolver: invalid read/write policy")
 	errNoDBToRead             = errors.New("dbresolver: no database to read")
+	errUnknownSecondaryGroup  = errors.New("dbresolver: unknown secondary group")
+	errNoLeader               = errors.New("dbresolver: no leader")
+	errUnknownSecondary       = errors.New("dbresolver: unknown secondary database")
+	errInvalidCanaryFraction  = errors.New("dbresolver: canary fraction must be between 0 and 1")
+	errUnsupportedBindArg     = errors.New("dbresolver: unsupported bind arg")
+	errAllCandidatesFiltered  = errors.New("dbresolver: all candidates filtered out")
+	errInvalidReadPreference  = errors.New("dbresolver: invalid read preference")
+	errInconsistentDrivers    = errors.New("dbresolver: inconsistent drivers")
+	errWriteOnReadReplica     = errors.New("dbresolver: write statement on read replica")
+	errUnknownPrimary         = errors.New("dbresolver: unknown primary database")
+	errInvalidSamplingRate    = errors.New("dbresolver: read sampling rate must be between 0 and 1")
 )
 
+// defaultMaxIdleConns is the default maximum number of the idle connections of database/sql.
+const defaultMaxIdleConns = 2
+
 // ReadWritePolicy is the read/write policy for the primary databases.
 type ReadWritePolicy string
 
@@ -46,68 +63,218 @@ func NewPrimaryDBsConfig(dbs []*sqlx.DB, policy ReadWritePolicy) *PrimaryDBsConf
 	}
 }
 
+// SecondaryDBConfig is the config of a secondary database.
+type SecondaryDBConfig struct {
+	DB *sqlx.DB
+	// Weight is the relative weight of the database for the WeightedLoadBalancer.
+	// Less than 1 is considered 1.
+	Weight int
+	// Name is the name of the database to identify it, e.g. in the route hook.
+	Name string
+	// Region is the region of the database for the RegionAwareLoadBalancer.
+	Region string
+}
+
 // DBResolver chooses one of databases and then executes a query.
 // This supposed to be aligned with sqlx.DB.
 // Some functions which must select from multiple database are only available for the primary DBResolver
 // or the first primary DBResolver (if using multi-primary). For example, `DriverName()`, `Unsafe()`.
 type DBResolver interface {
+	AcquirePrimary() *sqlx.DB
+	AcquireRead() *sqlx.DB
+	AddSecondary(db *sqlx.DB)
 	Begin() (*sql.Tx, error)
 	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
 	BeginTxx(ctx context.Context, opts *sql.TxOptions) (*sqlx.Tx, error)
 	Beginx() (*sqlx.Tx, error)
+	BeginxOn(db *sqlx.DB) (*sqlx.Tx, error)
+	BeginxResolver(ctx context.Context) (TxResolver, error)
 	BindNamed(query string, arg interface{}) (string, []interface{}, error)
+	BindNamedForDriver(driverName, query string, arg interface{}) (string, []interface{}, error)
+	CheckSchemaConsistency(
+		ctx context.Context, query string, scan func(rows *sqlx.Rows) (string, error),
+	) (map[*sqlx.DB]string, bool, error)
 	Close() error
+	CloseContext(ctx context.Context) error
+	CloseIdleConnections()
 	Conn(ctx context.Context) (*sql.Conn, error)
 	Connx(ctx context.Context) (*sqlx.Conn, error)
 	Driver() driver.Driver
 	DriverName() string
 	Exec(query string, args ...interface{}) (sql.Result, error)
 	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	ExecContextDetailed(ctx context.Context, query string, args ...interface{}) (sql.Result, ExecMeta, error)
+	ExecOnAllPrimaries(ctx context.Context, query string, args ...interface{}) (map[*sqlx.DB]sql.Result, error)
 	Get(dest interface{}, query string, args ...interface{}) error
 	GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	GetEachRead(ctx context.Context, newDest func(db *sqlx.DB) interface{}, query string, args ...interface{}) map[*sqlx.DB]error
+	GetFrom(ctx context.Context, dest interface{}, query string, args ...interface{}) (*sqlx.DB, error)
+	GetWithRole(ctx context.Context, dest interface{}, query string, args ...interface{}) (role string, err error)
+	GroupResolver(name string) (DBResolver, error)
+	HealthyReplicaCount() int
+	In(query string, args ...interface{}) (string, []interface{}, error)
+	LatencyPercentiles(role string) (p50, p95, p99 time.Duration)
 	MapperFunc(mf func(string) string)
 	MustBegin() *sqlx.Tx
 	MustBeginTx(ctx context.Context, opts *sql.TxOptions) *sqlx.Tx
 	MustExec(query string, args ...interface{}) sql.Result
 	MustExecContext(ctx context.Context, query string, args ...interface{}) sql.Result
+	MustPrepare(query string) Stmt
+	MustPrepareNamed(query string) NamedStmt
+	MustPreparex(query string) Stmt
+	MustTransaction(ctx context.Context, opts *sql.TxOptions, fn func(tx *sqlx.Tx) error)
 	NamedExec(query string, arg interface{}) (sql.Result, error)
 	NamedExecContext(ctx context.Context, query string, arg interface{}) (sql.Result, error)
+	NamedExecReturning(ctx context.Context, dest interface{}, query string, arg interface{}) error
 	NamedQuery(query string, arg interface{}) (*sqlx.Rows, error)
 	NamedQueryContext(ctx context.Context, query string, arg interface{}) (*sqlx.Rows, error)
+	OpenStatementQueries() []string
+	OpenStatements() int
+	PauseWrites()
 	Ping() error
 	PingContext(ctx context.Context) error
+	PingPrimaries(ctx context.Context) error
+	PingReads(ctx context.Context) error
 	Prepare(query string) (Stmt, error)
 	PrepareContext(ctx context.Context, query string) (Stmt, error)
 	PrepareNamed(query string) (NamedStmt, error)
 	PrepareNamedContext(ctx context.Context, query string) (NamedStmt, error)
+	PrepareWithLoadBalancer(query string, lb LoadBalancer) (Stmt, error)
 	Preparex(query string) (Stmt, error)
 	PreparexContext(ctx context.Context, query string) (Stmt, error)
+	PrimaryDBs() []*sqlx.DB
+	PrimaryStats() []sql.DBStats
 	Query(query string, args ...interface{}) (*sql.Rows, error)
 	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryEachRead(ctx context.Context, query string, args ...interface{}) (map[*sqlx.DB]*sqlx.Rows, error)
+	QueryFrom(ctx context.Context, query string, args ...interface{}) (*sql.Rows, *sqlx.DB, error)
 	QueryRow(query string, args ...interface{}) *sql.Row
 	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+	QueryRowFallback(ctx context.Context, query string, args ...interface{}) *Row
 	QueryRowx(query string, args ...interface{}) *sqlx.Row
 	QueryRowxContext(ctx context.Context, query string, args ...interface{}) *sqlx.Row
+	QueryRowxFallback(ctx context.Context, query string, args ...interface{}) *Rowx
 	Queryx(query string, args ...interface{}) (*sqlx.Rows, error)
 	QueryxContext(ctx context.Context, query string, args ...interface{}) (*sqlx.Rows, error)
+	QuoteIdentifier(name string) string
+	ReadDBs() []*sqlx.DB
+	ReadModifyWrite(
+		ctx context.Context, readQuery string, readArgs []interface{}, fn func(conn *sqlx.Conn, result ReadResult) error,
+	) error
+	ReadStats() []sql.DBStats
 	Rebind(query string) string
+	RebindForDriver(driverName, query string) string
+	RemoveSecondary(db *sqlx.DB) error
+	ResumeWrites()
+	RoutingStats() RoutingStats
+	SecondaryDBConfig(db *sqlx.DB) (SecondaryDBConfig, bool)
 	Select(dest interface{}, query string, args ...interface{}) error
 	SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	SelectFrom(ctx context.Context, dest interface{}, query string, args ...interface{}) (*sqlx.DB, error)
+	SelectWithRole(ctx context.Context, dest interface{}, query string, args ...interface{}) (role string, err error)
 	SetConnMaxIdleTime(d time.Duration)
 	SetConnMaxLifetime(d time.Duration)
 	SetMaxIdleConns(n int)
 	SetMaxOpenConns(n int)
+	Shutdown(ctx context.Context) error
 	Stats() sql.DBStats
+	Transaction(ctx context.Context, opts *sql.TxOptions, fn func(tx *sqlx.Tx) error) error
 	Unsafe() *sqlx.DB
+	WithReplicaConn(ctx context.Context, fn func(conn *sqlx.Conn) error) error
 }
 
+// ExecMeta describes how a write was executed.
+type ExecMeta struct {
+	// Attempts is the number of the attempts including retries.
+	Attempts int
+	// DB is the label of the primary database which executed the last attempt. e.g. "primary[0]", "canary".
+	DB string
+	// Tried is the labels of the primary databases in the order they were tried.
+	Tried []string
+}
+
+// ReadResult is the rows read by ReadModifyWrite. Each row maps the column names to the values.
+type ReadResult []map[string]interface{}
+
 type dbResolver struct {
-	primaries   []*sqlx.DB
-	secondaries []*sqlx.DB
+	// routing is the first field for the 64-bit alignment of the atomic operations on 32-bit platforms.
+	routing routingCounters
 
-	reads []*sqlx.DB
+	primaries   []*sqlx.DB
+	canary      *canaryPrimary
+	readSampler *readSampler
 
-	loadBalancer LoadBalancer
+	// poolMu guards secondaries, reads and groups, which are replaced as a whole when the read pool changes.
+	// It also guards mapperFunc, which is the mapper function set by MapperFunc or WithMapperFunc
+	// to set for the added secondaries.
+	poolMu      sync.RWMutex
+	secondaries []*sqlx.DB
+	reads       []*sqlx.DB
+	groups      map[string][]*sqlx.DB
+	mapperFunc  func(string) string
+
+	// secondaryConfigs is the configs of the secondary databases given by WithSecondaryDBConfigs.
+	secondaryConfigs map[*sqlx.DB]SecondaryDBConfig
+
+	loadBalancer    LoadBalancer
+	candidateFilter func(ctx context.Context, role string, dbs []*sqlx.DB) []*sqlx.DB
+	shardFunc       func(args []interface{}) int
+
+	// root is the resolver which this resolver is a view of. It is nil if this resolver is not a view.
+	root *dbResolver
+
+	health             healthState
+	healthChecker      *healthChecker
+	breaker            *circuitBreaker
+	poolChangeListener func(event PoolChangeEvent)
+
+	// readPreference is empty for PreferReplica.
+	readPreference        ReadPreference
+	preferPrimaryMaxInUse int
+
+	writeGate            writeGate
+	drain                drainGate
+	writeRetries         int
+	readRetries          int
+	strictReadSeparation bool
+	queryRouting         bool
+	readOnlyEnforcement  bool
+	connErrClassifier    connErrorClassifier
+	hedgeDelay           time.Duration
+	leaderSelector       func(ctx context.Context, primaries []*sqlx.DB) (*sqlx.DB, error)
+	queryTimeout         time.Duration
+	defaultContext       context.Context
+	readYourWritesWindow time.Duration
+	lastWrite            lastWrite
+	fanOutConcurrency    int
+
+	coalescer       *coalescer
+	connValidator   *connValidator
+	stmtLimiter     *stmtLimiter
+	tolerantPrepare bool
+	// stmts is the registry of the open prepared statements.
+	stmts stmtRegistry
+	// bindTypeCache is the cached bindvar type of the primary databases. See bindType.
+	bindTypeCache int32
+
+	// idleMu guards maxIdleConns, which is the number set by SetMaxIdleConns if it has been called.
+	idleMu       sync.Mutex
+	maxIdleConns *int
+	// primaryMaxIdleConns and readMaxIdleConns are the numbers of the tiers set by the options. Zero means unset.
+	primaryMaxIdleConns int
+	readMaxIdleConns    int
+
+	latencies          map[string]*latencyHistogram
+	n1Detector         *n1Detector
+	routeHook          func(ctx context.Context, op string, role string, db *sqlx.DB)
+	observer           QueryObserver
+	queryTag           func(ctx context.Context) string
+	wrapErrors         bool
+	effectiveQueryHook func(ctx context.Context, role string, query string, args []interface{})
+	logger             func(ctx context.Context, event string, err error)
+	now                func() time.Time
+	// connMaxIdleTimeSetter sets the maximum idle time of a database instead of sqlx.DB.SetConnMaxIdleTime if set.
+	connMaxIdleTimeSetter func(db *sqlx.DB, d time.Duration)
 }
 
 var _ DBResolver = (*dbResolver)(nil)
@@ -115,7 +282,8 @@ var _ DBResolver = (*dbResolver)(nil)
 // NewDBResolver creates a new DBResolver and returns it.
 // If no primary DBResolver is given, it returns an error.
 // If you do not give WriteOnly option, it will use the primary DBResolver as the read DBResolver.
-// if you do not give LoadBalancer option, it will use the RandomLoadBalancer.
+// if you do not give LoadBalancer option, it will use the RandomLoadBalancer,
+// or the WeightedLoadBalancer if the secondary databases are given by WithSecondaryDBConfigs.
 func NewDBResolver(primaryDBsCfg *PrimaryDBsConfig, opts ...OptionFunc) (DBResolver, error) {
 	if primaryDBsCfg == nil || len(primaryDBsCfg.DBs) == 0 {
 		return nil, errNoPrimaryDB
@@ -133,21 +301,153 @@ func NewDBResolver(primaryDBsCfg *PrimaryDBsConfig, opts ...OptionFunc) (DBResol
 		return nil, err
 	}
 
+	secondaries := options.SecondaryDBs
+	for _, name := range options.secondaryGroupNames {
+		for _, db := range options.SecondaryGroups[name] {
+			if !containsDB(secondaries, db) {
+				secondaries = append(secondaries, db)
+			}
+		}
+	}
+
 	var reads []*sqlx.DB
-	reads = append(reads, options.SecondaryDBs...)
-	if primaryDBsCfg.ReadWritePolicy == ReadWrite {
+	reads = append(reads, secondaries...)
+	primariesReadable := options.ReadPreference != ReadReplicaOnly &&
+		(!options.PrimaryReadFallbackOnly || len(secondaries) == 0)
+	if primaryDBsCfg.ReadWritePolicy == ReadWrite && primariesReadable {
 		reads = append(reads, primaryDBsCfg.DBs...)
 	}
 	if len(reads) == 0 {
 		return nil, errNoDBToRead
 	}
+	if !options.AllowMixedDrivers {
+		dbs := append(append([]*sqlx.DB{}, primaryDBsCfg.DBs...), secondaries...)
+		if options.CanaryPrimary != nil {
+			dbs = append(dbs, options.CanaryPrimary)
+		}
+		if err := checkDrivers(dbs); err != nil {
+			return nil, err
+		}
+	}
 
-	return &dbResolver{
-		primaries:    primaryDBsCfg.DBs,
-		secondaries:  options.SecondaryDBs,
-		reads:        reads,
-		loadBalancer: options.LoadBalancer,
-	}, nil
+	r := &dbResolver{
+		primaries:            primaryDBsCfg.DBs,
+		secondaries:          secondaries,
+		reads:                reads,
+		groups:               options.SecondaryGroups,
+		loadBalancer:         options.LoadBalancer,
+		writeRetries:         options.WriteRetries,
+		readRetries:          options.ReadRetries,
+		now:                  options.Clock,
+		queryTimeout:         options.DefaultQueryTimeout,
+		poolChangeListener:   options.PoolChangeListener,
+		strictReadSeparation: options.StrictReadSeparation || options.ReadPreference == ReadReplicaOnly,
+		hedgeDelay:           options.HedgeDelay,
+		leaderSelector:       options.LeaderSelector,
+		routeHook:            options.RouteHook,
+		effectiveQueryHook:   options.EffectiveQueryHook,
+		logger:               options.Logger,
+		connErrClassifier:    options.ConnectionErrorClassifier,
+		tolerantPrepare:      options.TolerantPrepare,
+		fanOutConcurrency:    options.FanOutConcurrency,
+		candidateFilter:      options.CandidateFilter,
+		readYourWritesWindow: options.ReadYourWritesWindow,
+		shardFunc:            options.HashShardFunc,
+		defaultContext:       options.DefaultContext,
+	}
+	if options.ReadCoalescing {
+		r.coalescer = &coalescer{}
+	}
+	if options.ConnValidatorMaxIdle > 0 {
+		r.connValidator = newConnValidator(options.ConnValidatorMaxIdle)
+	}
+	if options.MaxPreparedStatements > 0 {
+		r.stmtLimiter = newStmtLimiter(options.MaxPreparedStatements)
+	}
+	if options.LatencyHistogram {
+		r.latencies = newLatencyHistograms()
+	}
+	if options.N1Detector != nil {
+		r.n1Detector = newN1Detector(
+			options.N1Detector.Threshold, options.N1Detector.Window, options.N1Detector.OnDetect,
+		)
+	}
+	if options.CanaryPrimary != nil {
+		r.canary = &canaryPrimary{db: options.CanaryPrimary, fraction: options.CanaryFraction}
+	}
+	if len(options.ReadSampling) > 0 {
+		r.readSampler = &readSampler{rates: options.ReadSampling}
+	}
+	r.readPreference = options.ReadPreference
+	r.preferPrimaryMaxInUse = options.PreferPrimaryMaxInUse
+	r.queryRouting = options.QueryRouting
+	r.readOnlyEnforcement = options.ReadOnlyEnforcement
+	r.observer = options.Observer
+	r.queryTag = options.QueryTagFromContext
+	r.wrapErrors = options.WrappedErrors
+	if lb, ok := r.loadBalancer.(dbWeighted); ok {
+		lb.bindDBs(r.reads)
+	}
+	if len(options.SecondaryDBConfigs) > 0 {
+		r.secondaryConfigs = make(map[*sqlx.DB]SecondaryDBConfig, len(options.SecondaryDBConfigs))
+		weights := make(map[*sqlx.DB]int, len(options.SecondaryDBConfigs))
+		regions := make(map[*sqlx.DB]string, len(options.SecondaryDBConfigs))
+		for _, cfg := range options.SecondaryDBConfigs {
+			if cfg.Weight < 1 {
+				cfg.Weight = 1
+			}
+			r.secondaryConfigs[cfg.DB] = cfg
+			weights[cfg.DB] = cfg.Weight
+			if cfg.Region != "" {
+				regions[cfg.DB] = cfg.Region
+			}
+		}
+		if lb, ok := r.loadBalancer.(dbWeighted); ok {
+			lb.setDBWeights(weights)
+		}
+		if lb, ok := r.loadBalancer.(dbRegioned); ok {
+			lb.setDBRegions(regions)
+		}
+	}
+	r.primaryMaxIdleConns = options.PrimaryMaxIdleConns
+	r.readMaxIdleConns = options.ReadMaxIdleConns
+	setConnLimits(r.primaries, options.PrimaryMaxOpenConns, options.PrimaryMaxIdleConns)
+	if r.canary != nil {
+		setConnLimits([]*sqlx.DB{r.canary.db}, options.PrimaryMaxOpenConns, options.PrimaryMaxIdleConns)
+	}
+	setConnLimits(r.secondaries, options.ReadMaxOpenConns, options.ReadMaxIdleConns)
+	if options.RandSource != nil {
+		src := &lockedSource{src: options.RandSource}
+		if lb, ok := r.loadBalancer.(randomized); ok {
+			lb.setRandom(rand.New(src))
+		}
+		if r.canary != nil {
+			r.canary.setRandom(rand.New(src))
+		}
+		if r.readSampler != nil {
+			r.readSampler.setRandom(rand.New(src))
+		}
+	}
+	if options.MapperFunc != nil {
+		r.MapperFunc(options.MapperFunc)
+	}
+	if options.CircuitBreakerFailures > 0 {
+		r.breaker = newCircuitBreaker(options.CircuitBreakerFailures, options.CircuitBreakerCooldown, r.clock)
+	}
+	if options.PingOnStartContext != nil {
+		dbs := append(append([]*sqlx.DB{}, r.primaries...), r.secondaries...)
+		if r.canary != nil {
+			dbs = append(dbs, r.canary.db)
+		}
+		if err := pingAll(options.PingOnStartContext, dbs, options.FanOutConcurrency); err != nil {
+			return nil, err
+		}
+	}
+	if options.HealthCheckInterval > 0 {
+		r.healthChecker = startHealthChecker(&r.health, r.secondaryDBs, options.HealthCheckInterval, r.onHealthChange)
+	}
+
+	return r, nil
 }
 
 func compileOptions(opts ...OptionFunc) (*Options, error) {
@@ -156,9 +456,23 @@ func compileOptions(opts ...OptionFunc) (*Options, error) {
 		opt(options)
 	}
 
+	if options.LoadBalancer == nil && len(options.SecondaryDBConfigs) > 0 {
+		options.LoadBalancer = NewWeightedLoadBalancer()
+	}
 	if options.LoadBalancer == nil {
 		options.LoadBalancer = NewRandomLoadBalancer()
 	}
+	if options.CanaryPrimary != nil && (options.CanaryFraction < 0 || options.CanaryFraction > 1) {
+		return nil, errInvalidCanaryFraction
+	}
+	for _, rate := range options.ReadSampling {
+		if rate < 0 || rate > 1 {
+			return nil, errors.Wrapf(errInvalidSamplingRate, "rate: %v", rate)
+		}
+	}
+	if _, ok := validReadPreferences[options.ReadPreference]; options.ReadPreference != "" && !ok {
+		return nil, errInvalidReadPreference
+	}
 
 	return options, nil
 }
@@ -171,129 +485,577 @@ func MustNewDBResolver(primaryDBsCfg *PrimaryDBsConfig, opts ...OptionFunc) DBRe
 	return db
 }
 
+// AcquirePrimary chooses a primary database and returns it.
+// It is an escape hatch for the features of the driver which the resolver does not expose.
+func (r *dbResolver) AcquirePrimary() *sqlx.DB {
+	return r.choose(r.baseContext(), r.primaries)
+}
+
+// AcquireRead chooses a readable database and returns it.
+// It is an escape hatch for the features of the driver which the resolver does not expose,
+// so the callers are responsible for not writing with it.
+// If no readable database is available, it returns a primary database,
+// or nil if the strict read separation is enabled.
+func (r *dbResolver) AcquireRead() *sqlx.DB {
+	candidates := r.readCandidates(r.baseContext())
+	if len(candidates) == 0 {
+		if r.strictReadSeparation {
+			return nil
+		}
+		return r.AcquirePrimary()
+	}
+	return r.balanceRead(r.baseContext(), candidates)
+}
+
+// AddSecondary adds the secondary database to the read pool.
+// If the database is already a secondary database, it does nothing.
+// If the mapper function is set by MapperFunc, it is set for the database as well.
+// The views of the resolver like GroupResolver are not affected.
+func (r *dbResolver) AddSecondary(db *sqlx.DB) {
+	root := r.shared()
+	root.poolMu.Lock()
+	if containsDB(root.secondaries, db) {
+		root.poolMu.Unlock()
+		return
+	}
+	if root.mapperFunc != nil {
+		db.MapperFunc(root.mapperFunc)
+	}
+	secondaries := make([]*sqlx.DB, 0, len(root.secondaries)+1)
+	secondaries = append(secondaries, root.secondaries...)
+	secondaries = append(secondaries, db)
+	root.setSecondaries(secondaries)
+	root.poolMu.Unlock()
+
+	root.notifyPoolChange(PoolChangeAdded, db)
+}
+
 // Begin chooses a primary database and starts a transaction.
+// If it fails with a connection error, it is retried with the other primary databases up to the write retries.
 // This supposed to be aligned with sqlx.DB.Begin.
 func (r *dbResolver) Begin() (*sql.Tx, error) {
-	db := r.loadBalancer.Select(context.Background(), r.primaries)
-	return db.Begin()
+	var tx *sql.Tx
+	err := r.begin(r.baseContext(), func(db *sqlx.DB) error {
+		var err error
+		tx, err = db.Begin()
+		return err
+	})
+	return tx, err
 }
 
 // BeginTx chooses a primary database and starts a transaction.
+// If it fails with a connection error, it is retried with the other primary databases up to the write retries.
 // This supposed to be aligned with sqlx.DB.BeginTx.
 func (r *dbResolver) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
-	db := r.loadBalancer.Select(ctx, r.primaries)
-	return db.BeginTx(ctx, opts)
+	var tx *sql.Tx
+	err := r.begin(ctx, func(db *sqlx.DB) error {
+		var err error
+		tx, err = db.BeginTx(ctx, opts)
+		return err
+	})
+	return tx, err
 }
 
 // BeginTxx chooses a primary database, begins a transaction and returns an *sqlx.Tx.
+// If it fails with a connection error, it is retried with the other primary databases up to the write retries.
 // This supposed to be aligned with sqlx.DB.BeginTxx.
 func (r *dbResolver) BeginTxx(ctx context.Context, opts *sql.TxOptions) (*sqlx.Tx, error) {
-	db := r.loadBalancer.Select(ctx, r.primaries)
-	return db.BeginTxx(ctx, opts)
+	var tx *sqlx.Tx
+	err := r.begin(ctx, func(db *sqlx.DB) error {
+		var err error
+		tx, err = db.BeginTxx(ctx, opts)
+		return err
+	})
+	return tx, err
 }
 
 // Beginx chooses a primary database, begins a transaction and returns an *sqlx.Tx.
+// If it fails with a connection error, it is retried with the other primary databases up to the write retries.
 // This supposed to be aligned with sqlx.DB.Beginx.
 func (r *dbResolver) Beginx() (*sqlx.Tx, error) {
-	db := r.loadBalancer.Select(context.Background(), r.primaries)
+	var tx *sqlx.Tx
+	err := r.begin(r.baseContext(), func(db *sqlx.DB) error {
+		var err error
+		tx, err = db.Beginx()
+		return err
+	})
+	return tx, err
+}
+
+// BeginxOn begins a transaction on the given primary database and returns an *sqlx.Tx,
+// e.g. to run it on the primary database which the previous write ran on.
+// The database is one of PrimaryDBs, otherwise it returns errUnknownPrimary.
+// It is not retried with the other primary databases on a connection error.
+func (r *dbResolver) BeginxOn(db *sqlx.DB) (*sqlx.Tx, error) {
+	if !containsDB(r.primaries, db) {
+		return nil, errUnknownPrimary
+	}
+	if err := r.shared().writeGate.wait(r.baseContext()); err != nil {
+		return nil, err
+	}
 	return db.Beginx()
 }
 
+// BeginxResolver chooses a primary database, begins a transaction and returns a TxResolver wrapping it.
+// All the queries of the TxResolver run in the transaction, so they are not routed to the readable databases.
+// If it fails with a connection error, it is retried with the other primary databases up to the write retries.
+func (r *dbResolver) BeginxResolver(ctx context.Context) (TxResolver, error) {
+	tx, err := r.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &txResolver{tx: tx}, nil
+}
+
 // BindNamed chooses a primary database and binds a query using the DB driver's bindvar type.
+// If the type of the argument is not supported, it returns errUnsupportedBindArg wrapping the error of sqlx.
 // This supposed to be aligned with sqlx.DB.BindNamed.
 func (r *dbResolver) BindNamed(query string, arg interface{}) (string, []interface{}, error) {
-	db := r.loadBalancer.Select(context.Background(), r.primaries)
-	return db.BindNamed(query, arg)
+	var db *sqlx.DB
+	if _, ok := r.bindType(); ok {
+		// Any primary database has the same bindvar type.
+		db = r.primaries[0]
+	} else {
+		db = r.choose(r.baseContext(), r.primaries)
+	}
+	bound, args, err := db.BindNamed(query, arg)
+	return bound, args, wrapBindError(err)
 }
 
-// Close closes all the databases.
-func (r *dbResolver) Close() error {
+// BindNamedForDriver binds a query using the bindvar type of the given driver without choosing a database.
+// It works even if the driver is different from the drivers of the databases.
+// If the type of the argument is not supported, it returns errUnsupportedBindArg wrapping the error of sqlx.
+func (r *dbResolver) BindNamedForDriver(driverName, query string, arg interface{}) (string, []interface{}, error) {
+	// sqlx.DB.BindNamed uses only the driver name and the mapper, so the DB is not needed.
+	bound, args, err := sqlx.NewDb(nil, driverName).BindNamed(query, arg)
+	return bound, args, wrapBindError(err)
+}
+
+// CheckSchemaConsistency runs the given version query on all the databases
+// and returns the version of each database scanned by the given scan function.
+// It also reports whether all the databases have the same version.
+// The errors of the databases are aggregated and the databases which failed are not in the returned map.
+func (r *dbResolver) CheckSchemaConsistency(
+	ctx context.Context, query string, scan func(rows *sqlx.Rows) (string, error),
+) (map[*sqlx.DB]string, bool, error) {
+	secondaries := r.secondaryDBs()
+	dbs := make([]*sqlx.DB, 0, len(r.primaries)+len(secondaries))
+	dbs = append(dbs, r.primaries...)
+	dbs = append(dbs, secondaries...)
+
+	versions := make(map[*sqlx.DB]string, len(dbs))
 	var errs error
-	for _, db := range r.primaries {
-		if err := db.Close(); err != nil {
+	for _, db := range dbs {
+		if _, ok := versions[db]; ok {
+			continue
+		}
+
+		rows, err := db.QueryxContext(ctx, query)
+		if err != nil {
 			errs = multierror.Append(errs, err)
+			continue
 		}
-	}
-	for _, db := range r.secondaries {
-		if err := db.Close(); err != nil {
+		version, err := scan(rows)
+		if closeErr := rows.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+		if err != nil {
 			errs = multierror.Append(errs, err)
+			continue
 		}
+
+		versions[db] = version
+	}
+
+	distinct := make(map[string]struct{}, 1)
+	for _, version := range versions {
+		distinct[version] = struct{}{}
+	}
+	consistent := errs == nil && len(distinct) <= 1
+
+	return versions, consistent, errs
+}
+
+// Close stops the background health check and closes all the databases including the canary primary database.
+// If the resolver is a view of another resolver like GroupResolver, it does nothing
+// because the databases are shared. It is the same as CloseContext with context.Background().
+func (r *dbResolver) Close() error {
+	return r.CloseContext(context.Background())
+}
+
+// CloseContext stops the background health check, closes the prepared statements which are not closed yet,
+// and closes all the databases including the canary primary database concurrently.
+// Each of the leaked statements is reported to the logger set by WithLogger with the "stmt_leaked" event.
+// The errors are aggregated and each of them is wrapped with the label and the driver name of the database,
+// e.g. "close secondary[1] (mysql): ...". If the context is done before all the databases are closed,
+// it returns without waiting for the rest with the error of the context for each of them,
+// but they are still closed in the background.
+// If the resolver is a view of another resolver like GroupResolver, it does nothing
+// because the databases are shared.
+func (r *dbResolver) CloseContext(ctx context.Context) error {
+	if r.root != nil {
+		return nil
+	}
+	if r.healthChecker != nil {
+		r.healthChecker.stop()
+	}
+
+	var merr error
+	for stmt, query := range r.stmts.open() {
+		if r.logger != nil {
+			r.logger(ctx, "stmt_leaked", errors.Wrapf(errStatementLeaked, "query: %s", query))
+		}
+		if err := stmt.Close(); err != nil {
+			merr = multierror.Append(merr, err)
+		}
+	}
+
+	dbs := append([]*sqlx.DB{}, r.primaries...)
+	if r.canary != nil {
+		dbs = append(dbs, r.canary.db)
+	}
+	dbs = uniqueDBs(append(dbs, r.secondaryDBs()...))
+
+	type closeResult struct {
+		i   int
+		err error
+	}
+	results := make(chan closeResult, len(dbs))
+	for i, db := range dbs {
+		go func(i int, db *sqlx.DB) {
+			results <- closeResult{i: i, err: db.Close()}
+		}(i, db)
+	}
+
+	errs := make([]error, len(dbs))
+	closed := make([]bool, len(dbs))
+	for n := 0; n < len(dbs); n++ {
+		select {
+		case res := <-results:
+			errs[res.i], closed[res.i] = res.err, true
+		case <-ctx.Done():
+			for i := range dbs {
+				if !closed[i] {
+					errs[i] = ctx.Err()
+				}
+			}
+			n = len(dbs)
+		}
+	}
+
+	for i, err := range errs {
+		if err != nil {
+			merr = multierror.Append(merr, errors.Wrapf(err, "close %s (%s)", r.dbName(dbs[i]), dbs[i].DriverName()))
+		}
+	}
+	return merr
+}
+
+// CloseIdleConnections closes the idle connections of all the databases.
+// database/sql has no way to close the idle connections directly, so it sets the maximum number of
+// the idle connections to zero, which closes them, and restores the number set by SetMaxIdleConns,
+// the number of the tier set by WithPrimaryMaxIdleConns or WithReadMaxIdleConns,
+// or the default of database/sql in that order.
+// The numbers set to the databases directly are not restored.
+func (r *dbResolver) CloseIdleConnections() {
+	root := r.shared()
+	root.idleMu.Lock()
+	defer root.idleMu.Unlock()
+
+	maxIdleConns := func(tier int) int {
+		switch {
+		case root.maxIdleConns != nil:
+			return *root.maxIdleConns
+		case tier != 0:
+			return tier
+		default:
+			return defaultMaxIdleConns
+		}
+	}
+	primaries := r.primaries
+	if r.canary != nil {
+		primaries = append(append([]*sqlx.DB{}, primaries...), r.canary.db)
+	}
+	for _, db := range primaries {
+		db.SetMaxIdleConns(0)
+		db.SetMaxIdleConns(maxIdleConns(root.primaryMaxIdleConns))
+	}
+	for _, db := range r.secondaryDBs() {
+		db.SetMaxIdleConns(0)
+		db.SetMaxIdleConns(maxIdleConns(root.readMaxIdleConns))
 	}
-	return errs
 }
 
 // Conn chooses a primary database and returns a *sql.Conn.
 // This supposed to be aligned with sqlx.DB.Conn.
 func (r *dbResolver) Conn(ctx context.Context) (*sql.Conn, error) {
-	db := r.loadBalancer.Select(ctx, r.primaries)
+	if !r.shared().drain.enter() {
+		return nil, errShutdown
+	}
+	defer r.shared().drain.exit()
+
+	db, err := r.selectPrimary(ctx, r.primaries)
+	if err != nil {
+		return nil, err
+	}
 	return db.Conn(ctx)
 }
 
 // Connx chooses a primary database and returns a *sqlx.Conn.
 // This supposed to be aligned with sqlx.DB.Connx.
 func (r *dbResolver) Connx(ctx context.Context) (*sqlx.Conn, error) {
-	db := r.loadBalancer.Select(ctx, r.primaries)
+	if !r.shared().drain.enter() {
+		return nil, errShutdown
+	}
+	defer r.shared().drain.exit()
+
+	db, err := r.selectPrimary(ctx, r.primaries)
+	if err != nil {
+		return nil, err
+	}
 	return db.Connx(ctx)
 }
 
 // Driver chooses a primary database and returns a driver.Driver.
 // This supposed to be aligned with sqlx.DB.Driver.
 func (r *dbResolver) Driver() driver.Driver {
-	db := r.loadBalancer.Select(context.Background(), r.primaries)
+	db := r.choose(r.baseContext(), r.primaries)
 	return db.Driver()
 }
 
 // DriverName chooses a primary database and returns the driverName.
 // This supposed to be aligned with sqlx.DB.DriverName.
 func (r *dbResolver) DriverName() string {
-	db := r.loadBalancer.Select(context.Background(), r.primaries)
+	db := r.choose(r.baseContext(), r.primaries)
 	return db.DriverName()
 }
 
 // Exec chooses a primary database and executes a query without returning any rows.
 // This supposed to be aligned with sqlx.DB.Exec.
 func (r *dbResolver) Exec(query string, args ...interface{}) (sql.Result, error) {
-	db := r.loadBalancer.Select(context.Background(), r.primaries)
-	return db.Exec(query, args...)
+	var result sql.Result
+	err := r.write(r.baseContext(), newRequest("Exec", query, args...), func(db *sqlx.DB) error {
+		var err error
+		result, err = db.Exec(r.tagQuery(r.baseContext(), query), args...)
+		return err
+	})
+	return result, err
 }
 
 // ExecContext chooses a primary database and executes a query without returning any rows.
 // This supposed to be aligned with sqlx.DB.ExecContext.
 func (r *dbResolver) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
-	db := r.loadBalancer.Select(ctx, r.primaries)
-	return db.Exec(query, args...)
+	ctx, cancel := r.withQueryTimeout(ctx)
+	defer cancel()
+
+	var result sql.Result
+	err := r.write(ctx, newRequest("Exec", query, args...), func(db *sqlx.DB) error {
+		var err error
+		result, err = db.ExecContext(ctx, r.tagQuery(ctx, query), args...)
+		return err
+	})
+	return result, err
+}
+
+// ExecContextDetailed is the same as ExecContext but also returns how the write was executed.
+// It is useful to observe the retries enabled by WithWriteRetry.
+func (r *dbResolver) ExecContextDetailed(ctx context.Context, query string, args ...interface{}) (sql.Result, ExecMeta, error) {
+	ctx, cancel := r.withQueryTimeout(ctx)
+	defer cancel()
+
+	var result sql.Result
+	meta, err := r.writeWithMeta(ctx, newRequest("Exec", query, args...), func(db *sqlx.DB) error {
+		var err error
+		result, err = db.ExecContext(ctx, r.tagQuery(ctx, query), args...)
+		return err
+	})
+	return result, meta, err
+}
+
+// ExecOnAllPrimaries executes the query on each primary database concurrently and returns the result of each database.
+// It is for the DDL and the maintenance like the schema migrations which must run on every primary database,
+// not for the normal writes. The number of the concurrent queries is bounded by WithFanOutConcurrency.
+// The errors of the databases are aggregated without aborting the others,
+// and the databases which failed are not in the returned map.
+func (r *dbResolver) ExecOnAllPrimaries(
+	ctx context.Context, query string, args ...interface{},
+) (map[*sqlx.DB]sql.Result, error) {
+	if !r.shared().drain.enter() {
+		return nil, errShutdown
+	}
+	defer r.shared().drain.exit()
+
+	if err := r.shared().writeGate.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	var (
+		mu     sync.Mutex
+		result = make(map[*sqlx.DB]sql.Result, len(r.primaries))
+		errs   error
+	)
+	fanOut(r.primaries, r.fanOutConcurrency, func(db *sqlx.DB) {
+		res, err := db.ExecContext(ctx, r.tagQuery(ctx, query), args...)
+
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			errs = multierror.Append(errs, err)
+			return
+		}
+		result[db] = res
+	})
+
+	return result, errs
 }
 
 // Get chooses a readable database and Get using chosen DB.
 // This supposed to be aligned with sqlx.DB.Get.
 func (r *dbResolver) Get(dest interface{}, query string, args ...interface{}) error {
-	db := r.loadBalancer.Select(context.Background(), r.reads)
-	err := db.Get(dest, query, args...)
-	if isDBConnectionError(err) {
-		dbPrimary := r.loadBalancer.Select(context.Background(), r.primaries)
-		err = dbPrimary.Get(dest, query, args...)
-	}
-	return err
+	return r.readInto(
+		r.baseContext(), newRequest("Get", query, args...), dest,
+		func(_ context.Context, db *sqlx.DB, dest interface{}) error {
+			return db.Get(dest, r.tagQuery(r.baseContext(), query), args...)
+		},
+	)
 }
 
 // GetContext chooses a readable database and Get using chosen DB.
 // This supposed to be aligned with sqlx.DB.GetContext.
 func (r *dbResolver) GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
-	db := r.loadBalancer.Select(ctx, r.reads)
-	err := db.GetContext(ctx, dest, query, args...)
-	if isDBConnectionError(err) {
-		dbPrimary := r.loadBalancer.Select(ctx, r.primaries)
-		err = dbPrimary.GetContext(ctx, dest, query, args...)
+	ctx, cancel := r.withQueryTimeout(ctx)
+	defer cancel()
+
+	return r.readInto(
+		ctx, newRequest("Get", query, args...), dest,
+		func(ctx context.Context, db *sqlx.DB, dest interface{}) error {
+			return db.GetContext(ctx, dest, r.tagQuery(ctx, query), args...)
+		},
+	)
+}
+
+// GetEachRead executes the query returning a single row on each readable database concurrently
+// and scans the row of each database into the destination returned by newDest for the database,
+// e.g. to compare the replication lag of the databases. newDest may be called concurrently.
+// The number of the concurrent queries is bounded by WithFanOutConcurrency.
+// It returns the errors of the databases which failed, so the returned map is empty if all succeeded.
+// Once Shutdown is called, every readable database fails with an error.
+func (r *dbResolver) GetEachRead(
+	ctx context.Context, newDest func(db *sqlx.DB) interface{}, query string, args ...interface{},
+) map[*sqlx.DB]error {
+	var (
+		mu   sync.Mutex
+		errs = make(map[*sqlx.DB]error)
+	)
+	if !r.shared().drain.enter() {
+		for _, db := range r.readDBs() {
+			errs[db] = errShutdown
+		}
+		return errs
 	}
-	return err
+	defer r.shared().drain.exit()
+
+	fanOut(r.readDBs(), r.fanOutConcurrency, func(db *sqlx.DB) {
+		if err := db.GetContext(ctx, newDest(db), r.tagQuery(ctx, query), args...); err != nil {
+			mu.Lock()
+			defer mu.Unlock()
+			errs[db] = err
+		}
+	})
+
+	return errs
 }
 
-// MapperFunc sets the mapper function for the all primary databases and secondary databases.
+// GetFrom chooses a readable database and Get using chosen DB like GetContext,
+// and returns the database which served it, which is a primary database if the read fell back to it.
+// The read is not coalesced even if WithReadCoalescing is given.
+func (r *dbResolver) GetFrom(ctx context.Context, dest interface{}, query string, args ...interface{}) (*sqlx.DB, error) {
+	ctx, cancel := r.withQueryTimeout(ctx)
+	defer cancel()
+
+	return r.readIntoFrom(
+		ctx, newRequest("Get", query, args...), dest,
+		func(ctx context.Context, db *sqlx.DB, dest interface{}) error {
+			return db.GetContext(ctx, dest, r.tagQuery(ctx, query), args...)
+		},
+	)
+}
+
+// GetWithRole chooses a readable database and Get using chosen DB like GetContext,
+// and returns the role of the database which served it.
+// The role is RolePrimary if a primary database served it, e.g. by falling back, and RoleReplica otherwise.
+// The read is not coalesced even if WithReadCoalescing is given.
+func (r *dbResolver) GetWithRole(
+	ctx context.Context, dest interface{}, query string, args ...interface{},
+) (role string, err error) {
+	ctx, cancel := r.withQueryTimeout(ctx)
+	defer cancel()
+
+	return r.readIntoWithRole(
+		ctx, newRequest("Get", query, args...), dest,
+		func(ctx context.Context, db *sqlx.DB, dest interface{}) error {
+			return db.GetContext(ctx, dest, r.tagQuery(ctx, query), args...)
+		},
+	)
+}
+
+// GroupResolver returns a view of the resolver which reads only from the secondary databases
+// of the given group and writes to the primary databases.
+// The view shares the databases and the state with the resolver, so closing the view does not close the databases.
+// If the group is unknown, it returns an error.
+func (r *dbResolver) GroupResolver(name string) (DBResolver, error) {
+	dbs, ok := r.shared().groupDBs(name)
+	if !ok || len(dbs) == 0 {
+		return nil, errors.Wrapf(errUnknownSecondaryGroup, "group: %s", name)
+	}
+
+	return r.view(dbs), nil
+}
+
+// HealthyReplicaCount returns the number of secondary databases which are considered healthy.
+// If health checks are disabled, no database is ever marked unhealthy,
+// so it returns the number of all secondary databases.
+func (r *dbResolver) HealthyReplicaCount() int {
+	return r.shared().health.countHealthy(r.secondaryDBs())
+}
+
+// In expands the slice arguments into the IN clause of the query like sqlx.In,
+// and chooses a primary database and rebinds the expanded query into the bindvar type of it.
+// The expanded query can be given to the other methods like Query and Select.
+func (r *dbResolver) In(query string, args ...interface{}) (string, []interface{}, error) {
+	query, args, err := sqlx.In(query, args...)
+	if err != nil {
+		return "", nil, err
+	}
+	return r.Rebind(query), args, nil
+}
+
+// LatencyPercentiles returns the 50th, 95th and 99th percentile latencies of the queries
+// executed on the databases of the given role. The role is either RolePrimary or RoleRead.
+// If the latency histogram is not enabled or the role is unknown, it returns zeros.
+func (r *dbResolver) LatencyPercentiles(role string) (p50, p95, p99 time.Duration) {
+	h, ok := r.latencies[role]
+	if !ok {
+		return 0, 0, 0
+	}
+	return h.percentile(0.5), h.percentile(0.95), h.percentile(0.99)
+}
+
+// MapperFunc sets the mapper function for the all primary databases and readable databases.
+// The mapper function is also set for the secondary databases added by AddSecondary later.
 func (r *dbResolver) MapperFunc(mf func(string) string) {
+	root := r.shared()
+	root.poolMu.Lock()
+	root.mapperFunc = mf
+	root.poolMu.Unlock()
+
 	for _, db := range r.primaries {
 		db.MapperFunc(mf)
 	}
-	for _, db := range r.secondaries {
+	if r.canary != nil {
+		r.canary.db.MapperFunc(mf)
+	}
+	for _, db := range r.readDBs() {
 		db.MapperFunc(mf)
 	}
 }
@@ -301,112 +1063,223 @@ func (r *dbResolver) MapperFunc(mf func(string) string) {
 // MustBegin chooses a primary database, starts a transaction and returns an *sqlx.Tx or panic.
 // This supposed to be aligned with sqlx.DB.MustBegin.
 func (r *dbResolver) MustBegin() *sqlx.Tx {
-	db := r.loadBalancer.Select(context.Background(), r.primaries)
-	return db.MustBegin()
+	tx, err := r.Beginx()
+	if err != nil {
+		panic(err)
+	}
+	return tx
 }
 
 // MustBeginTx chooses a primary database, starts a transaction and returns an *sqlx.Tx or panic.
 // This supposed to be aligned with sqlx.DB.MustBeginTx.
 func (r *dbResolver) MustBeginTx(ctx context.Context, opts *sql.TxOptions) *sqlx.Tx {
-	db := r.loadBalancer.Select(ctx, r.primaries)
-	return db.MustBeginTx(ctx, opts)
+	tx, err := r.BeginTxx(ctx, opts)
+	if err != nil {
+		panic(err)
+	}
+	return tx
 }
 
 // MustExec chooses a primary database and executes a query or panic.
 // This supposed to be aligned with sqlx.DB.MustExec.
 func (r *dbResolver) MustExec(query string, args ...interface{}) sql.Result {
-	db := r.loadBalancer.Select(context.Background(), r.primaries)
-	return db.MustExec(query, args...)
+	var result sql.Result
+	err := r.write(r.baseContext(), newRequest("MustExec", query, args...), func(db *sqlx.DB) error {
+		result = db.MustExec(r.tagQuery(r.baseContext(), query), args...)
+		return nil
+	})
+	if err != nil {
+		panic(err)
+	}
+	return result
 }
 
 // MustExecContext chooses a primary database and executes a query or panic.
 // This supposed to be aligned with sqlx.DB.MustExecContext.
 func (r *dbResolver) MustExecContext(ctx context.Context, query string, args ...interface{}) sql.Result {
-	db := r.loadBalancer.Select(ctx, r.primaries)
-	return db.MustExecContext(ctx, query, args...)
+	ctx, cancel := r.withQueryTimeout(ctx)
+	defer cancel()
+
+	var result sql.Result
+	err := r.write(ctx, newRequest("MustExec", query, args...), func(db *sqlx.DB) error {
+		result = db.MustExecContext(ctx, r.tagQuery(ctx, query), args...)
+		return nil
+	})
+	if err != nil {
+		panic(err)
+	}
+	return result
+}
+
+// MustPrepare returns a Stmt like Prepare or panic.
+func (r *dbResolver) MustPrepare(query string) Stmt {
+	stmt, err := r.Prepare(query)
+	if err != nil {
+		panic(err)
+	}
+	return stmt
+}
+
+// MustPrepareNamed returns a NamedStmt like PrepareNamed or panic.
+func (r *dbResolver) MustPrepareNamed(query string) NamedStmt {
+	stmt, err := r.PrepareNamed(query)
+	if err != nil {
+		panic(err)
+	}
+	return stmt
+}
+
+// MustPreparex returns a Stmt like Preparex or panic.
+func (r *dbResolver) MustPreparex(query string) Stmt {
+	stmt, err := r.Preparex(query)
+	if err != nil {
+		panic(err)
+	}
+	return stmt
+}
+
+// MustTransaction runs fn in a transaction like Transaction or panic.
+func (r *dbResolver) MustTransaction(ctx context.Context, opts *sql.TxOptions, fn func(tx *sqlx.Tx) error) {
+	if err := r.Transaction(ctx, opts, fn); err != nil {
+		panic(err)
+	}
 }
 
 // NamedExec chooses a primary database and then executes a named query.
 // This supposed to be aligned with sqlx.DB.NamedExec.
 func (r *dbResolver) NamedExec(query string, arg interface{}) (sql.Result, error) {
-	db := r.loadBalancer.Select(context.Background(), r.primaries)
-	return db.NamedExec(query, arg)
+	var result sql.Result
+	err := r.write(r.baseContext(), newNamedRequest("NamedExec", query, arg), func(db *sqlx.DB) error {
+		var err error
+		result, err = db.NamedExec(r.tagNamedQuery(r.baseContext(), query), arg)
+		return err
+	})
+	return result, err
 }
 
 // NamedExecContext chooses a primary database and then executes a named query.
 // This supposed to be aligned with sqlx.DB.NamedExecContext.
 func (r *dbResolver) NamedExecContext(ctx context.Context, query string, arg interface{}) (sql.Result, error) {
-	db := r.loadBalancer.Select(ctx, r.primaries)
-	return db.NamedExecContext(ctx, query, arg)
+	ctx, cancel := r.withQueryTimeout(ctx)
+	defer cancel()
+
+	var result sql.Result
+	err := r.write(ctx, newNamedRequest("NamedExec", query, arg), func(db *sqlx.DB) error {
+		var err error
+		result, err = db.NamedExecContext(ctx, r.tagNamedQuery(ctx, query), arg)
+		return err
+	})
+	return result, err
+}
+
+// NamedExecReturning chooses a primary database, executes a named write with a RETURNING clause,
+// e.g. INSERT ... RETURNING id, and scans the single returned row into dest like Get.
+// It returns sql.ErrNoRows if no row is returned.
+func (r *dbResolver) NamedExecReturning(ctx context.Context, dest interface{}, query string, arg interface{}) error {
+	ctx, cancel := r.withQueryTimeout(ctx)
+	defer cancel()
+
+	return r.write(ctx, newNamedRequest("NamedExecReturning", query, arg), func(db *sqlx.DB) error {
+		bound, args, err := db.BindNamed(r.tagNamedQuery(ctx, query), arg)
+		if err != nil {
+			return err
+		}
+		return db.GetContext(ctx, dest, bound, args...)
+	})
 }
 
 // NamedQuery chooses a readable database and then executes a named query.
+// If the query is a write like INSERT ... RETURNING, it chooses a primary database instead.
 // This supposed to be aligned with sqlx.DB.NamedQuery.
 func (r *dbResolver) NamedQuery(query string, arg interface{}) (*sqlx.Rows, error) {
-	db := r.loadBalancer.Select(context.Background(), r.reads)
-	rows, err := db.NamedQuery(query, arg)
-	if isDBConnectionError(err) {
-		dbPrimary := r.loadBalancer.Select(context.Background(), r.primaries)
-		rows, err = dbPrimary.NamedQuery(query, arg)
-	}
+	var rows *sqlx.Rows
+	err := r.routeQuery(query)(r.baseContext(), newNamedRequest("NamedQuery", query, arg), func(db *sqlx.DB) error {
+		var err error
+		rows, err = db.NamedQuery(r.tagNamedQuery(r.baseContext(), query), arg)
+		return err
+	})
 	return rows, err
 }
 
 // NamedQueryContext chooses a readable database and then executes a named query.
+// If the query is a write like INSERT ... RETURNING, it chooses a primary database instead.
 // This supposed to be aligned with sqlx.DB.NamedQueryContext.
 func (r *dbResolver) NamedQueryContext(ctx context.Context, query string, arg interface{}) (*sqlx.Rows, error) {
-	db := r.loadBalancer.Select(ctx, r.reads)
-	rows, err := db.NamedQueryContext(ctx, query, arg)
-	if isDBConnectionError(err) {
-		dbPrimary := r.loadBalancer.Select(ctx, r.primaries)
-		rows, err = dbPrimary.NamedQueryContext(ctx, query, arg)
-	}
+	var rows *sqlx.Rows
+	err := r.routeQuery(query)(ctx, newNamedRequest("NamedQuery", query, arg), func(db *sqlx.DB) error {
+		var err error
+		rows, err = db.NamedQueryContext(ctx, r.tagNamedQuery(ctx, query), arg)
+		return err
+	})
 	return rows, err
 }
 
+// OpenStatementQueries returns the queries of the prepared statements which are not closed yet in order,
+// e.g. to find the statements leaked when OpenStatements keeps growing.
+func (r *dbResolver) OpenStatementQueries() []string {
+	open := r.shared().stmts.open()
+	queries := make([]string, 0, len(open))
+	for _, query := range open {
+		queries = append(queries, query)
+	}
+	sort.Strings(queries)
+	return queries
+}
+
+// OpenStatements returns the number of the prepared statements which are not closed yet,
+// i.e. the Stmts and the NamedStmts returned by the Prepare methods. A growing number is a sign of the leak.
+// Each of them holds a statement on each database.
+func (r *dbResolver) OpenStatements() int {
+	return r.shared().stmts.len()
+}
+
+// PauseWrites pauses the writes until ResumeWrites is called.
+// The write methods and the methods starting a transaction block while the writes are paused
+// and then proceed with the primary databases at that time.
+// If the context of the blocked method is done, it returns the context error.
+// It is useful for the planned primary switchover.
+func (r *dbResolver) PauseWrites() {
+	r.shared().writeGate.pause()
+}
+
 // Ping sends a ping to the all databases.
 func (r *dbResolver) Ping() error {
-	var errs error
-	for _, db := range r.primaries {
-		if err := db.Ping(); err != nil {
-			errs = multierror.Append(errs, err)
-		}
-	}
-	for _, db := range r.secondaries {
-		if err := db.Ping(); err != nil {
-			errs = multierror.Append(errs, err)
-		}
-	}
-	if errs != nil {
-		return errs
-	}
-	return nil
+	return r.PingContext(r.baseContext())
 }
 
-// PingContext sends a ping to the all databases.
+// PingContext sends a ping to the all databases concurrently.
+// The number of the concurrent pings is limited by WithFanOutConcurrency.
+// Once the context is done, the remaining databases are not pinged.
 func (r *dbResolver) PingContext(ctx context.Context) error {
-	var errs error
-	for _, db := range r.primaries {
-		if err := db.PingContext(ctx); err != nil {
-			errs = multierror.Append(errs, err)
-		}
-	}
-	for _, db := range r.secondaries {
-		if err := db.PingContext(ctx); err != nil {
-			errs = multierror.Append(errs, err)
-		}
-	}
-	if errs != nil {
-		return errs
-	}
-	return nil
+	primaries := r.primaries
+	secondaries := r.secondaryDBs()
+	dbs := make([]*sqlx.DB, 0, len(primaries)+len(secondaries))
+	dbs = append(dbs, primaries...)
+	dbs = append(dbs, secondaries...)
+	return pingAll(ctx, dbs, r.fanOutConcurrency)
+}
+
+// PingPrimaries sends a ping to the all primary databases.
+func (r *dbResolver) PingPrimaries(ctx context.Context) error {
+	return pingAll(ctx, r.primaries, r.fanOutConcurrency)
+}
+
+// PingReads sends a ping to the all readable databases.
+func (r *dbResolver) PingReads(ctx context.Context) error {
+	return pingAll(ctx, r.readDBs(), r.fanOutConcurrency)
 }
 
 // Prepare returns a Stmt which can be used sql.Stmt instead.
 // This supposed to be aligned with sqlx.DB.Prepare.
 func (r *dbResolver) Prepare(query string) (Stmt, error) {
+	reads := r.readDBs()
+	size := len(r.primaries) + len(reads)
+	if err := r.stmtLimiter.reserve(size); err != nil {
+		return nil, err
+	}
+
 	primaryDBStmts := make(map[*sqlx.DB]*sqlx.Stmt, len(r.primaries))
-	readDBStmts := make(map[*sqlx.DB]*sqlx.Stmt, len(r.reads))
+	readDBStmts := make(map[*sqlx.DB]*sqlx.Stmt, len(reads))
 
 	var errs error
 	for _, db := range r.primaries {
@@ -418,7 +1291,7 @@ func (r *dbResolver) Prepare(query string) (Stmt, error) {
 
 		primaryDBStmts[db] = stmt
 	}
-	for _, db := range r.reads {
+	for _, db := range reads {
 		stmt, err := db.Preparex(query)
 		if err != nil {
 			errs = multierror.Append(errs, err)
@@ -427,24 +1300,39 @@ func (r *dbResolver) Prepare(query string) (Stmt, error) {
 
 		readDBStmts[db] = stmt
 	}
-	if errs != nil {
+	if errs != nil && !r.toleratePrepareErrors(r.baseContext(), errs, len(primaryDBStmts), len(readDBStmts)) {
+		r.stmtLimiter.release(size)
 		return nil, errs
 	}
 
-	return &stmt{
-		primaries:    r.primaries,
-		reads:        r.reads,
-		primaryStmts: primaryDBStmts,
-		readStmts:    readDBStmts,
-		loadBalancer: r.loadBalancer,
-	}, nil
+	s := &stmt{
+		primaries:         r.primaries,
+		reads:             reads,
+		primaryStmts:      primaryDBStmts,
+		readStmts:         readDBStmts,
+		loadBalancer:      r.loadBalancer,
+		limiter:           r.stmtLimiter,
+		registry:          &r.shared().stmts,
+		drain:             &r.shared().drain,
+		connErrClassifier: r.connErrClassifier,
+	}
+	r.stmtLimiter.add(s, size)
+	r.shared().stmts.add(s, query)
+
+	return s, nil
 }
 
 // PrepareContext returns a Stmt which can be used sql.Stmt instead.
 // This supposed to be aligned with sqlx.DB.PrepareContext.
 func (r *dbResolver) PrepareContext(ctx context.Context, query string) (Stmt, error) {
+	reads := r.readDBs()
+	size := len(r.primaries) + len(reads)
+	if err := r.stmtLimiter.reserve(size); err != nil {
+		return nil, err
+	}
+
 	primaryDBStmts := make(map[*sqlx.DB]*sqlx.Stmt, len(r.primaries))
-	readDBStmts := make(map[*sqlx.DB]*sqlx.Stmt, len(r.reads))
+	readDBStmts := make(map[*sqlx.DB]*sqlx.Stmt, len(reads))
 
 	var errs error
 	for _, db := range r.primaries {
@@ -456,7 +1344,7 @@ func (r *dbResolver) PrepareContext(ctx context.Context, query string) (Stmt, er
 
 		primaryDBStmts[db] = stmt
 	}
-	for _, db := range r.reads {
+	for _, db := range reads {
 		stmt, err := db.PreparexContext(ctx, query)
 		if err != nil {
 			errs = multierror.Append(errs, err)
@@ -465,24 +1353,39 @@ func (r *dbResolver) PrepareContext(ctx context.Context, query string) (Stmt, er
 
 		readDBStmts[db] = stmt
 	}
-	if errs != nil {
+	if errs != nil && !r.toleratePrepareErrors(ctx, errs, len(primaryDBStmts), len(readDBStmts)) {
+		r.stmtLimiter.release(size)
 		return nil, errs
 	}
 
-	return &stmt{
-		primaries:    r.primaries,
-		reads:        r.reads,
-		primaryStmts: primaryDBStmts,
-		readStmts:    readDBStmts,
-		loadBalancer: r.loadBalancer,
-	}, nil
+	s := &stmt{
+		primaries:         r.primaries,
+		reads:             reads,
+		primaryStmts:      primaryDBStmts,
+		readStmts:         readDBStmts,
+		loadBalancer:      r.loadBalancer,
+		limiter:           r.stmtLimiter,
+		registry:          &r.shared().stmts,
+		drain:             &r.shared().drain,
+		connErrClassifier: r.connErrClassifier,
+	}
+	r.stmtLimiter.add(s, size)
+	r.shared().stmts.add(s, query)
+
+	return s, nil
 }
 
 // PrepareNamed returns an NamedStmt which can be used sqlx.NamedStmt instead.
 // This supposed to be aligned with sqlx.DB.PrepareNamed.
 func (r *dbResolver) PrepareNamed(query string) (NamedStmt, error) {
+	reads := r.readDBs()
+	size := len(r.primaries) + len(reads)
+	if err := r.stmtLimiter.reserve(size); err != nil {
+		return nil, err
+	}
+
 	primaryDBStmts := make(map[*sqlx.DB]*sqlx.NamedStmt, len(r.primaries))
-	readDBStmts := make(map[*sqlx.DB]*sqlx.NamedStmt, len(r.reads))
+	readDBStmts := make(map[*sqlx.DB]*sqlx.NamedStmt, len(reads))
 
 	var errs error
 	for _, db := range r.primaries {
@@ -494,7 +1397,7 @@ func (r *dbResolver) PrepareNamed(query string) (NamedStmt, error) {
 
 		primaryDBStmts[db] = stmt
 	}
-	for _, db := range r.reads {
+	for _, db := range reads {
 		stmt, err := db.PrepareNamed(query)
 		if err != nil {
 			errs = multierror.Append(errs, err)
@@ -503,24 +1406,42 @@ func (r *dbResolver) PrepareNamed(query string) (NamedStmt, error) {
 
 		readDBStmts[db] = stmt
 	}
-	if errs != nil {
+	if errs != nil && !r.toleratePrepareErrors(r.baseContext(), errs, len(primaryDBStmts), len(readDBStmts)) {
+		r.stmtLimiter.release(size)
 		return nil, errs
 	}
 
-	return &namedStmt{
-		primaries:    r.primaries,
-		reads:        r.reads,
-		primaryStmts: primaryDBStmts,
-		readStmts:    readDBStmts,
-		loadBalancer: r.loadBalancer,
-	}, nil
+	s := &namedStmt{
+		primaries:            r.primaries,
+		reads:                reads,
+		primaryStmts:         primaryDBStmts,
+		readStmts:            readDBStmts,
+		loadBalancer:         r.loadBalancer,
+		limiter:              r.stmtLimiter,
+		registry:             &r.shared().stmts,
+		drain:                &r.shared().drain,
+		connErrClassifier:    r.connErrClassifier,
+		readRetries:          r.readRetries,
+		writeRetries:         r.writeRetries,
+		strictReadSeparation: r.strictReadSeparation,
+	}
+	r.stmtLimiter.add(s, size)
+	r.shared().stmts.add(s, query)
+
+	return s, nil
 }
 
 // PrepareNamedContext returns an NamedStmt which can be used sqlx.NamedStmt instead.
 // This supposed to be aligned with sqlx.DB.PrepareNamedContext.
 func (r *dbResolver) PrepareNamedContext(ctx context.Context, query string) (NamedStmt, error) {
+	reads := r.readDBs()
+	size := len(r.primaries) + len(reads)
+	if err := r.stmtLimiter.reserve(size); err != nil {
+		return nil, err
+	}
+
 	primaryDBStmts := make(map[*sqlx.DB]*sqlx.NamedStmt, len(r.primaries))
-	readDBStmts := make(map[*sqlx.DB]*sqlx.NamedStmt, len(r.reads))
+	readDBStmts := make(map[*sqlx.DB]*sqlx.NamedStmt, len(reads))
 
 	var errs error
 	for _, db := range r.primaries {
@@ -532,7 +1453,7 @@ func (r *dbResolver) PrepareNamedContext(ctx context.Context, query string) (Nam
 
 		primaryDBStmts[db] = stmt
 	}
-	for _, db := range r.reads {
+	for _, db := range reads {
 		stmt, err := db.PrepareNamedContext(ctx, query)
 		if err != nil {
 			errs = multierror.Append(errs, err)
@@ -541,24 +1462,57 @@ func (r *dbResolver) PrepareNamedContext(ctx context.Context, query string) (Nam
 
 		readDBStmts[db] = stmt
 	}
-	if errs != nil {
+	if errs != nil && !r.toleratePrepareErrors(ctx, errs, len(primaryDBStmts), len(readDBStmts)) {
+		r.stmtLimiter.release(size)
 		return nil, errs
 	}
 
-	return &namedStmt{
-		primaries:    r.primaries,
-		reads:        r.reads,
-		primaryStmts: primaryDBStmts,
-		readStmts:    readDBStmts,
-		loadBalancer: r.loadBalancer,
-	}, nil
+	s := &namedStmt{
+		primaries:            r.primaries,
+		reads:                reads,
+		primaryStmts:         primaryDBStmts,
+		readStmts:            readDBStmts,
+		loadBalancer:         r.loadBalancer,
+		limiter:              r.stmtLimiter,
+		registry:             &r.shared().stmts,
+		drain:                &r.shared().drain,
+		connErrClassifier:    r.connErrClassifier,
+		readRetries:          r.readRetries,
+		writeRetries:         r.writeRetries,
+		strictReadSeparation: r.strictReadSeparation,
+	}
+	r.stmtLimiter.add(s, size)
+	r.shared().stmts.add(s, query)
+
+	return s, nil
+}
+
+// PrepareWithLoadBalancer returns a Stmt like Preparex, which chooses the statements with the given load balancer
+// instead of the load balancer of the resolver. e.g. to run the heavy queries on a specific secondary database.
+// The statement is still prepared on all the databases, so any of them can be chosen.
+// If the given load balancer is nil, it uses the load balancer of the resolver.
+func (r *dbResolver) PrepareWithLoadBalancer(query string, lb LoadBalancer) (Stmt, error) {
+	s, err := r.Preparex(query)
+	if err != nil {
+		return nil, err
+	}
+	if lb != nil {
+		s.(*stmt).loadBalancer = lb
+	}
+	return s, nil
 }
 
 // Preparex returns an Stmt which can be used sqlx.Stmt instead.
 // This supposed to be aligned with sqlx.DB.Preparex.
 func (r *dbResolver) Preparex(query string) (Stmt, error) {
+	reads := r.readDBs()
+	size := len(r.primaries) + len(reads)
+	if err := r.stmtLimiter.reserve(size); err != nil {
+		return nil, err
+	}
+
 	primaryDBStmts := make(map[*sqlx.DB]*sqlx.Stmt, len(r.primaries))
-	readDBStmts := make(map[*sqlx.DB]*sqlx.Stmt, len(r.reads))
+	readDBStmts := make(map[*sqlx.DB]*sqlx.Stmt, len(reads))
 
 	var errs error
 	for _, db := range r.primaries {
@@ -570,7 +1524,7 @@ func (r *dbResolver) Preparex(query string) (Stmt, error) {
 
 		primaryDBStmts[db] = stmt
 	}
-	for _, db := range r.reads {
+	for _, db := range reads {
 		stmt, err := db.Preparex(query)
 		if err != nil {
 			errs = multierror.Append(errs, err)
@@ -579,24 +1533,39 @@ func (r *dbResolver) Preparex(query string) (Stmt, error) {
 
 		readDBStmts[db] = stmt
 	}
-	if errs != nil {
+	if errs != nil && !r.toleratePrepareErrors(r.baseContext(), errs, len(primaryDBStmts), len(readDBStmts)) {
+		r.stmtLimiter.release(size)
 		return nil, errs
 	}
 
-	return &stmt{
-		primaries:    r.primaries,
-		reads:        r.reads,
-		primaryStmts: primaryDBStmts,
-		readStmts:    readDBStmts,
-		loadBalancer: r.loadBalancer,
-	}, nil
+	s := &stmt{
+		primaries:         r.primaries,
+		reads:             reads,
+		primaryStmts:      primaryDBStmts,
+		readStmts:         readDBStmts,
+		loadBalancer:      r.loadBalancer,
+		limiter:           r.stmtLimiter,
+		registry:          &r.shared().stmts,
+		drain:             &r.shared().drain,
+		connErrClassifier: r.connErrClassifier,
+	}
+	r.stmtLimiter.add(s, size)
+	r.shared().stmts.add(s, query)
+
+	return s, nil
 }
 
 // PreparexContext returns a Stmt which can be used sqlx.Stmt instead.
 // This supposed to be aligned with sqlx.DB.PreparexContext.
 func (r *dbResolver) PreparexContext(ctx context.Context, query string) (Stmt, error) {
+	reads := r.readDBs()
+	size := len(r.primaries) + len(reads)
+	if err := r.stmtLimiter.reserve(size); err != nil {
+		return nil, err
+	}
+
 	primaryDBStmts := make(map[*sqlx.DB]*sqlx.Stmt, len(r.primaries))
-	readDBStmts := make(map[*sqlx.DB]*sqlx.Stmt, len(r.reads))
+	readDBStmts := make(map[*sqlx.DB]*sqlx.Stmt, len(reads))
 
 	var errs error
 	for _, db := range r.primaries {
@@ -608,7 +1577,7 @@ func (r *dbResolver) PreparexContext(ctx context.Context, query string) (Stmt, e
 
 		primaryDBStmts[db] = stmt
 	}
-	for _, db := range r.reads {
+	for _, db := range reads {
 		stmt, err := db.PreparexContext(ctx, query)
 		if err != nil {
 			errs = multierror.Append(errs, err)
@@ -617,51 +1586,126 @@ func (r *dbResolver) PreparexContext(ctx context.Context, query string) (Stmt, e
 
 		readDBStmts[db] = stmt
 	}
-	if errs != nil {
+	if errs != nil && !r.toleratePrepareErrors(ctx, errs, len(primaryDBStmts), len(readDBStmts)) {
+		r.stmtLimiter.release(size)
 		return nil, errs
 	}
 
-	return &stmt{
-		primaries:    r.primaries,
-		reads:        r.reads,
-		primaryStmts: primaryDBStmts,
-		readStmts:    readDBStmts,
-		loadBalancer: r.loadBalancer,
-	}, nil
+	s := &stmt{
+		primaries:         r.primaries,
+		reads:             reads,
+		primaryStmts:      primaryDBStmts,
+		readStmts:         readDBStmts,
+		loadBalancer:      r.loadBalancer,
+		limiter:           r.stmtLimiter,
+		registry:          &r.shared().stmts,
+		drain:             &r.shared().drain,
+		connErrClassifier: r.connErrClassifier,
+	}
+	r.stmtLimiter.add(s, size)
+	r.shared().stmts.add(s, query)
+
+	return s, nil
+}
+
+// PrimaryDBs returns a copy of the primary databases.
+func (r *dbResolver) PrimaryDBs() []*sqlx.DB {
+	return append([]*sqlx.DB{}, r.primaries...)
+}
+
+// PrimaryStats returns the statistics of the primary databases in the configured order.
+func (r *dbResolver) PrimaryStats() []sql.DBStats {
+	return dbStats(r.primaries)
 }
 
 // Query chooses a readable database, executes the query and executes a query that returns sql.Rows.
 // This supposed to be aligned with sqlx.DB.Query.
 func (r *dbResolver) Query(query string, args ...interface{}) (*sql.Rows, error) {
-	db := r.loadBalancer.Select(context.Background(), r.reads)
-	rows, err := db.Query(query, args...)
-	if isDBConnectionError(err) {
-		dbPrimary := r.loadBalancer.Select(context.Background(), r.primaries)
-		rows, err = dbPrimary.Query(query, args...)
-	}
+	var rows *sql.Rows
+	err := r.queryRoute(query)(r.baseContext(), newRequest("Query", query, args...), func(db *sqlx.DB) error {
+		var err error
+		rows, err = db.Query(r.tagQuery(r.baseContext(), query), args...)
+		return err
+	})
 	return rows, err
 }
 
 // QueryContext chooses a readable database, executes the query and executes a query that returns sql.Rows.
 // This supposed to be aligned with sqlx.DB.QueryContext.
 func (r *dbResolver) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
-	db := r.loadBalancer.Select(ctx, r.reads)
-	rows, err := db.QueryContext(ctx, query, args...)
-	if isDBConnectionError(err) {
-		dbPrimary := r.loadBalancer.Select(ctx, r.primaries)
-		rows, err = dbPrimary.QueryContext(ctx, query, args...)
-	}
+	var rows *sql.Rows
+	err := r.queryRoute(query)(ctx, newRequest("Query", query, args...), func(db *sqlx.DB) error {
+		var err error
+		rows, err = db.QueryContext(ctx, r.tagQuery(ctx, query), args...)
+		return err
+	})
 	return rows, err
 }
 
+// QueryEachRead executes the query on each readable database concurrently
+// and returns the rows of each database. The callers must close all the returned rows.
+// The number of the concurrent queries is bounded by WithFanOutConcurrency.
+// The errors of the databases are aggregated and the databases which failed are not in the returned map.
+func (r *dbResolver) QueryEachRead(
+	ctx context.Context, query string, args ...interface{},
+) (map[*sqlx.DB]*sqlx.Rows, error) {
+	if !r.shared().drain.enter() {
+		return nil, errShutdown
+	}
+	defer r.shared().drain.exit()
+
+	reads := r.readDBs()
+
+	var (
+		mu     sync.Mutex
+		result = make(map[*sqlx.DB]*sqlx.Rows, len(reads))
+		errs   error
+	)
+	fanOut(reads, r.fanOutConcurrency, func(db *sqlx.DB) {
+		rows, err := db.QueryxContext(ctx, r.tagQuery(ctx, query), args...)
+
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			errs = multierror.Append(errs, err)
+			return
+		}
+		result[db] = rows
+	})
+
+	return result, errs
+}
+
+// QueryFrom chooses a readable database and executes the query like QueryContext,
+// and returns the database which served it, which is a primary database if the read fell back to it.
+// Like QueryContext, it is not bounded by the default query timeout, because the rows outlive the call.
+func (r *dbResolver) QueryFrom(ctx context.Context, query string, args ...interface{}) (*sql.Rows, *sqlx.DB, error) {
+	var (
+		rows   *sql.Rows
+		served *sqlx.DB
+	)
+	err := r.read(ctx, newRequest("Query", query, args...), func(db *sqlx.DB) error {
+		var err error
+		rows, err = db.QueryContext(ctx, r.tagQuery(ctx, query), args...)
+		served = db
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return rows, served, nil
+}
+
 // QueryRow chooses a readable database, executes the query and executes a query that returns sql.Row.
 // This supposed to be aligned with sqlx.DB.QueryRow.
 func (r *dbResolver) QueryRow(query string, args ...interface{}) *sql.Row {
-	db := r.loadBalancer.Select(context.Background(), r.reads)
-	row := db.QueryRow(query, args...)
-	if isDBConnectionError(row.Err()) {
-		dbPrimary := r.loadBalancer.Select(context.Background(), r.primaries)
-		row = dbPrimary.QueryRow(query, args...)
+	var row *sql.Row
+	err := r.queryRoute(query)(r.baseContext(), newRequest("QueryRow", query, args...), func(db *sqlx.DB) error {
+		row = db.QueryRow(r.tagQuery(r.baseContext(), query), args...)
+		return row.Err()
+	})
+	if row == nil {
+		row = errRow(err)
 	}
 	return row
 }
@@ -669,23 +1713,37 @@ func (r *dbResolver) QueryRow(query string, args ...interface{}) *sql.Row {
 // QueryRowContext chooses a readable database, executes the query and executes a query that returns sql.Row.
 // This supposed to be aligned with sqlx.DB.QueryRowContext.
 func (r *dbResolver) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
-	db := r.loadBalancer.Select(ctx, r.reads)
-	row := db.QueryRowContext(ctx, query, args...)
-	if isDBConnectionError(row.Err()) {
-		dbPrimary := r.loadBalancer.Select(ctx, r.primaries)
-		row = dbPrimary.QueryRowContext(ctx, query, args...)
+	var row *sql.Row
+	err := r.queryRoute(query)(ctx, newRequest("QueryRow", query, args...), func(db *sqlx.DB) error {
+		row = db.QueryRowContext(ctx, r.tagQuery(ctx, query), args...)
+		return row.Err()
+	})
+	if row == nil {
+		row = errRow(err)
 	}
 	return row
 }
 
+// QueryRowFallback is the same as QueryRowContext but returns a Row,
+// which is scanned from a primary database if it fails to scan with a connection error of a readable database.
+// The connection errors often surface only when the row is scanned, which QueryRowContext cannot fall back from.
+func (r *dbResolver) QueryRowFallback(ctx context.Context, query string, args ...interface{}) *Row {
+	row, _ := r.queryRow(ctx, newRequest("QueryRow", query, args...), func(db *sqlx.DB) *sql.Row {
+		return db.QueryRowContext(ctx, r.tagQuery(ctx, query), args...)
+	})
+	return row
+}
+
 // QueryRowx chooses a readable database, queries the database and returns an *sqlx.Row.
 // This supposed to be aligned with sqlx.DB.QueryRowx.
 func (r *dbResolver) QueryRowx(query string, args ...interface{}) *sqlx.Row {
-	db := r.loadBalancer.Select(context.Background(), r.reads)
-	row := db.QueryRowx(query, args...)
-	if isDBConnectionError(row.Err()) {
-		dbPrimary := r.loadBalancer.Select(context.Background(), r.primaries)
-		row = dbPrimary.QueryRowx(query, args...)
+	var row *sqlx.Row
+	err := r.queryRoute(query)(r.baseContext(), newRequest("QueryRowx", query, args...), func(db *sqlx.DB) error {
+		row = db.QueryRowx(r.tagQuery(r.baseContext(), query), args...)
+		return row.Err()
+	})
+	if row == nil {
+		row = errRowx(err)
 	}
 	return row
 }
@@ -693,109 +1751,276 @@ func (r *dbResolver) QueryRowx(query string, args ...interface{}) *sqlx.Row {
 // QueryRowxContext chooses a readable database, queries the database and returns an *sqlx.Row.
 // This supposed to be aligned with sqlx.DB.QueryRowxContext.
 func (r *dbResolver) QueryRowxContext(ctx context.Context, query string, args ...interface{}) *sqlx.Row {
-	db := r.loadBalancer.Select(ctx, r.reads)
-	row := db.QueryRowxContext(ctx, query, args...)
-	if isDBConnectionError(row.Err()) {
-		dbPrimary := r.loadBalancer.Select(ctx, r.primaries)
-		row = dbPrimary.QueryRowxContext(ctx, query, args...)
+	var row *sqlx.Row
+	err := r.queryRoute(query)(ctx, newRequest("QueryRowx", query, args...), func(db *sqlx.DB) error {
+		row = db.QueryRowxContext(ctx, r.tagQuery(ctx, query), args...)
+		return row.Err()
+	})
+	if row == nil {
+		row = errRowx(err)
 	}
 	return row
 }
 
+// QueryRowxFallback is the same as QueryRowxContext but returns a Rowx,
+// which is scanned from a primary database if it fails to scan with a connection error of a readable database.
+func (r *dbResolver) QueryRowxFallback(ctx context.Context, query string, args ...interface{}) *Rowx {
+	row, _ := r.queryRowx(ctx, newRequest("QueryRowx", query, args...), func(db *sqlx.DB) *sqlx.Row {
+		return db.QueryRowxContext(ctx, r.tagQuery(ctx, query), args...)
+	})
+	return row
+}
+
 // Queryx chooses a readable database, queries the database and returns an *sqlx.Rows.
 // This supposed to be aligned with sqlx.DB.Queryx.
 func (r *dbResolver) Queryx(query string, args ...interface{}) (*sqlx.Rows, error) {
-	db := r.loadBalancer.Select(context.Background(), r.reads)
-	rows, err := db.Queryx(query, args...)
-	if isDBConnectionError(err) {
-		dbPrimary := r.loadBalancer.Select(context.Background(), r.primaries)
-		rows, err = dbPrimary.Queryx(query, args...)
-	}
+	var rows *sqlx.Rows
+	err := r.queryRoute(query)(r.baseContext(), newRequest("Queryx", query, args...), func(db *sqlx.DB) error {
+		var err error
+		rows, err = db.Queryx(r.tagQuery(r.baseContext(), query), args...)
+		return err
+	})
 	return rows, err
 }
 
 // QueryxContext chooses a readable database, queries the database and returns an *sqlx.Rows.
 // This supposed to be aligned with sqlx.DB.QueryxContext.
 func (r *dbResolver) QueryxContext(ctx context.Context, query string, args ...interface{}) (*sqlx.Rows, error) {
-	db := r.loadBalancer.Select(ctx, r.reads)
-	rows, err := db.QueryxContext(ctx, query, args...)
-	if isDBConnectionError(err) {
-		dbPrimary := r.loadBalancer.Select(ctx, r.primaries)
-		rows, err = dbPrimary.QueryxContext(ctx, query, args...)
-	}
+	var rows *sqlx.Rows
+	err := r.queryRoute(query)(ctx, newRequest("Queryx", query, args...), func(db *sqlx.DB) error {
+		var err error
+		rows, err = db.QueryxContext(ctx, r.tagQuery(ctx, query), args...)
+		return err
+	})
 	return rows, err
 }
 
+// QuoteIdentifier chooses a primary database and quotes the identifier in the dialect of its driver.
+// The quote characters in the identifier are escaped by doubling them.
+func (r *dbResolver) QuoteIdentifier(name string) string {
+	db := r.choose(r.baseContext(), r.primaries)
+	return quoteIdentifier(db.DriverName(), name)
+}
+
+// ReadDBs returns a copy of the readable databases.
+func (r *dbResolver) ReadDBs() []*sqlx.DB {
+	return append([]*sqlx.DB{}, r.readDBs()...)
+}
+
+// ReadModifyWrite chooses a primary database and runs the read query with a dedicated connection of it.
+// Then it runs fn with the connection and the read rows, so the writes in fn are based on the read
+// from the same primary database. The connection is closed after fn returns.
+func (r *dbResolver) ReadModifyWrite(
+	ctx context.Context, readQuery string, readArgs []interface{}, fn func(conn *sqlx.Conn, result ReadResult) error,
+) error {
+	if err := r.shared().writeGate.wait(ctx); err != nil {
+		return err
+	}
+	conn, err := r.Connx(ctx)
+	if err != nil {
+		return err
+	}
+
+	result, err := readAll(ctx, conn, readQuery, readArgs...)
+	if err == nil {
+		err = fn(conn, result)
+	}
+	if closeErr := conn.Close(); closeErr != nil && err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// ReadStats returns the statistics of the readable databases in the configured order.
+func (r *dbResolver) ReadStats() []sql.DBStats {
+	return dbStats(r.readDBs())
+}
+
 // Rebind chooses a primary database and
 // transforms a query from QUESTION to the DB driver's bindvar type.
 // This supposed to be aligned with sqlx.DB.Rebind.
 func (r *dbResolver) Rebind(query string) string {
-	db := r.loadBalancer.Select(context.Background(), r.primaries)
+	if bindType, ok := r.bindType(); ok {
+		return sqlx.Rebind(bindType, query)
+	}
+	db := r.choose(r.baseContext(), r.primaries)
 	return db.Rebind(query)
 }
 
+// RebindForDriver transforms a query from QUESTION to the bindvar type of the given driver
+// without choosing a database.
+// It works even if the driver is different from the drivers of the databases.
+func (r *dbResolver) RebindForDriver(driverName, query string) string {
+	return sqlx.Rebind(sqlx.BindType(driverName), query)
+}
+
+// RemoveSecondary removes the secondary database from the read pool and the secondary groups.
+// The database is not closed, so the in-flight queries with it can finish.
+// If the database is not a secondary database, it returns an error.
+// The views of the resolver like GroupResolver are not affected.
+func (r *dbResolver) RemoveSecondary(db *sqlx.DB) error {
+	root := r.shared()
+	root.poolMu.Lock()
+	if !containsDB(root.secondaries, db) {
+		root.poolMu.Unlock()
+		return errUnknownSecondary
+	}
+	root.setSecondaries(excludeDB(root.secondaries, db))
+	if len(root.groups) > 0 {
+		groups := make(map[string][]*sqlx.DB, len(root.groups))
+		for name, dbs := range root.groups {
+			groups[name] = excludeDB(dbs, db)
+		}
+		root.groups = groups
+	}
+	root.poolMu.Unlock()
+
+	root.health.markHealthy(db)
+	root.notifyPoolChange(PoolChangeRemoved, db)
+	return nil
+}
+
+// ResumeWrites resumes the writes paused by PauseWrites.
+func (r *dbResolver) ResumeWrites() {
+	r.shared().writeGate.unpause()
+}
+
+// RoutingStats returns the numbers of the queries routed by the resolver.
+// The views of the resolver like GroupResolver share the numbers with the resolver.
+func (r *dbResolver) RoutingStats() RoutingStats {
+	return r.shared().routing.stats()
+}
+
+// SecondaryDBConfig returns the config of the given secondary database.
+// The secondary databases which are not given by WithSecondaryDBConfigs have weight 1 and no name.
+// It returns false if the database is not a secondary database.
+func (r *dbResolver) SecondaryDBConfig(db *sqlx.DB) (SecondaryDBConfig, bool) {
+	root := r.shared()
+	if !containsDB(root.secondaryDBs(), db) {
+		return SecondaryDBConfig{}, false
+	}
+	if cfg, ok := root.secondaryConfigs[db]; ok {
+		return cfg, true
+	}
+	return SecondaryDBConfig{DB: db, Weight: 1}, true
+}
+
 // Select chooses a readable database and execute SELECT using chosen DB.
 // This supposed to be aligned with sqlx.DB.Select.
 func (r *dbResolver) Select(dest interface{}, query string, args ...interface{}) error {
-	db := r.loadBalancer.Select(context.Background(), r.reads)
-	err := db.Select(dest, query, args...)
-	if isDBConnectionError(err) {
-		dbPrimary := r.loadBalancer.Select(context.Background(), r.primaries)
-		err = dbPrimary.Select(dest, query, args...)
-	}
-	return err
+	return r.readInto(
+		r.baseContext(), newRequest("Select", query, args...), dest,
+		func(_ context.Context, db *sqlx.DB, dest interface{}) error {
+			return db.Select(dest, r.tagQuery(r.baseContext(), query), args...)
+		},
+	)
 }
 
 // SelectContext chooses a readable database and execute SELECT using chosen DB.
 // This supposed to be aligned with sqlx.DB.SelectContext.
 func (r *dbResolver) SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
-	db := r.loadBalancer.Select(ctx, r.reads)
-	err := db.SelectContext(ctx, dest, query, args...)
-	if isDBConnectionError(err) {
-		dbPrimary := r.loadBalancer.Select(ctx, r.primaries)
-		err = dbPrimary.SelectContext(ctx, dest, query, args...)
-	}
-	return err
+	ctx, cancel := r.withQueryTimeout(ctx)
+	defer cancel()
+
+	return r.readInto(
+		ctx, newRequest("Select", query, args...), dest,
+		func(ctx context.Context, db *sqlx.DB, dest interface{}) error {
+			return db.SelectContext(ctx, dest, r.tagQuery(ctx, query), args...)
+		},
+	)
+}
+
+// SelectFrom chooses a readable database and execute SELECT using chosen DB like SelectContext,
+// and returns the database which served it, which is a primary database if the read fell back to it.
+// The read is not coalesced even if WithReadCoalescing is given.
+func (r *dbResolver) SelectFrom(ctx context.Context, dest interface{}, query string, args ...interface{}) (*sqlx.DB, error) {
+	ctx, cancel := r.withQueryTimeout(ctx)
+	defer cancel()
+
+	return r.readIntoFrom(
+		ctx, newRequest("Select", query, args...), dest,
+		func(ctx context.Context, db *sqlx.DB, dest interface{}) error {
+			return db.SelectContext(ctx, dest, r.tagQuery(ctx, query), args...)
+		},
+	)
+}
+
+// SelectWithRole chooses a readable database and execute SELECT using chosen DB like SelectContext,
+// and returns the role of the database which served it.
+// The role is RolePrimary if a primary database served it, e.g. by falling back, and RoleReplica otherwise.
+// The read is not coalesced even if WithReadCoalescing is given.
+func (r *dbResolver) SelectWithRole(
+	ctx context.Context, dest interface{}, query string, args ...interface{},
+) (role string, err error) {
+	ctx, cancel := r.withQueryTimeout(ctx)
+	defer cancel()
+
+	return r.readIntoWithRole(
+		ctx, newRequest("Select", query, args...), dest,
+		func(ctx context.Context, db *sqlx.DB, dest interface{}) error {
+			return db.SelectContext(ctx, dest, r.tagQuery(ctx, query), args...)
+		},
+	)
 }
 
 // SetConnMaxIdleTime sets the maximum amount of time a connection may be idle to all databases.
 func (r *dbResolver) SetConnMaxIdleTime(d time.Duration) {
-	for _, db := range r.primaries {
-		db.SetConnMaxIdleTime(d)
-	}
-	for _, db := range r.reads {
-		db.SetConnMaxIdleTime(d)
-	}
+	r.forEachDB(func(db *sqlx.DB) {
+		r.setConnMaxIdleTime(db, d)
+	})
 }
 
 // SetConnMaxLifetime sets the maximum amount of time a connection may be reused to all databases.
 func (r *dbResolver) SetConnMaxLifetime(d time.Duration) {
-	for _, db := range r.primaries {
-		db.SetConnMaxLifetime(d)
-	}
-	for _, db := range r.reads {
+	r.forEachDB(func(db *sqlx.DB) {
 		db.SetConnMaxLifetime(d)
-	}
+	})
 }
 
 // SetMaxIdleConns sets the maximum number of connections in the idle connection pool to all databases.
 func (r *dbResolver) SetMaxIdleConns(n int) {
-	for _, db := range r.primaries {
-		db.SetMaxIdleConns(n)
-	}
-	for _, db := range r.reads {
+	root := r.shared()
+	root.idleMu.Lock()
+	root.maxIdleConns = &n
+	root.idleMu.Unlock()
+
+	r.forEachDB(func(db *sqlx.DB) {
 		db.SetMaxIdleConns(n)
-	}
+	})
 }
 
 // SetMaxOpenConns sets the maximum number of open connections to all databases.
 func (r *dbResolver) SetMaxOpenConns(n int) {
-	for _, db := range r.primaries {
+	r.forEachDB(func(db *sqlx.DB) {
 		db.SetMaxOpenConns(n)
+	})
+}
+
+// Shutdown stops routing the new queries, which fail with an error,
+// waits for the in-flight queries to finish or the context to be done, and closes all the databases.
+// If the context is done before the in-flight queries finish, it still closes the databases
+// and returns the error of the context as well.
+// The in-flight queries include the executions of the prepared statements, the connections of WithReplicaConn
+// and the transactions of Transaction until they are committed or rolled back.
+// The rows returned by the queries, the transactions begun by Begin, Beginx, BeginTx and BeginTxx
+// and the connections returned by Conn and Connx are not waited for, because their end is not known.
+// The views of the resolver like GroupResolver can not shut down the resolver,
+// but their queries are stopped and waited for as well.
+func (r *dbResolver) Shutdown(ctx context.Context) error {
+	if r.root != nil {
+		return nil
 	}
-	for _, db := range r.reads {
-		db.SetMaxOpenConns(n)
+
+	var errs error
+	select {
+	case <-r.drain.close():
+	case <-ctx.Done():
+		errs = multierror.Append(errs, ctx.Err())
 	}
+	if err := r.Close(); err != nil {
+		errs = multierror.Append(errs, err)
+	}
+	return errs
 }
 
 // Stats returns first primary database statistics.
@@ -803,11 +2028,82 @@ func (r *dbResolver) Stats() sql.DBStats {
 	return r.primaries[0].Stats()
 }
 
+// Transaction chooses a primary database, begins a transaction and runs fn with it.
+// If fn returns nil, it commits the transaction. Otherwise, it rolls back the transaction
+// and returns the error of fn with the error of the rollback if any.
+// If fn panics, it rolls back the transaction and re-panics.
+// Shutdown waits for the transaction until it is committed or rolled back.
+func (r *dbResolver) Transaction(ctx context.Context, opts *sql.TxOptions, fn func(tx *sqlx.Tx) error) error {
+	if !r.shared().drain.enter() {
+		return errShutdown
+	}
+	defer r.shared().drain.exit()
+
+	tx, err := r.BeginTxx(ctx, opts)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			return multierror.Append(err, rollbackErr)
+		}
+		return err
+	}
+	return tx.Commit()
+}
+
 // Unsafe chose a primary database and returns a version of DB
 // which will silently succeed to scan
 // when columns in the SQL result have no fields in the destination struct.
 // This supposed to be aligned with sqlx.DB.Unsafe.
 func (r *dbResolver) Unsafe() *sqlx.DB {
-	db := r.loadBalancer.Select(context.Background(), r.primaries)
+	db := r.choose(r.baseContext(), r.primaries)
 	return db.Unsafe()
 }
+
+// WithReplicaConn chooses a readable database and runs fn with a dedicated connection of it.
+// If there are no readable databases or getting the connection fails,
+// it chooses a primary database instead unless the strict read separation is enabled.
+// The connection is closed after fn returns.
+// It is useful to run multiple statements on the same replica connection like a cursor-based pagination.
+func (r *dbResolver) WithReplicaConn(ctx context.Context, fn func(conn *sqlx.Conn) error) error {
+	if !r.shared().drain.enter() {
+		return errShutdown
+	}
+	defer r.shared().drain.exit()
+
+	candidates, err := r.filterCandidates(ctx, RoleRead, r.readCandidates(ctx))
+	if err != nil {
+		return err
+	}
+	fallback := !r.strictReadSeparation && len(r.primaries) > 0
+	if len(candidates) == 0 && !fallback {
+		return errNoDBToRead
+	}
+	var conn *sqlx.Conn
+	err = errNoDBToRead
+	if len(candidates) > 0 {
+		conn, err = r.selectRead(ctx, candidates).Connx(ctx)
+	}
+	if err != nil && fallback {
+		if dbPrimary, filterErr := r.balancePrimary(ctx); filterErr == nil {
+			conn, err = dbPrimary.Connx(ctx)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	err = fn(conn)
+	if closeErr := conn.Close(); closeErr != nil && err == nil {
+		err = closeErr
+	}
+	return err
+}
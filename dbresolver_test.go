@@ -3,8 +3,14 @@ package dbresolver
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
 	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/jmoiron/sqlx"
@@ -124,6 +130,184 @@ func TestNewDBResolver(t *testing.T) {
 		}
 		assert.Equal(t, expected, result)
 	})
+
+	t.Run("with load balancer", func(t *testing.T) {
+		mockDB, _, err := sqlmock.New()
+		assert.NoError(t, err)
+		mockPrimaryDB := sqlx.NewDb(mockDB, "primary")
+		primaryDBsConfig := &PrimaryDBsConfig{
+			DBs: []*sqlx.DB{mockPrimaryDB},
+		}
+
+		result, err := NewDBResolver(
+			primaryDBsConfig,
+			WithLoadBalancer(NewRoundRobinLoadBalancer()),
+		)
+
+		assert.NoError(t, err)
+		assert.IsType(t, &RoundRobinLoadBalancer{}, result.(*dbResolver).loadBalancer)
+	})
+
+	t.Run("with nil load balancer", func(t *testing.T) {
+		mockDB, _, err := sqlmock.New()
+		assert.NoError(t, err)
+		mockPrimaryDB := sqlx.NewDb(mockDB, "primary")
+		primaryDBsConfig := &PrimaryDBsConfig{
+			DBs: []*sqlx.DB{mockPrimaryDB},
+		}
+
+		result, err := NewDBResolver(
+			primaryDBsConfig,
+			WithLoadBalancer(nil),
+		)
+
+		assert.NoError(t, err)
+		assert.IsType(t, &RandomLoadBalancer{}, result.(*dbResolver).loadBalancer)
+	})
+
+	t.Run("with per-tier connection limits", func(t *testing.T) {
+		mockPrimaryDB := newMockDBs(t, 1)[0]
+		mockSecondaryDB := newMockDBs(t, 1)[0]
+		primaryDBsConfig := &PrimaryDBsConfig{
+			DBs: []*sqlx.DB{mockPrimaryDB},
+		}
+
+		result, err := NewDBResolver(
+			primaryDBsConfig,
+			WithSecondaryDBs(mockSecondaryDB),
+			WithPrimaryMaxOpenConns(20),
+			WithReadMaxOpenConns(5),
+			WithPrimaryMaxIdleConns(-1),
+			WithReadMaxIdleConns(1),
+		)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 20, mockPrimaryDB.Stats().MaxOpenConnections)
+		assert.Equal(t, 0, mockPrimaryDB.Stats().Idle)
+		assert.Equal(t, 5, mockSecondaryDB.Stats().MaxOpenConnections)
+		assert.Equal(t, 1, mockSecondaryDB.Stats().Idle)
+		assert.Equal(t, -1, result.(*dbResolver).primaryMaxIdleConns)
+		assert.Equal(t, 1, result.(*dbResolver).readMaxIdleConns)
+	})
+
+	t.Run("set max open conns after per-tier connection limits", func(t *testing.T) {
+		mockPrimaryDB := newMockDBs(t, 1)[0]
+		mockSecondaryDB := newMockDBs(t, 1)[0]
+		primaryDBsConfig := &PrimaryDBsConfig{
+			DBs: []*sqlx.DB{mockPrimaryDB},
+		}
+
+		result, err := NewDBResolver(
+			primaryDBsConfig,
+			WithSecondaryDBs(mockSecondaryDB),
+			WithPrimaryMaxOpenConns(20),
+			WithReadMaxOpenConns(5),
+		)
+		assert.NoError(t, err)
+		result.SetMaxOpenConns(10)
+
+		assert.Equal(t, 10, mockPrimaryDB.Stats().MaxOpenConnections)
+		assert.Equal(t, 10, mockSecondaryDB.Stats().MaxOpenConnections)
+	})
+
+	t.Run("with inconsistent drivers", func(t *testing.T) {
+		mockDB1, _, err := sqlmock.New()
+		assert.NoError(t, err)
+		mockPrimaryDB := sqlx.NewDb(mockDB1, "postgres")
+		mockDB2, _, err := sqlmock.New()
+		assert.NoError(t, err)
+		mockSecondaryDB := sqlx.NewDb(mockDB2, "mysql")
+
+		result, err := NewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB}},
+			WithSecondaryDBs(mockSecondaryDB),
+		)
+
+		assert.Nil(t, result)
+		assert.ErrorIs(t, err, errInconsistentDrivers)
+	})
+
+	t.Run("with inconsistent drivers & allow mixed drivers option", func(t *testing.T) {
+		mockDB1, _, err := sqlmock.New()
+		assert.NoError(t, err)
+		mockPrimaryDB := sqlx.NewDb(mockDB1, "postgres")
+		mockDB2, _, err := sqlmock.New()
+		assert.NoError(t, err)
+		mockSecondaryDB := sqlx.NewDb(mockDB2, "mysql")
+
+		result, err := NewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB}},
+			WithSecondaryDBs(mockSecondaryDB),
+			WithAllowMixedDrivers(),
+		)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []*sqlx.DB{mockSecondaryDB, mockPrimaryDB}, result.ReadDBs())
+	})
+
+	t.Run("with drivers sharing bindvar type", func(t *testing.T) {
+		mockDB1, _, err := sqlmock.New()
+		assert.NoError(t, err)
+		mockPrimaryDB := sqlx.NewDb(mockDB1, "postgres")
+		mockDB2, _, err := sqlmock.New()
+		assert.NoError(t, err)
+		mockSecondaryDB := sqlx.NewDb(mockDB2, "pgx")
+
+		_, err = NewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB}},
+			WithSecondaryDBs(mockSecondaryDB),
+		)
+
+		assert.NoError(t, err)
+	})
+}
+
+func TestDBResolver_AcquirePrimary(t *testing.T) {
+	mockPrimaryDBs := newMockDBs(t, 2)
+	r := &dbResolver{
+		primaries:    mockPrimaryDBs,
+		reads:        newMockDBs(t, 2),
+		loadBalancer: NewRandomLoadBalancer(),
+	}
+
+	for i := 0; i < 10; i++ {
+		assert.Contains(t, mockPrimaryDBs, r.AcquirePrimary())
+	}
+}
+
+func TestDBResolver_AcquireRead(t *testing.T) {
+	t.Run("choose readable database", func(t *testing.T) {
+		mockReadDBs := newMockDBs(t, 2)
+		r := &dbResolver{
+			primaries:    newMockDBs(t, 2),
+			reads:        mockReadDBs,
+			loadBalancer: NewRandomLoadBalancer(),
+		}
+
+		for i := 0; i < 10; i++ {
+			assert.Contains(t, mockReadDBs, r.AcquireRead())
+		}
+	})
+
+	t.Run("choose primary database without readable databases", func(t *testing.T) {
+		mockPrimaryDBs := newMockDBs(t, 1)
+		r := &dbResolver{
+			primaries:    mockPrimaryDBs,
+			loadBalancer: NewRandomLoadBalancer(),
+		}
+
+		assert.Same(t, mockPrimaryDBs[0], r.AcquireRead())
+	})
+
+	t.Run("return nil without readable databases in strict mode", func(t *testing.T) {
+		r := &dbResolver{
+			primaries:            newMockDBs(t, 1),
+			loadBalancer:         NewRandomLoadBalancer(),
+			strictReadSeparation: true,
+		}
+
+		assert.Nil(t, r.AcquireRead())
+	})
 }
 
 func TestDBResolver_Begin(t *testing.T) {
@@ -206,6 +390,46 @@ func TestDBResolver_BeginTx(t *testing.T) {
 		assert.NotNil(t, result)
 		assert.IsType(t, &sql.Tx{}, result)
 	})
+
+	t.Run("retry other primary on connection error", func(t *testing.T) {
+		connErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+		mockDB1, sqlMock1, _ := sqlmock.New()
+		sqlMock1.ExpectBegin().
+			WillReturnError(connErr)
+		mockDB2, sqlMock2, _ := sqlmock.New()
+		sqlMock2.ExpectBegin()
+		r := &dbResolver{
+			primaries:    []*sqlx.DB{sqlx.NewDb(mockDB1, "primary1"), sqlx.NewDb(mockDB2, "primary2")},
+			loadBalancer: &firstLoadBalancer{},
+			writeRetries: 1,
+		}
+
+		result, err := r.BeginTx(context.Background(), nil)
+
+		assert.NoError(t, err)
+		assert.IsType(t, &sql.Tx{}, result)
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
+
+	t.Run("return connection error without write retries", func(t *testing.T) {
+		connErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+		mockDB1, sqlMock1, _ := sqlmock.New()
+		sqlMock1.ExpectBegin().
+			WillReturnError(connErr)
+		mockDB2, sqlMock2, _ := sqlmock.New()
+		r := &dbResolver{
+			primaries:    []*sqlx.DB{sqlx.NewDb(mockDB1, "primary1"), sqlx.NewDb(mockDB2, "primary2")},
+			loadBalancer: &firstLoadBalancer{},
+		}
+
+		result, err := r.BeginTx(context.Background(), nil)
+
+		assert.Nil(t, result)
+		assert.ErrorIs(t, err, connErr)
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
 }
 
 func TestDBResolver_BeginTxx(t *testing.T) {
@@ -247,6 +471,46 @@ func TestDBResolver_BeginTxx(t *testing.T) {
 		assert.NotNil(t, result)
 		assert.IsType(t, &sqlx.Tx{}, result)
 	})
+
+	t.Run("retry other primary on connection error", func(t *testing.T) {
+		connErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+		mockDB1, sqlMock1, _ := sqlmock.New()
+		sqlMock1.ExpectBegin().
+			WillReturnError(connErr)
+		mockDB2, sqlMock2, _ := sqlmock.New()
+		sqlMock2.ExpectBegin()
+		r := &dbResolver{
+			primaries:    []*sqlx.DB{sqlx.NewDb(mockDB1, "primary1"), sqlx.NewDb(mockDB2, "primary2")},
+			loadBalancer: &firstLoadBalancer{},
+			writeRetries: 1,
+		}
+
+		result, err := r.BeginTxx(context.Background(), nil)
+
+		assert.NoError(t, err)
+		assert.IsType(t, &sqlx.Tx{}, result)
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
+
+	t.Run("return connection error without write retries", func(t *testing.T) {
+		connErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+		mockDB1, sqlMock1, _ := sqlmock.New()
+		sqlMock1.ExpectBegin().
+			WillReturnError(connErr)
+		mockDB2, sqlMock2, _ := sqlmock.New()
+		r := &dbResolver{
+			primaries:    []*sqlx.DB{sqlx.NewDb(mockDB1, "primary1"), sqlx.NewDb(mockDB2, "primary2")},
+			loadBalancer: &firstLoadBalancer{},
+		}
+
+		result, err := r.BeginTxx(context.Background(), nil)
+
+		assert.Nil(t, result)
+		assert.ErrorIs(t, err, connErr)
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
 }
 
 func TestDBResolver_Beginx(t *testing.T) {
@@ -290,6 +554,146 @@ func TestDBResolver_Beginx(t *testing.T) {
 	})
 }
 
+func TestDBResolver_BeginxOn(t *testing.T) {
+	t.Run("begin on given primary", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New()
+		mockDB2, sqlMock2, _ := sqlmock.New()
+		sqlMock2.ExpectBegin()
+		mockPrimaryDB := sqlx.NewDb(mockDB2, "mock")
+		r := &dbResolver{
+			primaries:    []*sqlx.DB{sqlx.NewDb(mockDB1, "mock"), mockPrimaryDB},
+			loadBalancer: &firstLoadBalancer{},
+		}
+
+		result, err := r.BeginxOn(mockPrimaryDB)
+
+		assert.NoError(t, err)
+		assert.IsType(t, &sqlx.Tx{}, result)
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
+
+	t.Run("return error", func(t *testing.T) {
+		mockDB, sqlMock, _ := sqlmock.New()
+		mockError := errors.New("mock error")
+		sqlMock.ExpectBegin().
+			WillReturnError(mockError)
+		mockPrimaryDB := sqlx.NewDb(mockDB, "mock")
+		r := &dbResolver{
+			primaries: []*sqlx.DB{mockPrimaryDB},
+		}
+
+		result, err := r.BeginxOn(mockPrimaryDB)
+
+		assert.Nil(t, result)
+		assert.ErrorIs(t, err, mockError)
+	})
+
+	t.Run("unknown primary", func(t *testing.T) {
+		mockDB1, _, _ := sqlmock.New()
+		mockDB2, sqlMock2, _ := sqlmock.New()
+		mockSecondaryDB := sqlx.NewDb(mockDB2, "mock")
+		r := &dbResolver{
+			primaries:   []*sqlx.DB{sqlx.NewDb(mockDB1, "mock")},
+			secondaries: []*sqlx.DB{mockSecondaryDB},
+			reads:       []*sqlx.DB{mockSecondaryDB},
+		}
+
+		result, err := r.BeginxOn(mockSecondaryDB)
+
+		assert.Nil(t, result)
+		assert.ErrorIs(t, err, errUnknownPrimary)
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
+}
+
+func TestDBResolver_BeginxResolver(t *testing.T) {
+	t.Run("return error", func(t *testing.T) {
+		mockDB, sqlMock, _ := sqlmock.New()
+		mockError := errors.New("mock error")
+		sqlMock.ExpectBegin().
+			WillReturnError(mockError)
+		mockPrimaryDB := sqlx.NewDb(mockDB, "mock")
+		r := &dbResolver{
+			primaries: []*sqlx.DB{mockPrimaryDB},
+			loadBalancer: &injectedLoadBalancer{
+				db: mockPrimaryDB,
+			},
+		}
+
+		result, err := r.BeginxResolver(context.Background())
+
+		assert.Nil(t, result)
+		assert.ErrorIs(t, err, mockError)
+	})
+
+	t.Run("insert and select in transaction", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock1.ExpectBegin()
+		sqlMock1.ExpectExec(`INSERT INTO person (first_name) VALUES (?)`).
+			WithArgs("foo").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		sqlMock1.ExpectQuery(`SELECT first_name FROM person WHERE id = ?`).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("foo"))
+		sqlMock1.ExpectExec(`UPDATE person SET first_name = ? WHERE id = ?`).
+			WithArgs("bar", 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		sqlMock1.ExpectQuery(`SELECT first_name FROM person`).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("bar"))
+		sqlMock1.ExpectCommit()
+		mockPrimaryDB := sqlx.NewDb(mockDB1, "mysql")
+		mockDB2, sqlMock2, _ := sqlmock.New()
+		mockSecondaryDB := sqlx.NewDb(mockDB2, "mysql")
+		r := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB}, ReadWritePolicy: WriteOnly},
+			WithSecondaryDBs(mockSecondaryDB),
+		)
+
+		tx, err := r.BeginxResolver(context.Background())
+		assert.NoError(t, err)
+		_, err = tx.ExecContext(context.Background(), `INSERT INTO person (first_name) VALUES (?)`, "foo")
+		assert.NoError(t, err)
+		var firstName string
+		err = tx.GetContext(context.Background(), &firstName, `SELECT first_name FROM person WHERE id = ?`, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, "foo", firstName)
+		_, err = tx.NamedExec(
+			`UPDATE person SET first_name = :first_name WHERE id = :id`,
+			map[string]interface{}{"first_name": "bar", "id": 1},
+		)
+		assert.NoError(t, err)
+		var firstNames []string
+		err = tx.Select(&firstNames, `SELECT first_name FROM person`)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"bar"}, firstNames)
+		assert.NoError(t, tx.Commit())
+
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
+
+	t.Run("rollback", func(t *testing.T) {
+		mockDB, sqlMock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock.ExpectBegin()
+		sqlMock.ExpectQuery(`SELECT first_name FROM person`).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("foo"))
+		sqlMock.ExpectRollback()
+		mockPrimaryDB := sqlx.NewDb(mockDB, "mock")
+		r := MustNewDBResolver(&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB}})
+
+		tx, err := r.BeginxResolver(context.Background())
+		assert.NoError(t, err)
+		rows, err := tx.Query(`SELECT first_name FROM person`)
+		assert.NoError(t, err)
+		assert.NoError(t, rows.Close())
+		assert.NoError(t, tx.Rollback())
+
+		assert.Equal(t, RoutingStats{}, r.RoutingStats())
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+}
+
 func TestDBResolver_BindNamed(t *testing.T) {
 	t.Run("return error", func(t *testing.T) {
 		mockDB, _, _ := sqlmock.New()
@@ -303,7 +707,9 @@ func TestDBResolver_BindNamed(t *testing.T) {
 
 		assert.Equal(t, "", result)
 		assert.Nil(t, args)
+		assert.ErrorIs(t, err, errUnsupportedBindArg)
 		assert.ErrorContains(t, err, "unsupported map type")
+		assert.EqualError(t, errors.Unwrap(err), "sqlx.bindNamedMapper: unsupported map type: map[string]string")
 	})
 
 	t.Run("success with dollar bindvar type", func(t *testing.T) {
@@ -367,49 +773,360 @@ func TestDBResolver_BindNamed(t *testing.T) {
 	})
 }
 
-func TestDBResolver_Close(t *testing.T) {
-	t.Run("fail to close", func(t *testing.T) {
-		mockDB, sqlMock, _ := sqlmock.New()
-		mockError := errors.New("mock error")
-		sqlMock.ExpectClose().WillReturnError(mockError)
-		mockPrimaryDB := sqlx.NewDb(mockDB, "primary")
-		mockDB, sqlMock, _ = sqlmock.New()
-		sqlMock.ExpectClose()
-		mockSecondaryDB := sqlx.NewDb(mockDB, "secondary")
+func TestDBResolver_BindNamedForDriver(t *testing.T) {
+	tests := []struct {
+		driverName string
+		expected   string
+	}{
+		{driverName: "mock", expected: "SELECT ?"},
+		{driverName: "postgres", expected: "SELECT $1"},
+		{driverName: "mysql", expected: "SELECT ?"},
+		{driverName: "ora", expected: "SELECT :first_name"},
+		{driverName: "sqlserver", expected: "SELECT @p1"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.driverName, func(t *testing.T) {
+			r := &dbResolver{}
+
+			result, args, err := r.BindNamedForDriver(tt.driverName, "SELECT :first_name", map[string]interface{}{"first_name": "foo"})
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+			assert.Equal(t, []interface{}{"foo"}, args)
+		})
+	}
+
+	t.Run("unsupported arg", func(t *testing.T) {
+		r := &dbResolver{}
+
+		result, args, err := r.BindNamedForDriver("postgres", "SELECT :first_name", map[string]string{"first_name": "foo"})
+
+		assert.Equal(t, "", result)
+		assert.Nil(t, args)
+		assert.ErrorIs(t, err, errUnsupportedBindArg)
+	})
+}
+
+func TestDBResolver_CheckSchemaConsistency(t *testing.T) {
+	scanVersion := func(rows *sqlx.Rows) (string, error) {
+		var version string
+		if rows.Next() {
+			if err := rows.Scan(&version); err != nil {
+				return "", err
+			}
+		}
+		return version, rows.Err()
+	}
+
+	t.Run("matching versions", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock1.ExpectQuery(`SELECT version FROM schema_migrations`).
+			WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow("42"))
+		mockPrimaryDB := sqlx.NewDb(mockDB1, "primary")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock2.ExpectQuery(`SELECT version FROM schema_migrations`).
+			WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow("42"))
+		mockSecondaryDB := sqlx.NewDb(mockDB2, "secondary")
 		r := &dbResolver{
 			primaries:   []*sqlx.DB{mockPrimaryDB},
 			secondaries: []*sqlx.DB{mockSecondaryDB},
+			reads:       []*sqlx.DB{mockSecondaryDB, mockPrimaryDB},
 		}
 
-		err := r.Close()
+		versions, consistent, err := r.CheckSchemaConsistency(
+			context.Background(), `SELECT version FROM schema_migrations`, scanVersion,
+		)
 
-		assert.ErrorIs(t, err, mockError)
+		assert.NoError(t, err)
+		assert.True(t, consistent)
+		assert.Equal(t, map[*sqlx.DB]string{mockPrimaryDB: "42", mockSecondaryDB: "42"}, versions)
 	})
 
-	t.Run("success to close", func(t *testing.T) {
-		mockDB, sqlMock, _ := sqlmock.New()
-		sqlMock.ExpectClose()
-		mockPrimaryDB := sqlx.NewDb(mockDB, "primary")
-		mockDB, sqlMock, _ = sqlmock.New()
-		sqlMock.ExpectClose()
-		mockSecondaryDB := sqlx.NewDb(mockDB, "secondary")
+	t.Run("mismatching versions", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock1.ExpectQuery(`SELECT version FROM schema_migrations`).
+			WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow("42"))
+		mockPrimaryDB := sqlx.NewDb(mockDB1, "primary")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock2.ExpectQuery(`SELECT version FROM schema_migrations`).
+			WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow("41"))
+		mockSecondaryDB := sqlx.NewDb(mockDB2, "secondary")
 		r := &dbResolver{
 			primaries:   []*sqlx.DB{mockPrimaryDB},
 			secondaries: []*sqlx.DB{mockSecondaryDB},
 		}
 
-		err := r.Close()
+		versions, consistent, err := r.CheckSchemaConsistency(
+			context.Background(), `SELECT version FROM schema_migrations`, scanVersion,
+		)
 
 		assert.NoError(t, err)
+		assert.False(t, consistent)
+		assert.Equal(t, map[*sqlx.DB]string{mockPrimaryDB: "42", mockSecondaryDB: "41"}, versions)
 	})
-}
 
-func TestDBResolver_Conn(t *testing.T) {
-	t.Run("success", func(t *testing.T) {
-		mockDB, _, _ := sqlmock.New()
-		mockPrimaryDB := sqlx.NewDb(mockDB, "mock")
-		fakePrimaryDB, _, _ := sqlmock.New()
-		r := &dbResolver{
+	t.Run("return error", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock1.ExpectQuery(`SELECT version FROM schema_migrations`).
+			WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow("42"))
+		mockPrimaryDB := sqlx.NewDb(mockDB1, "primary")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		mockError := errors.New("mock error")
+		sqlMock2.ExpectQuery(`SELECT version FROM schema_migrations`).
+			WillReturnError(mockError)
+		mockSecondaryDB := sqlx.NewDb(mockDB2, "secondary")
+		r := &dbResolver{
+			primaries:   []*sqlx.DB{mockPrimaryDB},
+			secondaries: []*sqlx.DB{mockSecondaryDB},
+		}
+
+		versions, consistent, err := r.CheckSchemaConsistency(
+			context.Background(), `SELECT version FROM schema_migrations`, scanVersion,
+		)
+
+		assert.ErrorIs(t, err, mockError)
+		assert.False(t, consistent)
+		assert.Equal(t, map[*sqlx.DB]string{mockPrimaryDB: "42"}, versions)
+	})
+}
+
+func TestDBResolver_Close(t *testing.T) {
+	t.Run("fail to close", func(t *testing.T) {
+		mockDB, sqlMock, _ := sqlmock.New()
+		mockError := errors.New("mock error")
+		sqlMock.ExpectClose().WillReturnError(mockError)
+		mockPrimaryDB := sqlx.NewDb(mockDB, "primary")
+		mockDB, sqlMock, _ = sqlmock.New()
+		sqlMock.ExpectClose()
+		mockSecondaryDB := sqlx.NewDb(mockDB, "secondary")
+		r := &dbResolver{
+			primaries:   []*sqlx.DB{mockPrimaryDB},
+			secondaries: []*sqlx.DB{mockSecondaryDB},
+		}
+
+		err := r.Close()
+
+		assert.ErrorIs(t, err, mockError)
+	})
+
+	t.Run("success to close", func(t *testing.T) {
+		mockDB, sqlMock, _ := sqlmock.New()
+		sqlMock.ExpectClose()
+		mockPrimaryDB := sqlx.NewDb(mockDB, "primary")
+		mockDB, sqlMock, _ = sqlmock.New()
+		sqlMock.ExpectClose()
+		mockSecondaryDB := sqlx.NewDb(mockDB, "secondary")
+		r := &dbResolver{
+			primaries:   []*sqlx.DB{mockPrimaryDB},
+			secondaries: []*sqlx.DB{mockSecondaryDB},
+		}
+
+		err := r.Close()
+
+		assert.NoError(t, err)
+	})
+}
+
+func TestDBResolver_CloseContext(t *testing.T) {
+	t.Run("name failed db", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New()
+		sqlMock1.ExpectClose()
+		mockDB2, sqlMock2, _ := sqlmock.New()
+		sqlMock2.ExpectClose()
+		mockDB3, sqlMock3, _ := sqlmock.New()
+		mockError := errors.New("mock error")
+		sqlMock3.ExpectClose().WillReturnError(mockError)
+		r := &dbResolver{
+			primaries: []*sqlx.DB{sqlx.NewDb(mockDB1, "primary")},
+			secondaries: []*sqlx.DB{
+				sqlx.NewDb(mockDB2, "secondary"),
+				sqlx.NewDb(mockDB3, "secondary"),
+			},
+		}
+
+		err := r.CloseContext(context.Background())
+
+		assert.ErrorIs(t, err, mockError)
+		assert.Contains(t, err.Error(), "close secondary[1] (secondary): mock error")
+		assert.NotContains(t, err.Error(), "primary[0]")
+		assert.NotContains(t, err.Error(), "secondary[0]")
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+		assert.NoError(t, sqlMock3.ExpectationsWereMet())
+	})
+
+	t.Run("return when context is done", func(t *testing.T) {
+		connector := &blockingConnector{release: make(chan struct{})}
+		defer close(connector.release)
+		mockDB, sqlMock, _ := sqlmock.New()
+		sqlMock.ExpectClose()
+		r := &dbResolver{
+			primaries:   []*sqlx.DB{sqlx.NewDb(sql.OpenDB(connector), "primary")},
+			secondaries: []*sqlx.DB{sqlx.NewDb(mockDB, "secondary")},
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		err := r.CloseContext(ctx)
+
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Contains(t, err.Error(), "close primary[0] (primary)")
+		assert.NotContains(t, err.Error(), "secondary[0]")
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("close canary primary once", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New()
+		mockError := errors.New("mock error")
+		sqlMock1.ExpectClose().WillReturnError(mockError)
+		mockPrimaryDB := sqlx.NewDb(mockDB1, "primary")
+		mockDB2, sqlMock2, _ := sqlmock.New()
+		sqlMock2.ExpectClose()
+		r := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB}, ReadWritePolicy: WriteOnly},
+			WithSecondaryDBs(sqlx.NewDb(mockDB2, "secondary")),
+			WithCanaryPrimary(mockPrimaryDB, 0.5),
+		)
+
+		err := r.CloseContext(context.Background())
+
+		assert.ErrorIs(t, err, mockError)
+		assert.Equal(t, 1, strings.Count(err.Error(), "close primary[0] (primary)"))
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
+
+	t.Run("close leaked statements", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock1.ExpectPrepare(`SELECT * FROM person WHERE id = ?`).
+			WillBeClosed()
+		sqlMock1.ExpectPrepare(`SELECT * FROM person WHERE first_name = ?`).
+			WillBeClosed()
+		sqlMock1.ExpectClose()
+		mockPrimaryDB := sqlx.NewDb(mockDB1, "mysql")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock2.ExpectPrepare(`SELECT * FROM person WHERE id = ?`).
+			WillBeClosed()
+		sqlMock2.ExpectPrepare(`SELECT * FROM person WHERE first_name = ?`).
+			WillBeClosed()
+		sqlMock2.ExpectClose()
+		mockSecondaryDB := sqlx.NewDb(mockDB2, "mysql")
+		var (
+			mu     sync.Mutex
+			leaked []string
+		)
+		r := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB}, ReadWritePolicy: WriteOnly},
+			WithSecondaryDBs(mockSecondaryDB),
+			WithLogger(func(_ context.Context, event string, err error) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				assert.Equal(t, "stmt_leaked", event)
+				assert.ErrorIs(t, err, errStatementLeaked)
+				leaked = append(leaked, err.Error())
+			}),
+		)
+		_, err := r.Preparex(`SELECT * FROM person WHERE id = ?`)
+		assert.NoError(t, err)
+		_, err = r.PrepareNamed(`SELECT * FROM person WHERE first_name = :first_name`)
+		assert.NoError(t, err)
+
+		err = r.CloseContext(context.Background())
+
+		assert.NoError(t, err)
+		assert.Equal(t, 0, r.OpenStatements())
+		assert.ElementsMatch(t, []string{
+			"query: SELECT * FROM person WHERE id = ?: dbresolver: prepared statement not closed",
+			"query: SELECT * FROM person WHERE first_name = :first_name: dbresolver: prepared statement not closed",
+		}, leaked)
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
+}
+
+// blockingConnector is a connector whose Close blocks until release is closed.
+type blockingConnector struct {
+	release chan struct{}
+}
+
+func (c *blockingConnector) Connect(context.Context) (driver.Conn, error) {
+	return nil, errors.New("blocking connector cannot connect")
+}
+
+func (c *blockingConnector) Driver() driver.Driver {
+	return nil
+}
+
+func (c *blockingConnector) Close() error {
+	<-c.release
+	return nil
+}
+
+func TestDBResolver_CloseIdleConnections(t *testing.T) {
+	newIdleDB := func(t *testing.T) *sqlx.DB {
+		mockDB, _, err := sqlmock.New()
+		assert.NoError(t, err)
+		db := sqlx.NewDb(mockDB, "sqlmock")
+		// sqlmock cannot connect again after all of the connections are closed,
+		// so it keeps a connection in use.
+		conn, err := db.Conn(context.Background())
+		assert.NoError(t, err)
+		t.Cleanup(func() { _ = conn.Close() })
+		assert.NoError(t, db.Ping())
+		assert.Equal(t, 1, db.Stats().Idle)
+		return db
+	}
+
+	t.Run("close idle connections of all databases", func(t *testing.T) {
+		primaryDB := newIdleDB(t)
+		secondaryDB := newIdleDB(t)
+		r := &dbResolver{
+			primaries:   []*sqlx.DB{primaryDB},
+			secondaries: []*sqlx.DB{secondaryDB},
+		}
+
+		r.CloseIdleConnections()
+
+		assert.Equal(t, 0, primaryDB.Stats().Idle)
+		assert.Equal(t, 0, secondaryDB.Stats().Idle)
+	})
+
+	t.Run("restore max idle connections", func(t *testing.T) {
+		primaryDB := newIdleDB(t)
+		r := &dbResolver{
+			primaries: []*sqlx.DB{primaryDB},
+		}
+		r.SetMaxIdleConns(1)
+
+		r.CloseIdleConnections()
+
+		assert.Equal(t, 0, primaryDB.Stats().Idle)
+		assert.NoError(t, primaryDB.Ping())
+		assert.Equal(t, 1, primaryDB.Stats().Idle)
+	})
+
+	t.Run("restore default max idle connections", func(t *testing.T) {
+		primaryDB := newIdleDB(t)
+		r := &dbResolver{
+			primaries: []*sqlx.DB{primaryDB},
+		}
+
+		r.CloseIdleConnections()
+
+		assert.Equal(t, 0, primaryDB.Stats().Idle)
+		assert.NoError(t, primaryDB.Ping())
+		assert.Equal(t, 1, primaryDB.Stats().Idle)
+	})
+}
+
+func TestDBResolver_Conn(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		mockDB, _, _ := sqlmock.New()
+		mockPrimaryDB := sqlx.NewDb(mockDB, "mock")
+		fakePrimaryDB, _, _ := sqlmock.New()
+		r := &dbResolver{
 			primaries: []*sqlx.DB{mockPrimaryDB, sqlx.NewDb(fakePrimaryDB, "fake")},
 			loadBalancer: &injectedLoadBalancer{
 				db: mockPrimaryDB,
@@ -576,6 +1293,80 @@ func TestDBResolver_ExecContext(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, int64(1), rowsAffected)
 	})
+
+	t.Run("propagate context", func(t *testing.T) {
+		mockDB, sqlMock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock.ExpectExec(`INSERT INTO person (first_name, last_name) VALUES (?, ?)`).
+			WillDelayFor(time.Second).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mockPrimaryDB := sqlx.NewDb(mockDB, "mock")
+		r := &dbResolver{
+			primaries:    []*sqlx.DB{mockPrimaryDB},
+			loadBalancer: &injectedLoadBalancer{db: mockPrimaryDB},
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		result, err := r.ExecContext(ctx, `INSERT INTO person (first_name, last_name) VALUES (?, ?)`, "foo", "bar")
+
+		assert.Nil(t, result)
+		assert.ErrorIs(t, err, sqlmock.ErrCancelled)
+		assert.ErrorIs(t, ctx.Err(), context.DeadlineExceeded)
+	})
+}
+
+func TestDBResolver_ExecOnAllPrimaries(t *testing.T) {
+	ddl := `ALTER TABLE person ADD COLUMN nickname VARCHAR(255)`
+
+	t.Run("exec on all primaries", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock1.ExpectExec(ddl).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mockPrimaryDB1 := sqlx.NewDb(mockDB1, "primary1")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock2.ExpectExec(ddl).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mockPrimaryDB2 := sqlx.NewDb(mockDB2, "primary2")
+		mockDB3, sqlMock3, _ := sqlmock.New()
+		r := &dbResolver{
+			primaries: []*sqlx.DB{mockPrimaryDB1, mockPrimaryDB2},
+			reads:     []*sqlx.DB{sqlx.NewDb(mockDB3, "secondary")},
+		}
+
+		result, err := r.ExecOnAllPrimaries(context.Background(), ddl)
+
+		assert.NoError(t, err)
+		assert.Len(t, result, 2)
+		assert.Contains(t, result, mockPrimaryDB1)
+		assert.Contains(t, result, mockPrimaryDB2)
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+		assert.NoError(t, sqlMock3.ExpectationsWereMet())
+	})
+
+	t.Run("aggregate errors without aborting others", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		mockError := errors.New("mock error")
+		sqlMock1.ExpectExec(ddl).
+			WillReturnError(mockError)
+		mockPrimaryDB1 := sqlx.NewDb(mockDB1, "primary1")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock2.ExpectExec(ddl).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mockPrimaryDB2 := sqlx.NewDb(mockDB2, "primary2")
+		r := &dbResolver{
+			primaries:         []*sqlx.DB{mockPrimaryDB1, mockPrimaryDB2},
+			fanOutConcurrency: 1,
+		}
+
+		result, err := r.ExecOnAllPrimaries(context.Background(), ddl)
+
+		assert.ErrorIs(t, err, mockError)
+		assert.Len(t, result, 1)
+		assert.Contains(t, result, mockPrimaryDB2)
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
 }
 
 func TestDBResolver_Get(t *testing.T) {
@@ -698,91 +1489,505 @@ func TestDBResolver_GetContext(t *testing.T) {
 	})
 }
 
-func TestDBResolver_MustBegin(t *testing.T) {
-	t.Run("panic", func(t *testing.T) {
-		mockDB, sqlMock, _ := sqlmock.New()
+func TestDBResolver_GetEachRead(t *testing.T) {
+	newReads := func(t *testing.T) ([]*sqlx.DB, []sqlmock.Sqlmock) {
+		t.Helper()
+
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		return []*sqlx.DB{sqlx.NewDb(mockDB1, "secondary1"), sqlx.NewDb(mockDB2, "secondary2")},
+			[]sqlmock.Sqlmock{sqlMock1, sqlMock2}
+	}
+
+	t.Run("scan row of each read", func(t *testing.T) {
+		reads, sqlMocks := newReads(t)
+		sqlMocks[0].ExpectQuery(`SELECT count(*) FROM person`).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(10))
+		sqlMocks[1].ExpectQuery(`SELECT count(*) FROM person`).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(8))
+		r := &dbResolver{
+			primaries: newMockDBs(t, 1),
+			reads:     reads,
+		}
+
+		var mu sync.Mutex
+		counts := make(map[*sqlx.DB]*int)
+		errs := r.GetEachRead(context.Background(), func(db *sqlx.DB) interface{} {
+			mu.Lock()
+			defer mu.Unlock()
+			counts[db] = new(int)
+			return counts[db]
+		}, `SELECT count(*) FROM person`)
+
+		assert.Empty(t, errs)
+		assert.Len(t, counts, 2)
+		assert.Equal(t, 10, *counts[reads[0]])
+		assert.Equal(t, 8, *counts[reads[1]])
+		assert.NoError(t, sqlMocks[0].ExpectationsWereMet())
+		assert.NoError(t, sqlMocks[1].ExpectationsWereMet())
+	})
+
+	t.Run("return error of each failed read", func(t *testing.T) {
+		reads, sqlMocks := newReads(t)
+		sqlMocks[0].ExpectQuery(`SELECT count(*) FROM person`).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(10))
 		mockError := errors.New("mock error")
-		sqlMock.ExpectBegin().
+		sqlMocks[1].ExpectQuery(`SELECT count(*) FROM person`).
 			WillReturnError(mockError)
-		mockPrimaryDB := sqlx.NewDb(mockDB, "mock")
-		fakePrimaryDB, _, _ := sqlmock.New()
 		r := &dbResolver{
-			primaries: []*sqlx.DB{mockPrimaryDB, sqlx.NewDb(fakePrimaryDB, "fake")},
-			loadBalancer: &injectedLoadBalancer{
-				db: mockPrimaryDB,
-			},
+			primaries:         newMockDBs(t, 1),
+			reads:             reads,
+			fanOutConcurrency: 1,
+		}
+
+		var count int
+		errs := r.GetEachRead(context.Background(), func(db *sqlx.DB) interface{} {
+			if db == reads[0] {
+				return &count
+			}
+			return new(int)
+		}, `SELECT count(*) FROM person`)
+
+		assert.Len(t, errs, 1)
+		assert.ErrorIs(t, errs[reads[1]], mockError)
+		assert.Equal(t, 10, count)
+		assert.NoError(t, sqlMocks[0].ExpectationsWereMet())
+		assert.NoError(t, sqlMocks[1].ExpectationsWereMet())
+	})
+}
+
+func TestDBResolver_GetFrom(t *testing.T) {
+	connErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+
+	t.Run("replica", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		mockPrimaryDB := sqlx.NewDb(mockDB1, "primary")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock2.ExpectQuery(`SELECT first_name FROM person`).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("foo"))
+		mockSecondaryDB := sqlx.NewDb(mockDB2, "secondary")
+		r := &dbResolver{
+			primaries:    []*sqlx.DB{mockPrimaryDB},
+			secondaries:  []*sqlx.DB{mockSecondaryDB},
+			reads:        []*sqlx.DB{mockSecondaryDB},
+			loadBalancer: &firstLoadBalancer{},
 		}
 
-		assert.Panics(t, func() {
-			result := r.MustBegin()
+		var firstName string
+		db, err := r.GetFrom(context.Background(), &firstName, `SELECT first_name FROM person`)
 
-			assert.Nil(t, result)
-		})
+		assert.NoError(t, err)
+		assert.Same(t, mockSecondaryDB, db)
+		assert.Equal(t, "foo", firstName)
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
 	})
 
-	t.Run("success", func(t *testing.T) {
-		mockDB, sqlMock, _ := sqlmock.New()
-		sqlMock.ExpectBegin()
-		mockPrimaryDB := sqlx.NewDb(mockDB, "mock")
-		fakePrimaryDB, _, _ := sqlmock.New()
+	t.Run("primary on fallback", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock1.ExpectQuery(`SELECT first_name FROM person`).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("foo"))
+		mockPrimaryDB := sqlx.NewDb(mockDB1, "primary")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock2.ExpectQuery(`SELECT first_name FROM person`).
+			WillReturnError(connErr)
+		mockSecondaryDB := sqlx.NewDb(mockDB2, "secondary")
 		r := &dbResolver{
-			primaries: []*sqlx.DB{mockPrimaryDB, sqlx.NewDb(fakePrimaryDB, "fake")},
-			loadBalancer: &injectedLoadBalancer{
-				db: mockPrimaryDB,
-			},
+			primaries:    []*sqlx.DB{mockPrimaryDB},
+			secondaries:  []*sqlx.DB{mockSecondaryDB},
+			reads:        []*sqlx.DB{mockSecondaryDB},
+			loadBalancer: &firstLoadBalancer{},
 		}
 
-		result := r.MustBegin()
+		var firstName string
+		db, err := r.GetFrom(context.Background(), &firstName, `SELECT first_name FROM person`)
 
-		assert.IsType(t, &sqlx.Tx{}, result)
+		assert.NoError(t, err)
+		assert.Same(t, mockPrimaryDB, db)
+		assert.Equal(t, "foo", firstName)
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
 	})
-}
 
-func TestDBResolver_MustBeginTx(t *testing.T) {
-	t.Run("panic", func(t *testing.T) {
-		mockDB, sqlMock, _ := sqlmock.New()
+	t.Run("return error", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		mockPrimaryDB := sqlx.NewDb(mockDB1, "primary")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
 		mockError := errors.New("mock error")
-		sqlMock.ExpectBegin().
+		sqlMock2.ExpectQuery(`SELECT first_name FROM person`).
 			WillReturnError(mockError)
-		mockPrimaryDB := sqlx.NewDb(mockDB, "mock")
-		fakePrimaryDB, _, _ := sqlmock.New()
+		mockSecondaryDB := sqlx.NewDb(mockDB2, "secondary")
 		r := &dbResolver{
-			primaries: []*sqlx.DB{mockPrimaryDB, sqlx.NewDb(fakePrimaryDB, "fake")},
-			loadBalancer: &injectedLoadBalancer{
-				db: mockPrimaryDB,
-			},
+			primaries:    []*sqlx.DB{mockPrimaryDB},
+			secondaries:  []*sqlx.DB{mockSecondaryDB},
+			reads:        []*sqlx.DB{mockSecondaryDB},
+			loadBalancer: &firstLoadBalancer{},
 		}
 
-		assert.Panics(t, func() {
-			result := r.MustBeginTx(context.Background(), nil)
+		var firstName string
+		db, err := r.GetFrom(context.Background(), &firstName, `SELECT first_name FROM person`)
 
-			assert.Nil(t, result)
-		})
+		assert.ErrorIs(t, err, mockError)
+		assert.Nil(t, db)
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
 	})
+}
 
-	t.Run("success", func(t *testing.T) {
-		mockDB, sqlMock, _ := sqlmock.New()
-		sqlMock.ExpectBegin()
-		mockPrimaryDB := sqlx.NewDb(mockDB, "mock")
-		fakePrimaryDB, _, _ := sqlmock.New()
+func TestDBResolver_GetWithRole(t *testing.T) {
+	connErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+
+	t.Run("replica", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		mockPrimaryDB := sqlx.NewDb(mockDB1, "primary")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock2.ExpectQuery(`SELECT first_name FROM person`).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("foo"))
+		mockSecondaryDB := sqlx.NewDb(mockDB2, "secondary")
 		r := &dbResolver{
-			primaries: []*sqlx.DB{mockPrimaryDB, sqlx.NewDb(fakePrimaryDB, "fake")},
-			loadBalancer: &injectedLoadBalancer{
-				db: mockPrimaryDB,
-			},
+			primaries:    []*sqlx.DB{mockPrimaryDB},
+			secondaries:  []*sqlx.DB{mockSecondaryDB},
+			reads:        []*sqlx.DB{mockSecondaryDB},
+			loadBalancer: &firstLoadBalancer{},
 		}
 
-		result := r.MustBeginTx(context.Background(), nil)
+		var firstName string
+		role, err := r.GetWithRole(context.Background(), &firstName, `SELECT first_name FROM person`)
 
-		assert.IsType(t, &sqlx.Tx{}, result)
+		assert.NoError(t, err)
+		assert.Equal(t, RoleReplica, role)
+		assert.Equal(t, "foo", firstName)
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
 	})
-}
 
-func TestDBResolver_MustExec(t *testing.T) {
-	t.Run("panic", func(t *testing.T) {
-		mockDB, sqlMock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
-		mockError := errors.New("mock error")
-		sqlMock.ExpectExec(`INSERT INTO person (first_name, last_name) VALUES ("foo", "bar")`).
+	t.Run("primary on fallback", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock1.ExpectQuery(`SELECT first_name FROM person`).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("foo"))
+		mockPrimaryDB := sqlx.NewDb(mockDB1, "primary")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock2.ExpectQuery(`SELECT first_name FROM person`).
+			WillReturnError(connErr)
+		mockSecondaryDB := sqlx.NewDb(mockDB2, "secondary")
+		r := &dbResolver{
+			primaries:    []*sqlx.DB{mockPrimaryDB},
+			secondaries:  []*sqlx.DB{mockSecondaryDB},
+			reads:        []*sqlx.DB{mockSecondaryDB},
+			loadBalancer: &firstLoadBalancer{},
+		}
+
+		var firstName string
+		role, err := r.GetWithRole(context.Background(), &firstName, `SELECT first_name FROM person`)
+
+		assert.NoError(t, err)
+		assert.Equal(t, RolePrimary, role)
+		assert.Equal(t, "foo", firstName)
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
+
+	t.Run("return error", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		mockPrimaryDB := sqlx.NewDb(mockDB1, "primary")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		mockError := errors.New("mock error")
+		sqlMock2.ExpectQuery(`SELECT first_name FROM person`).
+			WillReturnError(mockError)
+		mockSecondaryDB := sqlx.NewDb(mockDB2, "secondary")
+		r := &dbResolver{
+			primaries:    []*sqlx.DB{mockPrimaryDB},
+			secondaries:  []*sqlx.DB{mockSecondaryDB},
+			reads:        []*sqlx.DB{mockSecondaryDB},
+			loadBalancer: &firstLoadBalancer{},
+		}
+
+		var firstName string
+		role, err := r.GetWithRole(context.Background(), &firstName, `SELECT first_name FROM person`)
+
+		assert.ErrorIs(t, err, mockError)
+		assert.Empty(t, role)
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
+}
+
+func TestDBResolver_GroupResolver(t *testing.T) {
+	t.Run("unknown group", func(t *testing.T) {
+		mockDB, _, _ := sqlmock.New()
+		primaryDBsCfg := &PrimaryDBsConfig{
+			DBs: []*sqlx.DB{sqlx.NewDb(mockDB, "primary")},
+		}
+		r, err := NewDBResolver(primaryDBsCfg)
+		assert.NoError(t, err)
+
+		result, err := r.GroupResolver("analytics")
+
+		assert.Nil(t, result)
+		assert.ErrorIs(t, err, errUnknownSecondaryGroup)
+	})
+
+	t.Run("read only from the group", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock1.ExpectExec(`DELETE FROM person`).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mockPrimaryDB := sqlx.NewDb(mockDB1, "primary")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		mockSecondaryDB := sqlx.NewDb(mockDB2, "secondary")
+		mockDB3, sqlMock3, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		for i := 0; i < 10; i++ {
+			sqlMock3.ExpectQuery(`SELECT count(*) FROM person`).
+				WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(42))
+		}
+		mockAnalyticsDB := sqlx.NewDb(mockDB3, "analytics")
+		primaryDBsCfg := &PrimaryDBsConfig{
+			DBs:             []*sqlx.DB{mockPrimaryDB},
+			ReadWritePolicy: ReadWrite,
+		}
+		r, err := NewDBResolver(
+			primaryDBsCfg,
+			WithSecondaryDBs(mockSecondaryDB),
+			WithSecondaryGroup("analytics", mockAnalyticsDB),
+		)
+		assert.NoError(t, err)
+		assert.Equal(t, []*sqlx.DB{mockSecondaryDB, mockAnalyticsDB, mockPrimaryDB}, r.(*dbResolver).reads)
+
+		view, err := r.GroupResolver("analytics")
+		assert.NoError(t, err)
+		for i := 0; i < 10; i++ {
+			var count int
+			err = view.Get(&count, `SELECT count(*) FROM person`)
+			assert.NoError(t, err)
+			assert.Equal(t, 42, count)
+		}
+		_, err = view.Exec(`DELETE FROM person`)
+		assert.NoError(t, err)
+		err = view.Close()
+		assert.NoError(t, err)
+
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+		assert.NoError(t, sqlMock3.ExpectationsWereMet())
+	})
+
+	t.Run("keep read preference", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock1.ExpectQuery(`SELECT count(*) FROM person`).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(42))
+		mockPrimaryDB := sqlx.NewDb(mockDB1, "primary")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		mockAnalyticsDB := sqlx.NewDb(mockDB2, "analytics")
+		r, err := NewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB}, ReadWritePolicy: WriteOnly},
+			WithSecondaryGroup("analytics", mockAnalyticsDB),
+			WithReadPreference(PrimaryOnly),
+		)
+		assert.NoError(t, err)
+
+		view, err := r.GroupResolver("analytics")
+		assert.NoError(t, err)
+		var count int
+		err = view.GetContext(context.Background(), &count, `SELECT count(*) FROM person`)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 42, count)
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
+}
+
+func TestDBResolver_In(t *testing.T) {
+	t.Run("expand slice with dollar bindvar type", func(t *testing.T) {
+		mockDB, _, _ := sqlmock.New()
+		mockPrimaryDB := sqlx.NewDb(mockDB, "postgres")
+		r := &dbResolver{
+			primaries:    []*sqlx.DB{mockPrimaryDB},
+			loadBalancer: NewRandomLoadBalancer(),
+		}
+
+		query, args, err := r.In(`SELECT * FROM person WHERE id IN (?) AND first_name = ?`, []int{1, 2, 3}, "foo")
+
+		assert.NoError(t, err)
+		assert.Equal(t, `SELECT * FROM person WHERE id IN ($1, $2, $3) AND first_name = $4`, query)
+		assert.Equal(t, []interface{}{1, 2, 3, "foo"}, args)
+	})
+
+	t.Run("expand slice with question bindvar type", func(t *testing.T) {
+		mockDB, _, _ := sqlmock.New()
+		mockPrimaryDB := sqlx.NewDb(mockDB, "mysql")
+		r := &dbResolver{
+			primaries:    []*sqlx.DB{mockPrimaryDB},
+			loadBalancer: NewRandomLoadBalancer(),
+		}
+
+		query, args, err := r.In(`SELECT * FROM person WHERE id IN (?)`, []int{1, 2, 3})
+
+		assert.NoError(t, err)
+		assert.Equal(t, `SELECT * FROM person WHERE id IN (?, ?, ?)`, query)
+		assert.Equal(t, []interface{}{1, 2, 3}, args)
+	})
+
+	t.Run("return error with empty slice", func(t *testing.T) {
+		mockDB, _, _ := sqlmock.New()
+		mockPrimaryDB := sqlx.NewDb(mockDB, "postgres")
+		r := &dbResolver{
+			primaries:    []*sqlx.DB{mockPrimaryDB},
+			loadBalancer: NewRandomLoadBalancer(),
+		}
+
+		_, _, expectedErr := sqlx.In(`SELECT * FROM person WHERE id IN (?)`, []int{})
+		query, args, err := r.In(`SELECT * FROM person WHERE id IN (?)`, []int{})
+
+		assert.EqualError(t, err, expectedErr.Error())
+		assert.Empty(t, query)
+		assert.Nil(t, args)
+	})
+}
+
+func TestDBResolver_MapperFunc(t *testing.T) {
+	type Person struct {
+		FirstName string
+	}
+
+	t.Run("set mapper to configured databases", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock1.ExpectQuery(`SELECT FIRSTNAME FROM person`).
+			WillReturnRows(sqlmock.NewRows([]string{"FIRSTNAME"}).AddRow("foo"))
+		mockPrimaryDB := sqlx.NewDb(mockDB1, "primary")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock2.ExpectQuery(`SELECT FIRSTNAME FROM person`).
+			WillReturnRows(sqlmock.NewRows([]string{"FIRSTNAME"}).AddRow("bar"))
+		mockSecondaryDB := sqlx.NewDb(mockDB2, "secondary")
+		r := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB}, ReadWritePolicy: ReadWrite},
+			WithSecondaryDBs(mockSecondaryDB),
+			WithLoadBalancer(&firstLoadBalancer{}),
+		)
+
+		r.MapperFunc(strings.ToUpper)
+		var person1, person2 Person
+		err := r.Get(&person1, `SELECT FIRSTNAME FROM person`)
+		assert.NoError(t, err)
+		err = r.RemoveSecondary(mockSecondaryDB)
+		assert.NoError(t, err)
+		err = r.Get(&person2, `SELECT FIRSTNAME FROM person`)
+		assert.NoError(t, err)
+
+		assert.Equal(t, "bar", person1.FirstName)
+		assert.Equal(t, "foo", person2.FirstName)
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
+
+	t.Run("set mapper to added secondary", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		mockPrimaryDB := sqlx.NewDb(mockDB1, "primary")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock2.ExpectQuery(`SELECT FIRSTNAME FROM person`).
+			WillReturnRows(sqlmock.NewRows([]string{"FIRSTNAME"}).AddRow("foo"))
+		mockSecondaryDB := sqlx.NewDb(mockDB2, "secondary")
+		r := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB}, ReadWritePolicy: ReadWrite},
+			WithLoadBalancer(&injectedLoadBalancer{db: mockSecondaryDB}),
+		)
+
+		r.MapperFunc(strings.ToUpper)
+		r.AddSecondary(mockSecondaryDB)
+		var person Person
+		err := r.Get(&person, `SELECT FIRSTNAME FROM person`)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "foo", person.FirstName)
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
+}
+
+func TestDBResolver_MustBegin(t *testing.T) {
+	t.Run("panic", func(t *testing.T) {
+		mockDB, sqlMock, _ := sqlmock.New()
+		mockError := errors.New("mock error")
+		sqlMock.ExpectBegin().
+			WillReturnError(mockError)
+		mockPrimaryDB := sqlx.NewDb(mockDB, "mock")
+		fakePrimaryDB, _, _ := sqlmock.New()
+		r := &dbResolver{
+			primaries: []*sqlx.DB{mockPrimaryDB, sqlx.NewDb(fakePrimaryDB, "fake")},
+			loadBalancer: &injectedLoadBalancer{
+				db: mockPrimaryDB,
+			},
+		}
+
+		assert.Panics(t, func() {
+			result := r.MustBegin()
+
+			assert.Nil(t, result)
+		})
+	})
+
+	t.Run("success", func(t *testing.T) {
+		mockDB, sqlMock, _ := sqlmock.New()
+		sqlMock.ExpectBegin()
+		mockPrimaryDB := sqlx.NewDb(mockDB, "mock")
+		fakePrimaryDB, _, _ := sqlmock.New()
+		r := &dbResolver{
+			primaries: []*sqlx.DB{mockPrimaryDB, sqlx.NewDb(fakePrimaryDB, "fake")},
+			loadBalancer: &injectedLoadBalancer{
+				db: mockPrimaryDB,
+			},
+		}
+
+		result := r.MustBegin()
+
+		assert.IsType(t, &sqlx.Tx{}, result)
+	})
+}
+
+func TestDBResolver_MustBeginTx(t *testing.T) {
+	t.Run("panic", func(t *testing.T) {
+		mockDB, sqlMock, _ := sqlmock.New()
+		mockError := errors.New("mock error")
+		sqlMock.ExpectBegin().
+			WillReturnError(mockError)
+		mockPrimaryDB := sqlx.NewDb(mockDB, "mock")
+		fakePrimaryDB, _, _ := sqlmock.New()
+		r := &dbResolver{
+			primaries: []*sqlx.DB{mockPrimaryDB, sqlx.NewDb(fakePrimaryDB, "fake")},
+			loadBalancer: &injectedLoadBalancer{
+				db: mockPrimaryDB,
+			},
+		}
+
+		assert.Panics(t, func() {
+			result := r.MustBeginTx(context.Background(), nil)
+
+			assert.Nil(t, result)
+		})
+	})
+
+	t.Run("success", func(t *testing.T) {
+		mockDB, sqlMock, _ := sqlmock.New()
+		sqlMock.ExpectBegin()
+		mockPrimaryDB := sqlx.NewDb(mockDB, "mock")
+		fakePrimaryDB, _, _ := sqlmock.New()
+		r := &dbResolver{
+			primaries: []*sqlx.DB{mockPrimaryDB, sqlx.NewDb(fakePrimaryDB, "fake")},
+			loadBalancer: &injectedLoadBalancer{
+				db: mockPrimaryDB,
+			},
+		}
+
+		result := r.MustBeginTx(context.Background(), nil)
+
+		assert.IsType(t, &sqlx.Tx{}, result)
+	})
+}
+
+func TestDBResolver_MustExec(t *testing.T) {
+	t.Run("panic", func(t *testing.T) {
+		mockDB, sqlMock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		mockError := errors.New("mock error")
+		sqlMock.ExpectExec(`INSERT INTO person (first_name, last_name) VALUES ("foo", "bar")`).
 			WillReturnError(mockError)
 		mockPrimaryDB := sqlx.NewDb(mockDB, "mock")
 		fakeDB, _, _ := sqlmock.New()
@@ -884,6 +2089,180 @@ func TestDBResolver_MustExecContext(t *testing.T) {
 	})
 }
 
+func TestDBResolver_MustPrepare(t *testing.T) {
+	t.Run("panic", func(t *testing.T) {
+		mockDB, sqlMock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		mockError := errors.New("mock error")
+		sqlMock.ExpectPrepare(`SELECT * FROM person WHERE first_name=?`).
+			WillReturnError(mockError)
+		r := &dbResolver{
+			primaries: []*sqlx.DB{sqlx.NewDb(mockDB, "mock")},
+		}
+
+		assert.Panics(t, func() {
+			r.MustPrepare(`SELECT * FROM person WHERE first_name=?`)
+		})
+	})
+
+	t.Run("success", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock1.ExpectPrepare(`SELECT * FROM person WHERE first_name=?`)
+		mockPrimaryDB := sqlx.NewDb(mockDB1, "mock")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock2.ExpectPrepare(`SELECT * FROM person WHERE first_name=?`).
+			ExpectQuery().
+			WithArgs("foo").
+			WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("foo"))
+		mockReadDB := sqlx.NewDb(mockDB2, "mock")
+		r := &dbResolver{
+			primaries:    []*sqlx.DB{mockPrimaryDB},
+			reads:        []*sqlx.DB{mockReadDB},
+			loadBalancer: &firstLoadBalancer{},
+		}
+
+		stmt := r.MustPrepare(`SELECT * FROM person WHERE first_name=?`)
+
+		var firstName string
+		err := stmt.QueryRow("foo").Scan(&firstName)
+		assert.NoError(t, err)
+		assert.Equal(t, "foo", firstName)
+		assert.Equal(t, 1, r.OpenStatements())
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
+}
+
+func TestDBResolver_MustPrepareNamed(t *testing.T) {
+	t.Run("panic", func(t *testing.T) {
+		mockDB, sqlMock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		mockError := errors.New("mock error")
+		sqlMock.ExpectPrepare(`SELECT * FROM person WHERE first_name=?`).
+			WillReturnError(mockError)
+		r := &dbResolver{
+			primaries: []*sqlx.DB{sqlx.NewDb(mockDB, "mock")},
+		}
+
+		assert.Panics(t, func() {
+			r.MustPrepareNamed(`SELECT * FROM person WHERE first_name=:first_name`)
+		})
+	})
+
+	t.Run("success", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock1.ExpectPrepare(`SELECT * FROM person WHERE first_name=?`)
+		mockPrimaryDB := sqlx.NewDb(mockDB1, "mock")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock2.ExpectPrepare(`SELECT * FROM person WHERE first_name=?`).
+			ExpectQuery().
+			WithArgs("foo").
+			WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("foo"))
+		mockReadDB := sqlx.NewDb(mockDB2, "mock")
+		r := &dbResolver{
+			primaries:    []*sqlx.DB{mockPrimaryDB},
+			reads:        []*sqlx.DB{mockReadDB},
+			loadBalancer: &firstLoadBalancer{},
+		}
+
+		stmt := r.MustPrepareNamed(`SELECT * FROM person WHERE first_name=:first_name`)
+
+		var firstName string
+		err := stmt.Get(&firstName, map[string]interface{}{"first_name": "foo"})
+		assert.NoError(t, err)
+		assert.Equal(t, "foo", firstName)
+		assert.Equal(t, 1, r.OpenStatements())
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
+}
+
+func TestDBResolver_MustPreparex(t *testing.T) {
+	t.Run("panic", func(t *testing.T) {
+		mockDB, sqlMock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		mockError := errors.New("mock error")
+		sqlMock.ExpectPrepare(`SELECT * FROM person WHERE first_name=?`).
+			WillReturnError(mockError)
+		r := &dbResolver{
+			primaries: []*sqlx.DB{sqlx.NewDb(mockDB, "mock")},
+		}
+
+		assert.Panics(t, func() {
+			r.MustPreparex(`SELECT * FROM person WHERE first_name=?`)
+		})
+	})
+
+	t.Run("success", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock1.ExpectPrepare(`SELECT * FROM person WHERE first_name=?`)
+		mockPrimaryDB := sqlx.NewDb(mockDB1, "mock")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock2.ExpectPrepare(`SELECT * FROM person WHERE first_name=?`).
+			ExpectQuery().
+			WithArgs("foo").
+			WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("foo"))
+		mockReadDB := sqlx.NewDb(mockDB2, "mock")
+		r := &dbResolver{
+			primaries:    []*sqlx.DB{mockPrimaryDB},
+			reads:        []*sqlx.DB{mockReadDB},
+			loadBalancer: &firstLoadBalancer{},
+		}
+
+		stmt := r.MustPreparex(`SELECT * FROM person WHERE first_name=?`)
+
+		var firstName string
+		err := stmt.Get(&firstName, "foo")
+		assert.NoError(t, err)
+		assert.Equal(t, "foo", firstName)
+		assert.Equal(t, 1, r.OpenStatements())
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
+}
+
+func TestDBResolver_MustTransaction(t *testing.T) {
+	t.Run("panic", func(t *testing.T) {
+		mockDB, sqlMock, _ := sqlmock.New()
+		mockError := errors.New("mock error")
+		sqlMock.ExpectBegin().
+			WillReturnError(mockError)
+		mockPrimaryDB := sqlx.NewDb(mockDB, "mock")
+		r := &dbResolver{
+			primaries: []*sqlx.DB{mockPrimaryDB},
+			loadBalancer: &injectedLoadBalancer{
+				db: mockPrimaryDB,
+			},
+		}
+
+		assert.Panics(t, func() {
+			r.MustTransaction(context.Background(), nil, func(_ *sqlx.Tx) error {
+				return nil
+			})
+		})
+	})
+
+	t.Run("success", func(t *testing.T) {
+		mockDB, sqlMock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock.ExpectBegin()
+		sqlMock.ExpectExec(`DELETE FROM person`).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		sqlMock.ExpectCommit()
+		mockPrimaryDB := sqlx.NewDb(mockDB, "mock")
+		r := &dbResolver{
+			primaries: []*sqlx.DB{mockPrimaryDB},
+			loadBalancer: &injectedLoadBalancer{
+				db: mockPrimaryDB,
+			},
+		}
+
+		assert.NotPanics(t, func() {
+			r.MustTransaction(context.Background(), nil, func(tx *sqlx.Tx) error {
+				_, err := tx.Exec(`DELETE FROM person`)
+				return err
+			})
+		})
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+}
+
 func TestDBResolver_NamedExec(t *testing.T) {
 	t.Run("return error", func(t *testing.T) {
 		mockDB, sqlMock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
@@ -1010,6 +2389,101 @@ func TestDBResolver_NamedExecContext(t *testing.T) {
 	})
 }
 
+func TestDBResolver_NamedExecReturning(t *testing.T) {
+	newResolver := func(t *testing.T) (*dbResolver, sqlmock.Sqlmock, sqlmock.Sqlmock) {
+		t.Helper()
+
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		mockPrimaryDB := sqlx.NewDb(mockDB1, "postgres")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		mockSecondaryDB := sqlx.NewDb(mockDB2, "postgres")
+		r := &dbResolver{
+			primaries:    []*sqlx.DB{mockPrimaryDB},
+			secondaries:  []*sqlx.DB{mockSecondaryDB},
+			loadBalancer: &firstLoadBalancer{},
+			reads:        []*sqlx.DB{mockSecondaryDB},
+		}
+		return r, sqlMock1, sqlMock2
+	}
+	inputArgs := map[string]interface{}{
+		"firstName": "foo",
+		"lastName":  "bar",
+	}
+
+	t.Run("scan returned id", func(t *testing.T) {
+		r, primaryMock, secondaryMock := newResolver(t)
+		primaryMock.ExpectQuery(`INSERT INTO person (first_name, last_name) VALUES ($1, $2) RETURNING id`).
+			WithArgs("foo", "bar").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(42))
+
+		var id int
+		err := r.NamedExecReturning(
+			context.Background(), &id,
+			`INSERT INTO person (first_name, last_name) VALUES (:firstName, :lastName) RETURNING id`, inputArgs,
+		)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 42, id)
+		assert.NoError(t, primaryMock.ExpectationsWereMet())
+		assert.NoError(t, secondaryMock.ExpectationsWereMet())
+	})
+
+	t.Run("scan returned row into struct", func(t *testing.T) {
+		type Person struct {
+			ID        int    `db:"id"`
+			FirstName string `db:"first_name"`
+		}
+		r, primaryMock, secondaryMock := newResolver(t)
+		primaryMock.ExpectQuery(`UPDATE person SET first_name = $1 WHERE last_name = $2 RETURNING id, first_name`).
+			WithArgs("foo", "bar").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "first_name"}).AddRow(42, "foo"))
+
+		var person Person
+		err := r.NamedExecReturning(
+			context.Background(), &person,
+			`UPDATE person SET first_name = :firstName WHERE last_name = :lastName RETURNING id, first_name`, inputArgs,
+		)
+
+		assert.NoError(t, err)
+		assert.Equal(t, Person{ID: 42, FirstName: "foo"}, person)
+		assert.NoError(t, primaryMock.ExpectationsWereMet())
+		assert.NoError(t, secondaryMock.ExpectationsWereMet())
+	})
+
+	t.Run("no returned row", func(t *testing.T) {
+		r, primaryMock, _ := newResolver(t)
+		primaryMock.ExpectQuery(`UPDATE person SET first_name = $1 WHERE last_name = $2 RETURNING id`).
+			WithArgs("foo", "bar").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		var id int
+		err := r.NamedExecReturning(
+			context.Background(), &id,
+			`UPDATE person SET first_name = :firstName WHERE last_name = :lastName RETURNING id`, inputArgs,
+		)
+
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+		assert.NoError(t, primaryMock.ExpectationsWereMet())
+	})
+
+	t.Run("return error", func(t *testing.T) {
+		r, primaryMock, _ := newResolver(t)
+		mockError := errors.New("mock error")
+		primaryMock.ExpectQuery(`INSERT INTO person (first_name, last_name) VALUES ($1, $2) RETURNING id`).
+			WithArgs("foo", "bar").
+			WillReturnError(mockError)
+
+		var id int
+		err := r.NamedExecReturning(
+			context.Background(), &id,
+			`INSERT INTO person (first_name, last_name) VALUES (:firstName, :lastName) RETURNING id`, inputArgs,
+		)
+
+		assert.ErrorIs(t, err, mockError)
+		assert.NoError(t, primaryMock.ExpectationsWereMet())
+	})
+}
+
 func TestDBResolver_NamedQuery(t *testing.T) {
 	t.Run("return error", func(t *testing.T) {
 		mockDB, sqlMock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
@@ -1090,6 +2564,38 @@ func TestDBResolver_NamedQuery(t *testing.T) {
 			i++
 		}
 	})
+
+	t.Run("route write to primary", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock1.ExpectQuery(`INSERT INTO person (first_name) VALUES (?) RETURNING id`).
+			WithArgs("foo").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		mockPrimaryDB := sqlx.NewDb(mockDB1, "primary")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		mockSecondaryDB := sqlx.NewDb(mockDB2, "secondary")
+		r := &dbResolver{
+			primaries:    []*sqlx.DB{mockPrimaryDB},
+			secondaries:  []*sqlx.DB{mockSecondaryDB},
+			reads:        []*sqlx.DB{mockSecondaryDB},
+			loadBalancer: &firstLoadBalancer{},
+		}
+
+		inputQuery := `INSERT INTO person (first_name) VALUES (:first_name) RETURNING id`
+		inputArgs := map[string]interface{}{
+			"first_name": "foo",
+		}
+		rows, err := r.NamedQuery(inputQuery, inputArgs)
+
+		assert.NoError(t, err)
+		assert.True(t, rows.Next())
+		var id int
+		assert.NoError(t, rows.Scan(&id))
+		assert.Equal(t, 1, id)
+		assert.NoError(t, rows.Close())
+		assert.Equal(t, RoutingStats{PrimaryQueries: 1, Writes: 1}, r.RoutingStats())
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
 }
 
 func TestDBResolver_NamedQueryContext(t *testing.T) {
@@ -1172,6 +2678,118 @@ func TestDBResolver_NamedQueryContext(t *testing.T) {
 			i++
 		}
 	})
+
+	t.Run("route write to primary", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock1.ExpectQuery(`INSERT INTO person (first_name) VALUES (?) RETURNING id`).
+			WithArgs("foo").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		mockPrimaryDB := sqlx.NewDb(mockDB1, "primary")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		mockSecondaryDB := sqlx.NewDb(mockDB2, "secondary")
+		r := &dbResolver{
+			primaries:    []*sqlx.DB{mockPrimaryDB},
+			secondaries:  []*sqlx.DB{mockSecondaryDB},
+			reads:        []*sqlx.DB{mockSecondaryDB},
+			loadBalancer: &firstLoadBalancer{},
+		}
+
+		inputQuery := `INSERT INTO person (first_name) VALUES (:first_name) RETURNING id`
+		inputArgs := map[string]interface{}{
+			"first_name": "foo",
+		}
+		rows, err := r.NamedQueryContext(context.Background(), inputQuery, inputArgs)
+
+		assert.NoError(t, err)
+		assert.True(t, rows.Next())
+		var id int
+		assert.NoError(t, rows.Scan(&id))
+		assert.Equal(t, 1, id)
+		assert.NoError(t, rows.Close())
+		assert.Equal(t, RoutingStats{PrimaryQueries: 1, Writes: 1}, r.RoutingStats())
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
+}
+
+func TestDBResolver_OpenStatements(t *testing.T) {
+	t.Run("track open statements", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock1.ExpectPrepare(`SELECT * FROM person WHERE id = ?`).
+			WillBeClosed()
+		sqlMock1.ExpectPrepare(`SELECT * FROM person WHERE first_name = ?`)
+		mockPrimaryDB := sqlx.NewDb(mockDB1, "mysql")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock2.ExpectPrepare(`SELECT * FROM person WHERE id = ?`).
+			WillBeClosed()
+		sqlMock2.ExpectPrepare(`SELECT * FROM person WHERE first_name = ?`)
+		mockSecondaryDB := sqlx.NewDb(mockDB2, "mysql")
+		r := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB}, ReadWritePolicy: WriteOnly},
+			WithSecondaryDBs(mockSecondaryDB),
+		)
+
+		stmt, err := r.Preparex(`SELECT * FROM person WHERE id = ?`)
+		assert.NoError(t, err)
+		_, err = r.PrepareNamedContext(context.Background(), `SELECT * FROM person WHERE first_name = :first_name`)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, r.OpenStatements())
+
+		assert.NoError(t, stmt.Close())
+		assert.Equal(t, 1, r.OpenStatements())
+		assert.NoError(t, stmt.Close())
+		assert.Equal(t, 1, r.OpenStatements())
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
+
+	t.Run("not track failed statements", func(t *testing.T) {
+		mockDB, sqlMock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock.ExpectPrepare(`SELECT * FROM person WHERE id = ?`).
+			WillReturnError(errors.New("mock error"))
+		mockPrimaryDB := sqlx.NewDb(mockDB, "mysql")
+		r := MustNewDBResolver(&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB}})
+
+		_, err := r.Prepare(`SELECT * FROM person WHERE id = ?`)
+
+		assert.Error(t, err)
+		assert.Equal(t, 0, r.OpenStatements())
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("list queries of open statements", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock1.ExpectPrepare(`SELECT * FROM person WHERE id = ?`)
+		sqlMock1.ExpectPrepare(`SELECT * FROM person WHERE first_name = ?`).
+			WillBeClosed()
+		sqlMock1.ExpectPrepare(`SELECT * FROM person WHERE last_name = ?`)
+		mockPrimaryDB := sqlx.NewDb(mockDB1, "mysql")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock2.ExpectPrepare(`SELECT * FROM person WHERE id = ?`)
+		sqlMock2.ExpectPrepare(`SELECT * FROM person WHERE first_name = ?`).
+			WillBeClosed()
+		sqlMock2.ExpectPrepare(`SELECT * FROM person WHERE last_name = ?`)
+		mockSecondaryDB := sqlx.NewDb(mockDB2, "mysql")
+		r := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB}, ReadWritePolicy: WriteOnly},
+			WithSecondaryDBs(mockSecondaryDB),
+		)
+
+		_, err := r.Prepare(`SELECT * FROM person WHERE id = ?`)
+		assert.NoError(t, err)
+		stmt, err := r.Prepare(`SELECT * FROM person WHERE first_name = ?`)
+		assert.NoError(t, err)
+		_, err = r.Prepare(`SELECT * FROM person WHERE last_name = ?`)
+		assert.NoError(t, err)
+		assert.NoError(t, stmt.Close())
+
+		assert.Equal(t, []string{
+			`SELECT * FROM person WHERE id = ?`,
+			`SELECT * FROM person WHERE last_name = ?`,
+		}, r.OpenStatementQueries())
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
 }
 
 func TestDbResolver_Ping(t *testing.T) {
@@ -1219,34 +2837,163 @@ func TestDbResolver_PingContext(t *testing.T) {
 		sqlMock.ExpectPing().
 			WillReturnError(mockError)
 		mockPrimaryDB := sqlx.NewDb(mockDB, "primary")
-		mockDB, sqlMock, _ = sqlmock.New(sqlmock.MonitorPingsOption(true))
-		sqlMock.ExpectPing()
+		mockDB, sqlMock, _ = sqlmock.New(sqlmock.MonitorPingsOption(true))
+		sqlMock.ExpectPing()
+		mockSecondaryDB := sqlx.NewDb(mockDB, "secondary")
+		r := &dbResolver{
+			primaries:   []*sqlx.DB{mockPrimaryDB},
+			secondaries: []*sqlx.DB{mockSecondaryDB},
+		}
+
+		err := r.PingContext(context.Background())
+
+		assert.ErrorIs(t, err, mockError)
+	})
+
+	t.Run("success", func(t *testing.T) {
+		mockDB, sqlMock, _ := sqlmock.New(sqlmock.MonitorPingsOption(true))
+		sqlMock.ExpectPing()
+		mockPrimaryDB := sqlx.NewDb(mockDB, "primary")
+		mockDB, sqlMock, _ = sqlmock.New(sqlmock.MonitorPingsOption(true))
+		sqlMock.ExpectPing()
+		mockSecondaryDB := sqlx.NewDb(mockDB, "secondary")
+		r := &dbResolver{
+			primaries:   []*sqlx.DB{mockPrimaryDB},
+			secondaries: []*sqlx.DB{mockSecondaryDB},
+		}
+
+		err := r.PingContext(context.Background())
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("stop pinging after context is done", func(t *testing.T) {
+		mockDB, sqlMock1, _ := sqlmock.New(sqlmock.MonitorPingsOption(true))
+		sqlMock1.ExpectPing().
+			WillDelayFor(time.Second)
+		mockPrimaryDB := sqlx.NewDb(mockDB, "primary")
+		mockDB, sqlMock2, _ := sqlmock.New(sqlmock.MonitorPingsOption(true))
+		mockSecondaryDB := sqlx.NewDb(mockDB, "secondary")
+		r := &dbResolver{
+			primaries:         []*sqlx.DB{mockPrimaryDB},
+			secondaries:       []*sqlx.DB{mockSecondaryDB},
+			fanOutConcurrency: 1,
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		err := r.PingContext(ctx)
+
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
+
+	t.Run("ping nothing with canceled context", func(t *testing.T) {
+		mockDB, sqlMock1, _ := sqlmock.New(sqlmock.MonitorPingsOption(true))
+		mockPrimaryDB := sqlx.NewDb(mockDB, "primary")
+		mockDB, sqlMock2, _ := sqlmock.New(sqlmock.MonitorPingsOption(true))
+		mockSecondaryDB := sqlx.NewDb(mockDB, "secondary")
+		r := &dbResolver{
+			primaries:   []*sqlx.DB{mockPrimaryDB},
+			secondaries: []*sqlx.DB{mockSecondaryDB},
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := r.PingContext(ctx)
+
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
+
+	t.Run("ping concurrently", func(t *testing.T) {
+		delay := 200 * time.Millisecond
+		mockDB, sqlMock1, _ := sqlmock.New(sqlmock.MonitorPingsOption(true))
+		sqlMock1.ExpectPing().
+			WillDelayFor(delay)
+		mockPrimaryDB := sqlx.NewDb(mockDB, "primary")
+		mockDB, sqlMock2, _ := sqlmock.New(sqlmock.MonitorPingsOption(true))
+		sqlMock2.ExpectPing().
+			WillDelayFor(delay)
 		mockSecondaryDB := sqlx.NewDb(mockDB, "secondary")
 		r := &dbResolver{
 			primaries:   []*sqlx.DB{mockPrimaryDB},
 			secondaries: []*sqlx.DB{mockSecondaryDB},
 		}
 
+		start := time.Now()
 		err := r.PingContext(context.Background())
+		elapsed := time.Since(start)
 
-		assert.ErrorIs(t, err, mockError)
+		assert.NoError(t, err)
+		assert.GreaterOrEqual(t, elapsed, delay)
+		assert.Less(t, elapsed, 2*delay)
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
 	})
+}
 
-	t.Run("success", func(t *testing.T) {
-		mockDB, sqlMock, _ := sqlmock.New(sqlmock.MonitorPingsOption(true))
-		sqlMock.ExpectPing()
+func TestDBResolver_PingPrimaries(t *testing.T) {
+	mockDB, sqlMock, _ := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	mockError := errors.New("mock error")
+	sqlMock.ExpectPing().
+		WillReturnError(mockError)
+	mockPrimaryDB := sqlx.NewDb(mockDB, "primary")
+	mockDB, sqlMock, _ = sqlmock.New(sqlmock.MonitorPingsOption(true))
+	mockSecondaryDB := sqlx.NewDb(mockDB, "secondary")
+	r := &dbResolver{
+		primaries:   []*sqlx.DB{mockPrimaryDB},
+		secondaries: []*sqlx.DB{mockSecondaryDB},
+		reads:       []*sqlx.DB{mockSecondaryDB},
+	}
+
+	err := r.PingPrimaries(context.Background())
+
+	assert.ErrorIs(t, err, mockError)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestDBResolver_PingReads(t *testing.T) {
+	t.Run("primary is down", func(t *testing.T) {
+		mockDB, primaryMock, _ := sqlmock.New(sqlmock.MonitorPingsOption(true))
 		mockPrimaryDB := sqlx.NewDb(mockDB, "primary")
-		mockDB, sqlMock, _ = sqlmock.New(sqlmock.MonitorPingsOption(true))
+		mockDB, sqlMock, _ := sqlmock.New(sqlmock.MonitorPingsOption(true))
 		sqlMock.ExpectPing()
 		mockSecondaryDB := sqlx.NewDb(mockDB, "secondary")
 		r := &dbResolver{
 			primaries:   []*sqlx.DB{mockPrimaryDB},
 			secondaries: []*sqlx.DB{mockSecondaryDB},
+			reads:       []*sqlx.DB{mockSecondaryDB},
 		}
 
-		err := r.PingContext(context.Background())
+		err := r.PingReads(context.Background())
 
 		assert.NoError(t, err)
+		assert.NoError(t, primaryMock.ExpectationsWereMet())
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("return error", func(t *testing.T) {
+		mockDB, sqlMock1, _ := sqlmock.New(sqlmock.MonitorPingsOption(true))
+		mockError := errors.New("mock error")
+		sqlMock1.ExpectPing().
+			WillReturnError(mockError)
+		mockReadDB1 := sqlx.NewDb(mockDB, "read1")
+		mockDB, sqlMock2, _ := sqlmock.New(sqlmock.MonitorPingsOption(true))
+		sqlMock2.ExpectPing()
+		mockReadDB2 := sqlx.NewDb(mockDB, "read2")
+		r := &dbResolver{
+			primaries: []*sqlx.DB{mockReadDB1},
+			reads:     []*sqlx.DB{mockReadDB1, mockReadDB2},
+		}
+
+		err := r.PingReads(context.Background())
+
+		assert.ErrorIs(t, err, mockError)
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
 	})
 }
 
@@ -1526,6 +3273,58 @@ func TestDBResolver_PrepareContext(t *testing.T) {
 	})
 }
 
+func TestDBResolver_PrepareWithLoadBalancer(t *testing.T) {
+	t.Run("failed to prepare statement", func(t *testing.T) {
+		mockDB, sqlMock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		mockError := errors.New("mock error")
+		sqlMock.ExpectPrepare(`SELECT first_name FROM person WHERE id=?`).
+			WillReturnError(mockError)
+		mockPrimaryDB := sqlx.NewDb(mockDB, "mock")
+		r := &dbResolver{
+			primaries:    []*sqlx.DB{mockPrimaryDB},
+			loadBalancer: NewRandomLoadBalancer(),
+		}
+
+		result, err := r.PrepareWithLoadBalancer(`SELECT first_name FROM person WHERE id=?`, &firstLoadBalancer{})
+
+		assert.Nil(t, result)
+		assert.ErrorIs(t, err, mockError)
+	})
+
+	t.Run("choose statement with given load balancer", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock1.ExpectPrepare(`SELECT first_name FROM person WHERE id=?`)
+		mockPrimaryDB := sqlx.NewDb(mockDB1, "primary")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock2.ExpectPrepare(`SELECT first_name FROM person WHERE id=?`)
+		mockReadDB1 := sqlx.NewDb(mockDB2, "secondary1")
+		mockDB3, sqlMock3, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock3.ExpectPrepare(`SELECT first_name FROM person WHERE id=?`).
+			ExpectQuery().
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("foo"))
+		mockReadDB2 := sqlx.NewDb(mockDB3, "secondary2")
+		r := &dbResolver{
+			primaries:    []*sqlx.DB{mockPrimaryDB},
+			reads:        []*sqlx.DB{mockReadDB1, mockReadDB2},
+			loadBalancer: &firstLoadBalancer{},
+		}
+
+		result, err := r.PrepareWithLoadBalancer(
+			`SELECT first_name FROM person WHERE id=?`, &injectedLoadBalancer{db: mockReadDB2},
+		)
+		assert.NoError(t, err)
+		var firstName string
+		err = result.Get(&firstName, 1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "foo", firstName)
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+		assert.NoError(t, sqlMock3.ExpectationsWereMet())
+	})
+}
+
 func TestDBResolver_Preparex(t *testing.T) {
 	t.Run("failed to prepare primary DB statement", func(t *testing.T) {
 		mockDB, sqlMock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
@@ -1664,6 +3463,35 @@ func TestDBResolver_PreparexContext(t *testing.T) {
 	})
 }
 
+func TestDBResolver_PrimaryDBs(t *testing.T) {
+	mockPrimaryDBs := newMockDBs(t, 2)
+	r := &dbResolver{
+		primaries: mockPrimaryDBs,
+	}
+
+	result := r.PrimaryDBs()
+	assert.Equal(t, mockPrimaryDBs, result)
+
+	result[0] = nil
+	assert.NotNil(t, r.primaries[0])
+}
+
+func TestDBResolver_PrimaryStats(t *testing.T) {
+	mockPrimaryDBs := newMockDBs(t, 2)
+	conn, err := mockPrimaryDBs[1].Conn(context.Background())
+	assert.NoError(t, err)
+	defer conn.Close()
+	r := &dbResolver{
+		primaries: mockPrimaryDBs,
+	}
+
+	result := r.PrimaryStats()
+
+	assert.Len(t, result, 2)
+	assert.Equal(t, 0, result[0].InUse)
+	assert.Equal(t, 1, result[1].InUse)
+}
+
 func TestDBResolver_Query(t *testing.T) {
 	t.Run("return error", func(t *testing.T) {
 		mockDB, sqlMock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
@@ -1808,6 +3636,192 @@ func TestDBResolver_QueryContext(t *testing.T) {
 	})
 }
 
+func TestDBResolver_QueryEachRead(t *testing.T) {
+	scanFirstNames := func(t *testing.T, rows *sqlx.Rows) []string {
+		t.Helper()
+
+		var firstNames []string
+		for rows.Next() {
+			var firstName string
+			assert.NoError(t, rows.Scan(&firstName))
+			firstNames = append(firstNames, firstName)
+		}
+		assert.NoError(t, rows.Close())
+		return firstNames
+	}
+
+	for _, concurrency := range []int{0, 1} {
+		t.Run(fmt.Sprintf("query all reads with concurrency %d", concurrency), func(t *testing.T) {
+			mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			sqlMock1.ExpectQuery(`SELECT first_name FROM person`).
+				WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("foo"))
+			mockReadDB1 := sqlx.NewDb(mockDB1, "secondary1")
+			mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			sqlMock2.ExpectQuery(`SELECT first_name FROM person`).
+				WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("bar").AddRow("baz"))
+			mockReadDB2 := sqlx.NewDb(mockDB2, "secondary2")
+			r := &dbResolver{
+				primaries:         newMockDBs(t, 1),
+				reads:             []*sqlx.DB{mockReadDB1, mockReadDB2},
+				fanOutConcurrency: concurrency,
+			}
+
+			result, err := r.QueryEachRead(context.Background(), `SELECT first_name FROM person`)
+
+			assert.NoError(t, err)
+			assert.Len(t, result, 2)
+			assert.Equal(t, []string{"foo"}, scanFirstNames(t, result[mockReadDB1]))
+			assert.Equal(t, []string{"bar", "baz"}, scanFirstNames(t, result[mockReadDB2]))
+			assert.NoError(t, sqlMock1.ExpectationsWereMet())
+			assert.NoError(t, sqlMock2.ExpectationsWereMet())
+		})
+	}
+
+	t.Run("return error of failed read", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock1.ExpectQuery(`SELECT first_name FROM person`).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("foo"))
+		mockReadDB1 := sqlx.NewDb(mockDB1, "secondary1")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		mockError := errors.New("mock error")
+		sqlMock2.ExpectQuery(`SELECT first_name FROM person`).
+			WillReturnError(mockError)
+		mockReadDB2 := sqlx.NewDb(mockDB2, "secondary2")
+		r := &dbResolver{
+			primaries: newMockDBs(t, 1),
+			reads:     []*sqlx.DB{mockReadDB1, mockReadDB2},
+		}
+
+		result, err := r.QueryEachRead(context.Background(), `SELECT first_name FROM person`)
+
+		assert.ErrorIs(t, err, mockError)
+		assert.Len(t, result, 1)
+		assert.Equal(t, []string{"foo"}, scanFirstNames(t, result[mockReadDB1]))
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
+}
+
+func TestDBResolver_QueryFrom(t *testing.T) {
+	connErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+
+	t.Run("replica", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		mockPrimaryDB := sqlx.NewDb(mockDB1, "primary")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock2.ExpectQuery(`SELECT first_name FROM person`).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("foo"))
+		mockSecondaryDB := sqlx.NewDb(mockDB2, "secondary")
+		r := &dbResolver{
+			primaries:    []*sqlx.DB{mockPrimaryDB},
+			secondaries:  []*sqlx.DB{mockSecondaryDB},
+			reads:        []*sqlx.DB{mockSecondaryDB},
+			loadBalancer: &firstLoadBalancer{},
+		}
+
+		rows, db, err := r.QueryFrom(context.Background(), `SELECT first_name FROM person`)
+
+		assert.NoError(t, err)
+		assert.Same(t, mockSecondaryDB, db)
+		assert.True(t, rows.Next())
+		var firstName string
+		assert.NoError(t, rows.Scan(&firstName))
+		assert.Equal(t, "foo", firstName)
+		assert.NoError(t, rows.Close())
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
+
+	t.Run("primary on fallback", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock1.ExpectQuery(`SELECT first_name FROM person`).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("foo"))
+		mockPrimaryDB := sqlx.NewDb(mockDB1, "primary")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock2.ExpectQuery(`SELECT first_name FROM person`).
+			WillReturnError(connErr)
+		mockSecondaryDB := sqlx.NewDb(mockDB2, "secondary")
+		r := &dbResolver{
+			primaries:    []*sqlx.DB{mockPrimaryDB},
+			secondaries:  []*sqlx.DB{mockSecondaryDB},
+			reads:        []*sqlx.DB{mockSecondaryDB},
+			loadBalancer: &firstLoadBalancer{},
+		}
+
+		rows, db, err := r.QueryFrom(context.Background(), `SELECT first_name FROM person`)
+
+		assert.NoError(t, err)
+		assert.Same(t, mockPrimaryDB, db)
+		assert.True(t, rows.Next())
+		var firstName string
+		assert.NoError(t, rows.Scan(&firstName))
+		assert.Equal(t, "foo", firstName)
+		assert.NoError(t, rows.Close())
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
+
+	t.Run("not bind rows to default timeout", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		mockPrimaryDB := sqlx.NewDb(mockDB1, "primary")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock2.ExpectQuery(`SELECT first_name FROM person`).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("foo"))
+		mockSecondaryDB := sqlx.NewDb(mockDB2, "secondary")
+		var routed context.Context
+		r := &dbResolver{
+			primaries:    []*sqlx.DB{mockPrimaryDB},
+			secondaries:  []*sqlx.DB{mockSecondaryDB},
+			reads:        []*sqlx.DB{mockSecondaryDB},
+			loadBalancer: &firstLoadBalancer{},
+			queryTimeout: 10 * time.Millisecond,
+			routeHook: func(ctx context.Context, _ string, _ string, _ *sqlx.DB) {
+				routed = ctx
+			},
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		rows, db, err := r.QueryFrom(ctx, `SELECT first_name FROM person`)
+		assert.NoError(t, err)
+		time.Sleep(50 * time.Millisecond)
+
+		assert.Same(t, mockSecondaryDB, db)
+		assert.True(t, rows.Next())
+		var firstName string
+		assert.NoError(t, rows.Scan(&firstName))
+		assert.Equal(t, "foo", firstName)
+		assert.NoError(t, rows.Close())
+		assert.Same(t, ctx, routed)
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
+
+	t.Run("return error", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		mockPrimaryDB := sqlx.NewDb(mockDB1, "primary")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		mockError := errors.New("mock error")
+		sqlMock2.ExpectQuery(`SELECT first_name FROM person`).
+			WillReturnError(mockError)
+		mockSecondaryDB := sqlx.NewDb(mockDB2, "secondary")
+		r := &dbResolver{
+			primaries:    []*sqlx.DB{mockPrimaryDB},
+			secondaries:  []*sqlx.DB{mockSecondaryDB},
+			reads:        []*sqlx.DB{mockSecondaryDB},
+			loadBalancer: &firstLoadBalancer{},
+		}
+
+		rows, db, err := r.QueryFrom(context.Background(), `SELECT first_name FROM person`)
+
+		assert.ErrorIs(t, err, mockError)
+		assert.Nil(t, db)
+		assert.Nil(t, rows)
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
+}
+
 func TestDBResolver_QueryRow(t *testing.T) {
 	t.Run("return error", func(t *testing.T) {
 		mockDB, sqlMock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
@@ -1922,6 +3936,59 @@ func TestDBResolver_QueryRowContext(t *testing.T) {
 	})
 }
 
+func TestDBResolver_QueryRowFallback(t *testing.T) {
+	t.Run("fall back to primary on connection error while scanning", func(t *testing.T) {
+		connErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock1.ExpectQuery(`SELECT first_name FROM person WHERE id=?`).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("foo"))
+		mockPrimaryDB := sqlx.NewDb(mockDB1, "mock")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock2.ExpectQuery(`SELECT first_name FROM person WHERE id=?`).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("bar").RowError(0, connErr))
+		mockSecondaryDB := sqlx.NewDb(mockDB2, "mock")
+		r := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB}, ReadWritePolicy: WriteOnly},
+			WithSecondaryDBs(mockSecondaryDB),
+		)
+
+		result := r.QueryRowFallback(context.Background(), `SELECT first_name FROM person WHERE id=?`, 1)
+
+		assert.NoError(t, result.Err())
+		var firstName string
+		err := result.Scan(&firstName)
+		assert.NoError(t, err)
+		assert.Equal(t, "foo", firstName)
+		assert.Equal(t, RoutingStats{ReadQueries: 1, PrimaryQueries: 1, Fallbacks: 1}, r.RoutingStats())
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
+
+	t.Run("not fall back on other error while scanning", func(t *testing.T) {
+		mockError := errors.New("mock error")
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		mockPrimaryDB := sqlx.NewDb(mockDB1, "mock")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock2.ExpectQuery(`SELECT first_name FROM person WHERE id=?`).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("bar").RowError(0, mockError))
+		mockSecondaryDB := sqlx.NewDb(mockDB2, "mock")
+		r := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB}, ReadWritePolicy: WriteOnly},
+			WithSecondaryDBs(mockSecondaryDB),
+		)
+
+		var firstName string
+		err := r.QueryRowFallback(context.Background(), `SELECT first_name FROM person WHERE id=?`, 1).Scan(&firstName)
+
+		assert.ErrorIs(t, err, mockError)
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
+}
+
 func TestDBResolver_QueryRowx(t *testing.T) {
 	t.Run("return error", func(t *testing.T) {
 		mockDB, sqlMock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
@@ -2036,6 +4103,61 @@ func TestDBResolver_QueryRowxContext(t *testing.T) {
 	})
 }
 
+func TestDBResolver_QueryRowxFallback(t *testing.T) {
+	t.Run("fall back to primary on connection error while scanning", func(t *testing.T) {
+		type Person struct {
+			FirstName string `db:"first_name"`
+		}
+		connErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock1.ExpectQuery(`SELECT first_name FROM person WHERE id=?`).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("foo"))
+		mockPrimaryDB := sqlx.NewDb(mockDB1, "mock")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock2.ExpectQuery(`SELECT first_name FROM person WHERE id=?`).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("bar").RowError(0, connErr))
+		mockSecondaryDB := sqlx.NewDb(mockDB2, "mock")
+		r := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB}, ReadWritePolicy: WriteOnly},
+			WithSecondaryDBs(mockSecondaryDB),
+		)
+
+		var person Person
+		err := r.QueryRowxFallback(context.Background(), `SELECT first_name FROM person WHERE id=?`, 1).StructScan(&person)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "foo", person.FirstName)
+		assert.Equal(t, RoutingStats{ReadQueries: 1, PrimaryQueries: 1, Fallbacks: 1}, r.RoutingStats())
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
+
+	t.Run("not fall back with strict read separation", func(t *testing.T) {
+		connErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		mockPrimaryDB := sqlx.NewDb(mockDB1, "mock")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock2.ExpectQuery(`SELECT first_name FROM person WHERE id=?`).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("bar").RowError(0, connErr))
+		mockSecondaryDB := sqlx.NewDb(mockDB2, "mock")
+		r := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB}, ReadWritePolicy: WriteOnly},
+			WithSecondaryDBs(mockSecondaryDB),
+			WithStrictReadSeparation(),
+		)
+
+		var firstName string
+		err := r.QueryRowxFallback(context.Background(), `SELECT first_name FROM person WHERE id=?`, 1).Scan(&firstName)
+
+		assert.ErrorIs(t, err, connErr)
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
+}
+
 func TestDBResolver_Queryx(t *testing.T) {
 	t.Run("return error", func(t *testing.T) {
 		mockDB, sqlMock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
@@ -2180,6 +4302,125 @@ func TestDBResolver_QueryxContext(t *testing.T) {
 	})
 }
 
+func TestDBResolver_QuoteIdentifier(t *testing.T) {
+	tests := []struct {
+		driverName string
+		name       string
+		expected   string
+	}{
+		{"mysql", "first_name", "`first_name`"},
+		{"mysql", "first`name", "`first``name`"},
+		{"postgres", "first_name", `"first_name"`},
+		{"pgx", `first"name`, `"first""name"`},
+		{"sqlserver", "first_name", "[first_name]"},
+		{"sqlserver", "first]name", "[first]]name]"},
+		{"sqlite3", "first_name", `"first_name"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.driverName+" "+tt.name, func(t *testing.T) {
+			mockDB, _, _ := sqlmock.New()
+			mockPrimaryDB := sqlx.NewDb(mockDB, tt.driverName)
+			r := &dbResolver{
+				primaries: []*sqlx.DB{mockPrimaryDB},
+				loadBalancer: &injectedLoadBalancer{
+					db: mockPrimaryDB,
+				},
+			}
+
+			assert.Equal(t, tt.expected, r.QuoteIdentifier(tt.name))
+		})
+	}
+}
+
+func TestDBResolver_ReadDBs(t *testing.T) {
+	mockReadDBs := newMockDBs(t, 2)
+	r := &dbResolver{
+		reads: mockReadDBs,
+	}
+
+	result := r.ReadDBs()
+	assert.Equal(t, mockReadDBs, result)
+
+	result[0] = nil
+	assert.NotNil(t, r.reads[0])
+}
+
+func TestDBResolver_ReadModifyWrite(t *testing.T) {
+	t.Run("read and write with same primary connection", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock1.ExpectQuery(`SELECT balance FROM account WHERE id = ?`).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow(int64(100)))
+		sqlMock1.ExpectExec(`UPDATE account SET balance = ? WHERE id = ?`).
+			WithArgs(int64(90), 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mockPrimaryDB := sqlx.NewDb(mockDB1, "primary")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		mockSecondaryDB := sqlx.NewDb(mockDB2, "secondary")
+		r := &dbResolver{
+			primaries: []*sqlx.DB{mockPrimaryDB},
+			reads:     []*sqlx.DB{mockSecondaryDB},
+			loadBalancer: &injectedLoadBalancer{
+				db: mockPrimaryDB,
+			},
+		}
+
+		err := r.ReadModifyWrite(
+			context.Background(), `SELECT balance FROM account WHERE id = ?`, []interface{}{1},
+			func(conn *sqlx.Conn, result ReadResult) error {
+				balance := result[0]["balance"].(int64)
+				_, err := conn.ExecContext(
+					context.Background(), `UPDATE account SET balance = ? WHERE id = ?`, balance-10, 1,
+				)
+				return err
+			},
+		)
+
+		assert.NoError(t, err)
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
+
+	t.Run("read fails", func(t *testing.T) {
+		mockDB, sqlMock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		mockError := errors.New("mock error")
+		sqlMock.ExpectQuery(`SELECT balance FROM account`).
+			WillReturnError(mockError)
+		mockPrimaryDB := sqlx.NewDb(mockDB, "primary")
+		r := &dbResolver{
+			primaries: []*sqlx.DB{mockPrimaryDB},
+			loadBalancer: &injectedLoadBalancer{
+				db: mockPrimaryDB,
+			},
+		}
+		called := false
+
+		err := r.ReadModifyWrite(
+			context.Background(), `SELECT balance FROM account`, nil,
+			func(conn *sqlx.Conn, result ReadResult) error {
+				called = true
+				return nil
+			},
+		)
+
+		assert.ErrorIs(t, err, mockError)
+		assert.False(t, called)
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+}
+
+func TestDBResolver_ReadStats(t *testing.T) {
+	mockPrimaryDBs := newMockDBs(t, 1)
+	r := &dbResolver{
+		primaries: mockPrimaryDBs,
+		reads:     append(newMockDBs(t, 2), mockPrimaryDBs...),
+	}
+
+	result := r.ReadStats()
+
+	assert.Len(t, result, 3)
+}
+
 func TestDBResolver_Rebind(t *testing.T) {
 	t.Run("unknown driver", func(t *testing.T) {
 		mockDB, _, _ := sqlmock.New()
@@ -2228,22 +4469,208 @@ func TestDBResolver_Rebind(t *testing.T) {
 			loadBalancer: NewRandomLoadBalancer(),
 		}
 
-		result := r.Rebind("SELECT * FROM person WHERE first_name = ?")
+		result := r.Rebind("SELECT * FROM person WHERE first_name = ?")
+
+		assert.Equal(t, "SELECT * FROM person WHERE first_name = :arg1", result)
+	})
+
+	t.Run("success with at binavar type", func(t *testing.T) {
+		mockDB, _, _ := sqlmock.New()
+		mockPrimaryDB := sqlx.NewDb(mockDB, "sqlserver")
+		r := &dbResolver{
+			primaries:    []*sqlx.DB{mockPrimaryDB},
+			loadBalancer: NewRandomLoadBalancer(),
+		}
+
+		result := r.Rebind("SELECT * FROM person WHERE first_name = ?")
+
+		assert.Equal(t, "SELECT * FROM person WHERE first_name = @p1", result)
+	})
+
+	t.Run("cached bindvar type matches driver", func(t *testing.T) {
+		query := "SELECT * FROM person WHERE first_name = ? AND last_name = ?"
+		for _, driverName := range []string{"mock", "postgres", "pgx", "mysql", "sqlite3", "ora", "sqlserver"} {
+			mockDB, _, _ := sqlmock.New()
+			mockPrimaryDB := sqlx.NewDb(mockDB, driverName)
+			r := MustNewDBResolver(&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB}})
+
+			for i := 0; i < 2; i++ {
+				assert.Equal(t, mockPrimaryDB.Rebind(query), r.Rebind(query), driverName)
+			}
+			expected, expectedArgs, err := mockPrimaryDB.BindNamed("SELECT :first_name", map[string]interface{}{"first_name": "foo"})
+			assert.NoError(t, err)
+			result, args, err := r.BindNamed("SELECT :first_name", map[string]interface{}{"first_name": "foo"})
+			assert.NoError(t, err)
+			assert.Equal(t, expected, result, driverName)
+			assert.Equal(t, expectedArgs, args, driverName)
+		}
+	})
+
+	t.Run("choose primary with mixed drivers", func(t *testing.T) {
+		mockDB1, _, _ := sqlmock.New()
+		mockPrimaryDB1 := sqlx.NewDb(mockDB1, "mysql")
+		mockDB2, _, _ := sqlmock.New()
+		mockPrimaryDB2 := sqlx.NewDb(mockDB2, "postgres")
+		r := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB1, mockPrimaryDB2}},
+			WithAllowMixedDrivers(),
+			WithLoadBalancer(&injectedLoadBalancer{db: mockPrimaryDB2}),
+		)
+
+		result := r.Rebind("SELECT * FROM person WHERE first_name = ?")
+
+		assert.Equal(t, "SELECT * FROM person WHERE first_name = $1", result)
+	})
+}
+
+func TestDBResolver_RebindForDriver(t *testing.T) {
+	tests := []struct {
+		driverName string
+		expected   string
+	}{
+		{driverName: "mock", expected: "SELECT * FROM person WHERE first_name = ?"},
+		{driverName: "postgres", expected: "SELECT * FROM person WHERE first_name = $1"},
+		{driverName: "mysql", expected: "SELECT * FROM person WHERE first_name = ?"},
+		{driverName: "ora", expected: "SELECT * FROM person WHERE first_name = :arg1"},
+		{driverName: "sqlserver", expected: "SELECT * FROM person WHERE first_name = @p1"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.driverName, func(t *testing.T) {
+			r := &dbResolver{}
+
+			result := r.RebindForDriver(tt.driverName, "SELECT * FROM person WHERE first_name = ?")
+
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestDBResolver_RoutingStats(t *testing.T) {
+	connErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+	mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	sqlMock1.ExpectExec(`UPDATE person SET first_name = "foo"`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	sqlMock1.ExpectQuery(`SELECT first_name FROM person`).
+		WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("foo"))
+	mockPrimaryDB := sqlx.NewDb(mockDB1, "primary")
+	mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	sqlMock2.ExpectQuery(`SELECT first_name FROM person`).
+		WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("foo"))
+	sqlMock2.ExpectQuery(`SELECT first_name FROM person`).
+		WillReturnError(connErr)
+	mockSecondaryDB := sqlx.NewDb(mockDB2, "secondary")
+	r := &dbResolver{
+		primaries:    []*sqlx.DB{mockPrimaryDB},
+		secondaries:  []*sqlx.DB{mockSecondaryDB},
+		reads:        []*sqlx.DB{mockSecondaryDB},
+		loadBalancer: &firstLoadBalancer{},
+	}
+
+	_, err := r.Exec(`UPDATE person SET first_name = "foo"`)
+	assert.NoError(t, err)
+	var firstName string
+	err = r.Get(&firstName, `SELECT first_name FROM person`)
+	assert.NoError(t, err)
+	rows, err := r.Query(`SELECT first_name FROM person`)
+	assert.NoError(t, err)
+	assert.NoError(t, rows.Close())
+
+	assert.Equal(t, RoutingStats{
+		PrimaryQueries: 2,
+		ReadQueries:    2,
+		Fallbacks:      1,
+		Writes:         1,
+	}, r.RoutingStats())
+	assert.NoError(t, sqlMock1.ExpectationsWereMet())
+	assert.NoError(t, sqlMock2.ExpectationsWereMet())
+}
+
+func TestDBResolver_SecondaryDBConfig(t *testing.T) {
+	t.Run("named and weighted secondaries", func(t *testing.T) {
+		primaries := newMockDBs(t, 1)
+		secondaries := newMockDBs(t, 2)
+		r := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: primaries, ReadWritePolicy: WriteOnly},
+			WithSecondaryDBConfigs(
+				SecondaryDBConfig{DB: secondaries[0], Weight: 3, Name: "replica-a"},
+				SecondaryDBConfig{DB: secondaries[1], Name: "replica-b"},
+			),
+		)
 
-		assert.Equal(t, "SELECT * FROM person WHERE first_name = :arg1", result)
+		assert.Equal(t, secondaries, r.ReadDBs())
+		cfg, ok := r.SecondaryDBConfig(secondaries[0])
+		assert.True(t, ok)
+		assert.Equal(t, SecondaryDBConfig{DB: secondaries[0], Weight: 3, Name: "replica-a"}, cfg)
+		cfg, ok = r.SecondaryDBConfig(secondaries[1])
+		assert.True(t, ok)
+		assert.Equal(t, SecondaryDBConfig{DB: secondaries[1], Weight: 1, Name: "replica-b"}, cfg)
+		_, ok = r.SecondaryDBConfig(primaries[0])
+		assert.False(t, ok)
 	})
 
-	t.Run("success with at binavar type", func(t *testing.T) {
-		mockDB, _, _ := sqlmock.New()
-		mockPrimaryDB := sqlx.NewDb(mockDB, "sqlserver")
-		r := &dbResolver{
-			primaries:    []*sqlx.DB{mockPrimaryDB},
-			loadBalancer: NewRandomLoadBalancer(),
+	t.Run("secondaries without configs", func(t *testing.T) {
+		primaries := newMockDBs(t, 1)
+		secondaries := newMockDBs(t, 1)
+		r := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: primaries, ReadWritePolicy: WriteOnly},
+			WithSecondaryDBs(secondaries...),
+		)
+
+		cfg, ok := r.SecondaryDBConfig(secondaries[0])
+		assert.True(t, ok)
+		assert.Equal(t, SecondaryDBConfig{DB: secondaries[0], Weight: 1}, cfg)
+	})
+
+	t.Run("balance by weights", func(t *testing.T) {
+		const selections = 10000
+		primaries := newMockDBs(t, 1)
+		secondaries := newMockDBs(t, 2)
+		r := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: primaries, ReadWritePolicy: WriteOnly},
+			WithSecondaryDBConfigs(
+				SecondaryDBConfig{DB: secondaries[0], Weight: 1},
+				SecondaryDBConfig{DB: secondaries[1], Weight: 9},
+			),
+			WithLoadBalancerSeed(1),
+		)
+
+		assert.IsType(t, &WeightedLoadBalancer{}, r.(*dbResolver).loadBalancer)
+		counts := make(map[*sqlx.DB]int, len(secondaries))
+		for i := 0; i < selections; i++ {
+			counts[r.AcquireRead()]++
 		}
+		assert.InDelta(t, 0.1, float64(counts[secondaries[0]])/selections, 0.02)
+		assert.InDelta(t, 0.9, float64(counts[secondaries[1]])/selections, 0.02)
+	})
 
-		result := r.Rebind("SELECT * FROM person WHERE first_name = ?")
+	t.Run("report name in route hook", func(t *testing.T) {
+		mockDB1, _, _ := sqlmock.New()
+		mockPrimaryDB := sqlx.NewDb(mockDB1, "primary")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock2.ExpectQuery(`SELECT first_name FROM person`).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("foo"))
+		mockSecondaryDB := sqlx.NewDb(mockDB2, "secondary")
 
-		assert.Equal(t, "SELECT * FROM person WHERE first_name = @p1", result)
+		var (
+			r     DBResolver
+			names []string
+		)
+		r = MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB}, ReadWritePolicy: WriteOnly},
+			WithSecondaryDBConfigs(SecondaryDBConfig{DB: mockSecondaryDB, Name: "replica-a"}),
+			WithRouteHook(func(_ context.Context, _ string, _ string, db *sqlx.DB) {
+				cfg, _ := r.SecondaryDBConfig(db)
+				names = append(names, cfg.Name)
+			}),
+		)
+
+		var firstName string
+		err := r.GetContext(context.Background(), &firstName, `SELECT first_name FROM person`)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"replica-a"}, names)
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
 	})
 }
 
@@ -2402,6 +4829,364 @@ func TestDbResolver_Stats(t *testing.T) {
 	})
 }
 
+func TestDBResolver_SelectFrom(t *testing.T) {
+	connErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+
+	t.Run("replica", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		mockPrimaryDB := sqlx.NewDb(mockDB1, "primary")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock2.ExpectQuery(`SELECT first_name FROM person`).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("foo"))
+		mockSecondaryDB := sqlx.NewDb(mockDB2, "secondary")
+		r := &dbResolver{
+			primaries:    []*sqlx.DB{mockPrimaryDB},
+			secondaries:  []*sqlx.DB{mockSecondaryDB},
+			reads:        []*sqlx.DB{mockSecondaryDB},
+			loadBalancer: &firstLoadBalancer{},
+		}
+
+		var firstNames []string
+		db, err := r.SelectFrom(context.Background(), &firstNames, `SELECT first_name FROM person`)
+
+		assert.NoError(t, err)
+		assert.Same(t, mockSecondaryDB, db)
+		assert.Equal(t, []string{"foo"}, firstNames)
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
+
+	t.Run("primary on fallback", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock1.ExpectQuery(`SELECT first_name FROM person`).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("foo"))
+		mockPrimaryDB := sqlx.NewDb(mockDB1, "primary")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock2.ExpectQuery(`SELECT first_name FROM person`).
+			WillReturnError(connErr)
+		mockSecondaryDB := sqlx.NewDb(mockDB2, "secondary")
+		r := &dbResolver{
+			primaries:    []*sqlx.DB{mockPrimaryDB},
+			secondaries:  []*sqlx.DB{mockSecondaryDB},
+			reads:        []*sqlx.DB{mockSecondaryDB},
+			loadBalancer: &firstLoadBalancer{},
+		}
+
+		var firstNames []string
+		db, err := r.SelectFrom(context.Background(), &firstNames, `SELECT first_name FROM person`)
+
+		assert.NoError(t, err)
+		assert.Same(t, mockPrimaryDB, db)
+		assert.Equal(t, []string{"foo"}, firstNames)
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
+
+	t.Run("return error", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		mockPrimaryDB := sqlx.NewDb(mockDB1, "primary")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		mockError := errors.New("mock error")
+		sqlMock2.ExpectQuery(`SELECT first_name FROM person`).
+			WillReturnError(mockError)
+		mockSecondaryDB := sqlx.NewDb(mockDB2, "secondary")
+		r := &dbResolver{
+			primaries:    []*sqlx.DB{mockPrimaryDB},
+			secondaries:  []*sqlx.DB{mockSecondaryDB},
+			reads:        []*sqlx.DB{mockSecondaryDB},
+			loadBalancer: &firstLoadBalancer{},
+		}
+
+		var firstNames []string
+		db, err := r.SelectFrom(context.Background(), &firstNames, `SELECT first_name FROM person`)
+
+		assert.ErrorIs(t, err, mockError)
+		assert.Nil(t, db)
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
+}
+
+func TestDBResolver_SelectWithRole(t *testing.T) {
+	connErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+
+	t.Run("replica", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		mockPrimaryDB := sqlx.NewDb(mockDB1, "primary")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock2.ExpectQuery(`SELECT first_name FROM person`).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("foo"))
+		mockSecondaryDB := sqlx.NewDb(mockDB2, "secondary")
+		r := &dbResolver{
+			primaries:    []*sqlx.DB{mockPrimaryDB},
+			secondaries:  []*sqlx.DB{mockSecondaryDB},
+			reads:        []*sqlx.DB{mockSecondaryDB},
+			loadBalancer: &firstLoadBalancer{},
+		}
+
+		var firstNames []string
+		role, err := r.SelectWithRole(context.Background(), &firstNames, `SELECT first_name FROM person`)
+
+		assert.NoError(t, err)
+		assert.Equal(t, RoleReplica, role)
+		assert.Equal(t, []string{"foo"}, firstNames)
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
+
+	t.Run("primary on fallback", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock1.ExpectQuery(`SELECT first_name FROM person`).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("foo"))
+		mockPrimaryDB := sqlx.NewDb(mockDB1, "primary")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock2.ExpectQuery(`SELECT first_name FROM person`).
+			WillReturnError(connErr)
+		mockSecondaryDB := sqlx.NewDb(mockDB2, "secondary")
+		r := &dbResolver{
+			primaries:    []*sqlx.DB{mockPrimaryDB},
+			secondaries:  []*sqlx.DB{mockSecondaryDB},
+			reads:        []*sqlx.DB{mockSecondaryDB},
+			loadBalancer: &firstLoadBalancer{},
+		}
+
+		var firstNames []string
+		role, err := r.SelectWithRole(context.Background(), &firstNames, `SELECT first_name FROM person`)
+
+		assert.NoError(t, err)
+		assert.Equal(t, RolePrimary, role)
+		assert.Equal(t, []string{"foo"}, firstNames)
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
+
+	t.Run("return error", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		mockPrimaryDB := sqlx.NewDb(mockDB1, "primary")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		mockError := errors.New("mock error")
+		sqlMock2.ExpectQuery(`SELECT first_name FROM person`).
+			WillReturnError(mockError)
+		mockSecondaryDB := sqlx.NewDb(mockDB2, "secondary")
+		r := &dbResolver{
+			primaries:    []*sqlx.DB{mockPrimaryDB},
+			secondaries:  []*sqlx.DB{mockSecondaryDB},
+			reads:        []*sqlx.DB{mockSecondaryDB},
+			loadBalancer: &firstLoadBalancer{},
+		}
+
+		var firstNames []string
+		role, err := r.SelectWithRole(context.Background(), &firstNames, `SELECT first_name FROM person`)
+
+		assert.ErrorIs(t, err, mockError)
+		assert.Empty(t, role)
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
+}
+
+func TestDBResolver_SetConnMaxIdleTime(t *testing.T) {
+	t.Run("apply once to each database", func(t *testing.T) {
+		mockPrimaryDBs := newMockDBs(t, 2)
+		mockSecondaryDBs := newMockDBs(t, 2)
+		r := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: mockPrimaryDBs, ReadWritePolicy: ReadWrite},
+			WithSecondaryDBs(mockSecondaryDBs...),
+		).(*dbResolver)
+
+		// The readable databases include the primary databases with ReadWrite.
+		assert.Len(t, r.ReadDBs(), 4)
+		calls := make(map[*sqlx.DB]int)
+		r.connMaxIdleTimeSetter = func(db *sqlx.DB, d time.Duration) {
+			assert.Equal(t, time.Minute, d)
+			calls[db]++
+		}
+		r.SetConnMaxIdleTime(time.Minute)
+
+		assert.Equal(t, map[*sqlx.DB]int{
+			mockPrimaryDBs[0]:   1,
+			mockPrimaryDBs[1]:   1,
+			mockSecondaryDBs[0]: 1,
+			mockSecondaryDBs[1]: 1,
+		}, calls)
+	})
+
+	t.Run("apply to secondary databases", func(t *testing.T) {
+		mockPrimaryDBs := newMockDBs(t, 1)
+		mockSecondaryDBs := newMockDBs(t, 1)
+		r := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: mockPrimaryDBs, ReadWritePolicy: ReadWrite},
+			WithSecondaryDBs(mockSecondaryDBs...),
+		)
+		r.SetMaxOpenConns(1)
+
+		assert.Equal(t, 1, mockPrimaryDBs[0].Stats().MaxOpenConnections)
+		assert.Equal(t, 1, mockSecondaryDBs[0].Stats().MaxOpenConnections)
+	})
+}
+
+func TestDBResolver_SingleCandidate(t *testing.T) {
+	t.Run("choose single database without load balancer", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock1.ExpectExec(`INSERT INTO person (first_name) VALUES (?)`).
+			WithArgs("foo").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mockPrimaryDB := sqlx.NewDb(mockDB1, "primary")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock2.ExpectQuery(`SELECT first_name FROM person`).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("foo"))
+		mockSecondaryDB := sqlx.NewDb(mockDB2, "secondary")
+		lb := &countingLoadBalancer{LoadBalancer: NewRandomLoadBalancer()}
+		r := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB}, ReadWritePolicy: WriteOnly},
+			WithSecondaryDBs(mockSecondaryDB),
+			WithLoadBalancer(lb),
+		)
+
+		_, err := r.Exec(`INSERT INTO person (first_name) VALUES (?)`, "foo")
+		assert.NoError(t, err)
+		var firstName string
+		err = r.Get(&firstName, `SELECT first_name FROM person`)
+		assert.NoError(t, err)
+
+		assert.Equal(t, "foo", firstName)
+		assert.Same(t, mockPrimaryDB, r.AcquirePrimary())
+		assert.Same(t, mockSecondaryDB, r.AcquireRead())
+		assert.Zero(t, lb.calls)
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
+
+	t.Run("choose from databases with load balancer", func(t *testing.T) {
+		mockSecondaryDBs := newMockDBs(t, 2)
+		lb := &countingLoadBalancer{LoadBalancer: NewRandomLoadBalancer()}
+		r := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: newMockDBs(t, 1), ReadWritePolicy: WriteOnly},
+			WithSecondaryDBs(mockSecondaryDBs...),
+			WithLoadBalancer(lb),
+		)
+
+		assert.Contains(t, mockSecondaryDBs, r.AcquireRead())
+		assert.Equal(t, int64(1), lb.calls)
+	})
+}
+
+func TestDBResolver_Transaction(t *testing.T) {
+	t.Run("commit", func(t *testing.T) {
+		mockDB, sqlMock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock.ExpectBegin()
+		sqlMock.ExpectExec(`DELETE FROM person`).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		sqlMock.ExpectCommit()
+		mockPrimaryDB := sqlx.NewDb(mockDB, "mock")
+		r := &dbResolver{
+			primaries: []*sqlx.DB{mockPrimaryDB},
+			loadBalancer: &injectedLoadBalancer{
+				db: mockPrimaryDB,
+			},
+		}
+
+		err := r.Transaction(context.Background(), nil, func(tx *sqlx.Tx) error {
+			_, err := tx.Exec(`DELETE FROM person`)
+			return err
+		})
+
+		assert.NoError(t, err)
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("rollback on error", func(t *testing.T) {
+		mockDB, sqlMock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		mockError := errors.New("mock error")
+		sqlMock.ExpectBegin()
+		sqlMock.ExpectExec(`DELETE FROM person`).
+			WillReturnError(mockError)
+		sqlMock.ExpectRollback()
+		mockPrimaryDB := sqlx.NewDb(mockDB, "mock")
+		r := &dbResolver{
+			primaries: []*sqlx.DB{mockPrimaryDB},
+			loadBalancer: &injectedLoadBalancer{
+				db: mockPrimaryDB,
+			},
+		}
+
+		err := r.Transaction(context.Background(), nil, func(tx *sqlx.Tx) error {
+			_, err := tx.Exec(`DELETE FROM person`)
+			return err
+		})
+
+		assert.Equal(t, mockError, err)
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("rollback fails", func(t *testing.T) {
+		mockDB, sqlMock, _ := sqlmock.New()
+		mockError := errors.New("mock error")
+		rollbackError := errors.New("rollback error")
+		sqlMock.ExpectBegin()
+		sqlMock.ExpectRollback().
+			WillReturnError(rollbackError)
+		mockPrimaryDB := sqlx.NewDb(mockDB, "mock")
+		r := &dbResolver{
+			primaries: []*sqlx.DB{mockPrimaryDB},
+			loadBalancer: &injectedLoadBalancer{
+				db: mockPrimaryDB,
+			},
+		}
+
+		err := r.Transaction(context.Background(), nil, func(tx *sqlx.Tx) error {
+			return mockError
+		})
+
+		assert.ErrorIs(t, err, mockError)
+		assert.ErrorIs(t, err, rollbackError)
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("rollback on panic", func(t *testing.T) {
+		mockDB, sqlMock, _ := sqlmock.New()
+		sqlMock.ExpectBegin()
+		sqlMock.ExpectRollback()
+		mockPrimaryDB := sqlx.NewDb(mockDB, "mock")
+		r := &dbResolver{
+			primaries: []*sqlx.DB{mockPrimaryDB},
+			loadBalancer: &injectedLoadBalancer{
+				db: mockPrimaryDB,
+			},
+		}
+
+		assert.PanicsWithValue(t, "mock panic", func() {
+			_ = r.Transaction(context.Background(), nil, func(tx *sqlx.Tx) error {
+				panic("mock panic")
+			})
+		})
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("begin fails", func(t *testing.T) {
+		mockDB, sqlMock, _ := sqlmock.New()
+		mockError := errors.New("mock error")
+		sqlMock.ExpectBegin().
+			WillReturnError(mockError)
+		mockPrimaryDB := sqlx.NewDb(mockDB, "mock")
+		r := &dbResolver{
+			primaries: []*sqlx.DB{mockPrimaryDB},
+			loadBalancer: &injectedLoadBalancer{
+				db: mockPrimaryDB,
+			},
+		}
+		called := false
+
+		err := r.Transaction(context.Background(), nil, func(tx *sqlx.Tx) error {
+			called = true
+			return nil
+		})
+
+		assert.ErrorIs(t, err, mockError)
+		assert.False(t, called)
+	})
+}
+
 func TestDBResolver_Unsafe(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		mockDB, _, _ := sqlmock.New()
@@ -2424,3 +5209,156 @@ func TestDBResolver_Unsafe(t *testing.T) {
 		assert.Equal(t, expected, r.Unsafe())
 	})
 }
+
+func TestDBResolver_WithReplicaConn(t *testing.T) {
+	t.Run("run statements with replica connection", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		mockPrimaryDB := sqlx.NewDb(mockDB1, "primary")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock2.ExpectExec(`CREATE TEMPORARY TABLE ids (id INT)`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		sqlMock2.ExpectQuery(`SELECT id FROM ids`).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2))
+		mockSecondaryDB := sqlx.NewDb(mockDB2, "secondary")
+		r := &dbResolver{
+			primaries: []*sqlx.DB{mockPrimaryDB},
+			reads:     []*sqlx.DB{mockSecondaryDB},
+			loadBalancer: &injectedLoadBalancer{
+				db: mockSecondaryDB,
+			},
+		}
+
+		var ids []int
+		err := r.WithReplicaConn(context.Background(), func(conn *sqlx.Conn) error {
+			if _, err := conn.ExecContext(context.Background(), `CREATE TEMPORARY TABLE ids (id INT)`); err != nil {
+				return err
+			}
+			return conn.SelectContext(context.Background(), &ids, `SELECT id FROM ids`)
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []int{1, 2}, ids)
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
+
+	t.Run("fall back to primary connection", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock1.ExpectQuery(`SELECT id FROM person`).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		mockPrimaryDB := sqlx.NewDb(mockDB1, "primary")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock2.ExpectClose()
+		mockSecondaryDB := sqlx.NewDb(mockDB2, "secondary")
+		assert.NoError(t, mockSecondaryDB.Close())
+		r := &dbResolver{
+			primaries:    []*sqlx.DB{mockPrimaryDB},
+			reads:        []*sqlx.DB{mockSecondaryDB},
+			loadBalancer: &firstLoadBalancer{},
+		}
+
+		var ids []int
+		err := r.WithReplicaConn(context.Background(), func(conn *sqlx.Conn) error {
+			return conn.SelectContext(context.Background(), &ids, `SELECT id FROM person`)
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []int{1}, ids)
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
+
+	t.Run("return error of fn", func(t *testing.T) {
+		mockDB, _, _ := sqlmock.New()
+		mockSecondaryDB := sqlx.NewDb(mockDB, "secondary")
+		r := &dbResolver{
+			reads:        []*sqlx.DB{mockSecondaryDB},
+			loadBalancer: &firstLoadBalancer{},
+		}
+		expected := errors.New("fn failed")
+
+		err := r.WithReplicaConn(context.Background(), func(conn *sqlx.Conn) error {
+			return expected
+		})
+
+		assert.Equal(t, expected, err)
+	})
+}
+
+func BenchmarkDBResolver_AcquireRead(b *testing.B) {
+	newDBs := func(b *testing.B, n int) []*sqlx.DB {
+		b.Helper()
+
+		dbs := make([]*sqlx.DB, n)
+		for i := range dbs {
+			mockDB, _, err := sqlmock.New()
+			if err != nil {
+				b.Fatal(err)
+			}
+			dbs[i] = sqlx.NewDb(mockDB, "mock")
+		}
+		return dbs
+	}
+
+	for _, bm := range []struct {
+		name        string
+		secondaries int
+	}{
+		{name: "single secondary", secondaries: 1},
+		{name: "two secondaries", secondaries: 2},
+	} {
+		secondaries := bm.secondaries
+		b.Run(bm.name, func(b *testing.B) {
+			lb := &countingLoadBalancer{LoadBalancer: NewRandomLoadBalancer()}
+			r := MustNewDBResolver(
+				&PrimaryDBsConfig{DBs: newDBs(b, 1), ReadWritePolicy: WriteOnly},
+				WithSecondaryDBs(newDBs(b, secondaries)...),
+				WithLoadBalancer(lb),
+			)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_ = r.AcquireRead()
+			}
+			b.ReportMetric(float64(lb.calls)/float64(b.N), "selects/op")
+		})
+	}
+}
+
+func BenchmarkDBResolver_Rebind(b *testing.B) {
+	query := "SELECT * FROM person WHERE first_name = ? AND last_name = ?"
+	newPrimaryDBs := func(b *testing.B, driverNames ...string) []*sqlx.DB {
+		b.Helper()
+
+		dbs := make([]*sqlx.DB, len(driverNames))
+		for i, driverName := range driverNames {
+			mockDB, _, err := sqlmock.New()
+			if err != nil {
+				b.Fatal(err)
+			}
+			dbs[i] = sqlx.NewDb(mockDB, driverName)
+		}
+		return dbs
+	}
+
+	b.Run("cached bindvar type", func(b *testing.B) {
+		r := MustNewDBResolver(&PrimaryDBsConfig{DBs: newPrimaryDBs(b, "postgres", "postgres")})
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			_ = r.Rebind(query)
+		}
+	})
+
+	b.Run("choose primary with mixed drivers", func(b *testing.B) {
+		r := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: newPrimaryDBs(b, "postgres", "sqlserver")},
+			WithAllowMixedDrivers(),
+		)
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			_ = r.Rebind(query)
+		}
+	})
+}
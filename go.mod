@@ -9,6 +9,7 @@ require (
 	github.com/pkg/errors v0.9.1
 	github.com/rakyll/gotest v0.0.6
 	github.com/stretchr/testify v1.8.1
+	golang.org/x/sync v0.7.0
 	golang.org/x/tools v0.4.0
 )
 
@@ -0,0 +1,161 @@
+package dbresolver
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// healthState keeps track of the databases which are considered unhealthy.
+// The zero value considers every database healthy.
+type healthState struct {
+	mu        sync.RWMutex
+	unhealthy map[*sqlx.DB]struct{}
+}
+
+// markUnhealthy marks the given database as unhealthy.
+// It reports whether the database was healthy before.
+func (h *healthState) markUnhealthy(db *sqlx.DB) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.unhealthy[db]; ok {
+		return false
+	}
+	if h.unhealthy == nil {
+		h.unhealthy = make(map[*sqlx.DB]struct{})
+	}
+	h.unhealthy[db] = struct{}{}
+	return true
+}
+
+// markHealthy marks the given database as healthy.
+// It reports whether the database was unhealthy before.
+func (h *healthState) markHealthy(db *sqlx.DB) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.unhealthy[db]; !ok {
+		return false
+	}
+	delete(h.unhealthy, db)
+	return true
+}
+
+// isHealthy reports whether the given database is considered healthy.
+func (h *healthState) isHealthy(db *sqlx.DB) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	_, ok := h.unhealthy[db]
+	return !ok
+}
+
+// countHealthy returns the number of the given databases which are considered healthy.
+func (h *healthState) countHealthy(dbs []*sqlx.DB) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	n := 0
+	for _, db := range dbs {
+		if _, ok := h.unhealthy[db]; !ok {
+			n++
+		}
+	}
+	return n
+}
+
+// healthyDBs returns the given databases which are considered healthy.
+// If none of them is healthy, it returns all the given databases so that there is always a database to choose.
+func (h *healthState) healthyDBs(dbs []*sqlx.DB) []*sqlx.DB {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if len(h.unhealthy) == 0 {
+		return dbs
+	}
+	healthy := make([]*sqlx.DB, 0, len(dbs))
+	for _, db := range dbs {
+		if _, ok := h.unhealthy[db]; !ok {
+			healthy = append(healthy, db)
+		}
+	}
+	if len(healthy) == 0 {
+		return dbs
+	}
+	return healthy
+}
+
+// healthChecker pings the databases periodically in the background
+// and marks them healthy or unhealthy according to the result.
+type healthChecker struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// startHealthChecker starts pinging the databases returned by dbs on every interval.
+// Each ping times out after the interval.
+// onChange is called when the health of a database changes. It can be nil.
+func startHealthChecker(
+	health *healthState, dbs func() []*sqlx.DB, interval time.Duration, onChange func(db *sqlx.DB, healthy bool),
+) *healthChecker {
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &healthChecker{
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+
+	go func() {
+		defer close(c.done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				checkHealth(ctx, health, dbs(), interval, onChange)
+			}
+		}
+	}()
+	return c
+}
+
+// stop stops the health checker and waits until it stops.
+func (c *healthChecker) stop() {
+	c.cancel()
+	<-c.done
+}
+
+// checkHealth pings the given databases once and marks them healthy or unhealthy.
+// onChange is called when the health of a database changes. It can be nil.
+func checkHealth(
+	ctx context.Context,
+	health *healthState,
+	dbs []*sqlx.DB,
+	timeout time.Duration,
+	onChange func(db *sqlx.DB, healthy bool),
+) {
+	for _, db := range dbs {
+		pingCtx, cancel := context.WithTimeout(ctx, timeout)
+		err := db.PingContext(pingCtx)
+		cancel()
+		if ctx.Err() != nil {
+			return
+		}
+
+		healthy := err == nil
+		var changed bool
+		if healthy {
+			changed = health.markHealthy(db)
+		} else {
+			changed = health.markUnhealthy(db)
+		}
+		if changed && onChange != nil {
+			onChange(db, healthy)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package dbresolver
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDBResolver_HealthyReplicaCount(t *testing.T) {
+	t.Run("without health state", func(t *testing.T) {
+		mockDB1, _, _ := sqlmock.New()
+		mockDB2, _, _ := sqlmock.New()
+		r := &dbResolver{
+			secondaries: []*sqlx.DB{sqlx.NewDb(mockDB1, "secondary1"), sqlx.NewDb(mockDB2, "secondary2")},
+		}
+
+		assert.Equal(t, 2, r.HealthyReplicaCount())
+	})
+
+	t.Run("marked unhealthy and healthy again", func(t *testing.T) {
+		mockDB1, _, _ := sqlmock.New()
+		mockSecondaryDB1 := sqlx.NewDb(mockDB1, "secondary1")
+		mockDB2, _, _ := sqlmock.New()
+		mockSecondaryDB2 := sqlx.NewDb(mockDB2, "secondary2")
+		r := &dbResolver{
+			secondaries: []*sqlx.DB{mockSecondaryDB1, mockSecondaryDB2},
+		}
+
+		r.health.markUnhealthy(mockSecondaryDB1)
+		assert.Equal(t, 1, r.HealthyReplicaCount())
+
+		r.health.markUnhealthy(mockSecondaryDB2)
+		assert.Equal(t, 0, r.HealthyReplicaCount())
+
+		r.health.markHealthy(mockSecondaryDB1)
+		assert.Equal(t, 1, r.HealthyReplicaCount())
+	})
+}
+
+func TestHealthState_HealthyDBs(t *testing.T) {
+	dbs := newMockDBs(t, 3)
+
+	t.Run("all healthy", func(t *testing.T) {
+		h := &healthState{}
+
+		assert.Equal(t, dbs, h.healthyDBs(dbs))
+	})
+
+	t.Run("exclude unhealthy", func(t *testing.T) {
+		h := &healthState{}
+		h.markUnhealthy(dbs[1])
+
+		assert.Equal(t, []*sqlx.DB{dbs[0], dbs[2]}, h.healthyDBs(dbs))
+	})
+
+	t.Run("all unhealthy", func(t *testing.T) {
+		h := &healthState{}
+		for _, db := range dbs {
+			h.markUnhealthy(db)
+		}
+
+		assert.Equal(t, dbs, h.healthyDBs(dbs))
+	})
+}
+
+func TestWithHealthCheck(t *testing.T) {
+	mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	mockPrimaryDB := sqlx.NewDb(mockDB1, "primary")
+	mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	mockSecondaryDB1 := sqlx.NewDb(mockDB2, "secondary1")
+	mockDB3, sqlMock3, _ := sqlmock.New(
+		sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual),
+		sqlmock.MonitorPingsOption(true),
+	)
+	// The ping succeeds once and then fails.
+	sqlMock3.ExpectPing()
+	sqlMock3.ExpectPing().WillReturnError(errors.New("connection refused"))
+	mockSecondaryDB2 := sqlx.NewDb(mockDB3, "secondary2")
+	interval := 10 * time.Millisecond
+	resolver := MustNewDBResolver(
+		&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB}, ReadWritePolicy: WriteOnly},
+		WithSecondaryDBs(mockSecondaryDB1, mockSecondaryDB2),
+		WithLoadBalancer(&firstLoadBalancer{}),
+		WithHealthCheck(interval),
+	)
+	r := resolver.(*dbResolver)
+
+	assert.Eventually(t, func() bool {
+		return resolver.HealthyReplicaCount() == 1
+	}, time.Second, interval)
+	assert.Equal(t, []*sqlx.DB{mockSecondaryDB1}, r.readCandidates(context.Background()))
+
+	sqlMock1.ExpectClose()
+	sqlMock2.ExpectClose()
+	sqlMock3.ExpectClose()
+	assert.NoError(t, resolver.Close())
+	select {
+	case <-r.healthChecker.done:
+	default:
+		t.Error("health checker is not stopped")
+	}
+}
+
+func TestCheckHealth(t *testing.T) {
+	mockDB1, _, _ := sqlmock.New()
+	mockHealthyDB := sqlx.NewDb(mockDB1, "healthy")
+	mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	sqlMock2.ExpectPing().WillReturnError(errors.New("connection refused"))
+	sqlMock2.ExpectPing()
+	mockRecoveringDB := sqlx.NewDb(mockDB2, "recovering")
+	h := &healthState{}
+	dbs := []*sqlx.DB{mockHealthyDB, mockRecoveringDB}
+
+	checkHealth(context.Background(), h, dbs, time.Second, nil)
+
+	assert.True(t, h.isHealthy(mockHealthyDB))
+	assert.False(t, h.isHealthy(mockRecoveringDB))
+
+	checkHealth(context.Background(), h, dbs, time.Second, nil)
+
+	assert.True(t, h.isHealthy(mockHealthyDB))
+	assert.True(t, h.isHealthy(mockRecoveringDB))
+	assert.NoError(t, sqlMock2.ExpectationsWereMet())
+}
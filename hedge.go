@@ -0,0 +1,126 @@
+package dbresolver
+
+import (
+	"context"
+	"reflect"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// hedgeResult is the result of a hedged read.
+type hedgeResult struct {
+	db   *sqlx.DB
+	dest reflect.Value
+	err  error
+}
+
+// hedges reports whether the reads using the given context are hedged.
+func (r *dbResolver) hedges(ctx context.Context) bool {
+	if _, ok := stickyReadFromContext(ctx); ok {
+		return false
+	}
+	if r.readPreference == PreferPrimary || r.readPreference == PrimaryOnly {
+		return false
+	}
+	return r.hedgeDelay > 0 && !withoutHedgingFromContext(ctx) && !forcePrimaryFromContext(ctx)
+}
+
+// readHedged reads like read, but a read with a readable database is hedged:
+// if fn does not finish within the hedge delay or fails with a connection error,
+// it chooses another readable database and runs fn with it and a fresh destination as well.
+// The result of the first one finishing without a connection error is used, and the other one is cancelled.
+// If both of them fail with a connection error, it retries and falls back to a primary database like read.
+// It returns the database whose result is used.
+func (r *dbResolver) readHedged(
+	ctx context.Context,
+	req request,
+	dest interface{},
+	fn func(ctx context.Context, db *sqlx.DB, dest interface{}) error,
+) (*sqlx.DB, error) {
+	var served *sqlx.DB
+	read := func(db *sqlx.DB) error {
+		if err := fn(ctx, db, dest); err != nil {
+			return err
+		}
+		served = db
+		return nil
+	}
+	destValue := reflect.ValueOf(dest)
+	if destValue.Kind() != reflect.Ptr || destValue.IsNil() {
+		err := r.read(ctx, req, read)
+		return served, err
+	}
+
+	err := r.readWith(ctx, req, read, func(candidates []*sqlx.DB, db *sqlx.DB) ([]*sqlx.DB, error) {
+		if len(candidates) < 2 {
+			return []*sqlx.DB{db}, r.run(ctx, req, RoleRead, db, read)
+		}
+		tried, hedgeServed, err := r.runHedged(ctx, req, destValue, fn, candidates, db)
+		if err == nil {
+			served = hedgeServed
+		}
+		return tried, err
+	})
+	return served, err
+}
+
+// runHedged runs fn with the given database, and with another one of the candidates
+// if it does not finish within the hedge delay or fails with a connection error.
+// It returns the databases which it tried and the database whose result is copied into the destination.
+func (r *dbResolver) runHedged(
+	ctx context.Context,
+	req request,
+	destValue reflect.Value,
+	fn func(ctx context.Context, db *sqlx.DB, dest interface{}) error,
+	candidates []*sqlx.DB,
+	db *sqlx.DB,
+) ([]*sqlx.DB, *sqlx.DB, error) {
+	hedgeCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	results := make(chan hedgeResult, 2)
+	attempt := func(db *sqlx.DB) {
+		fresh := reflect.New(destValue.Type().Elem())
+		err := r.run(hedgeCtx, req, RoleRead, db, func(db *sqlx.DB) error {
+			return fn(hedgeCtx, db, fresh.Interface())
+		})
+		results <- hedgeResult{db: db, dest: fresh.Elem(), err: err}
+	}
+
+	tried := []*sqlx.DB{db}
+	go attempt(db)
+	pending := 1
+	hedge := func() {
+		hedged := r.selectRead(ctx, excludeDB(candidates, db))
+		tried = append(tried, hedged)
+		pending++
+		go attempt(hedged)
+	}
+
+	timer := time.NewTimer(r.hedgeDelay)
+	defer timer.Stop()
+	var err error
+	for pending > 0 {
+		select {
+		case <-timer.C:
+			if len(tried) == 1 {
+				hedge()
+			}
+		case result := <-results:
+			pending--
+			err = result.err
+			if !r.connErrClassifier.isConnectionError(err) {
+				if err != nil {
+					return tried, nil, err
+				}
+				copyCoalescedValue(destValue.Elem(), result.dest)
+				return tried, result.db, nil
+			}
+			// A connection error is not the result of the read, so wait for the other one or hedge right away.
+			if len(tried) == 1 {
+				hedge()
+			}
+		}
+	}
+	return tried, nil, err
+}
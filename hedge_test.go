@@ -0,0 +1,186 @@
+package dbresolver
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithHedgedReads(t *testing.T) {
+	newResolver := func(t *testing.T, delay time.Duration) (DBResolver, []sqlmock.Sqlmock) {
+		mockDB, _, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		assert.NoError(t, err)
+		mockPrimaryDB := sqlx.NewDb(mockDB, "primary")
+		secondaries := make([]*sqlx.DB, 2)
+		sqlMocks := make([]sqlmock.Sqlmock, 2)
+		for i := range secondaries {
+			mockDB, sqlMock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			assert.NoError(t, err)
+			secondaries[i] = sqlx.NewDb(mockDB, "secondary")
+			sqlMocks[i] = sqlMock
+		}
+		resolver := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB}, ReadWritePolicy: WriteOnly},
+			WithSecondaryDBs(secondaries...),
+			WithLoadBalancer(&firstLoadBalancer{}),
+			WithHedgedReads(delay),
+		)
+		return resolver, sqlMocks
+	}
+
+	t.Run("hedge slow read", func(t *testing.T) {
+		resolver, sqlMocks := newResolver(t, 10*time.Millisecond)
+		sqlMocks[0].ExpectQuery(`SELECT first_name FROM person`).
+			WillDelayFor(time.Second).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("slow"))
+		sqlMocks[1].ExpectQuery(`SELECT first_name FROM person`).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("fast"))
+
+		start := time.Now()
+		var firstNames []string
+		err := resolver.SelectContext(context.Background(), &firstNames, `SELECT first_name FROM person`)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"fast"}, firstNames)
+		assert.Less(t, time.Since(start), time.Second)
+		assert.NoError(t, sqlMocks[1].ExpectationsWereMet())
+	})
+
+	t.Run("fast read is not hedged", func(t *testing.T) {
+		resolver, sqlMocks := newResolver(t, time.Second)
+		sqlMocks[0].ExpectQuery(`SELECT first_name FROM person`).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("foo"))
+
+		var firstName string
+		err := resolver.GetContext(context.Background(), &firstName, `SELECT first_name FROM person`)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "foo", firstName)
+		assert.NoError(t, sqlMocks[0].ExpectationsWereMet())
+		assert.NoError(t, sqlMocks[1].ExpectationsWereMet())
+	})
+
+	t.Run("without hedging", func(t *testing.T) {
+		resolver, sqlMocks := newResolver(t, 10*time.Millisecond)
+		sqlMocks[0].ExpectQuery(`SELECT first_name FROM person`).
+			WillDelayFor(50 * time.Millisecond).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("foo"))
+
+		var firstName string
+		ctx := WithoutHedging(context.Background())
+		err := resolver.GetContext(ctx, &firstName, `SELECT first_name FROM person`)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "foo", firstName)
+		assert.NoError(t, sqlMocks[0].ExpectationsWereMet())
+		assert.NoError(t, sqlMocks[1].ExpectationsWereMet())
+	})
+	t.Run("enforce read only", func(t *testing.T) {
+		mockDB, _, err := sqlmock.New()
+		assert.NoError(t, err)
+		secondaries := newMockDBs(t, 2)
+		resolver := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{sqlx.NewDb(mockDB, "primary")}, ReadWritePolicy: WriteOnly},
+			WithSecondaryDBs(secondaries...),
+			WithHedgedReads(10*time.Millisecond),
+			WithReadOnlyEnforcement(),
+		)
+
+		var id int
+		err = resolver.GetContext(context.Background(), &id, `DELETE FROM person RETURNING id`)
+
+		assert.ErrorIs(t, err, errWriteOnReadReplica)
+	})
+
+	t.Run("retry after hedged reads fail", func(t *testing.T) {
+		connErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+		mockDB, primaryMock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		assert.NoError(t, err)
+		secondaries := make([]*sqlx.DB, 3)
+		sqlMocks := make([]sqlmock.Sqlmock, 3)
+		for i := range secondaries {
+			mockDB, sqlMock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			assert.NoError(t, err)
+			secondaries[i] = sqlx.NewDb(mockDB, "secondary")
+			sqlMocks[i] = sqlMock
+		}
+		sqlMocks[0].ExpectQuery(`SELECT first_name FROM person`).
+			WillReturnError(connErr)
+		sqlMocks[1].ExpectQuery(`SELECT first_name FROM person`).
+			WillReturnError(connErr)
+		sqlMocks[2].ExpectQuery(`SELECT first_name FROM person`).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("foo"))
+		resolver := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{sqlx.NewDb(mockDB, "primary")}, ReadWritePolicy: WriteOnly},
+			WithSecondaryDBs(secondaries...),
+			WithLoadBalancer(&firstLoadBalancer{}),
+			WithHedgedReads(time.Second),
+			WithReadRetries(1),
+		)
+
+		var firstName string
+		err = resolver.GetContext(context.Background(), &firstName, `SELECT first_name FROM person`)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "foo", firstName)
+		for _, sqlMock := range sqlMocks {
+			assert.NoError(t, sqlMock.ExpectationsWereMet())
+		}
+		assert.NoError(t, primaryMock.ExpectationsWereMet())
+	})
+
+	t.Run("report database whose result is used", func(t *testing.T) {
+		mockDB, _, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		assert.NoError(t, err)
+		mockPrimaryDB := sqlx.NewDb(mockDB, "primary")
+		mockDB1, sqlMock1, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		assert.NoError(t, err)
+		sqlMock1.ExpectQuery(`SELECT first_name FROM person`).
+			WillDelayFor(100 * time.Millisecond).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("first"))
+		mockSecondaryDB1 := sqlx.NewDb(mockDB1, "secondary")
+		mockDB2, sqlMock2, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		assert.NoError(t, err)
+		sqlMock2.ExpectQuery(`SELECT first_name FROM person`).
+			WillDelayFor(100 * time.Millisecond).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("hedged"))
+		mockSecondaryDB2 := sqlx.NewDb(mockDB2, "secondary")
+		// The first read finishes first, but its result is sent after the hedged one.
+		resolver := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB}, ReadWritePolicy: WriteOnly},
+			WithSecondaryDBs(mockSecondaryDB1, mockSecondaryDB2),
+			WithLoadBalancer(&firstLoadBalancer{}),
+			WithHedgedReads(50*time.Millisecond),
+			WithObserver(&delayingObserver{db: "secondary[0]", delay: 300 * time.Millisecond}),
+		)
+
+		var firstName string
+		served, err := resolver.GetFrom(context.Background(), &firstName, `SELECT first_name FROM person`)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "hedged", firstName)
+		assert.Same(t, mockSecondaryDB2, served)
+	})
+}
+
+// delayingObserver delays AfterQuery of the given database.
+type delayingObserver struct {
+	db    string
+	delay time.Duration
+}
+
+func (o *delayingObserver) BeforeQuery(ctx context.Context, _ QueryInfo) context.Context {
+	return ctx
+}
+
+func (o *delayingObserver) AfterQuery(_ context.Context, info QueryInfo, _ error) {
+	if info.DB == o.db {
+		time.Sleep(o.delay)
+	}
+}
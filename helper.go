@@ -1,8 +1,15 @@
 package dbresolver
 
 import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
 	"net"
+	"strings"
+	"sync"
 
+	"github.com/hashicorp/go-multierror"
+	"github.com/jmoiron/sqlx"
 	"github.com/pkg/errors"
 )
 
@@ -15,3 +22,215 @@ func isDBConnectionError(err error) bool {
 	var opErr *net.OpError
 	return errors.As(err, &opErr)
 }
+
+// connErrorClassifier reports whether the error of a query is a connection error, which makes the query
+// retried with the other databases or a read fall back to a primary database.
+// nil classifies the errors by isDBConnectionError.
+type connErrorClassifier func(err error) bool
+
+func (c connErrorClassifier) isConnectionError(err error) bool {
+	if err == nil {
+		return false
+	}
+	// The classifier classifies the original error of the database.
+	var qErr *QueryError
+	if errors.As(err, &qErr) {
+		err = qErr.Err
+	}
+	if c == nil {
+		return isDBConnectionError(err)
+	}
+	return c(err)
+}
+
+// setConnLimits sets the maximum numbers of the open and the idle connections of the given databases.
+// Zero leaves the number as is.
+func setConnLimits(dbs []*sqlx.DB, maxOpenConns, maxIdleConns int) {
+	for _, db := range dbs {
+		if maxOpenConns != 0 {
+			db.SetMaxOpenConns(maxOpenConns)
+		}
+		if maxIdleConns != 0 {
+			db.SetMaxIdleConns(maxIdleConns)
+		}
+	}
+}
+
+// dbStats returns the statistics of the given databases in order.
+func dbStats(dbs []*sqlx.DB) []sql.DBStats {
+	stats := make([]sql.DBStats, 0, len(dbs))
+	for _, db := range dbs {
+		stats = append(stats, db.Stats())
+	}
+	return stats
+}
+
+// fanOut runs fn with each given database concurrently and waits for all of them.
+// At most concurrency fn run at once, and zero or a negative number means no limit.
+func fanOut(dbs []*sqlx.DB, concurrency int, fn func(db *sqlx.DB)) {
+	if concurrency <= 0 || concurrency > len(dbs) {
+		concurrency = len(dbs)
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+	for _, db := range dbs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(db *sqlx.DB) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			fn(db)
+		}(db)
+	}
+	wg.Wait()
+}
+
+// checkDrivers checks the drivers of the given databases are consistent, i.e. they have the same bindvar type,
+// because the queries are rebound and the named queries are bound by the driver of any of the databases.
+// The drivers sharing the bindvar type like "postgres" and "pgx" are consistent.
+func checkDrivers(dbs []*sqlx.DB) error {
+	for _, db := range dbs[1:] {
+		if sqlx.BindType(db.DriverName()) != sqlx.BindType(dbs[0].DriverName()) {
+			return errors.Wrapf(errInconsistentDrivers, "%q and %q", dbs[0].DriverName(), db.DriverName())
+		}
+	}
+	return nil
+}
+
+// pingAll sends a ping to the all given databases concurrently and returns the errors of the pings.
+// At most concurrency pings are in flight at once, and zero or a negative number means no limit.
+// Once the context is done, the remaining databases are not pinged and the error of the context is returned as well.
+func pingAll(ctx context.Context, dbs []*sqlx.DB, concurrency int) error {
+	if concurrency <= 0 || concurrency > len(dbs) {
+		concurrency = len(dbs)
+	}
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs error
+	)
+	sem := make(chan struct{}, concurrency)
+	for _, db := range dbs {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if err := ctx.Err(); err != nil {
+			wg.Wait()
+			return multierror.Append(errs, err)
+		}
+
+		wg.Add(1)
+		go func(db *sqlx.DB) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			if err := db.PingContext(ctx); err != nil {
+				mu.Lock()
+				errs = multierror.Append(errs, err)
+				mu.Unlock()
+			}
+		}(db)
+	}
+	wg.Wait()
+	return errs
+}
+
+// readAll runs the query with the given connection and reads all the rows.
+func readAll(ctx context.Context, conn *sqlx.Conn, query string, args ...interface{}) (ReadResult, error) {
+	rows, err := conn.QueryxContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result ReadResult
+	for rows.Next() {
+		row := make(map[string]interface{})
+		if err := rows.MapScan(row); err != nil {
+			return nil, err
+		}
+		result = append(result, row)
+	}
+	return result, rows.Err()
+}
+
+// errConnector is a connector which always fails to connect with the error.
+type errConnector struct {
+	err error
+}
+
+func (c errConnector) Connect(_ context.Context) (driver.Conn, error) {
+	return nil, c.err
+}
+
+func (c errConnector) Driver() driver.Driver {
+	return c
+}
+
+func (c errConnector) Open(_ string) (driver.Conn, error) {
+	return nil, c.err
+}
+
+// errRow returns a *sql.Row whose Err returns the given error.
+func errRow(err error) *sql.Row {
+	db := sql.OpenDB(errConnector{err: err})
+	defer db.Close()
+
+	return db.QueryRow("")
+}
+
+// errRowx returns an *sqlx.Row whose Err returns the given error.
+func errRowx(err error) *sqlx.Row {
+	db := sqlx.NewDb(sql.OpenDB(errConnector{err: err}), "")
+	defer db.Close()
+
+	return db.QueryRowx("")
+}
+
+// quoteIdentifier quotes the identifier in the dialect of the given driver.
+// MySQL uses backticks, SQL Server uses brackets and the others use double quotes as the SQL standard.
+func quoteIdentifier(driverName, name string) string {
+	switch driverName {
+	case "mysql", "nrmysql":
+		return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+	case "sqlserver", "mssql", "azuresql":
+		return "[" + strings.ReplaceAll(name, "]", "]]") + "]"
+	default:
+		return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+	}
+}
+
+// unsupportedBindArgError is the error of binding an argument whose type is not supported by sqlx.
+// It is errUnsupportedBindArg and wraps the error of sqlx.
+type unsupportedBindArgError struct {
+	err error
+}
+
+func (e *unsupportedBindArgError) Error() string {
+	return errUnsupportedBindArg.Error() + ": " + e.err.Error()
+}
+
+func (e *unsupportedBindArgError) Is(target error) bool {
+	return target == errUnsupportedBindArg
+}
+
+func (e *unsupportedBindArgError) Unwrap() error {
+	return e.err
+}
+
+// wrapBindError wraps the error of sqlx binding the named arguments into unsupportedBindArgError
+// if the argument type is not supported. sqlx does not have a typed error for it, so the message is checked.
+func wrapBindError(err error) error {
+	if err != nil && strings.Contains(err.Error(), "unsupported map type") {
+		return &unsupportedBindArgError{err: err}
+	}
+	return err
+}
@@ -0,0 +1,84 @@
+package dbresolver
+
+import (
+	"math/bits"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// latencySubBucketBits is the number of the significant bits kept by a latency bucket.
+	// Each power of two range is split into 2^latencySubBucketBits linear sub buckets,
+	// so the relative error of a recorded latency is at most 1/16.
+	latencySubBucketBits  = 4
+	latencySubBucketCount = 1 << latencySubBucketBits
+	latencyBucketCount    = (64 - latencySubBucketBits + 1) * latencySubBucketCount
+)
+
+// latencyHistogram is an HDR-style histogram of latencies in microseconds.
+// It is safe for concurrent use.
+type latencyHistogram struct {
+	counts [latencyBucketCount]uint64
+	total  uint64
+}
+
+func newLatencyHistograms() map[string]*latencyHistogram {
+	return map[string]*latencyHistogram{
+		RolePrimary: {},
+		RoleRead:    {},
+	}
+}
+
+// record records the given latency.
+func (h *latencyHistogram) record(d time.Duration) {
+	v := uint64(0)
+	if d > 0 {
+		v = uint64(d / time.Microsecond)
+	}
+	atomic.AddUint64(&h.counts[latencyBucketIndex(v)], 1)
+	atomic.AddUint64(&h.total, 1)
+}
+
+// percentile returns the highest latency which falls into the same bucket as the given quantile.
+func (h *latencyHistogram) percentile(q float64) time.Duration {
+	total := atomic.LoadUint64(&h.total)
+	if total == 0 {
+		return 0
+	}
+
+	rank := uint64(q * float64(total))
+	if float64(rank) < q*float64(total) {
+		rank++
+	}
+	if rank == 0 {
+		rank = 1
+	}
+
+	var cumulative uint64
+	for i := range h.counts {
+		cumulative += atomic.LoadUint64(&h.counts[i])
+		if cumulative >= rank {
+			return time.Duration(latencyBucketHighest(i)) * time.Microsecond
+		}
+	}
+	return time.Duration(latencyBucketHighest(latencyBucketCount-1)) * time.Microsecond
+}
+
+// latencyBucketIndex returns the index of the bucket for the given value.
+func latencyBucketIndex(v uint64) int {
+	if v < latencySubBucketCount {
+		return int(v)
+	}
+	shift := bits.Len64(v) - latencySubBucketBits - 1
+	return (shift+1)*latencySubBucketCount + int(v>>uint(shift)) - latencySubBucketCount
+}
+
+// latencyBucketHighest returns the highest value of the bucket of the given index.
+func latencyBucketHighest(i int) uint64 {
+	if i < latencySubBucketCount {
+		return uint64(i)
+	}
+	shift := i/latencySubBucketCount - 1
+	lowest := uint64(i%latencySubBucketCount+latencySubBucketCount) << uint(shift)
+	return lowest + (1 << uint(shift)) - 1
+}
@@ -0,0 +1,119 @@
+package dbresolver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+// steppingClock is a fake clock which advances by the next step whenever Now is called.
+type steppingClock struct {
+	now   time.Time
+	steps []time.Duration
+}
+
+func (c *steppingClock) Now() time.Time {
+	if len(c.steps) > 0 {
+		c.now = c.now.Add(c.steps[0])
+		c.steps = c.steps[1:]
+	}
+	return c.now
+}
+
+func TestLatencyHistogram_Percentile(t *testing.T) {
+	t.Run("empty histogram", func(t *testing.T) {
+		h := &latencyHistogram{}
+
+		assert.Equal(t, time.Duration(0), h.percentile(0.5))
+	})
+
+	t.Run("bucket boundaries", func(t *testing.T) {
+		for _, v := range []uint64{0, 1, 15, 16, 17, 31, 32, 1000, 1 << 40, 1<<64 - 1} {
+			i := latencyBucketIndex(v)
+
+			assert.Less(t, i, latencyBucketCount)
+			assert.GreaterOrEqual(t, latencyBucketHighest(i), v)
+			if v >= latencySubBucketCount {
+				assert.InEpsilon(t, float64(v), float64(latencyBucketHighest(i)), 1.0/latencySubBucketCount)
+			}
+		}
+	})
+
+	t.Run("known latencies", func(t *testing.T) {
+		h := &latencyHistogram{}
+		for i := 1; i <= 100; i++ {
+			h.record(time.Duration(i) * time.Millisecond)
+		}
+
+		assert.InEpsilon(t, float64(50*time.Millisecond), float64(h.percentile(0.5)), 1.0/latencySubBucketCount)
+		assert.InEpsilon(t, float64(95*time.Millisecond), float64(h.percentile(0.95)), 1.0/latencySubBucketCount)
+		assert.InEpsilon(t, float64(99*time.Millisecond), float64(h.percentile(0.99)), 1.0/latencySubBucketCount)
+	})
+}
+
+func TestDBResolver_LatencyPercentiles(t *testing.T) {
+	t.Run("latency histogram disabled", func(t *testing.T) {
+		mockDB, _, _ := sqlmock.New()
+		primaryDBsCfg := &PrimaryDBsConfig{
+			DBs: []*sqlx.DB{sqlx.NewDb(mockDB, "primary")},
+		}
+		r, err := NewDBResolver(primaryDBsCfg)
+		assert.NoError(t, err)
+
+		p50, p95, p99 := r.LatencyPercentiles(RolePrimary)
+
+		assert.Zero(t, p50)
+		assert.Zero(t, p95)
+		assert.Zero(t, p99)
+	})
+
+	t.Run("records latencies per role", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		mockPrimaryDB := sqlx.NewDb(mockDB1, "primary")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		mockSecondaryDB := sqlx.NewDb(mockDB2, "secondary")
+		primaryDBsCfg := &PrimaryDBsConfig{
+			DBs:             []*sqlx.DB{mockPrimaryDB},
+			ReadWritePolicy: WriteOnly,
+		}
+		resolver, err := NewDBResolver(
+			primaryDBsCfg,
+			WithSecondaryDBs(mockSecondaryDB),
+			WithLatencyHistogram(),
+		)
+		assert.NoError(t, err)
+		clock := &steppingClock{now: time.Now()}
+		r := resolver.(*dbResolver)
+		r.now = clock.Now
+
+		for i := 1; i <= 100; i++ {
+			sqlMock1.ExpectExec(`DELETE FROM person`).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			clock.steps = []time.Duration{0, time.Duration(i) * time.Millisecond}
+			_, err = r.Exec(`DELETE FROM person`)
+			assert.NoError(t, err)
+		}
+		sqlMock2.ExpectQuery(`SELECT * FROM person`).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("foo"))
+		clock.steps = []time.Duration{0, 3 * time.Second}
+		rows, err := r.Query(`SELECT * FROM person`)
+		assert.NoError(t, err)
+		assert.NoError(t, rows.Close())
+
+		p50, p95, p99 := r.LatencyPercentiles(RolePrimary)
+		assert.InEpsilon(t, float64(50*time.Millisecond), float64(p50), 1.0/latencySubBucketCount)
+		assert.InEpsilon(t, float64(95*time.Millisecond), float64(p95), 1.0/latencySubBucketCount)
+		assert.InEpsilon(t, float64(99*time.Millisecond), float64(p99), 1.0/latencySubBucketCount)
+		p50, p95, p99 = r.LatencyPercentiles(RoleRead)
+		assert.InEpsilon(t, float64(3*time.Second), float64(p50), 1.0/latencySubBucketCount)
+		assert.InEpsilon(t, float64(3*time.Second), float64(p95), 1.0/latencySubBucketCount)
+		assert.InEpsilon(t, float64(3*time.Second), float64(p99), 1.0/latencySubBucketCount)
+		p50, p95, p99 = r.LatencyPercentiles("unknown")
+		assert.Zero(t, p50)
+		assert.Zero(t, p95)
+		assert.Zero(t, p99)
+	})
+}
@@ -3,25 +3,115 @@ package dbresolver
 import (
 	"context"
 	"math/rand"
+	"sync/atomic"
 
 	"github.com/jmoiron/sqlx"
 )
 
 // LoadBalancer chooses a database from the given databases.
+// The resolver does not call it if there is only one database to choose.
 type LoadBalancer interface {
 	// Select returns the database to use for the given operation.
 	Select(ctx context.Context, dbs []*sqlx.DB) *sqlx.DB
 }
 
+// FixedLoadBalancer is a load balancer that always chooses the given database if it is one of the given databases.
+// Otherwise, it chooses the first of the given databases, so it never chooses a database of the other role.
+// It is useful with WithLoadBalancerInstance to run a query on a specific database.
+type FixedLoadBalancer struct {
+	db *sqlx.DB
+}
+
+var _ LoadBalancer = (*FixedLoadBalancer)(nil)
+
+func NewFixedLoadBalancer(db *sqlx.DB) *FixedLoadBalancer {
+	return &FixedLoadBalancer{
+		db: db,
+	}
+}
+
+// Select returns the database to use for the given operation.
+// If there are no databases, it returns nil. but it should not happen.
+func (b *FixedLoadBalancer) Select(_ context.Context, dbs []*sqlx.DB) *sqlx.DB {
+	if len(dbs) == 0 {
+		return nil
+	}
+	if containsDB(dbs, b.db) {
+		return b.db
+	}
+	return dbs[0]
+}
+
+// LeastConnectionsLoadBalancer is a load balancer that chooses the database with the fewest connections in use.
+// If several databases have the fewest connections in use, it chooses one of them randomly.
+// It calls Stats of every given database on each Select, which takes the lock of each connection pool.
+type LeastConnectionsLoadBalancer struct {
+	random *rand.Rand
+}
+
+var (
+	_ LoadBalancer = (*LeastConnectionsLoadBalancer)(nil)
+	_ randomized   = (*LeastConnectionsLoadBalancer)(nil)
+)
+
+func NewLeastConnectionsLoadBalancer() *LeastConnectionsLoadBalancer {
+	return &LeastConnectionsLoadBalancer{}
+}
+
+// Select returns the database to use for the given operation.
+// If there are no databases, it returns nil. but it should not happen.
+func (b *LeastConnectionsLoadBalancer) Select(_ context.Context, dbs []*sqlx.DB) *sqlx.DB {
+	n := len(dbs)
+	if n == 0 {
+		return nil
+	}
+	if n == 1 {
+		return dbs[0]
+	}
+
+	var selected *sqlx.DB
+	minInUse, ties := 0, 0
+	for _, db := range dbs {
+		inUse := db.Stats().InUse
+		switch {
+		case selected == nil || inUse < minInUse:
+			selected, minInUse, ties = db, inUse, 1
+		case inUse == minInUse:
+			// Choose one of the ties uniformly by reservoir sampling.
+			ties++
+			if randIntn(b.random, ties) == 0 {
+				selected = db
+			}
+		}
+	}
+	return selected
+}
+
+func (b *LeastConnectionsLoadBalancer) setRandom(random *rand.Rand) {
+	b.random = random
+}
+
 // RandomLoadBalancer is a load balancer that chooses a database randomly.
-type RandomLoadBalancer struct{}
+type RandomLoadBalancer struct {
+	random *rand.Rand
+}
 
-var _ LoadBalancer = (*RandomLoadBalancer)(nil)
+var (
+	_ LoadBalancer = (*RandomLoadBalancer)(nil)
+	_ randomized   = (*RandomLoadBalancer)(nil)
+)
 
 func NewRandomLoadBalancer() *RandomLoadBalancer {
 	return &RandomLoadBalancer{}
 }
 
+// NewRandomLoadBalancerWithSource creates a RandomLoadBalancer choosing the databases with the given source,
+// so that the balancers with the sources of the same seed make the same choices.
+// The source is guarded by a mutex. It is replaced by the source of WithRandSource if the option is given.
+func NewRandomLoadBalancerWithSource(src rand.Source) *RandomLoadBalancer {
+	return &RandomLoadBalancer{random: rand.New(&lockedSource{src: src})}
+}
+
 // Select returns the database to use for the given operation.
 // If there are no databases, it returns nil. but it should not happen.
 func (b *RandomLoadBalancer) Select(_ context.Context, dbs []*sqlx.DB) *sqlx.DB {
@@ -32,7 +122,282 @@ func (b *RandomLoadBalancer) Select(_ context.Context, dbs []*sqlx.DB) *sqlx.DB
 	if n == 1 {
 		return dbs[0]
 	}
-	return dbs[rand.Intn(n)]
+	return dbs[randIntn(b.random, n)]
+}
+
+func (b *RandomLoadBalancer) setRandom(random *rand.Rand) {
+	b.random = random
+}
+
+// RegionAwareLoadBalancer is a load balancer that chooses a database randomly
+// among the databases in the region of the caller given by WithRegion.
+// If the context has no region or no database is in the region, it chooses a database randomly.
+// If it is created without the regions, the regions of WithSecondaryDBConfigs are used instead.
+type RegionAwareLoadBalancer struct {
+	regions map[*sqlx.DB]string
+	random  *rand.Rand
+}
+
+var (
+	_ LoadBalancer = (*RegionAwareLoadBalancer)(nil)
+	_ randomized   = (*RegionAwareLoadBalancer)(nil)
+	_ dbRegioned   = (*RegionAwareLoadBalancer)(nil)
+)
+
+func NewRegionAwareLoadBalancer(regions map[*sqlx.DB]string) *RegionAwareLoadBalancer {
+	return &RegionAwareLoadBalancer{
+		regions: regions,
+	}
+}
+
+// Select returns the database to use for the given operation.
+// If there are no databases, it returns nil. but it should not happen.
+func (b *RegionAwareLoadBalancer) Select(ctx context.Context, dbs []*sqlx.DB) *sqlx.DB {
+	n := len(dbs)
+	if n == 0 {
+		return nil
+	}
+
+	if region, ok := RegionFromContext(ctx); ok {
+		local := make([]*sqlx.DB, 0, n)
+		for _, db := range dbs {
+			if b.regions[db] == region {
+				local = append(local, db)
+			}
+		}
+		if len(local) > 0 {
+			dbs = local
+			n = len(local)
+		}
+	}
+	if n == 1 {
+		return dbs[0]
+	}
+	return dbs[randIntn(b.random, n)]
+}
+
+func (b *RegionAwareLoadBalancer) setRandom(random *rand.Rand) {
+	b.random = random
+}
+
+func (b *RegionAwareLoadBalancer) setDBRegions(regions map[*sqlx.DB]string) {
+	if len(b.regions) == 0 {
+		b.regions = regions
+	}
+}
+
+// RoundRobinLoadBalancer is a load balancer that chooses a database in turn.
+type RoundRobinLoadBalancer struct {
+	counter uint64
+}
+
+var _ LoadBalancer = (*RoundRobinLoadBalancer)(nil)
+
+func NewRoundRobinLoadBalancer() *RoundRobinLoadBalancer {
+	return &RoundRobinLoadBalancer{}
+}
+
+// Select returns the database to use for the given operation.
+// If there are no databases, it returns nil. but it should not happen.
+func (b *RoundRobinLoadBalancer) Select(_ context.Context, dbs []*sqlx.DB) *sqlx.DB {
+	n := len(dbs)
+	if n == 0 {
+		return nil
+	}
+	if n == 1 {
+		return dbs[0]
+	}
+	i := atomic.AddUint64(&b.counter, 1) - 1
+	return dbs[i%uint64(n)]
+}
+
+// SaturationAwareLoadBalancer is a load balancer that chooses a database randomly
+// among the databases whose connection pool is not saturated over the threshold.
+// The saturation of a database is the ratio of the connections in use to the maximum open connections.
+// A database without the maximum open connections is never saturated.
+// If all the databases are saturated over the threshold, it chooses the least saturated database.
+type SaturationAwareLoadBalancer struct {
+	threshold float64
+	random    *rand.Rand
+}
+
+var (
+	_ LoadBalancer = (*SaturationAwareLoadBalancer)(nil)
+	_ randomized   = (*SaturationAwareLoadBalancer)(nil)
+)
+
+func NewSaturationAwareLoadBalancer(threshold float64) *SaturationAwareLoadBalancer {
+	return &SaturationAwareLoadBalancer{
+		threshold: threshold,
+	}
+}
+
+// Select returns the database to use for the given operation.
+// If there are no databases, it returns nil. but it should not happen.
+func (b *SaturationAwareLoadBalancer) Select(_ context.Context, dbs []*sqlx.DB) *sqlx.DB {
+	n := len(dbs)
+	if n == 0 {
+		return nil
+	}
+	if n == 1 {
+		return dbs[0]
+	}
+
+	available := make([]*sqlx.DB, 0, n)
+	var leastSaturated *sqlx.DB
+	minSaturation := 0.0
+	for _, db := range dbs {
+		saturation := poolSaturation(db)
+		if saturation <= b.threshold {
+			available = append(available, db)
+		}
+		if leastSaturated == nil || saturation < minSaturation {
+			leastSaturated = db
+			minSaturation = saturation
+		}
+	}
+	if len(available) == 0 {
+		return leastSaturated
+	}
+	return available[randIntn(b.random, len(available))]
+}
+
+func (b *SaturationAwareLoadBalancer) setRandom(random *rand.Rand) {
+	b.random = random
+}
+
+// poolSaturation returns the ratio of the connections in use to the maximum open connections of the given database.
+func poolSaturation(db *sqlx.DB) float64 {
+	stats := db.Stats()
+	if stats.MaxOpenConnections <= 0 {
+		return 0
+	}
+	return float64(stats.InUse) / float64(stats.MaxOpenConnections)
+}
+
+// WeightedLoadBalancer is a load balancer that chooses a database
+// with probability proportional to its weight.
+// The weights are resolved by the identity of the databases, so they stay with the databases
+// even if some of them are filtered out, e.g. as unhealthy, before Select.
+// The positional weights of NewWeightedLoadBalancer are bound to the readable databases of the resolver
+// in the order of ReadDBs when the resolver is created with it.
+// A database whose weight is zero is never chosen unless all the weights are zero,
+// in which case a database is chosen uniformly.
+// A database without a weight is considered to have weight 1.
+// If it is created without the weights, the weights of WithSecondaryDBConfigs are used instead.
+type WeightedLoadBalancer struct {
+	weights []int
+	random  *rand.Rand
+	// dbWeights is the weights of the databases given by NewWeightedLoadBalancerWithDBWeights,
+	// bound from the positional weights or configured by WithSecondaryDBConfigs.
+	dbWeights map[*sqlx.DB]int
+}
+
+var (
+	_ LoadBalancer = (*WeightedLoadBalancer)(nil)
+	_ randomized   = (*WeightedLoadBalancer)(nil)
+	_ dbWeighted   = (*WeightedLoadBalancer)(nil)
+)
+
+// NewWeightedLoadBalancer creates a WeightedLoadBalancer with the positional weights,
+// which are bound to the readable databases of the resolver in the order of ReadDBs.
+// The databases beyond the weights are considered to have weight 1.
+func NewWeightedLoadBalancer(weights ...int) *WeightedLoadBalancer {
+	return &WeightedLoadBalancer{
+		weights: weights,
+	}
+}
+
+// NewWeightedLoadBalancerWithDBWeights creates a WeightedLoadBalancer with the weights of the given databases.
+// It is useful for the load balancer not given to the resolver, e.g. by WithLoadBalancerInstance.
+func NewWeightedLoadBalancerWithDBWeights(weights map[*sqlx.DB]int) *WeightedLoadBalancer {
+	dbWeights := make(map[*sqlx.DB]int, len(weights))
+	for db, w := range weights {
+		dbWeights[db] = nonNegative(w)
+	}
+	return &WeightedLoadBalancer{
+		dbWeights: dbWeights,
+	}
+}
+
+// Select returns the database to use for the given operation.
+// If there are no databases, it returns nil. but it should not happen.
+func (b *WeightedLoadBalancer) Select(_ context.Context, dbs []*sqlx.DB) *sqlx.DB {
+	n := len(dbs)
+	if n == 0 {
+		return nil
+	}
+	if n == 1 {
+		return dbs[0]
+	}
+
+	total := 0
+	for _, db := range dbs {
+		total += b.weight(db)
+	}
+	if total == 0 {
+		return dbs[randIntn(b.random, n)]
+	}
+
+	target := randIntn(b.random, total)
+	for _, db := range dbs {
+		target -= b.weight(db)
+		if target < 0 {
+			return db
+		}
+	}
+	return dbs[n-1]
+}
+
+func (b *WeightedLoadBalancer) setRandom(random *rand.Rand) {
+	b.random = random
+}
+
+func (b *WeightedLoadBalancer) bindDBs(dbs []*sqlx.DB) {
+	if len(b.weights) == 0 || b.dbWeights != nil {
+		return
+	}
+	b.dbWeights = make(map[*sqlx.DB]int, len(b.weights))
+	for i, db := range dbs {
+		if i >= len(b.weights) {
+			break
+		}
+		b.dbWeights[db] = nonNegative(b.weights[i])
+	}
+}
+
+func (b *WeightedLoadBalancer) setDBWeights(weights map[*sqlx.DB]int) {
+	if len(b.weights) == 0 && b.dbWeights == nil {
+		b.dbWeights = weights
+	}
+}
+
+func (b *WeightedLoadBalancer) weight(db *sqlx.DB) int {
+	if w, ok := b.dbWeights[db]; ok {
+		return w
+	}
+	return 1
+}
+
+func nonNegative(n int) int {
+	if n < 0 {
+		return 0
+	}
+	return n
+}
+
+// dbWeighted is implemented by the load balancers using the weights of the databases.
+// bindDBs binds the positional weights to the readable databases,
+// and setDBWeights sets the weights configured by WithSecondaryDBConfigs.
+type dbWeighted interface {
+	bindDBs(dbs []*sqlx.DB)
+	setDBWeights(weights map[*sqlx.DB]int)
+}
+
+// dbRegioned is implemented by the load balancers using the regions of the databases
+// configured by WithSecondaryDBConfigs.
+type dbRegioned interface {
+	setDBRegions(regions map[*sqlx.DB]string)
 }
 
 // injectedLoadBalancer is a load balancer that always chooses the given database.
@@ -46,3 +411,30 @@ var _ LoadBalancer = (*injectedLoadBalancer)(nil)
 func (b *injectedLoadBalancer) Select(_ context.Context, _ []*sqlx.DB) *sqlx.DB {
 	return b.db
 }
+
+// firstLoadBalancer is a load balancer that always chooses the first of the given databases.
+// It is used for testing.
+type firstLoadBalancer struct{}
+
+var _ LoadBalancer = (*firstLoadBalancer)(nil)
+
+func (b *firstLoadBalancer) Select(_ context.Context, dbs []*sqlx.DB) *sqlx.DB {
+	if len(dbs) == 0 {
+		return nil
+	}
+	return dbs[0]
+}
+
+// countingLoadBalancer is a load balancer that counts the calls of the given load balancer.
+// It is used for testing.
+type countingLoadBalancer struct {
+	LoadBalancer
+	calls int64
+}
+
+var _ LoadBalancer = (*countingLoadBalancer)(nil)
+
+func (b *countingLoadBalancer) Select(ctx context.Context, dbs []*sqlx.DB) *sqlx.DB {
+	atomic.AddInt64(&b.calls, 1)
+	return b.LoadBalancer.Select(ctx, dbs)
+}
@@ -2,6 +2,7 @@ package dbresolver
 
 import (
 	"context"
+	"math/rand"
 	"testing"
 
 	"github.com/DATA-DOG/go-sqlmock"
@@ -9,6 +10,99 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
+func TestFixedLoadBalancer_Select(t *testing.T) {
+	t.Run("no db given", func(t *testing.T) {
+		dbs := newMockDBs(t, 1)
+		r := NewFixedLoadBalancer(dbs[0])
+
+		assert.Nil(t, r.Select(context.Background(), nil))
+	})
+
+	t.Run("choose fixed db", func(t *testing.T) {
+		dbs := newMockDBs(t, 3)
+		r := NewFixedLoadBalancer(dbs[1])
+
+		for i := 0; i < 100; i++ {
+			assert.Same(t, dbs[1], r.Select(context.Background(), dbs))
+		}
+	})
+
+	t.Run("choose first db if fixed db not given", func(t *testing.T) {
+		dbs := newMockDBs(t, 3)
+		r := NewFixedLoadBalancer(dbs[2])
+
+		assert.Same(t, dbs[0], r.Select(context.Background(), dbs[:2]))
+	})
+}
+
+func TestLeastConnectionsLoadBalancer_Select(t *testing.T) {
+	// newBusyDB returns a database with the given connections in use.
+	newBusyDB := func(t *testing.T, inUse int) *sqlx.DB {
+		mockDB, _, err := sqlmock.New()
+		assert.NoError(t, err)
+		db := sqlx.NewDb(mockDB, "sqlmock")
+		for i := 0; i < inUse; i++ {
+			conn, err := db.Conn(context.Background())
+			assert.NoError(t, err)
+			t.Cleanup(func() { _ = conn.Close() })
+		}
+		return db
+	}
+
+	t.Run("no db given", func(t *testing.T) {
+		r := NewLeastConnectionsLoadBalancer()
+
+		assert.Nil(t, r.Select(context.Background(), nil))
+	})
+
+	t.Run("choose least loaded db", func(t *testing.T) {
+		busy1 := newBusyDB(t, 3)
+		leastLoaded := newBusyDB(t, 1)
+		busy2 := newBusyDB(t, 2)
+		input := []*sqlx.DB{busy1, leastLoaded, busy2}
+
+		r := NewLeastConnectionsLoadBalancer()
+
+		for i := 0; i < 100; i++ {
+			assert.Same(t, leastLoaded, r.Select(context.Background(), input))
+		}
+	})
+
+	t.Run("break ties randomly", func(t *testing.T) {
+		busy := newBusyDB(t, 2)
+		idle1 := newBusyDB(t, 0)
+		idle2 := newBusyDB(t, 0)
+		input := []*sqlx.DB{busy, idle1, idle2}
+
+		r := NewLeastConnectionsLoadBalancer()
+
+		counts := make(map[*sqlx.DB]int)
+		for i := 0; i < 1000; i++ {
+			counts[r.Select(context.Background(), input)]++
+		}
+		assert.Zero(t, counts[busy])
+		assert.InDelta(t, 500, counts[idle1], 100)
+		assert.InDelta(t, 500, counts[idle2], 100)
+	})
+}
+
+func TestNewRandomLoadBalancerWithSource(t *testing.T) {
+	input := newMockDBs(t, 5)
+	selections := func(b LoadBalancer) []*sqlx.DB {
+		result := make([]*sqlx.DB, 0, 100)
+		for i := 0; i < 100; i++ {
+			result = append(result, b.Select(context.Background(), input))
+		}
+		return result
+	}
+
+	first := selections(NewRandomLoadBalancerWithSource(rand.NewSource(42)))
+	second := selections(NewRandomLoadBalancerWithSource(rand.NewSource(42)))
+
+	assert.Equal(t, first, second)
+	assert.NotEqual(t, first, selections(NewRandomLoadBalancerWithSource(rand.NewSource(43))))
+}
+
 func TestRandomLoadBalancer_Apply(t *testing.T) {
 	t.Run("only one db given", func(t *testing.T) {
 		mockDB, _, err := sqlmock.New()
@@ -22,3 +116,282 @@ func TestRandomLoadBalancer_Apply(t *testing.T) {
 		assert.Equal(t, expectedDB, result)
 	})
 }
+
+func TestRegionAwareLoadBalancer_Select(t *testing.T) {
+	newDBs := func(n int) []*sqlx.DB {
+		dbs := make([]*sqlx.DB, n)
+		for i := range dbs {
+			mockDB, _, _ := sqlmock.New()
+			dbs[i] = sqlx.NewDb(mockDB, "sqlmock")
+		}
+		return dbs
+	}
+
+	t.Run("no db given", func(t *testing.T) {
+		b := NewRegionAwareLoadBalancer(nil)
+
+		assert.Nil(t, b.Select(WithRegion(context.Background(), "us-east-1"), nil))
+	})
+
+	t.Run("choose db in region", func(t *testing.T) {
+		dbs := newDBs(4)
+		b := NewRegionAwareLoadBalancer(map[*sqlx.DB]string{
+			dbs[0]: "us-east-1",
+			dbs[1]: "eu-west-1",
+			dbs[2]: "us-east-1",
+			dbs[3]: "ap-northeast-2",
+		})
+		ctx := WithRegion(context.Background(), "us-east-1")
+
+		counts := make(map[*sqlx.DB]int, len(dbs))
+		for i := 0; i < 1000; i++ {
+			counts[b.Select(ctx, dbs)]++
+		}
+
+		assert.Len(t, counts, 2)
+		assert.NotZero(t, counts[dbs[0]])
+		assert.NotZero(t, counts[dbs[2]])
+		assert.Same(t, dbs[3], b.Select(WithRegion(context.Background(), "ap-northeast-2"), dbs))
+	})
+
+	t.Run("fall back to random without db in region", func(t *testing.T) {
+		dbs := newDBs(2)
+		b := NewRegionAwareLoadBalancer(map[*sqlx.DB]string{
+			dbs[0]: "us-east-1",
+			dbs[1]: "eu-west-1",
+		})
+		b.setRandom(rand.New(rand.NewSource(1)))
+
+		for _, ctx := range []context.Context{
+			WithRegion(context.Background(), "ap-northeast-2"),
+			context.Background(),
+		} {
+			counts := make(map[*sqlx.DB]int, len(dbs))
+			for i := 0; i < 1000; i++ {
+				counts[b.Select(ctx, dbs)]++
+			}
+
+			assert.NotZero(t, counts[dbs[0]])
+			assert.NotZero(t, counts[dbs[1]])
+		}
+	})
+
+	t.Run("use regions of secondary db configs", func(t *testing.T) {
+		primaryDB, primaryMock, _ := sqlmock.New()
+		usDB, usMock, _ := sqlmock.New()
+		euDB, euMock, _ := sqlmock.New()
+		for i := 0; i < 10; i++ {
+			euMock.ExpectQuery(`SELECT 1`).
+				WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+		}
+		r := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{sqlx.NewDb(primaryDB, "sqlmock")}, ReadWritePolicy: WriteOnly},
+			WithSecondaryDBConfigs(
+				SecondaryDBConfig{DB: sqlx.NewDb(usDB, "sqlmock"), Region: "us-east-1"},
+				SecondaryDBConfig{DB: sqlx.NewDb(euDB, "sqlmock"), Region: "eu-west-1"},
+			),
+			WithLoadBalancer(NewRegionAwareLoadBalancer(nil)),
+		)
+
+		ctx := WithRegion(context.Background(), "eu-west-1")
+		for i := 0; i < 10; i++ {
+			var one int
+			assert.NoError(t, r.GetContext(ctx, &one, `SELECT 1`))
+		}
+
+		assert.NoError(t, primaryMock.ExpectationsWereMet())
+		assert.NoError(t, usMock.ExpectationsWereMet())
+		assert.NoError(t, euMock.ExpectationsWereMet())
+	})
+}
+
+func TestRoundRobinLoadBalancer_Select(t *testing.T) {
+	t.Run("no db given", func(t *testing.T) {
+		r := NewRoundRobinLoadBalancer()
+
+		assert.Nil(t, r.Select(context.Background(), nil))
+	})
+
+	t.Run("choose in turn", func(t *testing.T) {
+		mockDB1, _, _ := sqlmock.New()
+		db1 := sqlx.NewDb(mockDB1, "sqlmock")
+		mockDB2, _, _ := sqlmock.New()
+		db2 := sqlx.NewDb(mockDB2, "sqlmock")
+		input := []*sqlx.DB{db1, db2}
+
+		r := NewRoundRobinLoadBalancer()
+
+		assert.Equal(t, db1, r.Select(context.Background(), input))
+		assert.Equal(t, db2, r.Select(context.Background(), input))
+		assert.Equal(t, db1, r.Select(context.Background(), input))
+	})
+}
+
+func TestSaturationAwareLoadBalancer_Select(t *testing.T) {
+	// newSaturatedDB returns a database whose pool has the given connections in use out of 4.
+	newSaturatedDB := func(t *testing.T, inUse int) *sqlx.DB {
+		mockDB, _, err := sqlmock.New()
+		assert.NoError(t, err)
+		db := sqlx.NewDb(mockDB, "sqlmock")
+		db.SetMaxOpenConns(4)
+		for i := 0; i < inUse; i++ {
+			conn, err := db.Conn(context.Background())
+			assert.NoError(t, err)
+			t.Cleanup(func() { _ = conn.Close() })
+		}
+		return db
+	}
+
+	t.Run("no db given", func(t *testing.T) {
+		r := NewSaturationAwareLoadBalancer(0.5)
+
+		assert.Nil(t, r.Select(context.Background(), nil))
+	})
+
+	t.Run("skip saturated dbs", func(t *testing.T) {
+		saturated1 := newSaturatedDB(t, 3)
+		available := newSaturatedDB(t, 1)
+		saturated2 := newSaturatedDB(t, 4)
+		input := []*sqlx.DB{saturated1, available, saturated2}
+
+		r := NewSaturationAwareLoadBalancer(0.5)
+
+		for i := 0; i < 100; i++ {
+			assert.Same(t, available, r.Select(context.Background(), input))
+		}
+	})
+
+	t.Run("db without max open connections is never saturated", func(t *testing.T) {
+		saturated := newSaturatedDB(t, 4)
+		mockDB, _, _ := sqlmock.New()
+		unlimited := sqlx.NewDb(mockDB, "sqlmock")
+		input := []*sqlx.DB{saturated, unlimited}
+
+		r := NewSaturationAwareLoadBalancer(0.5)
+
+		for i := 0; i < 100; i++ {
+			assert.Same(t, unlimited, r.Select(context.Background(), input))
+		}
+	})
+
+	t.Run("least saturated db if all saturated", func(t *testing.T) {
+		saturated1 := newSaturatedDB(t, 4)
+		leastSaturated := newSaturatedDB(t, 3)
+		saturated2 := newSaturatedDB(t, 4)
+		input := []*sqlx.DB{saturated1, leastSaturated, saturated2}
+
+		r := NewSaturationAwareLoadBalancer(0.5)
+
+		assert.Same(t, leastSaturated, r.Select(context.Background(), input))
+	})
+}
+
+func TestWeightedLoadBalancer_Select(t *testing.T) {
+	const selections = 10000
+	newDBs := func(n int) []*sqlx.DB {
+		dbs := make([]*sqlx.DB, n)
+		for i := range dbs {
+			mockDB, _, _ := sqlmock.New()
+			dbs[i] = sqlx.NewDb(mockDB, "sqlmock")
+		}
+		return dbs
+	}
+	distribution := func(b LoadBalancer, dbs []*sqlx.DB) map[*sqlx.DB]int {
+		counts := make(map[*sqlx.DB]int, len(dbs))
+		for i := 0; i < selections; i++ {
+			counts[b.Select(context.Background(), dbs)]++
+		}
+		return counts
+	}
+
+	t.Run("no db given", func(t *testing.T) {
+		b := NewWeightedLoadBalancer(1)
+
+		assert.Nil(t, b.Select(context.Background(), nil))
+	})
+
+	t.Run("proportional to weights", func(t *testing.T) {
+		dbs := newDBs(3)
+		b := NewWeightedLoadBalancer(1, 3, 6)
+		b.bindDBs(dbs)
+
+		counts := distribution(b, dbs)
+
+		assert.InDelta(t, 0.1, float64(counts[dbs[0]])/selections, 0.02)
+		assert.InDelta(t, 0.3, float64(counts[dbs[1]])/selections, 0.02)
+		assert.InDelta(t, 0.6, float64(counts[dbs[2]])/selections, 0.02)
+	})
+
+	t.Run("zero weight is never chosen", func(t *testing.T) {
+		dbs := newDBs(3)
+		b := NewWeightedLoadBalancer(0, 1, 1)
+		b.bindDBs(dbs)
+
+		counts := distribution(b, dbs)
+
+		assert.Zero(t, counts[dbs[0]])
+		assert.InDelta(t, 0.5, float64(counts[dbs[1]])/selections, 0.03)
+		assert.InDelta(t, 0.5, float64(counts[dbs[2]])/selections, 0.03)
+	})
+
+	t.Run("all zero weights fall back to uniform", func(t *testing.T) {
+		dbs := newDBs(2)
+		b := NewWeightedLoadBalancer(0, 0)
+		b.bindDBs(dbs)
+
+		counts := distribution(b, dbs)
+
+		assert.InDelta(t, 0.5, float64(counts[dbs[0]])/selections, 0.03)
+		assert.InDelta(t, 0.5, float64(counts[dbs[1]])/selections, 0.03)
+	})
+
+	t.Run("missing weights are treated as 1", func(t *testing.T) {
+		dbs := newDBs(3)
+		b := NewWeightedLoadBalancer(2)
+		b.bindDBs(dbs)
+
+		counts := distribution(b, dbs)
+
+		assert.InDelta(t, 0.5, float64(counts[dbs[0]])/selections, 0.03)
+		assert.InDelta(t, 0.25, float64(counts[dbs[1]])/selections, 0.03)
+		assert.InDelta(t, 0.25, float64(counts[dbs[2]])/selections, 0.03)
+	})
+	t.Run("weights follow dbs when filtered", func(t *testing.T) {
+		dbs := newDBs(3)
+		b := NewWeightedLoadBalancer(0, 1, 3)
+		b.bindDBs(dbs)
+
+		counts := distribution(b, dbs[1:])
+
+		assert.InDelta(t, 0.25, float64(counts[dbs[1]])/selections, 0.03)
+		assert.InDelta(t, 0.75, float64(counts[dbs[2]])/selections, 0.03)
+	})
+
+	t.Run("weights of dbs", func(t *testing.T) {
+		dbs := newDBs(3)
+		b := NewWeightedLoadBalancerWithDBWeights(map[*sqlx.DB]int{dbs[0]: 0, dbs[2]: 3})
+
+		counts := distribution(b, dbs)
+
+		assert.Zero(t, counts[dbs[0]])
+		assert.InDelta(t, 0.25, float64(counts[dbs[1]])/selections, 0.03)
+		assert.InDelta(t, 0.75, float64(counts[dbs[2]])/selections, 0.03)
+	})
+
+	t.Run("weights bound to read dbs by resolver", func(t *testing.T) {
+		primaries := newDBs(1)
+		secondaries := newDBs(3)
+		b := NewWeightedLoadBalancer(0, 1, 3)
+		MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: primaries, ReadWritePolicy: WriteOnly},
+			WithSecondaryDBs(secondaries...),
+			WithLoadBalancer(b),
+		)
+
+		counts := distribution(b, []*sqlx.DB{secondaries[2], secondaries[0], secondaries[1]})
+
+		assert.Zero(t, counts[secondaries[0]])
+		assert.InDelta(t, 0.25, float64(counts[secondaries[1]])/selections, 0.03)
+		assert.InDelta(t, 0.75, float64(counts[secondaries[2]])/selections, 0.03)
+	})
+}
@@ -0,0 +1,82 @@
+package dbresolver
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// n1Detector detects the same query executed many times in quick succession within one context,
+// which is usually caused by the N+1 query pattern.
+type n1Detector struct {
+	threshold int
+	window    time.Duration
+	onDetect  func(query string, count int)
+
+	mu       sync.Mutex
+	executed map[n1Key][]time.Time
+	// pruned is when the executions of the other contexts were last pruned.
+	pruned time.Time
+}
+
+// n1Key identifies the executions of the same query within one context.
+type n1Key struct {
+	ctx   context.Context
+	query string
+}
+
+func newN1Detector(threshold int, window time.Duration, onDetect func(query string, count int)) *n1Detector {
+	return &n1Detector{
+		threshold: threshold,
+		window:    window,
+		onDetect:  onDetect,
+		executed:  make(map[n1Key][]time.Time),
+	}
+}
+
+// observe records an execution of the given query within the given context.
+// It calls onDetect when the number of the executions within the window reaches the threshold.
+// The executions older than the window are forgotten, so it is called again once the pattern repeats.
+// The executions with context.Background or context.TODO are not observed,
+// because they are shared by the unrelated callers.
+func (d *n1Detector) observe(ctx context.Context, query string, now time.Time) {
+	if ctx == context.Background() || ctx == context.TODO() {
+		return
+	}
+	key := n1Key{ctx: ctx, query: normalizeQuery(query)}
+	expired := now.Add(-d.window)
+
+	d.mu.Lock()
+	if !d.pruned.After(expired) {
+		d.prune(expired)
+		d.pruned = now
+	}
+	times := d.executed[key]
+	for len(times) > 0 && !times[0].After(expired) {
+		times = times[1:]
+	}
+	times = append(times, now)
+	d.executed[key] = times
+	count := len(times)
+	d.mu.Unlock()
+
+	if count == d.threshold {
+		d.onDetect(key.query, count)
+	}
+}
+
+// prune forgets the queries whose last execution is not after the given time.
+// It is called at most once per window, so the executions of the finished contexts are kept up to two windows.
+func (d *n1Detector) prune(expired time.Time) {
+	for k, times := range d.executed {
+		if !times[len(times)-1].After(expired) {
+			delete(d.executed, k)
+		}
+	}
+}
+
+// normalizeQuery collapses the whitespaces of the given query.
+func normalizeQuery(query string) string {
+	return strings.Join(strings.Fields(query), " ")
+}
@@ -0,0 +1,156 @@
+package dbresolver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestN1Detector_Observe(t *testing.T) {
+	type detected struct {
+		query string
+		count int
+	}
+
+	t.Run("detect repeated query within window", func(t *testing.T) {
+		var got []detected
+		d := newN1Detector(3, time.Second, func(query string, count int) {
+			got = append(got, detected{query, count})
+		})
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		now := time.Now()
+
+		d.observe(ctx, "SELECT * FROM person WHERE id = ?", now)
+		d.observe(ctx, "SELECT *  FROM person\n\tWHERE id = ?", now.Add(100*time.Millisecond))
+		d.observe(ctx, " SELECT * FROM person WHERE id = ? ", now.Add(200*time.Millisecond))
+		d.observe(ctx, "SELECT * FROM person WHERE id = ?", now.Add(300*time.Millisecond))
+
+		assert.Equal(t, []detected{{"SELECT * FROM person WHERE id = ?", 3}}, got)
+	})
+
+	t.Run("forget executions out of window", func(t *testing.T) {
+		var got []detected
+		d := newN1Detector(2, time.Second, func(query string, count int) {
+			got = append(got, detected{query, count})
+		})
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		now := time.Now()
+
+		d.observe(ctx, "SELECT 1", now)
+		d.observe(ctx, "SELECT 1", now.Add(time.Second))
+		d.observe(ctx, "SELECT 1", now.Add(3*time.Second))
+
+		assert.Empty(t, got)
+		assert.Len(t, d.executed, 1)
+	})
+
+	t.Run("keyed per context", func(t *testing.T) {
+		var got []detected
+		d := newN1Detector(2, time.Second, func(query string, count int) {
+			got = append(got, detected{query, count})
+		})
+		ctx1, cancel1 := context.WithCancel(context.Background())
+		defer cancel1()
+		ctx2, cancel2 := context.WithCancel(context.Background())
+		defer cancel2()
+		now := time.Now()
+
+		d.observe(ctx1, "SELECT 1", now)
+		d.observe(ctx2, "SELECT 1", now)
+
+		assert.Empty(t, got)
+
+		d.observe(ctx2, "SELECT 1", now)
+
+		assert.Equal(t, []detected{{"SELECT 1", 2}}, got)
+	})
+
+	t.Run("skip shared contexts", func(t *testing.T) {
+		var got []detected
+		d := newN1Detector(2, time.Second, func(query string, count int) {
+			got = append(got, detected{query, count})
+		})
+		now := time.Now()
+
+		for i := 0; i < 2; i++ {
+			d.observe(context.Background(), "SELECT 1", now)
+			d.observe(context.TODO(), "SELECT 1", now)
+		}
+
+		assert.Empty(t, got)
+		assert.Empty(t, d.executed)
+	})
+
+	t.Run("prune other contexts once per window", func(t *testing.T) {
+		d := newN1Detector(10, time.Second, func(string, int) {})
+		newContext := func() context.Context {
+			ctx, cancel := context.WithCancel(context.Background())
+			t.Cleanup(cancel)
+			return ctx
+		}
+		now := time.Now()
+
+		d.observe(newContext(), "SELECT 1", now)
+		d.observe(newContext(), "SELECT 1", now.Add(500*time.Millisecond))
+		d.observe(newContext(), "SELECT 1", now.Add(900*time.Millisecond))
+
+		assert.Len(t, d.executed, 3)
+
+		d.observe(newContext(), "SELECT 1", now.Add(1600*time.Millisecond))
+
+		assert.Len(t, d.executed, 2)
+
+		// The execution at 900ms is expired but kept until the next pruning.
+		d.observe(newContext(), "SELECT 1", now.Add(2*time.Second))
+
+		assert.Len(t, d.executed, 3)
+	})
+}
+
+func TestWithN1Detector(t *testing.T) {
+	mockDB, sqlMock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.NoError(t, err)
+	mockPrimaryDB := sqlx.NewDb(mockDB, "primary")
+	var queries []string
+	clock := &steppingClock{now: time.Now()}
+	resolver, err := NewDBResolver(
+		&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB}, ReadWritePolicy: ReadWrite},
+		WithN1Detector(2, time.Second, func(query string, count int) {
+			queries = append(queries, query)
+		}),
+		WithClock(clock.Now),
+	)
+	assert.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	for i := 0; i < 2; i++ {
+		sqlMock.ExpectQuery(`SELECT * FROM person WHERE id = ?`).
+			WithArgs(i).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(i))
+		clock.steps = []time.Duration{2 * time.Second}
+		rows, err := resolver.QueryContext(ctx, `SELECT * FROM person WHERE id = ?`, i)
+		assert.NoError(t, err)
+		assert.NoError(t, rows.Close())
+	}
+
+	assert.Empty(t, queries)
+
+	for i := 0; i < 2; i++ {
+		sqlMock.ExpectExec(`DELETE FROM person WHERE id = ?`).
+			WithArgs(i).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		clock.steps = []time.Duration{100 * time.Millisecond}
+		_, err := resolver.ExecContext(ctx, `DELETE FROM person WHERE id = ?`, i)
+		assert.NoError(t, err)
+	}
+
+	assert.Equal(t, []string{"DELETE FROM person WHERE id = ?"}, queries)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
@@ -3,6 +3,7 @@ package dbresolver
 import (
 	"context"
 	"database/sql"
+	"reflect"
 
 	"github.com/hashicorp/go-multierror"
 	"github.com/jmoiron/sqlx"
@@ -12,13 +13,20 @@ import (
 // errors.
 var (
 	errSelectedNamedStmtNotFound = errors.New("dbresolver: selected named stmt not found")
+	errNamedStmtNotPreparedOnTx  = errors.New("dbresolver: named stmt not prepared on the database of the tx")
+	errUnsupportedNamedStmtArg   = errors.New("dbresolver: unsupported named stmt arg")
 )
 
+// stmtFromDifferentDBMessage is the error message of database/sql
+// when a statement is used within a transaction of another database.
+const stmtFromDifferentDBMessage = "sql: Tx.Stmt: statement from different database used"
+
 // NamedStmt is a wrapper around sqlx.NamedStmt.
 type NamedStmt interface {
 	Close() error
 	Exec(arg interface{}) (sql.Result, error)
 	ExecContext(ctx context.Context, arg interface{}) (sql.Result, error)
+	ExecTx(tx *sqlx.Tx, arg interface{}) (sql.Result, error)
 	Get(dest interface{}, arg interface{}) error
 	GetContext(ctx context.Context, dest interface{}, arg interface{}) error
 	MustExec(arg interface{}) sql.Result
@@ -43,12 +51,23 @@ type namedStmt struct {
 	primaryStmts map[*sqlx.DB]*sqlx.NamedStmt
 	readStmts    map[*sqlx.DB]*sqlx.NamedStmt
 
-	loadBalancer LoadBalancer
+	loadBalancer      LoadBalancer
+	limiter           *stmtLimiter
+	registry          *stmtRegistry
+	drain             *drainGate
+	connErrClassifier connErrorClassifier
+	readRetries       int
+	writeRetries      int
+	// strictReadSeparation disables the fallback of the reads to the primary databases.
+	strictReadSeparation bool
 }
 
 // Close closes all primary database's named statements and readable database's named statements.
 // Close wraps sqlx.NamedStmt.Close.
 func (s *namedStmt) Close() error {
+	s.limiter.remove(s)
+	s.registry.remove(s)
+
 	var errs error
 	for _, pStmt := range s.primaryStmts {
 		err := pStmt.Close()
@@ -70,122 +89,143 @@ func (s *namedStmt) Close() error {
 }
 
 // Exec chooses a primary database's named statement and executes a named statement given argument.
+// If it fails with a connection error, it is retried with the other primary databases up to the write retries.
 // Exec wraps sqlx.NamedStmt.Exec.
 func (s *namedStmt) Exec(arg interface{}) (sql.Result, error) {
-	db := s.loadBalancer.Select(context.Background(), s.primaries)
-	stmt, ok := s.primaryStmts[db]
-	if !ok {
-		// Should not happen.
-		return nil, errors.Wrapf(errSelectedNamedStmtNotFound, "primary db: %v", db)
-	}
-	return stmt.Exec(arg)
+	s.limiter.touch(s)
+	if err := validateNamedArg(arg); err != nil {
+		return nil, err
+	}
+
+	var result sql.Result
+	err := s.write(context.Background(), func(stmt *sqlx.NamedStmt) error {
+		var err error
+		result, err = stmt.Exec(arg)
+		return err
+	})
+	return result, err
 }
 
 // ExecContext chooses a primary database's named statement and executes a named statement given argument.
+// If it fails with a connection error, it is retried with the other primary databases up to the write retries.
 // ExecContext wraps sqlx.NamedStmt.ExecContext.
 func (s *namedStmt) ExecContext(ctx context.Context, arg interface{}) (sql.Result, error) {
-	db := s.loadBalancer.Select(ctx, s.primaries)
-	stmt, ok := s.primaryStmts[db]
-	if !ok {
-		// Should not happen.
-		return nil, errors.Wrapf(errSelectedNamedStmtNotFound, "primary db: %v", db)
+	s.limiter.touch(s)
+	if err := validateNamedArg(arg); err != nil {
+		return nil, err
+	}
+
+	var result sql.Result
+	err := s.write(ctx, func(stmt *sqlx.NamedStmt) error {
+		var err error
+		result, err = stmt.ExecContext(ctx, arg)
+		return err
+	})
+	return result, err
+}
+
+// ExecTx chooses the primary database's named statement prepared on the database of the given transaction
+// and executes it with given argument within the transaction.
+// If the transaction is not on any of the primary databases, it returns an error.
+// ExecTx wraps sqlx.Tx.NamedStmt and sqlx.NamedStmt.Exec.
+func (s *namedStmt) ExecTx(tx *sqlx.Tx, arg interface{}) (sql.Result, error) {
+	s.limiter.touch(s)
+	if err := validateNamedArg(arg); err != nil {
+		return nil, err
 	}
-	return stmt.ExecContext(ctx, arg)
+
+	// The database of the transaction is not exposed,
+	// so the statements are tried in turn until one is not rejected by database/sql.
+	for _, db := range s.primaries {
+		stmt, ok := s.primaryStmts[db]
+		if !ok {
+			continue
+		}
+
+		txStmt := tx.NamedStmt(stmt)
+		result, err := txStmt.Exec(arg)
+		_ = txStmt.Close()
+		if err != nil && err.Error() == stmtFromDifferentDBMessage {
+			continue
+		}
+		return result, err
+	}
+	return nil, errNamedStmtNotPreparedOnTx
 }
 
 // Get chooses a readable database's named statement and Get using chosen statement.
 // Get wraps sqlx.NamedStmt.Get.
 func (s *namedStmt) Get(dest interface{}, arg interface{}) error {
-	db := s.loadBalancer.Select(context.Background(), s.reads)
-	stmt, ok := s.readStmts[db]
-	if !ok {
-		// Should not happen.
-		return errors.Wrapf(errSelectedNamedStmtNotFound, "readable db: %v", db)
+	s.limiter.touch(s)
+	if err := validateNamedArg(arg); err != nil {
+		return err
 	}
-	err := stmt.Get(dest, arg)
 
-	if isDBConnectionError(err) {
-		dbPrimary := s.loadBalancer.Select(context.Background(), s.primaries)
-		stmtPrimary, ok := s.readStmts[dbPrimary]
-		if !ok {
-			// Should not happen.
-			return errors.Wrapf(errSelectedNamedStmtNotFound, "readable db: %v", db)
-		}
-		err = stmtPrimary.Get(dest, arg)
-	}
-	return err
+	return s.read(context.Background(), func(stmt *sqlx.NamedStmt) error {
+		return stmt.Get(dest, arg)
+	})
 }
 
 // GetContext chooses a readable database's named statement and Get using chosen statement.
 // GetContext wraps sqlx.NamedStmt.GetContext.
 func (s *namedStmt) GetContext(ctx context.Context, dest interface{}, arg interface{}) error {
-	db := s.loadBalancer.Select(ctx, s.reads)
-	stmt, ok := s.readStmts[db]
-	if !ok {
-		// Should not happen.
-		return errors.Wrapf(errSelectedNamedStmtNotFound, "readable db: %v", db)
+	s.limiter.touch(s)
+	if err := validateNamedArg(arg); err != nil {
+		return err
 	}
-	err := stmt.GetContext(ctx, dest, arg)
 
-	if isDBConnectionError(err) {
-		dbPrimary := s.loadBalancer.Select(ctx, s.primaries)
-		stmtPrimary, ok := s.readStmts[dbPrimary]
-		if !ok {
-			// Should not happen.
-			return errors.Wrapf(errSelectedNamedStmtNotFound, "readable db: %v", db)
-		}
-		err = stmtPrimary.GetContext(ctx, dest, arg)
-	}
-	return err
+	return s.read(ctx, func(stmt *sqlx.NamedStmt) error {
+		return stmt.GetContext(ctx, dest, arg)
+	})
 }
 
 // MustExec chooses a primary database's named statement
 // and executes chosen statement with given argument.
 // MustExec wraps sqlx.NamedStmt.MustExec.
 func (s *namedStmt) MustExec(arg interface{}) sql.Result {
-	db := s.loadBalancer.Select(context.Background(), s.primaries)
-	stmt, ok := s.primaryStmts[db]
-	if !ok {
-		// Should not happen.
-		panic(errors.Wrapf(errSelectedNamedStmtNotFound, "primary db: %v", db))
+	s.limiter.touch(s)
+	if err := validateNamedArg(arg); err != nil {
+		panic(err)
 	}
-	return stmt.MustExec(arg)
+
+	result, err := s.Exec(arg)
+	if err != nil {
+		panic(err)
+	}
+	return result
 }
 
 // MustExecContext chooses a primary database's named statement
 // and executes chosen statement with given argument.
 // MustExecContext wraps sqlx.NamedStmt.MustExecContext.
 func (s *namedStmt) MustExecContext(ctx context.Context, arg interface{}) sql.Result {
-	db := s.loadBalancer.Select(ctx, s.primaries)
-	stmt, ok := s.primaryStmts[db]
-	if !ok {
-		// Should not happen.
-		panic(errors.Wrapf(errSelectedNamedStmtNotFound, "primary db: %v", db))
+	s.limiter.touch(s)
+	if err := validateNamedArg(arg); err != nil {
+		panic(err)
+	}
+
+	result, err := s.ExecContext(ctx, arg)
+	if err != nil {
+		panic(err)
 	}
-	return stmt.MustExecContext(ctx, arg)
+	return result
 }
 
 // Query chooses a readable database's named statement, executes chosen statement with given argument
 // and returns sql.Rows.
 // Query wraps sqlx.NamedStmt.Query.
 func (s *namedStmt) Query(arg interface{}) (*sql.Rows, error) {
-	db := s.loadBalancer.Select(context.Background(), s.reads)
-	stmt, ok := s.readStmts[db]
-	if !ok {
-		// Should not happen.
-		return nil, errors.Wrapf(errSelectedNamedStmtNotFound, "readable db: %v", db)
+	s.limiter.touch(s)
+	if err := validateNamedArg(arg); err != nil {
+		return nil, err
 	}
-	rows, err := stmt.Query(arg)
 
-	if isDBConnectionError(err) {
-		dbPrimary := s.loadBalancer.Select(context.Background(), s.primaries)
-		stmtPrimary, ok := s.readStmts[dbPrimary]
-		if !ok {
-			// Should not happen.
-			return nil, errors.Wrapf(errSelectedNamedStmtNotFound, "readable db: %v", db)
-		}
-		rows, err = stmtPrimary.Query(arg)
-	}
+	var rows *sql.Rows
+	err := s.read(context.Background(), func(stmt *sqlx.NamedStmt) error {
+		var err error
+		rows, err = stmt.Query(arg)
+		return err
+	})
 	return rows, err
 }
 
@@ -193,23 +233,17 @@ func (s *namedStmt) Query(arg interface{}) (*sql.Rows, error) {
 // and returns sql.Rows.
 // QueryContext wraps sqlx.NamedStmt.QueryContext.
 func (s *namedStmt) QueryContext(ctx context.Context, arg interface{}) (*sql.Rows, error) {
-	db := s.loadBalancer.Select(ctx, s.reads)
-	stmt, ok := s.readStmts[db]
-	if !ok {
-		// Should not happen.
-		return nil, errors.Wrapf(errSelectedNamedStmtNotFound, "readable db: %v", db)
+	s.limiter.touch(s)
+	if err := validateNamedArg(arg); err != nil {
+		return nil, err
 	}
-	rows, err := stmt.QueryContext(ctx, arg)
 
-	if isDBConnectionError(err) {
-		dbPrimary := s.loadBalancer.Select(ctx, s.primaries)
-		stmtPrimary, ok := s.readStmts[dbPrimary]
-		if !ok {
-			// Should not happen.
-			return nil, errors.Wrapf(errSelectedNamedStmtNotFound, "readable db: %v", db)
-		}
-		rows, err = stmtPrimary.QueryContext(ctx, arg)
-	}
+	var rows *sql.Rows
+	err := s.read(ctx, func(stmt *sqlx.NamedStmt) error {
+		var err error
+		rows, err = stmt.QueryContext(ctx, arg)
+		return err
+	})
 	return rows, err
 }
 
@@ -218,22 +252,22 @@ func (s *namedStmt) QueryContext(ctx context.Context, arg interface{}) (*sql.Row
 // If selected statement is not found, returns nil.
 // QueryRow wraps sqlx.NamedStmt.QueryRow.
 func (s *namedStmt) QueryRow(arg interface{}) *sqlx.Row {
-	db := s.loadBalancer.Select(context.Background(), s.reads)
-	stmt, ok := s.readStmts[db]
-	if !ok {
+	s.limiter.touch(s)
+	if err := validateNamedArg(arg); err != nil {
+		return errRowx(err)
+	}
+
+	var row *sqlx.Row
+	err := s.read(context.Background(), func(stmt *sqlx.NamedStmt) error {
+		row = stmt.QueryRow(arg)
+		return row.Err()
+	})
+	if errors.Is(err, errSelectedNamedStmtNotFound) {
 		// Should not happen.
 		return nil
 	}
-	row := stmt.QueryRow(arg)
-
-	if isDBConnectionError(row.Err()) {
-		dbPrimary := s.loadBalancer.Select(context.Background(), s.primaries)
-		stmtPrimary, ok := s.readStmts[dbPrimary]
-		if !ok {
-			// Should not happen.
-			return nil
-		}
-		row = stmtPrimary.QueryRow(arg)
+	if row == nil {
+		return errRowx(err)
 	}
 	return row
 }
@@ -243,22 +277,22 @@ func (s *namedStmt) QueryRow(arg interface{}) *sqlx.Row {
 // If selected statement is not found, returns nil.
 // QueryRowContext wraps sqlx.NamedStmt.QueryRowContext.
 func (s *namedStmt) QueryRowContext(ctx context.Context, arg interface{}) *sqlx.Row {
-	db := s.loadBalancer.Select(ctx, s.reads)
-	stmt, ok := s.readStmts[db]
-	if !ok {
+	s.limiter.touch(s)
+	if err := validateNamedArg(arg); err != nil {
+		return errRowx(err)
+	}
+
+	var row *sqlx.Row
+	err := s.read(ctx, func(stmt *sqlx.NamedStmt) error {
+		row = stmt.QueryRowContext(ctx, arg)
+		return row.Err()
+	})
+	if errors.Is(err, errSelectedNamedStmtNotFound) {
 		// Should not happen.
 		return nil
 	}
-	row := stmt.QueryRowContext(ctx, arg)
-
-	if isDBConnectionError(row.Err()) {
-		dbPrimary := s.loadBalancer.Select(ctx, s.primaries)
-		stmtPrimary, ok := s.readStmts[dbPrimary]
-		if !ok {
-			// Should not happen.
-			return nil
-		}
-		row = stmtPrimary.QueryRowContext(ctx, arg)
+	if row == nil {
+		return errRowx(err)
 	}
 	return row
 }
@@ -268,22 +302,22 @@ func (s *namedStmt) QueryRowContext(ctx context.Context, arg interface{}) *sqlx.
 // If selected statement is not found, returns nil.
 // QueryRowx wraps sqlx.NamedStmt.QueryRowx.
 func (s *namedStmt) QueryRowx(arg interface{}) *sqlx.Row {
-	db := s.loadBalancer.Select(context.Background(), s.reads)
-	stmt, ok := s.readStmts[db]
-	if !ok {
+	s.limiter.touch(s)
+	if err := validateNamedArg(arg); err != nil {
+		return errRowx(err)
+	}
+
+	var row *sqlx.Row
+	err := s.read(context.Background(), func(stmt *sqlx.NamedStmt) error {
+		row = stmt.QueryRowx(arg)
+		return row.Err()
+	})
+	if errors.Is(err, errSelectedNamedStmtNotFound) {
 		// Should not happen.
 		return nil
 	}
-	row := stmt.QueryRowx(arg)
-
-	if isDBConnectionError(row.Err()) {
-		dbPrimary := s.loadBalancer.Select(context.Background(), s.primaries)
-		stmtPrimary, ok := s.readStmts[dbPrimary]
-		if !ok {
-			// Should not happen.
-			return nil
-		}
-		row = stmtPrimary.QueryRowx(arg)
+	if row == nil {
+		return errRowx(err)
 	}
 	return row
 }
@@ -293,22 +327,22 @@ func (s *namedStmt) QueryRowx(arg interface{}) *sqlx.Row {
 // If selected statement is not found, returns nil.
 // QueryRowxContext wraps sqlx.NamedStmt.QueryRowxContext.
 func (s *namedStmt) QueryRowxContext(ctx context.Context, arg interface{}) *sqlx.Row {
-	db := s.loadBalancer.Select(ctx, s.reads)
-	stmt, ok := s.readStmts[db]
-	if !ok {
+	s.limiter.touch(s)
+	if err := validateNamedArg(arg); err != nil {
+		return errRowx(err)
+	}
+
+	var row *sqlx.Row
+	err := s.read(ctx, func(stmt *sqlx.NamedStmt) error {
+		row = stmt.QueryRowxContext(ctx, arg)
+		return row.Err()
+	})
+	if errors.Is(err, errSelectedNamedStmtNotFound) {
 		// Should not happen.
 		return nil
 	}
-	row := stmt.QueryRowxContext(ctx, arg)
-
-	if isDBConnectionError(row.Err()) {
-		dbPrimary := s.loadBalancer.Select(ctx, s.primaries)
-		stmtPrimary, ok := s.readStmts[dbPrimary]
-		if !ok {
-			// Should not happen.
-			return nil
-		}
-		row = stmtPrimary.QueryRowxContext(ctx, arg)
+	if row == nil {
+		return errRowx(err)
 	}
 	return row
 }
@@ -317,23 +351,17 @@ func (s *namedStmt) QueryRowxContext(ctx context.Context, arg interface{}) *sqlx
 // and returns sqlx.Rows.
 // Queryx wraps sqlx.NamedStmt.Queryx.
 func (s *namedStmt) Queryx(arg interface{}) (*sqlx.Rows, error) {
-	db := s.loadBalancer.Select(context.Background(), s.reads)
-	stmt, ok := s.readStmts[db]
-	if !ok {
-		// Should not happen.
-		return nil, errors.Wrapf(errSelectedNamedStmtNotFound, "readable db: %v", db)
+	s.limiter.touch(s)
+	if err := validateNamedArg(arg); err != nil {
+		return nil, err
 	}
-	rows, err := stmt.Queryx(arg)
 
-	if isDBConnectionError(err) {
-		dbPrimary := s.loadBalancer.Select(context.Background(), s.primaries)
-		stmtPrimary, ok := s.readStmts[dbPrimary]
-		if !ok {
-			// Should not happen.
-			return nil, errors.Wrapf(errSelectedNamedStmtNotFound, "readable db: %v", db)
-		}
-		rows, err = stmtPrimary.Queryx(arg)
-	}
+	var rows *sqlx.Rows
+	err := s.read(context.Background(), func(stmt *sqlx.NamedStmt) error {
+		var err error
+		rows, err = stmt.Queryx(arg)
+		return err
+	})
 	return rows, err
 }
 
@@ -341,81 +369,183 @@ func (s *namedStmt) Queryx(arg interface{}) (*sqlx.Rows, error) {
 // and returns sqlx.Rows.
 // QueryxContext wraps sqlx.NamedStmt.QueryxContext.
 func (s *namedStmt) QueryxContext(ctx context.Context, arg interface{}) (*sqlx.Rows, error) {
-	db := s.loadBalancer.Select(ctx, s.reads)
-	stmt, ok := s.readStmts[db]
-	if !ok {
-		// Should not happen.
-		return nil, errors.Wrapf(errSelectedNamedStmtNotFound, "readable db: %v", db)
+	s.limiter.touch(s)
+	if err := validateNamedArg(arg); err != nil {
+		return nil, err
 	}
-	rows, err := stmt.QueryxContext(ctx, arg)
 
-	if isDBConnectionError(err) {
-		dbPrimary := s.loadBalancer.Select(ctx, s.primaries)
-		stmtPrimary, ok := s.readStmts[dbPrimary]
-		if !ok {
-			// Should not happen.
-			return nil, errors.Wrapf(errSelectedNamedStmtNotFound, "readable db: %v", db)
-		}
-		rows, err = stmtPrimary.QueryxContext(ctx, arg)
-	}
+	var rows *sqlx.Rows
+	err := s.read(ctx, func(stmt *sqlx.NamedStmt) error {
+		var err error
+		rows, err = stmt.QueryxContext(ctx, arg)
+		return err
+	})
 	return rows, err
 }
 
 // Select chooses a readable database's named statement, executes chosen statement with given argument
 // Select wraps sqlx.NamedStmt.Select.
 func (s *namedStmt) Select(dest interface{}, arg interface{}) error {
-	db := s.loadBalancer.Select(context.Background(), s.reads)
-	stmt, ok := s.readStmts[db]
-	if !ok {
-		// Should not happen.
-		return errors.Wrapf(errSelectedNamedStmtNotFound, "readable db: %v", db)
+	s.limiter.touch(s)
+	if err := validateNamedArg(arg); err != nil {
+		return err
 	}
-	err := stmt.Select(dest, arg)
 
-	if isDBConnectionError(err) {
-		dbPrimary := s.loadBalancer.Select(context.Background(), s.primaries)
-		stmtPrimary, ok := s.readStmts[dbPrimary]
-		if !ok {
-			// Should not happen.
-			return errors.Wrapf(errSelectedNamedStmtNotFound, "readable db: %v", db)
-		}
-		err = stmtPrimary.Select(dest, arg)
-	}
-	return err
+	return s.read(context.Background(), func(stmt *sqlx.NamedStmt) error {
+		return stmt.Select(dest, arg)
+	})
 }
 
 // SelectContext chooses a readable database's named statement, executes chosen statement with given argument
 // SelectContext wraps sqlx.NamedStmt.SelectContext.
 func (s *namedStmt) SelectContext(ctx context.Context, dest interface{}, arg interface{}) error {
-	db := s.loadBalancer.Select(ctx, s.reads)
+	s.limiter.touch(s)
+	if err := validateNamedArg(arg); err != nil {
+		return err
+	}
+
+	return s.read(ctx, func(stmt *sqlx.NamedStmt) error {
+		return stmt.SelectContext(ctx, dest, arg)
+	})
+}
+
+// Unsafe chooses a primary database's named statement and returns the underlying sqlx.NamedStmt.
+// If selected statement is not found, returns nil.
+// Unsafe wraps sqlx.NamedStmt.Unsafe.
+func (s *namedStmt) Unsafe() *sqlx.NamedStmt {
+	s.limiter.touch(s)
+
+	db := s.selectPrimary(context.Background(), s.primaries)
+	stmt, ok := s.primaryStmts[db]
+	if !ok {
+		// Should not happen.
+		return nil
+	}
+	return stmt.Unsafe()
+}
+
+// read chooses a readable database's named statement and runs fn with it.
+// If fn fails with a connection error, it retries with the other readable databases' named statements
+// up to the number of the read retries of the resolver, and then chooses a primary database's named statement
+// and runs fn again unless the strict read separation is enabled or the context is given by WithNoFallback.
+// It tracks fn as an in-flight query for Shutdown, and fails with errShutdown once Shutdown is called.
+func (s *namedStmt) read(ctx context.Context, fn func(stmt *sqlx.NamedStmt) error) error {
+	if !s.drain.enter() {
+		return errShutdown
+	}
+	defer s.drain.exit()
+
+	reads := s.reads
+	db := s.selectRead(ctx, reads)
 	stmt, ok := s.readStmts[db]
 	if !ok {
 		// Should not happen.
 		return errors.Wrapf(errSelectedNamedStmtNotFound, "readable db: %v", db)
 	}
-	err := stmt.SelectContext(ctx, dest, arg)
-
-	if isDBConnectionError(err) {
-		dbPrimary := s.loadBalancer.Select(ctx, s.primaries)
-		stmtPrimary, ok := s.readStmts[dbPrimary]
+	err := fn(stmt)
+	for retries := 0; s.connErrClassifier.isConnectionError(err) && retries < s.readRetries; retries++ {
+		reads = excludeDB(reads, db)
+		if len(reads) == 0 {
+			break
+		}
+		db = s.selectRead(ctx, reads)
+		stmt, ok = s.readStmts[db]
 		if !ok {
 			// Should not happen.
 			return errors.Wrapf(errSelectedNamedStmtNotFound, "readable db: %v", db)
 		}
-		err = stmtPrimary.SelectContext(ctx, dest, arg)
+		err = fn(stmt)
+	}
+
+	if s.connErrClassifier.isConnectionError(err) && s.canFallBack(ctx) {
+		dbPrimary := s.selectPrimary(ctx, s.primaries)
+		stmtPrimary, ok := s.primaryStmts[dbPrimary]
+		if !ok {
+			// Should not happen.
+			return errors.Wrapf(errSelectedNamedStmtNotFound, "primary db: %v", dbPrimary)
+		}
+		err = fn(stmtPrimary)
 	}
 	return err
 }
 
-// Unsafe chooses a primary database's named statement and returns the underlying sqlx.NamedStmt.
-// If selected statement is not found, returns nil.
-// Unsafe wraps sqlx.NamedStmt.Unsafe.
-func (s *namedStmt) Unsafe() *sqlx.NamedStmt {
-	db := s.loadBalancer.Select(context.Background(), s.primaries)
+// canFallBack reports whether a read failed with a connection error can fall back to a primary database
+// like dbResolver.canFallBack.
+func (s *namedStmt) canFallBack(ctx context.Context) bool {
+	return !s.strictReadSeparation && len(s.primaries) > 0 && !noFallbackFromContext(ctx)
+}
+
+// write chooses a primary database's named statement and runs fn with it.
+// If fn fails with a connection error, it retries with the other primary databases' named statements
+// up to the number of the write retries of the resolver.
+// It tracks fn as an in-flight query for Shutdown, and fails with errShutdown once Shutdown is called.
+func (s *namedStmt) write(ctx context.Context, fn func(stmt *sqlx.NamedStmt) error) error {
+	if !s.drain.enter() {
+		return errShutdown
+	}
+	defer s.drain.exit()
+
+	primaries := s.primaries
+	db := s.selectPrimary(ctx, primaries)
 	stmt, ok := s.primaryStmts[db]
 	if !ok {
 		// Should not happen.
+		return errors.Wrapf(errSelectedNamedStmtNotFound, "primary db: %v", db)
+	}
+	err := fn(stmt)
+	for retries := 0; s.connErrClassifier.isConnectionError(err) && retries < s.writeRetries; retries++ {
+		primaries = excludeDB(primaries, db)
+		if len(primaries) == 0 {
+			break
+		}
+		db = s.selectPrimary(ctx, primaries)
+		stmt, ok = s.primaryStmts[db]
+		if !ok {
+			// Should not happen.
+			return errors.Wrapf(errSelectedNamedStmtNotFound, "primary db: %v", db)
+		}
+		err = fn(stmt)
+	}
+	return err
+}
+
+// selectPrimary chooses one of the given primary databases on which the named statement is prepared.
+func (s *namedStmt) selectPrimary(ctx context.Context, primaries []*sqlx.DB) *sqlx.DB {
+	return selectPrepared(ctx, s.loadBalancer, primaries, func(db *sqlx.DB) bool {
+		_, ok := s.primaryStmts[db]
+		return ok
+	})
+}
+
+// selectRead chooses one of the given readable databases on which the named statement is prepared.
+func (s *namedStmt) selectRead(ctx context.Context, reads []*sqlx.DB) *sqlx.DB {
+	return selectPrepared(ctx, s.loadBalancer, reads, func(db *sqlx.DB) bool {
+		_, ok := s.readStmts[db]
+		return ok
+	})
+}
+
+// mapStringInterfaceType is the type of the map arguments which sqlx binds by name.
+var mapStringInterfaceType = reflect.TypeOf(map[string]interface{}{})
+
+// validateNamedArg checks that the argument of a named statement is one of the forms supported by sqlx.
+// sqlx panics or fails with an opaque error for the other forms.
+func validateNamedArg(arg interface{}) error {
+	if _, ok := arg.(map[string]interface{}); ok {
 		return nil
 	}
-	return stmt.Unsafe()
+	v := reflect.ValueOf(arg)
+	if v.Kind() == reflect.Map && v.Type().ConvertibleTo(mapStringInterfaceType) {
+		return nil
+	}
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+	if v.Kind() == reflect.Struct {
+		return nil
+	}
+	return errors.Wrapf(
+		errUnsupportedNamedStmtArg,
+		"arg must be a map[string]interface{}, a struct or a pointer to a struct, but got %T", arg,
+	)
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql/driver"
 	"errors"
+	"net"
 	"testing"
 
 	"github.com/DATA-DOG/go-sqlmock"
@@ -188,6 +189,166 @@ func TestNamedStmt_Exec(t *testing.T) {
 		assert.Equal(t, int64(1), lastInsertIDResult)
 		assert.Equal(t, int64(1), lastRowsAffected)
 	})
+
+	t.Run("unsupported arg", func(t *testing.T) {
+		mockDB, sqlMock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock.ExpectPrepare(`INSERT INTO person (first_name, last_name) VALUES (?, ?)`)
+		mockPrimaryDB := sqlx.NewDb(mockDB, "mock")
+		mockPrimaryDBStmt, err := mockPrimaryDB.PrepareNamed(`INSERT INTO person (first_name, last_name) VALUES (:first_name, :last_name)`)
+		assert.NoError(t, err)
+		stmt := &namedStmt{
+			primaries: []*sqlx.DB{mockPrimaryDB},
+			primaryStmts: map[*sqlx.DB]*sqlx.NamedStmt{
+				mockPrimaryDB: mockPrimaryDBStmt,
+			},
+			loadBalancer: &injectedLoadBalancer{
+				db: mockPrimaryDB,
+			},
+		}
+
+		result, err := stmt.Exec("foo")
+
+		assert.Nil(t, result)
+		assert.ErrorIs(t, err, errUnsupportedNamedStmtArg)
+		assert.ErrorContains(t, err, "map[string]interface{}, a struct or a pointer to a struct, but got string")
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("success with named map type arg", func(t *testing.T) {
+		type Params map[string]interface{}
+		mockDB, sqlMock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock.ExpectPrepare(`INSERT INTO person (first_name, last_name) VALUES (?, ?)`).
+			ExpectExec().
+			WithArgs(driver.Value("foo"), driver.Value("bar")).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mockPrimaryDB := sqlx.NewDb(mockDB, "mock")
+		mockPrimaryDBStmt, err := mockPrimaryDB.PrepareNamed(`INSERT INTO person (first_name, last_name) VALUES (:first_name, :last_name)`)
+		assert.NoError(t, err)
+		stmt := &namedStmt{
+			primaries: []*sqlx.DB{mockPrimaryDB},
+			primaryStmts: map[*sqlx.DB]*sqlx.NamedStmt{
+				mockPrimaryDB: mockPrimaryDBStmt,
+			},
+			loadBalancer: &injectedLoadBalancer{
+				db: mockPrimaryDB,
+			},
+		}
+
+		result, err := stmt.Exec(Params{"first_name": "foo", "last_name": "bar"})
+
+		assert.NoError(t, err)
+		rowsAffected, err := result.RowsAffected()
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), rowsAffected)
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("success with pointer to struct arg", func(t *testing.T) {
+		type Person struct {
+			FirstName string `db:"first_name"`
+			LastName  string `db:"last_name"`
+		}
+		mockDB, sqlMock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock.ExpectPrepare(`INSERT INTO person (first_name, last_name) VALUES (?, ?)`).
+			ExpectExec().
+			WithArgs(driver.Value("foo"), driver.Value("bar")).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mockPrimaryDB := sqlx.NewDb(mockDB, "mock")
+		mockPrimaryDBStmt, err := mockPrimaryDB.PrepareNamed(`INSERT INTO person (first_name, last_name) VALUES (:first_name, :last_name)`)
+		assert.NoError(t, err)
+		stmt := &namedStmt{
+			primaries: []*sqlx.DB{mockPrimaryDB},
+			primaryStmts: map[*sqlx.DB]*sqlx.NamedStmt{
+				mockPrimaryDB: mockPrimaryDBStmt,
+			},
+			loadBalancer: &injectedLoadBalancer{
+				db: mockPrimaryDB,
+			},
+		}
+
+		_, err = stmt.Exec(&Person{FirstName: "foo", LastName: "bar"})
+
+		assert.NoError(t, err)
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("retry other primary on connection error", func(t *testing.T) {
+		connErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock1.ExpectPrepare(`INSERT INTO person (first_name, last_name) VALUES (?, ?)`).
+			ExpectExec().
+			WithArgs(driver.Value("foo"), driver.Value("bar")).
+			WillReturnError(connErr)
+		mockPrimaryDB1 := sqlx.NewDb(mockDB1, "mock1")
+		mockPrimaryDB1Stmt, err := mockPrimaryDB1.PrepareNamed(`INSERT INTO person (first_name, last_name) VALUES (:first_name, :last_name)`)
+		assert.NoError(t, err)
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock2.ExpectPrepare(`INSERT INTO person (first_name, last_name) VALUES (?, ?)`).
+			ExpectExec().
+			WithArgs(driver.Value("foo"), driver.Value("bar")).
+			WillReturnResult(sqlmock.NewResult(2, 1))
+		mockPrimaryDB2 := sqlx.NewDb(mockDB2, "mock2")
+		mockPrimaryDB2Stmt, err := mockPrimaryDB2.PrepareNamed(`INSERT INTO person (first_name, last_name) VALUES (:first_name, :last_name)`)
+		assert.NoError(t, err)
+		stmt := &namedStmt{
+			primaries: []*sqlx.DB{mockPrimaryDB1, mockPrimaryDB2},
+			primaryStmts: map[*sqlx.DB]*sqlx.NamedStmt{
+				mockPrimaryDB1: mockPrimaryDB1Stmt,
+				mockPrimaryDB2: mockPrimaryDB2Stmt,
+			},
+			loadBalancer: &firstLoadBalancer{},
+			writeRetries: 1,
+		}
+
+		inputArg := map[string]interface{}{
+			"first_name": "foo",
+			"last_name":  "bar",
+		}
+		result, err := stmt.Exec(inputArg)
+
+		assert.NoError(t, err)
+		lastInsertIDResult, err := result.LastInsertId()
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), lastInsertIDResult)
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
+
+	t.Run("return connection error without write retries", func(t *testing.T) {
+		connErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock1.ExpectPrepare(`INSERT INTO person (first_name, last_name) VALUES (?, ?)`).
+			ExpectExec().
+			WithArgs(driver.Value("foo"), driver.Value("bar")).
+			WillReturnError(connErr)
+		mockPrimaryDB1 := sqlx.NewDb(mockDB1, "mock1")
+		mockPrimaryDB1Stmt, err := mockPrimaryDB1.PrepareNamed(`INSERT INTO person (first_name, last_name) VALUES (:first_name, :last_name)`)
+		assert.NoError(t, err)
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock2.ExpectPrepare(`INSERT INTO person (first_name, last_name) VALUES (?, ?)`)
+		mockPrimaryDB2 := sqlx.NewDb(mockDB2, "mock2")
+		mockPrimaryDB2Stmt, err := mockPrimaryDB2.PrepareNamed(`INSERT INTO person (first_name, last_name) VALUES (:first_name, :last_name)`)
+		assert.NoError(t, err)
+		stmt := &namedStmt{
+			primaries: []*sqlx.DB{mockPrimaryDB1, mockPrimaryDB2},
+			primaryStmts: map[*sqlx.DB]*sqlx.NamedStmt{
+				mockPrimaryDB1: mockPrimaryDB1Stmt,
+				mockPrimaryDB2: mockPrimaryDB2Stmt,
+			},
+			loadBalancer: &firstLoadBalancer{},
+		}
+
+		inputArg := map[string]interface{}{
+			"first_name": "foo",
+			"last_name":  "bar",
+		}
+		result, err := stmt.Exec(inputArg)
+
+		assert.Nil(t, result)
+		assert.ErrorIs(t, err, connErr)
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
 }
 
 func TestNamedStmt_ExecContext(t *testing.T) {
@@ -228,73 +389,541 @@ func TestNamedStmt_ExecContext(t *testing.T) {
 		mockPrimaryDB1Stmt, err := mockPrimaryDB1.PrepareNamed(`INSERT INTO person (first_name, last_name) VALUES (:first_name, :last_name)`)
 		assert.NoError(t, err)
 		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
-		sqlMock2.ExpectPrepare(`INSERT INTO person (first_name, last_name) VALUES (?, ?)`)
-		mockPrimaryDB2 := sqlx.NewDb(mockDB2, "mock2")
-		mockPrimaryDB2Stmt, err := mockPrimaryDB2.PrepareNamed(`INSERT INTO person (first_name, last_name) VALUES (:first_name, :last_name)`)
+		sqlMock2.ExpectPrepare(`INSERT INTO person (first_name, last_name) VALUES (?, ?)`)
+		mockPrimaryDB2 := sqlx.NewDb(mockDB2, "mock2")
+		mockPrimaryDB2Stmt, err := mockPrimaryDB2.PrepareNamed(`INSERT INTO person (first_name, last_name) VALUES (:first_name, :last_name)`)
+		assert.NoError(t, err)
+		stmt := &namedStmt{
+			primaries: []*sqlx.DB{mockPrimaryDB1, mockPrimaryDB2},
+			primaryStmts: map[*sqlx.DB]*sqlx.NamedStmt{
+				mockPrimaryDB1: mockPrimaryDB1Stmt,
+				mockPrimaryDB2: mockPrimaryDB2Stmt,
+			},
+			loadBalancer: &injectedLoadBalancer{
+				db: mockPrimaryDB1,
+			},
+		}
+
+		inputArg := map[string]interface{}{
+			"first_name": "foo",
+			"last_name":  "bar",
+		}
+		result, err := stmt.ExecContext(context.Background(), inputArg)
+
+		assert.Nil(t, result)
+		assert.ErrorIs(t, err, mockError)
+	})
+
+	t.Run("success", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock1.ExpectPrepare(`INSERT INTO person (first_name, last_name) VALUES (?, ?)`).
+			ExpectExec().
+			WithArgs(driver.Value("foo"), driver.Value("bar")).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mockPrimaryDB1 := sqlx.NewDb(mockDB1, "mock1")
+		mockPrimaryDB1Stmt, err := mockPrimaryDB1.PrepareNamed(`INSERT INTO person (first_name, last_name) VALUES (:first_name, :last_name)`)
+		assert.NoError(t, err)
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock2.ExpectPrepare(`INSERT INTO person (first_name, last_name) VALUES (?, ?)`)
+		mockPrimaryDB2 := sqlx.NewDb(mockDB2, "mock2")
+		mockPrimaryDB2Stmt, err := mockPrimaryDB2.PrepareNamed(`INSERT INTO person (first_name, last_name) VALUES (:first_name, :last_name)`)
+		assert.NoError(t, err)
+		stmt := &namedStmt{
+			primaries: []*sqlx.DB{mockPrimaryDB1, mockPrimaryDB2},
+			primaryStmts: map[*sqlx.DB]*sqlx.NamedStmt{
+				mockPrimaryDB1: mockPrimaryDB1Stmt,
+				mockPrimaryDB2: mockPrimaryDB2Stmt,
+			},
+			loadBalancer: &injectedLoadBalancer{
+				db: mockPrimaryDB1,
+			},
+		}
+
+		inputArg := map[string]interface{}{
+			"first_name": "foo",
+			"last_name":  "bar",
+		}
+		result, err := stmt.ExecContext(context.Background(), inputArg)
+
+		assert.NoError(t, err)
+		lastInsertIDResult, err := result.LastInsertId()
+		assert.NoError(t, err)
+		lastRowsAffected, err := result.RowsAffected()
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), lastInsertIDResult)
+		assert.Equal(t, int64(1), lastRowsAffected)
+	})
+
+	t.Run("unsupported arg", func(t *testing.T) {
+		mockDB, sqlMock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock.ExpectPrepare(`INSERT INTO person (first_name, last_name) VALUES (?, ?)`)
+		mockPrimaryDB := sqlx.NewDb(mockDB, "mock")
+		mockPrimaryDBStmt, err := mockPrimaryDB.PrepareNamed(`INSERT INTO person (first_name, last_name) VALUES (:first_name, :last_name)`)
+		assert.NoError(t, err)
+		stmt := &namedStmt{
+			primaries: []*sqlx.DB{mockPrimaryDB},
+			primaryStmts: map[*sqlx.DB]*sqlx.NamedStmt{
+				mockPrimaryDB: mockPrimaryDBStmt,
+			},
+			loadBalancer: &injectedLoadBalancer{
+				db: mockPrimaryDB,
+			},
+		}
+
+		result, err := stmt.ExecContext(context.Background(), "foo")
+
+		assert.Nil(t, result)
+		assert.ErrorIs(t, err, errUnsupportedNamedStmtArg)
+		assert.ErrorContains(t, err, "map[string]interface{}, a struct or a pointer to a struct, but got string")
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("retry other primary on connection error", func(t *testing.T) {
+		connErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock1.ExpectPrepare(`INSERT INTO person (first_name, last_name) VALUES (?, ?)`).
+			ExpectExec().
+			WithArgs(driver.Value("foo"), driver.Value("bar")).
+			WillReturnError(connErr)
+		mockPrimaryDB1 := sqlx.NewDb(mockDB1, "mock1")
+		mockPrimaryDB1Stmt, err := mockPrimaryDB1.PrepareNamed(`INSERT INTO person (first_name, last_name) VALUES (:first_name, :last_name)`)
+		assert.NoError(t, err)
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock2.ExpectPrepare(`INSERT INTO person (first_name, last_name) VALUES (?, ?)`).
+			ExpectExec().
+			WithArgs(driver.Value("foo"), driver.Value("bar")).
+			WillReturnResult(sqlmock.NewResult(2, 1))
+		mockPrimaryDB2 := sqlx.NewDb(mockDB2, "mock2")
+		mockPrimaryDB2Stmt, err := mockPrimaryDB2.PrepareNamed(`INSERT INTO person (first_name, last_name) VALUES (:first_name, :last_name)`)
+		assert.NoError(t, err)
+		stmt := &namedStmt{
+			primaries: []*sqlx.DB{mockPrimaryDB1, mockPrimaryDB2},
+			primaryStmts: map[*sqlx.DB]*sqlx.NamedStmt{
+				mockPrimaryDB1: mockPrimaryDB1Stmt,
+				mockPrimaryDB2: mockPrimaryDB2Stmt,
+			},
+			loadBalancer: &firstLoadBalancer{},
+			writeRetries: 1,
+		}
+
+		inputArg := map[string]interface{}{
+			"first_name": "foo",
+			"last_name":  "bar",
+		}
+		result, err := stmt.ExecContext(context.Background(), inputArg)
+
+		assert.NoError(t, err)
+		lastInsertIDResult, err := result.LastInsertId()
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), lastInsertIDResult)
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
+
+	t.Run("return connection error without write retries", func(t *testing.T) {
+		connErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock1.ExpectPrepare(`INSERT INTO person (first_name, last_name) VALUES (?, ?)`).
+			ExpectExec().
+			WithArgs(driver.Value("foo"), driver.Value("bar")).
+			WillReturnError(connErr)
+		mockPrimaryDB1 := sqlx.NewDb(mockDB1, "mock1")
+		mockPrimaryDB1Stmt, err := mockPrimaryDB1.PrepareNamed(`INSERT INTO person (first_name, last_name) VALUES (:first_name, :last_name)`)
+		assert.NoError(t, err)
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock2.ExpectPrepare(`INSERT INTO person (first_name, last_name) VALUES (?, ?)`)
+		mockPrimaryDB2 := sqlx.NewDb(mockDB2, "mock2")
+		mockPrimaryDB2Stmt, err := mockPrimaryDB2.PrepareNamed(`INSERT INTO person (first_name, last_name) VALUES (:first_name, :last_name)`)
+		assert.NoError(t, err)
+		stmt := &namedStmt{
+			primaries: []*sqlx.DB{mockPrimaryDB1, mockPrimaryDB2},
+			primaryStmts: map[*sqlx.DB]*sqlx.NamedStmt{
+				mockPrimaryDB1: mockPrimaryDB1Stmt,
+				mockPrimaryDB2: mockPrimaryDB2Stmt,
+			},
+			loadBalancer: &firstLoadBalancer{},
+		}
+
+		inputArg := map[string]interface{}{
+			"first_name": "foo",
+			"last_name":  "bar",
+		}
+		result, err := stmt.ExecContext(context.Background(), inputArg)
+
+		assert.Nil(t, result)
+		assert.ErrorIs(t, err, connErr)
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
+}
+
+func TestNamedStmt_ExecTx(t *testing.T) {
+	t.Run("transaction on unknown database", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock1.ExpectPrepare(`INSERT INTO person (first_name, last_name) VALUES (?, ?)`)
+		mockPrimaryDB := sqlx.NewDb(mockDB1, "mock1")
+		mockPrimaryDBStmt, err := mockPrimaryDB.PrepareNamed(`INSERT INTO person (first_name, last_name) VALUES (:first_name, :last_name)`)
+		assert.NoError(t, err)
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock2.ExpectBegin()
+		mockOtherDB := sqlx.NewDb(mockDB2, "mock2")
+		stmt := &namedStmt{
+			primaries: []*sqlx.DB{mockPrimaryDB},
+			primaryStmts: map[*sqlx.DB]*sqlx.NamedStmt{
+				mockPrimaryDB: mockPrimaryDBStmt,
+			},
+		}
+		tx, err := mockOtherDB.Beginx()
+		assert.NoError(t, err)
+
+		inputArg := map[string]interface{}{
+			"first_name": "foo",
+			"last_name":  "bar",
+		}
+		result, err := stmt.ExecTx(tx, inputArg)
+
+		assert.Nil(t, result)
+		assert.ErrorIs(t, err, errNamedStmtNotPreparedOnTx)
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
+
+	t.Run("success", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock1.ExpectPrepare(`INSERT INTO person (first_name, last_name) VALUES (?, ?)`)
+		mockPrimaryDB1 := sqlx.NewDb(mockDB1, "mock1")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock2.ExpectPrepare(`INSERT INTO person (first_name, last_name) VALUES (?, ?)`)
+		sqlMock2.ExpectBegin()
+		sqlMock2.ExpectExec(`INSERT INTO person (first_name, last_name) VALUES (?, ?)`).
+			WithArgs(driver.Value("foo"), driver.Value("bar")).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		sqlMock2.ExpectCommit()
+		mockPrimaryDB2 := sqlx.NewDb(mockDB2, "mock2")
+		mockDB3, sqlMock3, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock3.ExpectPrepare(`INSERT INTO person (first_name, last_name) VALUES (?, ?)`)
+		mockSecondaryDB := sqlx.NewDb(mockDB3, "mock3")
+		r := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB1, mockPrimaryDB2}, ReadWritePolicy: WriteOnly},
+			WithSecondaryDBs(mockSecondaryDB),
+			WithLoadBalancer(&injectedLoadBalancer{db: mockPrimaryDB2}),
+		)
+		stmt, err := r.PrepareNamed(`INSERT INTO person (first_name, last_name) VALUES (:first_name, :last_name)`)
+		assert.NoError(t, err)
+		tx, err := r.Beginx()
+		assert.NoError(t, err)
+
+		inputArg := map[string]interface{}{
+			"first_name": "foo",
+			"last_name":  "bar",
+		}
+		result, err := stmt.ExecTx(tx, inputArg)
+
+		assert.NoError(t, err)
+		rowsAffected, err := result.RowsAffected()
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), rowsAffected)
+		assert.NoError(t, tx.Commit())
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+		assert.NoError(t, sqlMock3.ExpectationsWereMet())
+	})
+}
+
+func TestNamedStmt_Get(t *testing.T) {
+	t.Run("statement not found", func(t *testing.T) {
+		type Person struct {
+			FirstName string `db:"first_name"`
+			LastName  string `db:"last_name"`
+		}
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock1.ExpectPrepare(`SELECT * FROM person WHERE first_name=?`).
+			ExpectQuery()
+		mockRead1 := sqlx.NewDb(mockDB1, "mock1")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock2.ExpectPrepare(`SELECT * FROM person WHERE first_name=?`)
+		mockRead2 := sqlx.NewDb(mockDB2, "mock2")
+		stmt := &namedStmt{
+			reads:     []*sqlx.DB{mockRead1, mockRead2},
+			readStmts: map[*sqlx.DB]*sqlx.NamedStmt{},
+			loadBalancer: &injectedLoadBalancer{
+				db: mockRead1,
+			},
+		}
+
+		inputArg := map[string]interface{}{
+			"first_name": "foo",
+		}
+		result := &Person{}
+		err := stmt.Get(result, inputArg)
+
+		assert.ErrorIs(t, err, errSelectedNamedStmtNotFound)
+	})
+
+	t.Run("failed to execute query", func(t *testing.T) {
+		type Person struct {
+			FirstName string `db:"first_name"`
+			LastName  string `db:"last_name"`
+		}
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		mockError := errors.New("mock error")
+		sqlMock1.ExpectPrepare(`SELECT * FROM person WHERE first_name=?`).
+			ExpectQuery().
+			WithArgs(driver.Value("foo")).
+			WillReturnError(mockError)
+		mockRead1 := sqlx.NewDb(mockDB1, "mock1")
+		mockReadStmt1, err := mockRead1.PrepareNamed(`SELECT * FROM person WHERE first_name=:first_name`)
+		assert.NoError(t, err)
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock2.ExpectPrepare(`SELECT * FROM person WHERE first_name=?`)
+		mockRead2 := sqlx.NewDb(mockDB2, "mock2")
+		mockReadStmt2, err := mockRead2.PrepareNamed(`SELECT * FROM person WHERE first_name=:first_name`)
+		assert.NoError(t, err)
+		stmt := &namedStmt{
+			reads: []*sqlx.DB{mockRead1, mockRead2},
+			readStmts: map[*sqlx.DB]*sqlx.NamedStmt{
+				mockRead1: mockReadStmt1,
+				mockRead2: mockReadStmt2,
+			},
+			loadBalancer: &injectedLoadBalancer{
+				db: mockRead1,
+			},
+		}
+
+		inputArg := map[string]interface{}{
+			"first_name": "foo",
+		}
+		result := &Person{}
+		err = stmt.Get(result, inputArg)
+
+		assert.ErrorIs(t, err, mockError)
+	})
+
+	t.Run("success", func(t *testing.T) {
+		type Person struct {
+			FirstName string `db:"first_name"`
+			LastName  string `db:"last_name"`
+		}
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock1.ExpectPrepare(`SELECT * FROM person WHERE first_name=?`).
+			ExpectQuery().
+			WithArgs(driver.Value("foo")).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name", "last_name"}).
+				AddRow("foo", "bar"))
+		mockRead1 := sqlx.NewDb(mockDB1, "mock1")
+		mockReadStmt1, err := mockRead1.PrepareNamed(`SELECT * FROM person WHERE first_name=:first_name`)
+		assert.NoError(t, err)
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock2.ExpectPrepare(`SELECT * FROM person WHERE first_name=?`)
+		mockRead2 := sqlx.NewDb(mockDB2, "mock2")
+		mockReadStmt2, err := mockRead2.PrepareNamed(`SELECT * FROM person WHERE first_name=:first_name`)
+		assert.NoError(t, err)
+		stmt := &namedStmt{
+			reads: []*sqlx.DB{mockRead1, mockRead2},
+			readStmts: map[*sqlx.DB]*sqlx.NamedStmt{
+				mockRead1: mockReadStmt1,
+				mockRead2: mockReadStmt2,
+			},
+			loadBalancer: &injectedLoadBalancer{
+				db: mockRead1,
+			},
+		}
+
+		inputArg := map[string]interface{}{
+			"first_name": "foo",
+		}
+		result := &Person{}
+		err = stmt.Get(result, inputArg)
+
+		assert.NoError(t, err)
+		expected := &Person{
+			FirstName: "foo",
+			LastName:  "bar",
+		}
+		assert.Equal(t, expected, result)
+	})
+
+	t.Run("retry with other readable database on connection error", func(t *testing.T) {
+		type Person struct {
+			FirstName string `db:"first_name"`
+			LastName  string `db:"last_name"`
+		}
+		connErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock1.ExpectPrepare(`SELECT * FROM person WHERE first_name=?`).
+			ExpectQuery().
+			WithArgs(driver.Value("foo")).
+			WillReturnError(connErr)
+		mockRead1 := sqlx.NewDb(mockDB1, "mock1")
+		mockReadStmt1, err := mockRead1.PrepareNamed(`SELECT * FROM person WHERE first_name=:first_name`)
+		assert.NoError(t, err)
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock2.ExpectPrepare(`SELECT * FROM person WHERE first_name=?`).
+			ExpectQuery().
+			WithArgs(driver.Value("foo")).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name", "last_name"}).
+				AddRow("foo", "bar"))
+		mockRead2 := sqlx.NewDb(mockDB2, "mock2")
+		mockReadStmt2, err := mockRead2.PrepareNamed(`SELECT * FROM person WHERE first_name=:first_name`)
+		assert.NoError(t, err)
+		stmt := &namedStmt{
+			reads: []*sqlx.DB{mockRead1, mockRead2},
+			readStmts: map[*sqlx.DB]*sqlx.NamedStmt{
+				mockRead1: mockReadStmt1,
+				mockRead2: mockReadStmt2,
+			},
+			loadBalancer: &firstLoadBalancer{},
+			readRetries:  1,
+		}
+
+		inputArg := map[string]interface{}{
+			"first_name": "foo",
+		}
+		result := &Person{}
+		err = stmt.Get(result, inputArg)
+
+		assert.NoError(t, err)
+		expected := &Person{
+			FirstName: "foo",
+			LastName:  "bar",
+		}
+		assert.Equal(t, expected, result)
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
+
+	t.Run("fall back to primary on connection error", func(t *testing.T) {
+		type Person struct {
+			FirstName string `db:"first_name"`
+			LastName  string `db:"last_name"`
+		}
+		connErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock1.ExpectPrepare(`SELECT * FROM person WHERE first_name=?`).
+			ExpectQuery().
+			WithArgs(driver.Value("foo")).
+			WillReturnError(connErr)
+		mockRead := sqlx.NewDb(mockDB1, "mock1")
+		mockReadStmt, err := mockRead.PrepareNamed(`SELECT * FROM person WHERE first_name=:first_name`)
+		assert.NoError(t, err)
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock2.ExpectPrepare(`SELECT * FROM person WHERE first_name=?`).
+			ExpectQuery().
+			WithArgs(driver.Value("foo")).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name", "last_name"}).
+				AddRow("foo", "bar"))
+		mockPrimary := sqlx.NewDb(mockDB2, "mock2")
+		mockPrimaryStmt, err := mockPrimary.PrepareNamed(`SELECT * FROM person WHERE first_name=:first_name`)
+		assert.NoError(t, err)
+		stmt := &namedStmt{
+			primaries: []*sqlx.DB{mockPrimary},
+			reads:     []*sqlx.DB{mockRead},
+			primaryStmts: map[*sqlx.DB]*sqlx.NamedStmt{
+				mockPrimary: mockPrimaryStmt,
+			},
+			readStmts: map[*sqlx.DB]*sqlx.NamedStmt{
+				mockRead: mockReadStmt,
+			},
+			loadBalancer: &firstLoadBalancer{},
+		}
+
+		inputArg := map[string]interface{}{
+			"first_name": "foo",
+		}
+		result := &Person{}
+		err = stmt.Get(result, inputArg)
+
+		assert.NoError(t, err)
+		expected := &Person{
+			FirstName: "foo",
+			LastName:  "bar",
+		}
+		assert.Equal(t, expected, result)
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
+
+	t.Run("not fall back with strict read separation", func(t *testing.T) {
+		type Person struct {
+			FirstName string `db:"first_name"`
+			LastName  string `db:"last_name"`
+		}
+		connErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock1.ExpectPrepare(`SELECT * FROM person WHERE first_name=?`).
+			ExpectQuery().
+			WithArgs(driver.Value("foo")).
+			WillReturnError(connErr)
+		mockRead1 := sqlx.NewDb(mockDB1, "mock1")
+		mockReadStmt1, err := mockRead1.PrepareNamed(`SELECT * FROM person WHERE first_name=:first_name`)
+		assert.NoError(t, err)
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock2.ExpectPrepare(`SELECT * FROM person WHERE first_name=?`)
+		mockRead2 := sqlx.NewDb(mockDB2, "mock2")
+		mockReadStmt2, err := mockRead2.PrepareNamed(`SELECT * FROM person WHERE first_name=:first_name`)
+		assert.NoError(t, err)
+		mockDB3, sqlMock3, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock3.ExpectPrepare(`SELECT * FROM person WHERE first_name=?`)
+		mockPrimary := sqlx.NewDb(mockDB3, "mock3")
+		mockPrimaryStmt, err := mockPrimary.PrepareNamed(`SELECT * FROM person WHERE first_name=:first_name`)
 		assert.NoError(t, err)
 		stmt := &namedStmt{
-			primaries: []*sqlx.DB{mockPrimaryDB1, mockPrimaryDB2},
+			primaries: []*sqlx.DB{mockPrimary},
+			reads:     []*sqlx.DB{mockRead1, mockRead2},
 			primaryStmts: map[*sqlx.DB]*sqlx.NamedStmt{
-				mockPrimaryDB1: mockPrimaryDB1Stmt,
-				mockPrimaryDB2: mockPrimaryDB2Stmt,
+				mockPrimary: mockPrimaryStmt,
 			},
-			loadBalancer: &injectedLoadBalancer{
-				db: mockPrimaryDB1,
+			readStmts: map[*sqlx.DB]*sqlx.NamedStmt{
+				mockRead1: mockReadStmt1,
+				mockRead2: mockReadStmt2,
 			},
+			loadBalancer:         &firstLoadBalancer{},
+			strictReadSeparation: true,
 		}
 
 		inputArg := map[string]interface{}{
 			"first_name": "foo",
-			"last_name":  "bar",
 		}
-		result, err := stmt.ExecContext(context.Background(), inputArg)
+		result := &Person{}
+		err = stmt.Get(result, inputArg)
 
-		assert.Nil(t, result)
-		assert.ErrorIs(t, err, mockError)
+		assert.ErrorIs(t, err, connErr)
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+		assert.NoError(t, sqlMock3.ExpectationsWereMet())
 	})
 
-	t.Run("success", func(t *testing.T) {
-		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
-		sqlMock1.ExpectPrepare(`INSERT INTO person (first_name, last_name) VALUES (?, ?)`).
-			ExpectExec().
-			WithArgs(driver.Value("foo"), driver.Value("bar")).
-			WillReturnResult(sqlmock.NewResult(1, 1))
-		mockPrimaryDB1 := sqlx.NewDb(mockDB1, "mock1")
-		mockPrimaryDB1Stmt, err := mockPrimaryDB1.PrepareNamed(`INSERT INTO person (first_name, last_name) VALUES (:first_name, :last_name)`)
-		assert.NoError(t, err)
-		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
-		sqlMock2.ExpectPrepare(`INSERT INTO person (first_name, last_name) VALUES (?, ?)`)
-		mockPrimaryDB2 := sqlx.NewDb(mockDB2, "mock2")
-		mockPrimaryDB2Stmt, err := mockPrimaryDB2.PrepareNamed(`INSERT INTO person (first_name, last_name) VALUES (:first_name, :last_name)`)
+	t.Run("unsupported arg", func(t *testing.T) {
+		type Person struct {
+			FirstName string `db:"first_name"`
+			LastName  string `db:"last_name"`
+		}
+		mockDB, sqlMock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock.ExpectPrepare(`SELECT * FROM person WHERE first_name=?`)
+		mockRead := sqlx.NewDb(mockDB, "mock")
+		mockReadStmt, err := mockRead.PrepareNamed(`SELECT * FROM person WHERE first_name=:first_name`)
 		assert.NoError(t, err)
 		stmt := &namedStmt{
-			primaries: []*sqlx.DB{mockPrimaryDB1, mockPrimaryDB2},
-			primaryStmts: map[*sqlx.DB]*sqlx.NamedStmt{
-				mockPrimaryDB1: mockPrimaryDB1Stmt,
-				mockPrimaryDB2: mockPrimaryDB2Stmt,
+			reads: []*sqlx.DB{mockRead},
+			readStmts: map[*sqlx.DB]*sqlx.NamedStmt{
+				mockRead: mockReadStmt,
 			},
 			loadBalancer: &injectedLoadBalancer{
-				db: mockPrimaryDB1,
+				db: mockRead,
 			},
 		}
 
-		inputArg := map[string]interface{}{
-			"first_name": "foo",
-			"last_name":  "bar",
-		}
-		result, err := stmt.ExecContext(context.Background(), inputArg)
+		result := &Person{}
+		err = stmt.Get(result, "foo")
 
-		assert.NoError(t, err)
-		lastInsertIDResult, err := result.LastInsertId()
-		assert.NoError(t, err)
-		lastRowsAffected, err := result.RowsAffected()
-		assert.NoError(t, err)
-		assert.Equal(t, int64(1), lastInsertIDResult)
-		assert.Equal(t, int64(1), lastRowsAffected)
+		assert.ErrorIs(t, err, errUnsupportedNamedStmtArg)
+		assert.ErrorContains(t, err, "but got string")
+		assert.Equal(t, &Person{}, result)
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
 	})
 }
 
-func TestNamedStmt_Get(t *testing.T) {
+func TestNamedStmt_GetContext(t *testing.T) {
 	t.Run("statement not found", func(t *testing.T) {
 		type Person struct {
 			FirstName string `db:"first_name"`
@@ -319,7 +948,7 @@ func TestNamedStmt_Get(t *testing.T) {
 			"first_name": "foo",
 		}
 		result := &Person{}
-		err := stmt.Get(result, inputArg)
+		err := stmt.GetContext(context.Background(), result, inputArg)
 
 		assert.ErrorIs(t, err, errSelectedNamedStmtNotFound)
 	})
@@ -358,7 +987,7 @@ func TestNamedStmt_Get(t *testing.T) {
 			"first_name": "foo",
 		}
 		result := &Person{}
-		err = stmt.Get(result, inputArg)
+		err = stmt.GetContext(context.Background(), result, inputArg)
 
 		assert.ErrorIs(t, err, mockError)
 	})
@@ -397,7 +1026,7 @@ func TestNamedStmt_Get(t *testing.T) {
 			"first_name": "foo",
 		}
 		result := &Person{}
-		err = stmt.Get(result, inputArg)
+		err = stmt.GetContext(context.Background(), result, inputArg)
 
 		assert.NoError(t, err)
 		expected := &Person{
@@ -406,66 +1035,89 @@ func TestNamedStmt_Get(t *testing.T) {
 		}
 		assert.Equal(t, expected, result)
 	})
-}
 
-func TestNamedStmt_GetContext(t *testing.T) {
-	t.Run("statement not found", func(t *testing.T) {
+	t.Run("retry with other readable database on connection error", func(t *testing.T) {
 		type Person struct {
 			FirstName string `db:"first_name"`
 			LastName  string `db:"last_name"`
 		}
+		connErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
 		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
 		sqlMock1.ExpectPrepare(`SELECT * FROM person WHERE first_name=?`).
-			ExpectQuery()
+			ExpectQuery().
+			WithArgs(driver.Value("foo")).
+			WillReturnError(connErr)
 		mockRead1 := sqlx.NewDb(mockDB1, "mock1")
+		mockReadStmt1, err := mockRead1.PrepareNamed(`SELECT * FROM person WHERE first_name=:first_name`)
+		assert.NoError(t, err)
 		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
-		sqlMock2.ExpectPrepare(`SELECT * FROM person WHERE first_name=?`)
+		sqlMock2.ExpectPrepare(`SELECT * FROM person WHERE first_name=?`).
+			ExpectQuery().
+			WithArgs(driver.Value("foo")).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name", "last_name"}).
+				AddRow("foo", "bar"))
 		mockRead2 := sqlx.NewDb(mockDB2, "mock2")
+		mockReadStmt2, err := mockRead2.PrepareNamed(`SELECT * FROM person WHERE first_name=:first_name`)
+		assert.NoError(t, err)
 		stmt := &namedStmt{
-			reads:     []*sqlx.DB{mockRead1, mockRead2},
-			readStmts: map[*sqlx.DB]*sqlx.NamedStmt{},
-			loadBalancer: &injectedLoadBalancer{
-				db: mockRead1,
+			reads: []*sqlx.DB{mockRead1, mockRead2},
+			readStmts: map[*sqlx.DB]*sqlx.NamedStmt{
+				mockRead1: mockReadStmt1,
+				mockRead2: mockReadStmt2,
 			},
+			loadBalancer: &firstLoadBalancer{},
+			readRetries:  1,
 		}
 
 		inputArg := map[string]interface{}{
 			"first_name": "foo",
 		}
 		result := &Person{}
-		err := stmt.GetContext(context.Background(), result, inputArg)
+		err = stmt.GetContext(context.Background(), result, inputArg)
 
-		assert.ErrorIs(t, err, errSelectedNamedStmtNotFound)
+		assert.NoError(t, err)
+		expected := &Person{
+			FirstName: "foo",
+			LastName:  "bar",
+		}
+		assert.Equal(t, expected, result)
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
 	})
 
-	t.Run("failed to execute query", func(t *testing.T) {
+	t.Run("fall back to primary on connection error", func(t *testing.T) {
 		type Person struct {
 			FirstName string `db:"first_name"`
 			LastName  string `db:"last_name"`
 		}
+		connErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
 		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
-		mockError := errors.New("mock error")
 		sqlMock1.ExpectPrepare(`SELECT * FROM person WHERE first_name=?`).
 			ExpectQuery().
 			WithArgs(driver.Value("foo")).
-			WillReturnError(mockError)
-		mockRead1 := sqlx.NewDb(mockDB1, "mock1")
-		mockReadStmt1, err := mockRead1.PrepareNamed(`SELECT * FROM person WHERE first_name=:first_name`)
+			WillReturnError(connErr)
+		mockRead := sqlx.NewDb(mockDB1, "mock1")
+		mockReadStmt, err := mockRead.PrepareNamed(`SELECT * FROM person WHERE first_name=:first_name`)
 		assert.NoError(t, err)
 		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
-		sqlMock2.ExpectPrepare(`SELECT * FROM person WHERE first_name=?`)
-		mockRead2 := sqlx.NewDb(mockDB2, "mock2")
-		mockReadStmt2, err := mockRead2.PrepareNamed(`SELECT * FROM person WHERE first_name=:first_name`)
+		sqlMock2.ExpectPrepare(`SELECT * FROM person WHERE first_name=?`).
+			ExpectQuery().
+			WithArgs(driver.Value("foo")).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name", "last_name"}).
+				AddRow("foo", "bar"))
+		mockPrimary := sqlx.NewDb(mockDB2, "mock2")
+		mockPrimaryStmt, err := mockPrimary.PrepareNamed(`SELECT * FROM person WHERE first_name=:first_name`)
 		assert.NoError(t, err)
 		stmt := &namedStmt{
-			reads: []*sqlx.DB{mockRead1, mockRead2},
-			readStmts: map[*sqlx.DB]*sqlx.NamedStmt{
-				mockRead1: mockReadStmt1,
-				mockRead2: mockReadStmt2,
+			primaries: []*sqlx.DB{mockPrimary},
+			reads:     []*sqlx.DB{mockRead},
+			primaryStmts: map[*sqlx.DB]*sqlx.NamedStmt{
+				mockPrimary: mockPrimaryStmt,
 			},
-			loadBalancer: &injectedLoadBalancer{
-				db: mockRead1,
+			readStmts: map[*sqlx.DB]*sqlx.NamedStmt{
+				mockRead: mockReadStmt,
 			},
+			loadBalancer: &firstLoadBalancer{},
 		}
 
 		inputArg := map[string]interface{}{
@@ -474,20 +1126,27 @@ func TestNamedStmt_GetContext(t *testing.T) {
 		result := &Person{}
 		err = stmt.GetContext(context.Background(), result, inputArg)
 
-		assert.ErrorIs(t, err, mockError)
+		assert.NoError(t, err)
+		expected := &Person{
+			FirstName: "foo",
+			LastName:  "bar",
+		}
+		assert.Equal(t, expected, result)
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
 	})
 
-	t.Run("success", func(t *testing.T) {
+	t.Run("not fall back with no fallback context", func(t *testing.T) {
 		type Person struct {
 			FirstName string `db:"first_name"`
 			LastName  string `db:"last_name"`
 		}
+		connErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
 		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
 		sqlMock1.ExpectPrepare(`SELECT * FROM person WHERE first_name=?`).
 			ExpectQuery().
 			WithArgs(driver.Value("foo")).
-			WillReturnRows(sqlmock.NewRows([]string{"first_name", "last_name"}).
-				AddRow("foo", "bar"))
+			WillReturnError(connErr)
 		mockRead1 := sqlx.NewDb(mockDB1, "mock1")
 		mockReadStmt1, err := mockRead1.PrepareNamed(`SELECT * FROM person WHERE first_name=:first_name`)
 		assert.NoError(t, err)
@@ -496,29 +1155,63 @@ func TestNamedStmt_GetContext(t *testing.T) {
 		mockRead2 := sqlx.NewDb(mockDB2, "mock2")
 		mockReadStmt2, err := mockRead2.PrepareNamed(`SELECT * FROM person WHERE first_name=:first_name`)
 		assert.NoError(t, err)
+		mockDB3, sqlMock3, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock3.ExpectPrepare(`SELECT * FROM person WHERE first_name=?`)
+		mockPrimary := sqlx.NewDb(mockDB3, "mock3")
+		mockPrimaryStmt, err := mockPrimary.PrepareNamed(`SELECT * FROM person WHERE first_name=:first_name`)
+		assert.NoError(t, err)
 		stmt := &namedStmt{
-			reads: []*sqlx.DB{mockRead1, mockRead2},
+			primaries: []*sqlx.DB{mockPrimary},
+			reads:     []*sqlx.DB{mockRead1, mockRead2},
+			primaryStmts: map[*sqlx.DB]*sqlx.NamedStmt{
+				mockPrimary: mockPrimaryStmt,
+			},
 			readStmts: map[*sqlx.DB]*sqlx.NamedStmt{
 				mockRead1: mockReadStmt1,
 				mockRead2: mockReadStmt2,
 			},
-			loadBalancer: &injectedLoadBalancer{
-				db: mockRead1,
-			},
+			loadBalancer: &firstLoadBalancer{},
 		}
 
 		inputArg := map[string]interface{}{
 			"first_name": "foo",
 		}
 		result := &Person{}
-		err = stmt.GetContext(context.Background(), result, inputArg)
+		err = stmt.GetContext(WithNoFallback(context.Background()), result, inputArg)
+
+		assert.ErrorIs(t, err, connErr)
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+		assert.NoError(t, sqlMock3.ExpectationsWereMet())
+	})
 
+	t.Run("unsupported arg", func(t *testing.T) {
+		type Person struct {
+			FirstName string `db:"first_name"`
+			LastName  string `db:"last_name"`
+		}
+		mockDB, sqlMock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock.ExpectPrepare(`SELECT * FROM person WHERE first_name=?`)
+		mockRead := sqlx.NewDb(mockDB, "mock")
+		mockReadStmt, err := mockRead.PrepareNamed(`SELECT * FROM person WHERE first_name=:first_name`)
 		assert.NoError(t, err)
-		expected := &Person{
-			FirstName: "foo",
-			LastName:  "bar",
+		stmt := &namedStmt{
+			reads: []*sqlx.DB{mockRead},
+			readStmts: map[*sqlx.DB]*sqlx.NamedStmt{
+				mockRead: mockReadStmt,
+			},
+			loadBalancer: &injectedLoadBalancer{
+				db: mockRead,
+			},
 		}
-		assert.Equal(t, expected, result)
+
+		result := &Person{}
+		err = stmt.GetContext(context.Background(), result, "foo")
+
+		assert.ErrorIs(t, err, errUnsupportedNamedStmtArg)
+		assert.ErrorContains(t, err, "but got string")
+		assert.Equal(t, &Person{}, result)
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
 	})
 }
 
@@ -966,6 +1659,54 @@ func TestNamedStmt_QueryContext(t *testing.T) {
 			i++
 		}
 	})
+
+	t.Run("fall back to primary on connection error", func(t *testing.T) {
+		connErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock1.ExpectPrepare(`SELECT * FROM person WHERE first_name=?`).
+			ExpectQuery().
+			WithArgs(driver.Value("foo")).
+			WillReturnError(connErr)
+		mockRead := sqlx.NewDb(mockDB1, "mock1")
+		mockReadStmt, err := mockRead.PrepareNamed(`SELECT * FROM person WHERE first_name=:first_name`)
+		assert.NoError(t, err)
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock2.ExpectPrepare(`SELECT * FROM person WHERE first_name=?`).
+			ExpectQuery().
+			WithArgs(driver.Value("foo")).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name", "last_name"}).
+				AddRow("foo", "bar"))
+		mockPrimary := sqlx.NewDb(mockDB2, "mock2")
+		mockPrimaryStmt, err := mockPrimary.PrepareNamed(`SELECT * FROM person WHERE first_name=:first_name`)
+		assert.NoError(t, err)
+		stmt := &namedStmt{
+			primaries: []*sqlx.DB{mockPrimary},
+			reads:     []*sqlx.DB{mockRead},
+			primaryStmts: map[*sqlx.DB]*sqlx.NamedStmt{
+				mockPrimary: mockPrimaryStmt,
+			},
+			readStmts: map[*sqlx.DB]*sqlx.NamedStmt{
+				mockRead: mockReadStmt,
+			},
+			loadBalancer: &firstLoadBalancer{},
+		}
+
+		inputArg := map[string]interface{}{
+			"first_name": "foo",
+		}
+		rows, err := stmt.QueryContext(context.Background(), inputArg)
+
+		assert.NoError(t, err)
+		assert.NotErrorIs(t, err, errSelectedNamedStmtNotFound)
+		assert.True(t, rows.Next())
+		var firstName, lastName string
+		assert.NoError(t, rows.Scan(&firstName, &lastName))
+		assert.Equal(t, "foo", firstName)
+		assert.Equal(t, "bar", lastName)
+		assert.NoError(t, rows.Close())
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
 }
 
 func TestNamedStmt_QueryRow(t *testing.T) {
@@ -1394,6 +2135,56 @@ func TestNamedStmt_QueryRowxContext(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, expected, &actual)
 	})
+
+	t.Run("fall back to primary on connection error", func(t *testing.T) {
+		connErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock1.ExpectPrepare(`SELECT * FROM person WHERE first_name=?`).
+			ExpectQuery().
+			WithArgs(driver.Value("foo")).
+			WillReturnError(connErr)
+		mockRead := sqlx.NewDb(mockDB1, "mock1")
+		mockReadStmt, err := mockRead.PrepareNamed(`SELECT * FROM person WHERE first_name=:first_name`)
+		assert.NoError(t, err)
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock2.ExpectPrepare(`SELECT * FROM person WHERE first_name=?`).
+			ExpectQuery().
+			WithArgs(driver.Value("foo")).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name", "last_name"}).
+				AddRow("foo", "bar"))
+		mockPrimary := sqlx.NewDb(mockDB2, "mock2")
+		mockPrimaryStmt, err := mockPrimary.PrepareNamed(`SELECT * FROM person WHERE first_name=:first_name`)
+		assert.NoError(t, err)
+		stmt := &namedStmt{
+			primaries: []*sqlx.DB{mockPrimary},
+			reads:     []*sqlx.DB{mockRead},
+			primaryStmts: map[*sqlx.DB]*sqlx.NamedStmt{
+				mockPrimary: mockPrimaryStmt,
+			},
+			readStmts: map[*sqlx.DB]*sqlx.NamedStmt{
+				mockRead: mockReadStmt,
+			},
+			loadBalancer: &firstLoadBalancer{},
+		}
+
+		inputArg := map[string]interface{}{
+			"first_name": "foo",
+		}
+		type Person struct {
+			FirstName string `db:"first_name"`
+			LastName  string `db:"last_name"`
+		}
+		result := &Person{}
+		row := stmt.QueryRowxContext(context.Background(), inputArg)
+		assert.NotNil(t, row)
+		err = row.StructScan(result)
+
+		assert.NoError(t, err)
+		assert.NotErrorIs(t, err, errSelectedNamedStmtNotFound)
+		assert.Equal(t, &Person{FirstName: "foo", LastName: "bar"}, result)
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
 }
 
 func TestNamedStmt_Queryx(t *testing.T) {
@@ -1874,6 +2665,54 @@ func TestNamedStmt_SelectContext(t *testing.T) {
 		}
 		assert.Equal(t, expected, result)
 	})
+
+	t.Run("fall back to primary on connection error", func(t *testing.T) {
+		connErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock1.ExpectPrepare(`SELECT * FROM person WHERE first_name=?`).
+			ExpectQuery().
+			WithArgs(driver.Value("foo")).
+			WillReturnError(connErr)
+		mockRead := sqlx.NewDb(mockDB1, "mock1")
+		mockReadStmt, err := mockRead.PrepareNamed(`SELECT * FROM person WHERE first_name=:first_name`)
+		assert.NoError(t, err)
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock2.ExpectPrepare(`SELECT * FROM person WHERE first_name=?`).
+			ExpectQuery().
+			WithArgs(driver.Value("foo")).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name", "last_name"}).
+				AddRow("foo", "bar"))
+		mockPrimary := sqlx.NewDb(mockDB2, "mock2")
+		mockPrimaryStmt, err := mockPrimary.PrepareNamed(`SELECT * FROM person WHERE first_name=:first_name`)
+		assert.NoError(t, err)
+		stmt := &namedStmt{
+			primaries: []*sqlx.DB{mockPrimary},
+			reads:     []*sqlx.DB{mockRead},
+			primaryStmts: map[*sqlx.DB]*sqlx.NamedStmt{
+				mockPrimary: mockPrimaryStmt,
+			},
+			readStmts: map[*sqlx.DB]*sqlx.NamedStmt{
+				mockRead: mockReadStmt,
+			},
+			loadBalancer: &firstLoadBalancer{},
+		}
+
+		inputArg := map[string]interface{}{
+			"first_name": "foo",
+		}
+		type Person struct {
+			FirstName string `db:"first_name"`
+			LastName  string `db:"last_name"`
+		}
+		var result []Person
+		err = stmt.SelectContext(context.Background(), &result, inputArg)
+
+		assert.NoError(t, err)
+		assert.NotErrorIs(t, err, errSelectedNamedStmtNotFound)
+		assert.Equal(t, []Person{{FirstName: "foo", LastName: "bar"}}, result)
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
 }
 
 func TestNamedStmt_Unsafe(t *testing.T) {
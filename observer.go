@@ -0,0 +1,64 @@
+package dbresolver
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+)
+
+// errors.
+var (
+	errQueryPanicked = errors.New("dbresolver: query panicked")
+)
+
+// QueryInfo describes a query observed by a QueryObserver.
+type QueryInfo struct {
+	// Op is the name of the method without the Context suffix. e.g. "Query", "Exec".
+	Op string
+	// Role is RolePrimary or RoleRead.
+	Role string
+	// DB is the name of the database given by WithSecondaryDBConfigs,
+	// or the label of the database otherwise. e.g. "primary[0]", "secondary[1]", "canary".
+	DB    string
+	Query string
+	// Duration is how long the query took. It is zero in BeforeQuery.
+	Duration time.Duration
+}
+
+// QueryObserver observes the queries of the resolver, which is the single extension point
+// for the logging, the metrics and the tracing.
+type QueryObserver interface {
+	// BeforeQuery is called before the query runs on the chosen database.
+	// The returned context is passed to AfterQuery, e.g. to carry a tracing span,
+	// but the query itself runs with the context given to the resolver.
+	BeforeQuery(ctx context.Context, info QueryInfo) context.Context
+	// AfterQuery is called after the query with the error of it, even if the query panics.
+	AfterQuery(ctx context.Context, info QueryInfo, err error)
+}
+
+// beforeQuery calls BeforeQuery of the observer and returns the context and the info to pass to AfterQuery.
+func (r *dbResolver) beforeQuery(ctx context.Context, req request, role string, db *sqlx.DB) (context.Context, QueryInfo) {
+	info := QueryInfo{
+		Op:    req.op,
+		Role:  role,
+		DB:    r.dbName(db),
+		Query: req.query,
+	}
+	if observed := r.observer.BeforeQuery(ctx, info); observed != nil {
+		ctx = observed
+	}
+	return ctx, info
+}
+
+// dbName returns the name of the database for QueryInfo.
+func (r *dbResolver) dbName(db *sqlx.DB) string {
+	if containsDB(r.primaries, db) || r.canary != nil && r.canary.db == db {
+		return r.primaryLabel(db)
+	}
+	if cfg, ok := r.SecondaryDBConfig(db); ok && cfg.Name != "" {
+		return cfg.Name
+	}
+	return dbLabel("secondary", r.shared().secondaryDBs(), db)
+}
@@ -1,13 +1,75 @@
 package dbresolver
 
 import (
+	"context"
+	"math/rand"
+	"time"
+
 	"github.com/jmoiron/sqlx"
 )
 
 // Options is the config for dbResolver.
 type Options struct {
-	SecondaryDBs []*sqlx.DB
-	LoadBalancer LoadBalancer
+	SecondaryDBs    []*sqlx.DB
+	SecondaryGroups map[string][]*sqlx.DB
+	LoadBalancer    LoadBalancer
+
+	LatencyHistogram bool
+	WriteRetries     int
+	ReadCoalescing   bool
+
+	ConnValidatorMaxIdle      time.Duration
+	N1Detector                *N1DetectorConfig
+	Clock                     func() time.Time
+	DefaultQueryTimeout       time.Duration
+	ReadRetries               int
+	HealthCheckInterval       time.Duration
+	PoolChangeListener        func(event PoolChangeEvent)
+	RandSource                rand.Source
+	StrictReadSeparation      bool
+	HedgeDelay                time.Duration
+	LeaderSelector            func(ctx context.Context, primaries []*sqlx.DB) (*sqlx.DB, error)
+	RouteHook                 func(ctx context.Context, op string, role string, db *sqlx.DB)
+	MaxPreparedStatements     int
+	CanaryPrimary             *sqlx.DB
+	CanaryFraction            float64
+	CandidateFilter           func(ctx context.Context, role string, dbs []*sqlx.DB) []*sqlx.DB
+	ReadYourWritesWindow      time.Duration
+	HashShardFunc             func(args []interface{}) int
+	EffectiveQueryHook        func(ctx context.Context, role string, query string, args []interface{})
+	PrimaryMaxOpenConns       int
+	ReadMaxOpenConns          int
+	PrimaryMaxIdleConns       int
+	ReadMaxIdleConns          int
+	Logger                    func(ctx context.Context, event string, err error)
+	ConnectionErrorClassifier func(err error) bool
+	TolerantPrepare           bool
+	FanOutConcurrency         int
+	PrimaryReadFallbackOnly   bool
+	ReadPreference            ReadPreference
+	PreferPrimaryMaxInUse     int
+	DefaultContext            context.Context
+	CircuitBreakerFailures    int
+	CircuitBreakerCooldown    time.Duration
+	QueryRouting              bool
+	SecondaryDBConfigs        []SecondaryDBConfig
+	AllowMixedDrivers         bool
+	MapperFunc                func(string) string
+	ReadOnlyEnforcement       bool
+	PingOnStartContext        context.Context
+	Observer                  QueryObserver
+	QueryTagFromContext       func(ctx context.Context) string
+	ReadSampling              map[*sqlx.DB]float64
+	WrappedErrors             bool
+
+	secondaryGroupNames []string
+}
+
+// N1DetectorConfig is the config of the N+1 query detector.
+type N1DetectorConfig struct {
+	Threshold int
+	Window    time.Duration
+	OnDetect  func(query string, count int)
 }
 
 // OptionFunc is a function that configures a Options.
@@ -20,9 +82,496 @@ func WithSecondaryDBs(dbs ...*sqlx.DB) OptionFunc {
 	}
 }
 
+// WithSecondaryGroup adds the named group of the secondary databases.
+// The databases of the group are also used as the secondary databases.
+// The resolver reading only from the group can be obtained by DBResolver.GroupResolver.
+func WithSecondaryGroup(name string, dbs ...*sqlx.DB) OptionFunc {
+	return func(opt *Options) {
+		if opt.SecondaryGroups == nil {
+			opt.SecondaryGroups = make(map[string][]*sqlx.DB)
+		}
+		if _, ok := opt.SecondaryGroups[name]; !ok {
+			opt.secondaryGroupNames = append(opt.secondaryGroupNames, name)
+		}
+		opt.SecondaryGroups[name] = dbs
+	}
+}
+
 // WithLoadBalancer sets the load balancer.
 func WithLoadBalancer(loadBalancer LoadBalancer) OptionFunc {
 	return func(opt *Options) {
 		opt.LoadBalancer = loadBalancer
 	}
 }
+
+// WithLatencyHistogram enables the in-memory latency histogram per role.
+// The percentiles can be read by DBResolver.LatencyPercentiles.
+func WithLatencyHistogram() OptionFunc {
+	return func(opt *Options) {
+		opt.LatencyHistogram = true
+	}
+}
+
+// WithWriteRetry sets the maximum number of retries of a write.
+// If a write fails with a connection error, it is retried with the other primary databases.
+// The beginnings of the transactions and the writes of the named statements prepared by the resolver
+// are retried as well.
+// Be careful that a retried write may be executed twice if the connection was lost after the execution.
+func WithWriteRetry(retries int) OptionFunc {
+	return func(opt *Options) {
+		opt.WriteRetries = retries
+	}
+}
+
+// WithReadCoalescing enables collapsing the identical concurrent reads into one read.
+// Get, GetContext, Select and SelectContext with the same query, arguments and destination type
+// share one underlying query, and the scanned result is copied into the destination of each caller.
+// Values referenced by pointers in the result are shared by the callers, so they must be treated as read-only.
+// The shared query uses the values of the context of the first caller but is not canceled with it,
+// and each caller stops waiting for it when its own context is done.
+// The reads whose context changes the routing, e.g. by WithForcePrimary or WithStickyRead, are not collapsed.
+func WithReadCoalescing() OptionFunc {
+	return func(opt *Options) {
+		opt.ReadCoalescing = true
+	}
+}
+
+// WithConnValidator enables validating a readable database before a read
+// when the database has not been used for longer than the given duration.
+// The validation is a ping, and another readable database is chosen if the validation fails.
+func WithConnValidator(maxIdle time.Duration) OptionFunc {
+	return func(opt *Options) {
+		opt.ConnValidatorMaxIdle = maxIdle
+	}
+}
+
+// WithN1Detector enables detecting the same query executed many times in quick succession within one context.
+// onDetect is called with the normalized query when the query is executed threshold times within the window
+// using the same context. The queries with context.Background, context.TODO or the default context of
+// WithDefaultContext are not observed, because they are shared by the unrelated callers.
+// It is a diagnostic aid for development and is off by default.
+func WithN1Detector(threshold int, window time.Duration, onDetect func(query string, count int)) OptionFunc {
+	return func(opt *Options) {
+		if threshold <= 0 || onDetect == nil {
+			return
+		}
+		opt.N1Detector = &N1DetectorConfig{
+			Threshold: threshold,
+			Window:    window,
+			OnDetect:  onDetect,
+		}
+	}
+}
+
+// WithClock sets the function returning the current time, which is used by the time-based features.
+// It is useful to make the time-based features deterministic in tests.
+func WithClock(now func() time.Time) OptionFunc {
+	return func(opt *Options) {
+		opt.Clock = now
+	}
+}
+
+// WithDefaultQueryTimeout sets the timeout of the queries run by the Context methods whose context has no deadline.
+// The methods returning rows or a row like QueryContext and QueryRowContext are not bounded by it,
+// because the rows outlive the call and the timeout could not be released until it expires.
+// Give them a context with a deadline instead.
+// If it is zero, the queries have no default timeout.
+func WithDefaultQueryTimeout(d time.Duration) OptionFunc {
+	return func(opt *Options) {
+		opt.DefaultQueryTimeout = d
+	}
+}
+
+// WithReadRetries sets the number of retries of a read with the other readable databases
+// when the read fails with a connection error.
+// The retried databases are chosen by the load balancer without the already failed databases.
+// If all the retries fail, the read falls back to a primary database.
+// The single-row reads like QueryRowx are retried when the error of the returned row is a connection error.
+func WithReadRetries(n int) OptionFunc {
+	return func(opt *Options) {
+		opt.ReadRetries = n
+	}
+}
+
+// WithHealthCheck enables pinging the secondary databases on every interval in the background.
+// The secondary databases failing the ping are excluded from the reads until the ping succeeds again.
+// If all the secondary databases fail the ping, none of them is excluded.
+// The background health check is stopped by Close.
+func WithHealthCheck(interval time.Duration) OptionFunc {
+	return func(opt *Options) {
+		opt.HealthCheckInterval = interval
+	}
+}
+
+// WithPoolChangeListener sets the listener called whenever the read pool changes,
+// like a secondary database being added, removed, or marked unhealthy or healthy again by the health check.
+// The listener is called synchronously, so it must not block.
+func WithPoolChangeListener(listener func(event PoolChangeEvent)) OptionFunc {
+	return func(opt *Options) {
+		opt.PoolChangeListener = listener
+	}
+}
+
+// WithRandSource sets the source of the randomness used by all the random-based features of the resolver
+// like the random, weighted and saturation-aware load balancers.
+// Each feature gets its own generator on top of the source, which is guarded by a mutex.
+// The source is set when the resolver is created, so the randomness consumed before it, e.g. by a load balancer
+// used elsewhere, is not affected.
+// It is useful to make the resolver deterministic in tests.
+func WithRandSource(src rand.Source) OptionFunc {
+	return func(opt *Options) {
+		opt.RandSource = src
+	}
+}
+
+// WithStrictReadSeparation disables falling back to a primary database when a read fails with a connection error.
+// The error of the readable database is returned as it is.
+// If the primary databases are readable by the ReadWrite policy, they are still chosen for the reads.
+// The readable databases are checked on every read, so the reads return errNoDBToRead
+// once all the secondary databases are removed by RemoveSecondary with the WriteOnly policy,
+// while the reads without the strict read separation choose a primary database instead.
+// It is different from NewDBResolver, which returns the error if there are no readable databases at the construction.
+func WithStrictReadSeparation() OptionFunc {
+	return func(opt *Options) {
+		opt.StrictReadSeparation = true
+	}
+}
+
+// WithLeaderSelector sets the function choosing the leader from the primary databases for the writes,
+// the transactions and the connections, which is used instead of the load balancer.
+// If it returns an error, the error is returned to the caller without writing.
+// It is useful for the clusters where the leader changes.
+func WithLeaderSelector(selector func(ctx context.Context, primaries []*sqlx.DB) (*sqlx.DB, error)) OptionFunc {
+	return func(opt *Options) {
+		opt.LeaderSelector = selector
+	}
+}
+
+// WithRouteHook sets the hook called with the chosen database whenever a read or a write chooses a database.
+// op is the name of the method without the Context suffix like "Query" or "Exec",
+// and role is RolePrimary or RoleRead. Retries and fallbacks call the hook again with the newly chosen database.
+// It is useful to debug the replication lag and the traffic skew.
+func WithRouteHook(hook func(ctx context.Context, op string, role string, db *sqlx.DB)) OptionFunc {
+	return func(opt *Options) {
+		opt.RouteHook = hook
+	}
+}
+
+// WithHedgedReads enables hedging the reads of Get, GetContext, Select and SelectContext.
+// If a read does not finish within the delay, the same read is issued to another readable database
+// and the result of the first successful one is used. The other one is cancelled if it uses the context.
+// The hedging can be disabled per call by WithoutHedging.
+func WithHedgedReads(delay time.Duration) OptionFunc {
+	return func(opt *Options) {
+		opt.HedgeDelay = delay
+	}
+}
+
+// WithMaxPreparedStatements limits the number of the outstanding prepared statements across all the databases.
+// A statement prepared by Prepare, PrepareNamed, Preparex and their context variants counts
+// once for every database it is prepared on.
+// When preparing would exceed the limit, the least recently used statements are closed first,
+// so using a closed statement afterwards returns an error.
+// If a statement alone exceeds the limit, preparing it returns an error.
+func WithMaxPreparedStatements(n int) OptionFunc {
+	return func(opt *Options) {
+		opt.MaxPreparedStatements = n
+	}
+}
+
+// WithCanaryPrimary routes the fraction of the writes to the canary primary database
+// and the rest to the primary databases, e.g. to validate a newly promoted primary database.
+// The fraction must be between 0 and 1. The canary is chosen by the generator of WithRandSource if given.
+// If a write to the canary fails with a connection error, the retries by WithWriteRetry choose the primary databases.
+// The transactions and the connections are not routed to the canary.
+func WithCanaryPrimary(db *sqlx.DB, fraction float64) OptionFunc {
+	return func(opt *Options) {
+		opt.CanaryPrimary = db
+		opt.CanaryFraction = fraction
+	}
+}
+
+// WithCandidateFilter sets the filter narrowing the candidate databases before the load balancer chooses one of them.
+// It is called for every read and write with the role of the candidates, which is RolePrimary or RoleRead.
+// If it filters out all the candidates, the read or the write returns an error without running.
+// It is the most general extension point of the routing, e.g. to route by a tenant in the context.
+func WithCandidateFilter(filter func(ctx context.Context, role string, dbs []*sqlx.DB) []*sqlx.DB) OptionFunc {
+	return func(opt *Options) {
+		opt.CandidateFilter = filter
+	}
+}
+
+// WithReadYourWrites routes the reads to the primary database written most recently
+// for the window after the write, so that the reads see the write without being affected by the replication lag.
+// Only the writes of the resolver like Exec and NamedExec are tracked, not the transactions and the connections.
+// After the window lapses, the reads are routed to the readable databases again.
+func WithReadYourWrites(window time.Duration) OptionFunc {
+	return func(opt *Options) {
+		opt.ReadYourWritesWindow = window
+	}
+}
+
+// WithHashShardedReads routes the reads to the readable database owning the shard of the query arguments,
+// for the readable databases each holding a subset of the data.
+// shardFunc maps the arguments to the index of the shard in the readable databases, which are the secondary
+// databases followed by the primary databases if the read/write policy is ReadWrite.
+// If the index is out of range, the read falls back to the whole pool.
+// The reads routed to a shard are neither retried on the other databases nor hedged,
+// because only the shard has the data.
+func WithHashShardedReads(shardFunc func(args []interface{}) int) OptionFunc {
+	return func(opt *Options) {
+		opt.HashShardFunc = shardFunc
+	}
+}
+
+// WithEffectiveQueryHook sets the hook called with the query and the arguments right before they are sent to
+// the chosen database by a read or a write. role is RolePrimary or RoleRead.
+// The named queries are given compiled into the bindvar type of the database, e.g. "$1" for PostgreSQL.
+// It is useful to debug what is actually sent to the database.
+func WithEffectiveQueryHook(hook func(ctx context.Context, role string, query string, args []interface{})) OptionFunc {
+	return func(opt *Options) {
+		opt.EffectiveQueryHook = hook
+	}
+}
+
+// WithPrimaryMaxOpenConns sets the maximum number of the open connections of each primary database.
+// Zero leaves the default. Calling SetMaxOpenConns after the resolver is created overrides it.
+func WithPrimaryMaxOpenConns(n int) OptionFunc {
+	return func(opt *Options) {
+		opt.PrimaryMaxOpenConns = n
+	}
+}
+
+// WithReadMaxOpenConns sets the maximum number of the open connections of each secondary database.
+// The primary databases used for the reads keep the number set by WithPrimaryMaxOpenConns.
+// Zero leaves the default. Calling SetMaxOpenConns after the resolver is created overrides it.
+func WithReadMaxOpenConns(n int) OptionFunc {
+	return func(opt *Options) {
+		opt.ReadMaxOpenConns = n
+	}
+}
+
+// WithPrimaryMaxIdleConns sets the maximum number of the idle connections of each primary database.
+// Zero leaves the default, and a negative number retains no idle connections.
+// Calling SetMaxIdleConns after the resolver is created overrides it.
+func WithPrimaryMaxIdleConns(n int) OptionFunc {
+	return func(opt *Options) {
+		opt.PrimaryMaxIdleConns = n
+	}
+}
+
+// WithReadMaxIdleConns sets the maximum number of the idle connections of each secondary database.
+// The primary databases used for the reads keep the number set by WithPrimaryMaxIdleConns.
+// Zero leaves the default, and a negative number retains no idle connections.
+// Calling SetMaxIdleConns after the resolver is created overrides it.
+func WithReadMaxIdleConns(n int) OptionFunc {
+	return func(opt *Options) {
+		opt.ReadMaxIdleConns = n
+	}
+}
+
+// WithLogger sets the logger called whenever a read falls back to a primary database
+// because the readable databases fail with a connection error.
+// event is "read_fallback_" followed by the operation in snake case, e.g. "read_fallback_query",
+// and err is the connection error of the readable database.
+// It is also called for the databases skipped by WithTolerantPrepare.
+func WithLogger(logger func(ctx context.Context, event string, err error)) OptionFunc {
+	return func(opt *Options) {
+		opt.Logger = logger
+	}
+}
+
+// WithConnectionErrorClassifier sets the classifier reporting whether the error of a query is a connection error,
+// which makes the read retried with the other readable databases or fall back to a primary database,
+// and the write or the beginning of a transaction retried by WithWriteRetry with the other primary databases.
+// It is called only with non-nil errors. By default, the network errors are the connection errors.
+// It is useful to opt the transient errors of the driver in, e.g. the connection resets.
+func WithConnectionErrorClassifier(classifier func(err error) bool) OptionFunc {
+	return func(opt *Options) {
+		opt.ConnectionErrorClassifier = classifier
+	}
+}
+
+// WithTolerantPrepare makes the preparation of the statements skip the databases failing to prepare them
+// as long as the statements are prepared on at least one primary database and one readable database.
+// The statements choose only the databases on which they are prepared.
+// The errors of the skipped databases are reported to the logger set by WithLogger with the "prepare_skipped" event.
+func WithTolerantPrepare() OptionFunc {
+	return func(opt *Options) {
+		opt.TolerantPrepare = true
+	}
+}
+
+// WithFanOutConcurrency sets the maximum number of the concurrent queries of QueryEachRead and GetEachRead
+// and the concurrent pings of Ping, PingContext, PingPrimaries and PingReads.
+// Zero or a negative number means no limit, which queries or pings all the databases at once.
+func WithFanOutConcurrency(n int) OptionFunc {
+	return func(opt *Options) {
+		opt.FanOutConcurrency = n
+	}
+}
+
+// WithPrimaryReadFallbackOnly keeps the primary databases of the ReadWrite policy out of the read pool,
+// so the reads are served by the secondary databases and fall back to a primary database only on the connection errors.
+// Unlike the WriteOnly policy, the resolver can be created without the secondary databases,
+// in which case the primary databases are readable as usual.
+func WithPrimaryReadFallbackOnly() OptionFunc {
+	return func(opt *Options) {
+		opt.PrimaryReadFallbackOnly = true
+	}
+}
+
+// WithReadPreference sets the preference of the databases serving the reads. The default is PreferReplica.
+// PreferPrimary and PrimaryOnly disable the hedged reads, and ReadReplicaOnly enables the strict read separation
+// and keeps the primary databases out of the read pool even with the ReadWrite policy.
+// The views of the resolver like GroupResolver read from their secondary databases regardless of the preference.
+func WithReadPreference(p ReadPreference) OptionFunc {
+	return func(opt *Options) {
+		opt.ReadPreference = p
+	}
+}
+
+// WithPreferPrimaryMaxInUse sets the number of the connections in use of a primary database
+// above which the reads spill to the readable databases by the PreferPrimary preference.
+// By default, they spill only when all the open connections allowed by SetMaxOpenConns are in use.
+func WithPreferPrimaryMaxInUse(n int) OptionFunc {
+	return func(opt *Options) {
+		opt.PreferPrimaryMaxInUse = n
+	}
+}
+
+// WithDefaultContext sets the context which the methods without a context, e.g. Get and Exec, use
+// instead of context.Background() to route the queries, so that the values of it like the tracing baggage
+// are visible to the hooks, the load balancer and the candidate filter.
+// The methods with a context still use the given context.
+// The context should not carry a deadline or be canceled, because it is shared by all the calls.
+func WithDefaultContext(ctx context.Context) OptionFunc {
+	return func(opt *Options) {
+		opt.DefaultContext = ctx
+	}
+}
+
+// WithLoadBalancerSeed is the shorthand for WithRandSource(rand.NewSource(seed)),
+// which makes the load balancer and the other random-based features deterministic with the given seed.
+func WithLoadBalancerSeed(seed int64) OptionFunc {
+	return WithRandSource(rand.NewSource(seed))
+}
+
+// WithReadCircuitBreaker enables the circuit breaker of each readable database.
+// After the given number of the consecutive connection errors of the reads, the database is excluded from the reads
+// until the cooldown elapses. Then a probe read is let through, and the database is included again if it succeeds,
+// or excluded for another cooldown otherwise. The errors are classified by WithConnectionErrorClassifier if given.
+// If the breakers of all the readable databases are open, the reads fall back to a primary database
+// unless the strict read separation is enabled. The views of the resolver like GroupResolver share the breakers.
+func WithReadCircuitBreaker(failures int, cooldown time.Duration) OptionFunc {
+	return func(opt *Options) {
+		opt.CircuitBreakerFailures = failures
+		opt.CircuitBreakerCooldown = cooldown
+	}
+}
+
+// WithQueryRouting sets whether the queries of Query, QueryContext, QueryRow, QueryRowContext,
+// QueryRowx, QueryRowxContext, Queryx and QueryxContext are routed by the kind of the statement.
+// If enabled, the writes like INSERT ... RETURNING or WITH ... INSERT are routed to a primary database,
+// and so are the statements which are not known to be reads, to be conservative.
+// The comments and the whitespaces before the statement are skipped. It is disabled by default.
+func WithQueryRouting(enabled bool) OptionFunc {
+	return func(opt *Options) {
+		opt.QueryRouting = enabled
+	}
+}
+
+// WithSecondaryDBConfigs sets the secondary databases with their metadata.
+// It replaces the secondary databases set by WithSecondaryDBs, which are considered to have weight 1 and no name.
+// The metadata can be looked up by DBResolver.SecondaryDBConfig, e.g. to report the name in the route hook.
+// If the load balancer is not given, the WeightedLoadBalancer with the weights is used.
+func WithSecondaryDBConfigs(cfgs ...SecondaryDBConfig) OptionFunc {
+	return func(opt *Options) {
+		dbs := make([]*sqlx.DB, 0, len(cfgs))
+		for _, cfg := range cfgs {
+			dbs = append(dbs, cfg.DB)
+		}
+		opt.SecondaryDBs = dbs
+		opt.SecondaryDBConfigs = cfgs
+	}
+}
+
+// WithAllowMixedDrivers allows the databases whose drivers are inconsistent, e.g. a "postgres" primary database
+// with a "mysql" secondary database. Without it, NewDBResolver returns an error for them,
+// because the queries are rebound and the named queries are bound by the driver of any of the databases.
+// The databases added by DBResolver.AddSecondary are not validated.
+func WithAllowMixedDrivers() OptionFunc {
+	return func(opt *Options) {
+		opt.AllowMixedDrivers = true
+	}
+}
+
+// WithMapperFunc sets the mapper function of the all primary and secondary databases on NewDBResolver,
+// which maps the names of the struct fields to the column names.
+// The secondary databases added by DBResolver.AddSecondary inherit it as well.
+// It can be changed later by DBResolver.MapperFunc.
+func WithMapperFunc(mf func(string) string) OptionFunc {
+	return func(opt *Options) {
+		opt.MapperFunc = mf
+	}
+}
+
+// WithReadOnlyEnforcement rejects the write statements like UPDATE routed to the readable databases,
+// e.g. by Query or QueryRow, with errWriteOnReadReplica instead of executing them.
+// It prevents the accidental writes which would fail cryptically on a read replica.
+// The reads forced to a primary database, e.g. by WithForcePrimary, are not rejected.
+// Combined with WithQueryRouting, the writes of the Query methods are routed to a primary database instead.
+func WithReadOnlyEnforcement() OptionFunc {
+	return func(opt *Options) {
+		opt.ReadOnlyEnforcement = true
+	}
+}
+
+// WithPingOnStart makes NewDBResolver ping the all primary and secondary databases with the given context,
+// and return the aggregated errors of the pings instead of the resolver if any of them fails.
+// It surfaces the broken databases like a typo in the DSN on the construction rather than on the first query.
+// The number of the concurrent pings is bounded by WithFanOutConcurrency.
+func WithPingOnStart(ctx context.Context) OptionFunc {
+	return func(opt *Options) {
+		opt.PingOnStartContext = ctx
+	}
+}
+
+// WithObserver sets the observer called before and after every query of the reads and the writes
+// on the chosen database. Retries and fallbacks call it again with the newly chosen database.
+func WithObserver(o QueryObserver) OptionFunc {
+	return func(opt *Options) {
+		opt.Observer = o
+	}
+}
+
+// WithQueryTagFromContext prepends the tag extracted from the context by fn to the queries as a comment
+// like "/* tag */ SELECT ...", e.g. to correlate the queries in the database with the traces of the requests.
+// The queries are not tagged if fn returns an empty string.
+// The prepared statements are not tagged, because the tag would differ by the context of each execution.
+func WithQueryTagFromContext(fn func(ctx context.Context) string) OptionFunc {
+	return func(opt *Options) {
+		opt.QueryTagFromContext = fn
+	}
+}
+
+// WithReadSampling sets the fraction of the reads routed to each of the given readable databases,
+// e.g. 0.05 to validate a new replica with 5% of the reads before giving it the full share.
+// A sampled database takes the fraction of the reads regardless of the load balancer,
+// and the rest of the reads are balanced among the databases without a fraction.
+// Unlike the weights, the fraction of 0 stops the reads to the database right away.
+// The fractions must be between 0 and 1, and their sum should not exceed 1.
+func WithReadSampling(rates map[*sqlx.DB]float64) OptionFunc {
+	return func(opt *Options) {
+		opt.ReadSampling = rates
+	}
+}
+
+// WithWrappedErrors wraps the errors of the queries with QueryError identifying the database which returned them.
+// The errors of the resolver itself like errNoDBToRead, and the errors of scanning the rows
+// after the query returned, e.g. by Row.Scan, are not wrapped.
+func WithWrappedErrors() OptionFunc {
+	return func(opt *Options) {
+		opt.WrappedErrors = true
+	}
+}
@@ -0,0 +1,52 @@
+package dbresolver
+
+import (
+	"context"
+	"sync"
+)
+
+// writeGate blocks writes while it is paused.
+// The zero value is not paused.
+type writeGate struct {
+	mu     sync.Mutex
+	resume chan struct{}
+}
+
+// pause pauses the gate. Pausing an already paused gate does nothing.
+func (g *writeGate) pause() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.resume == nil {
+		g.resume = make(chan struct{})
+	}
+}
+
+// unpause resumes the gate and wakes up all the waiting writes.
+func (g *writeGate) unpause() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.resume != nil {
+		close(g.resume)
+		g.resume = nil
+	}
+}
+
+// wait blocks until the gate is resumed or the context is done.
+func (g *writeGate) wait(ctx context.Context) error {
+	g.mu.Lock()
+	resume := g.resume
+	g.mu.Unlock()
+
+	if resume == nil {
+		return nil
+	}
+
+	select {
+	case <-resume:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
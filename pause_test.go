@@ -0,0 +1,110 @@
+package dbresolver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDBResolver_PauseWrites(t *testing.T) {
+	t.Run("write completes after resume", func(t *testing.T) {
+		mockDB, sqlMock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock.ExpectExec(`INSERT INTO person (first_name) VALUES (?)`).
+			WithArgs("foo").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mockPrimaryDB := sqlx.NewDb(mockDB, "mock")
+		r := &dbResolver{
+			primaries: []*sqlx.DB{mockPrimaryDB},
+			loadBalancer: &injectedLoadBalancer{
+				db: mockPrimaryDB,
+			},
+		}
+
+		r.PauseWrites()
+		done := make(chan error, 1)
+		go func() {
+			_, err := r.ExecContext(context.Background(), `INSERT INTO person (first_name) VALUES (?)`, "foo")
+			done <- err
+		}()
+
+		select {
+		case <-done:
+			t.Fatal("write must be blocked while paused")
+		case <-time.After(50 * time.Millisecond):
+		}
+		r.ResumeWrites()
+
+		select {
+		case err := <-done:
+			assert.NoError(t, err)
+		case <-time.After(time.Second):
+			t.Fatal("write must be completed after resume")
+		}
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("write times out if not resumed", func(t *testing.T) {
+		mockDB, sqlMock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		mockPrimaryDB := sqlx.NewDb(mockDB, "mock")
+		r := &dbResolver{
+			primaries: []*sqlx.DB{mockPrimaryDB},
+			loadBalancer: &injectedLoadBalancer{
+				db: mockPrimaryDB,
+			},
+		}
+
+		r.PauseWrites()
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		_, err := r.ExecContext(ctx, `INSERT INTO person (first_name) VALUES (?)`, "foo")
+
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("begin times out if not resumed", func(t *testing.T) {
+		mockDB, sqlMock, _ := sqlmock.New()
+		mockPrimaryDB := sqlx.NewDb(mockDB, "mock")
+		r := &dbResolver{
+			primaries: []*sqlx.DB{mockPrimaryDB},
+			loadBalancer: &injectedLoadBalancer{
+				db: mockPrimaryDB,
+			},
+		}
+
+		r.PauseWrites()
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		tx, err := r.BeginTxx(ctx, nil)
+
+		assert.Nil(t, tx)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("reads are not paused", func(t *testing.T) {
+		mockDB, sqlMock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock.ExpectQuery(`SELECT * FROM person`).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("foo"))
+		mockReadDB := sqlx.NewDb(mockDB, "mock")
+		r := &dbResolver{
+			primaries: []*sqlx.DB{mockReadDB},
+			reads:     []*sqlx.DB{mockReadDB},
+			loadBalancer: &injectedLoadBalancer{
+				db: mockReadDB,
+			},
+		}
+
+		r.PauseWrites()
+		defer r.ResumeWrites()
+		var firstName string
+		err := r.GetContext(context.Background(), &firstName, `SELECT * FROM person`)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "foo", firstName)
+	})
+}
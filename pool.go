@@ -0,0 +1,117 @@
+package dbresolver
+
+import (
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// PoolChangeKind is the kind of the change of the read pool.
+type PoolChangeKind string
+
+// PoolChangeKinds.
+const (
+	PoolChangeAdded     PoolChangeKind = "added"
+	PoolChangeRemoved   PoolChangeKind = "removed"
+	PoolChangeHealthy   PoolChangeKind = "healthy"
+	PoolChangeUnhealthy PoolChangeKind = "unhealthy"
+)
+
+// PoolChangeEvent describes a change of the read pool.
+type PoolChangeEvent struct {
+	Kind PoolChangeKind
+	// DB is the secondary database which changed.
+	DB *sqlx.DB
+	// Secondaries is the number of the secondary databases after the change.
+	Secondaries int
+	// HealthySecondaries is the number of the secondary databases considered healthy after the change.
+	HealthySecondaries int
+}
+
+// notifyPoolChange notifies the pool change listener of the change of the given database if the listener is set.
+func (r *dbResolver) notifyPoolChange(kind PoolChangeKind, db *sqlx.DB) {
+	if r.poolChangeListener == nil {
+		return
+	}
+	secondaries := r.secondaryDBs()
+	r.poolChangeListener(PoolChangeEvent{
+		Kind:               kind,
+		DB:                 db,
+		Secondaries:        len(secondaries),
+		HealthySecondaries: r.health.countHealthy(secondaries),
+	})
+}
+
+// onHealthChange notifies the pool change listener of the change of the health of the given database.
+func (r *dbResolver) onHealthChange(db *sqlx.DB, healthy bool) {
+	if healthy {
+		r.notifyPoolChange(PoolChangeHealthy, db)
+	} else {
+		r.notifyPoolChange(PoolChangeUnhealthy, db)
+	}
+}
+
+// readDBs returns the readable databases.
+// The returned slice must not be modified because it may be shared with the other callers.
+func (r *dbResolver) readDBs() []*sqlx.DB {
+	r.poolMu.RLock()
+	defer r.poolMu.RUnlock()
+
+	return r.reads
+}
+
+// secondaryDBs returns the secondary databases.
+// The returned slice must not be modified because it may be shared with the other callers.
+func (r *dbResolver) secondaryDBs() []*sqlx.DB {
+	r.poolMu.RLock()
+	defer r.poolMu.RUnlock()
+
+	return r.secondaries
+}
+
+// forEachDB calls fn for each of the primary and the secondary databases, i.e. each physical database once,
+// unlike iterating the primary and the readable databases which include the primary databases
+// depending on the read-write policy.
+func (r *dbResolver) forEachDB(fn func(db *sqlx.DB)) {
+	for _, db := range r.primaries {
+		fn(db)
+	}
+	for _, db := range r.secondaryDBs() {
+		fn(db)
+	}
+}
+
+// groupDBs returns the secondary databases of the given group.
+func (r *dbResolver) groupDBs(name string) ([]*sqlx.DB, bool) {
+	r.poolMu.RLock()
+	defer r.poolMu.RUnlock()
+
+	dbs, ok := r.groups[name]
+	return dbs, ok
+}
+
+// setSecondaries replaces the secondary databases with the given ones and recomputes the readable databases.
+// The readable databases are the secondary databases followed by the primary databases if they are readable
+// by the ReadWrite policy, so the primary databases stay readable only if they were.
+// It must be called with poolMu locked.
+func (r *dbResolver) setSecondaries(secondaries []*sqlx.DB) {
+	reads := make([]*sqlx.DB, 0, len(secondaries)+len(r.primaries))
+	reads = append(reads, secondaries...)
+	for _, db := range r.reads {
+		if !containsDB(r.secondaries, db) && !containsDB(reads, db) {
+			reads = append(reads, db)
+		}
+	}
+
+	r.secondaries = secondaries
+	r.reads = reads
+}
+
+// setConnMaxIdleTime sets the maximum amount of time a connection of the given database may be idle.
+func (r *dbResolver) setConnMaxIdleTime(db *sqlx.DB, d time.Duration) {
+	if r.connMaxIdleTimeSetter == nil {
+		db.SetConnMaxIdleTime(d)
+		return
+	}
+	r.connMaxIdleTimeSetter(db, d)
+}
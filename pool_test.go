@@ -0,0 +1,222 @@
+package dbresolver
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithPoolChangeListener(t *testing.T) {
+	mockDB1, _, _ := sqlmock.New()
+	mockPrimaryDB := sqlx.NewDb(mockDB1, "primary")
+	mockDB2, _, _ := sqlmock.New()
+	mockSecondaryDB1 := sqlx.NewDb(mockDB2, "secondary1")
+	mockDB3, sqlMock3, _ := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	sqlMock3.ExpectPing().WillReturnError(errors.New("connection refused"))
+	sqlMock3.ExpectPing().WillReturnError(errors.New("connection refused"))
+	sqlMock3.ExpectPing()
+	mockSecondaryDB2 := sqlx.NewDb(mockDB3, "secondary2")
+	var events []PoolChangeEvent
+	resolver := MustNewDBResolver(
+		&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB}, ReadWritePolicy: WriteOnly},
+		WithSecondaryDBs(mockSecondaryDB1, mockSecondaryDB2),
+		WithPoolChangeListener(func(event PoolChangeEvent) {
+			events = append(events, event)
+		}),
+	)
+	r := resolver.(*dbResolver)
+
+	for i := 0; i < 3; i++ {
+		checkHealth(context.Background(), &r.health, r.secondaries, time.Second, r.onHealthChange)
+	}
+
+	expected := []PoolChangeEvent{
+		{Kind: PoolChangeUnhealthy, DB: mockSecondaryDB2, Secondaries: 2, HealthySecondaries: 1},
+		{Kind: PoolChangeHealthy, DB: mockSecondaryDB2, Secondaries: 2, HealthySecondaries: 2},
+	}
+	assert.Equal(t, expected, events)
+	assert.NoError(t, sqlMock3.ExpectationsWereMet())
+}
+
+func TestDBResolver_AddSecondary(t *testing.T) {
+	t.Run("write-only primary", func(t *testing.T) {
+		primaries := newMockDBs(t, 1)
+		secondaries := newMockDBs(t, 2)
+		var events []PoolChangeEvent
+		resolver := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: primaries, ReadWritePolicy: WriteOnly},
+			WithSecondaryDBs(secondaries[0]),
+			WithPoolChangeListener(func(event PoolChangeEvent) {
+				events = append(events, event)
+			}),
+		)
+		r := resolver.(*dbResolver)
+
+		r.AddSecondary(secondaries[1])
+		r.AddSecondary(secondaries[1])
+
+		assert.Equal(t, secondaries, r.secondaryDBs())
+		assert.Equal(t, secondaries, r.readDBs())
+		expected := []PoolChangeEvent{
+			{Kind: PoolChangeAdded, DB: secondaries[1], Secondaries: 2, HealthySecondaries: 2},
+		}
+		assert.Equal(t, expected, events)
+	})
+
+	t.Run("read-write primary", func(t *testing.T) {
+		primaries := newMockDBs(t, 1)
+		secondaries := newMockDBs(t, 2)
+		resolver := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: primaries, ReadWritePolicy: ReadWrite},
+			WithSecondaryDBs(secondaries[0]),
+		)
+		r := resolver.(*dbResolver)
+
+		r.AddSecondary(secondaries[1])
+
+		assert.Equal(t, secondaries, r.secondaryDBs())
+		assert.Equal(t, []*sqlx.DB{secondaries[0], secondaries[1], primaries[0]}, r.readDBs())
+	})
+
+	t.Run("read from added secondary", func(t *testing.T) {
+		primaries := newMockDBs(t, 1)
+		mockDB, sqlMock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock.ExpectQuery(`SELECT first_name FROM person`).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("foo"))
+		mockSecondaryDB := sqlx.NewDb(mockDB, "secondary")
+		resolver := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: primaries, ReadWritePolicy: ReadWrite},
+			WithLoadBalancer(&firstLoadBalancer{}),
+		)
+
+		resolver.AddSecondary(mockSecondaryDB)
+		var firstName string
+		err := resolver.Get(&firstName, `SELECT first_name FROM person`)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "foo", firstName)
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+}
+
+func TestDBResolver_RemoveSecondary(t *testing.T) {
+	t.Run("write-only primary", func(t *testing.T) {
+		primaries := newMockDBs(t, 1)
+		secondaries := newMockDBs(t, 3)
+		var events []PoolChangeEvent
+		resolver := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: primaries, ReadWritePolicy: WriteOnly},
+			WithSecondaryDBs(secondaries...),
+			WithPoolChangeListener(func(event PoolChangeEvent) {
+				events = append(events, event)
+			}),
+		)
+		r := resolver.(*dbResolver)
+		inFlight := r.readDBs()
+
+		err := r.RemoveSecondary(secondaries[1])
+
+		assert.NoError(t, err)
+		assert.Equal(t, []*sqlx.DB{secondaries[0], secondaries[2]}, r.secondaryDBs())
+		assert.Equal(t, []*sqlx.DB{secondaries[0], secondaries[2]}, r.readDBs())
+		assert.Equal(t, secondaries, inFlight)
+		expected := []PoolChangeEvent{
+			{Kind: PoolChangeRemoved, DB: secondaries[1], Secondaries: 2, HealthySecondaries: 2},
+		}
+		assert.Equal(t, expected, events)
+	})
+
+	t.Run("read-write primary", func(t *testing.T) {
+		primaries := newMockDBs(t, 1)
+		secondaries := newMockDBs(t, 2)
+		resolver := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: primaries, ReadWritePolicy: ReadWrite},
+			WithSecondaryDBs(secondaries...),
+		)
+		r := resolver.(*dbResolver)
+
+		err := r.RemoveSecondary(secondaries[0])
+
+		assert.NoError(t, err)
+		assert.Equal(t, []*sqlx.DB{secondaries[1]}, r.secondaryDBs())
+		assert.Equal(t, []*sqlx.DB{secondaries[1], primaries[0]}, r.readDBs())
+	})
+
+	t.Run("read from primary after removing all secondaries", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock1.ExpectQuery(`SELECT first_name FROM person`).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("foo"))
+		mockPrimaryDB := sqlx.NewDb(mockDB1, "primary")
+		secondaries := newMockDBs(t, 1)
+		resolver := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB}, ReadWritePolicy: WriteOnly},
+			WithSecondaryDBs(secondaries...),
+		)
+
+		assert.NoError(t, resolver.RemoveSecondary(secondaries[0]))
+		var firstName string
+		err := resolver.GetContext(context.Background(), &firstName, `SELECT first_name FROM person`)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "foo", firstName)
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+	})
+
+	t.Run("remove from secondary group", func(t *testing.T) {
+		primaries := newMockDBs(t, 1)
+		secondaries := newMockDBs(t, 2)
+		resolver := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: primaries, ReadWritePolicy: WriteOnly},
+			WithSecondaryGroup("analytics", secondaries...),
+		)
+		r := resolver.(*dbResolver)
+
+		err := r.RemoveSecondary(secondaries[0])
+
+		assert.NoError(t, err)
+		dbs, ok := r.groupDBs("analytics")
+		assert.True(t, ok)
+		assert.Equal(t, []*sqlx.DB{secondaries[1]}, dbs)
+	})
+
+	t.Run("unknown secondary", func(t *testing.T) {
+		primaries := newMockDBs(t, 1)
+		unknown := newMockDBs(t, 1)
+		resolver := MustNewDBResolver(&PrimaryDBsConfig{DBs: primaries, ReadWritePolicy: ReadWrite})
+
+		err := resolver.RemoveSecondary(unknown[0])
+
+		assert.ErrorIs(t, err, errUnknownSecondary)
+	})
+
+	t.Run("concurrent reads", func(t *testing.T) {
+		primaries := newMockDBs(t, 1)
+		secondaries := newMockDBs(t, 4)
+		resolver := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: primaries, ReadWritePolicy: ReadWrite},
+			WithSecondaryDBs(secondaries[:2]...),
+		)
+		r := resolver.(*dbResolver)
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for i := 0; i < 100; i++ {
+				r.AddSecondary(secondaries[2+i%2])
+				assert.NoError(t, r.RemoveSecondary(secondaries[2+i%2]))
+			}
+		}()
+		for i := 0; i < 100; i++ {
+			db := r.selectRead(context.Background(), r.readCandidates(context.Background()))
+			assert.NotNil(t, db)
+		}
+		<-done
+
+		assert.Equal(t, []*sqlx.DB{secondaries[0], secondaries[1], primaries[0]}, r.readDBs())
+	})
+}
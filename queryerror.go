@@ -0,0 +1,50 @@
+package dbresolver
+
+import (
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// QueryError is the error of a query with the database which returned it, returned if WithWrappedErrors is given.
+// It unwraps to the original error, so errors.Is and errors.As work as without it.
+type QueryError struct {
+	Err error
+	// Role is RolePrimary for the primary databases including the canary primary database,
+	// and RoleReplica for the secondary databases.
+	Role       string
+	DriverName string
+	// Index is the index of the database in the primary or the secondary databases by Role.
+	// It is -1 for the canary primary database, which is not one of the primary databases.
+	Index int
+}
+
+func (e *QueryError) Error() string {
+	return fmt.Sprintf("%s[%d] (%s): %v", e.Role, e.Index, e.DriverName, e.Err)
+}
+
+func (e *QueryError) Unwrap() error {
+	return e.Err
+}
+
+// wrapError wraps the error of the query run on the given database with QueryError if WithWrappedErrors is given.
+func (r *dbResolver) wrapError(db *sqlx.DB, err error) error {
+	if err == nil || !r.wrapErrors {
+		return err
+	}
+
+	qErr := &QueryError{Err: err, Role: RolePrimary, DriverName: db.DriverName(), Index: -1}
+	for i, d := range r.primaries {
+		if d == db {
+			qErr.Index = i
+			return qErr
+		}
+	}
+	for i, d := range r.shared().secondaryDBs() {
+		if d == db {
+			qErr.Role, qErr.Index = RoleReplica, i
+			return qErr
+		}
+	}
+	return qErr
+}
@@ -0,0 +1,49 @@
+package dbresolver
+
+import (
+	"math/rand"
+	"sync"
+)
+
+// lockedSource is a rand.Source which is safe for concurrent use.
+type lockedSource struct {
+	mu  sync.Mutex
+	src rand.Source
+}
+
+func (s *lockedSource) Int63() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.src.Int63()
+}
+
+func (s *lockedSource) Seed(seed int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.src.Seed(seed)
+}
+
+// randomized is implemented by the features using randomness whose generator can be replaced.
+type randomized interface {
+	setRandom(random *rand.Rand)
+}
+
+// randIntn returns a random number in [0, n) using the given generator.
+// If the generator is nil, it uses the global generator.
+func randIntn(random *rand.Rand, n int) int {
+	if random == nil {
+		return rand.Intn(n)
+	}
+	return random.Intn(n)
+}
+
+// randFloat64 returns a random number in [0.0, 1.0) using the given generator.
+// If the generator is nil, it uses the global generator.
+func randFloat64(random *rand.Rand) float64 {
+	if random == nil {
+		return rand.Float64()
+	}
+	return random.Float64()
+}
@@ -0,0 +1,56 @@
+package dbresolver
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithRandSource(t *testing.T) {
+	primaries := newMockDBs(t, 1)
+	secondaries := newMockDBs(t, 5)
+	selections := func(opts ...OptionFunc) []*sqlx.DB {
+		resolver := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: primaries, ReadWritePolicy: WriteOnly},
+			append([]OptionFunc{WithSecondaryDBs(secondaries...)}, opts...)...,
+		)
+		r := resolver.(*dbResolver)
+
+		result := make([]*sqlx.DB, 0, 100)
+		for i := 0; i < 100; i++ {
+			result = append(result, r.loadBalancer.Select(context.Background(), r.reads))
+		}
+		return result
+	}
+
+	t.Run("random load balancer", func(t *testing.T) {
+		first := selections(WithRandSource(rand.NewSource(42)))
+		second := selections(WithRandSource(rand.NewSource(42)))
+
+		assert.Equal(t, first, second)
+		assert.NotEqual(t, first, selections(WithRandSource(rand.NewSource(43))))
+	})
+
+	t.Run("load balancer seed", func(t *testing.T) {
+		first := selections(WithLoadBalancerSeed(42))
+
+		assert.Equal(t, first, selections(WithLoadBalancerSeed(42)))
+		assert.Equal(t, first, selections(WithRandSource(rand.NewSource(42))))
+	})
+
+	t.Run("weighted load balancer", func(t *testing.T) {
+		first := selections(
+			WithLoadBalancer(NewWeightedLoadBalancer(1, 2, 3, 4, 5)),
+			WithRandSource(rand.NewSource(42)),
+		)
+		second := selections(
+			WithLoadBalancer(NewWeightedLoadBalancer(1, 2, 3, 4, 5)),
+			WithRandSource(rand.NewSource(42)),
+		)
+
+		assert.Equal(t, first, second)
+	})
+}
@@ -0,0 +1,54 @@
+package dbresolver
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ReadPreference is the preference of the databases serving the reads.
+type ReadPreference string
+
+// ReadPreferences.
+const (
+	// PreferReplica reads from the readable databases, which is the default.
+	PreferReplica ReadPreference = "prefer-replica"
+	// PreferPrimary reads from a primary database unless it is busy, and spills the reads to the readable databases.
+	PreferPrimary ReadPreference = "prefer-primary"
+	// PrimaryOnly reads from the primary databases only.
+	PrimaryOnly ReadPreference = "primary-only"
+	// ReadReplicaOnly reads from the secondary databases only, without falling back to the primary databases.
+	ReadReplicaOnly ReadPreference = "read-replica-only"
+)
+
+var validReadPreferences = map[ReadPreference]struct{}{
+	PreferReplica:   {},
+	PreferPrimary:   {},
+	PrimaryOnly:     {},
+	ReadReplicaOnly: {},
+}
+
+// preferredPrimary returns the primary database serving the read by the PreferPrimary preference.
+// It returns nil if the preference is not PreferPrimary or the chosen primary database is busy,
+// so that the read is served by the readable databases.
+func (r *dbResolver) preferredPrimary(ctx context.Context) *sqlx.DB {
+	if r.readPreference != PreferPrimary || len(r.primaries) == 0 {
+		return nil
+	}
+
+	db, err := r.balancePrimary(ctx)
+	if err != nil || db == nil {
+		return nil
+	}
+	stats := db.Stats()
+	if r.preferPrimaryMaxInUse > 0 {
+		if stats.InUse > r.preferPrimaryMaxInUse {
+			return nil
+		}
+		return db
+	}
+	if stats.MaxOpenConnections > 0 && stats.InUse >= stats.MaxOpenConnections {
+		return nil
+	}
+	return db
+}
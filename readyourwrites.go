@@ -0,0 +1,43 @@
+package dbresolver
+
+import (
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// lastWrite records the primary database written most recently and when it was written.
+type lastWrite struct {
+	mu sync.Mutex
+	db *sqlx.DB
+	at time.Time
+}
+
+func (w *lastWrite) record(db *sqlx.DB, at time.Time) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.db = db
+	w.at = at
+}
+
+// within returns the primary database written most recently if it was written within the window before now.
+// Otherwise, it returns nil.
+func (w *lastWrite) within(now time.Time, window time.Duration) *sqlx.DB {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.db == nil || now.Sub(w.at) >= window {
+		return nil
+	}
+	return w.db
+}
+
+// recentlyWrittenPrimary returns the primary database written within the read-your-writes window if it is enabled.
+func (r *dbResolver) recentlyWrittenPrimary() *sqlx.DB {
+	if r.readYourWritesWindow <= 0 {
+		return nil
+	}
+	return r.shared().lastWrite.within(r.clock(), r.readYourWritesWindow)
+}
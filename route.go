@@ -0,0 +1,731 @@
+package dbresolver
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+	"unicode"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+)
+
+// Roles of the databases.
+const (
+	RolePrimary = "primary"
+	RoleRead    = "read"
+	// RoleReplica is the role of the secondary databases reported by GetWithRole and SelectWithRole.
+	RoleReplica = "replica"
+)
+
+// request describes a query routed by the resolver.
+type request struct {
+	// op is the name of the method without the Context suffix. e.g. "Query", "Exec".
+	op    string
+	query string
+	args  []interface{}
+	// named reports whether the query is a named query with the only argument.
+	named bool
+}
+
+func newRequest(op, query string, args ...interface{}) request {
+	return request{
+		op:    op,
+		query: query,
+		args:  args,
+	}
+}
+
+func newNamedRequest(op, query string, arg interface{}) request {
+	return request{
+		op:    op,
+		query: query,
+		args:  []interface{}{arg},
+		named: true,
+	}
+}
+
+// read chooses a readable database and runs fn with it.
+// If fn fails with a connection error, it retries with the other readable databases
+// up to the configured number of read retries, and then chooses a primary database and runs fn again
+// unless the strict read separation is enabled or the context is given by WithNoFallback.
+// If the context forces the primary, it runs fn with a primary database only.
+// If a primary database was written within the read-your-writes window, it runs fn with the primary database.
+// If there are no readable databases, it runs fn with a primary database unless the strict read separation is enabled.
+// If there are no databases to choose, it returns errNoDBToRead or errNoPrimaryDB without running fn.
+// If the candidate filter filters out all the databases, it returns errAllCandidatesFiltered without running fn.
+// If the read-only enforcement is enabled and the query is a write, it returns errWriteOnReadReplica without running fn.
+func (r *dbResolver) read(ctx context.Context, req request, fn func(db *sqlx.DB) error) error {
+	return r.readWith(ctx, req, fn, func(_ []*sqlx.DB, db *sqlx.DB) ([]*sqlx.DB, error) {
+		return []*sqlx.DB{db}, r.run(ctx, req, RoleRead, db, fn)
+	})
+}
+
+// readAttempt runs a read with the chosen readable database among the candidates.
+// It returns the databases which it tried, which are excluded from the candidates of the retries.
+type readAttempt func(candidates []*sqlx.DB, db *sqlx.DB) ([]*sqlx.DB, error)
+
+// readWith is the same as read but runs the reads with the readable databases by attempt,
+// e.g. to hedge them. fn is run for the reads with a primary database.
+func (r *dbResolver) readWith(ctx context.Context, req request, fn func(db *sqlx.DB) error, attempt readAttempt) error {
+	r.observe(ctx, req)
+	if forcePrimaryFromContext(ctx) || r.readPreference == PrimaryOnly {
+		if len(r.primaries) == 0 {
+			return errNoPrimaryDB
+		}
+		dbPrimary, err := r.balancePrimary(ctx)
+		if err != nil {
+			return err
+		}
+		return r.run(ctx, req, RolePrimary, dbPrimary, fn)
+	}
+	if r.readOnlyEnforcement && classifyStatement(req.query) == stmtKindWrite {
+		return errors.Wrapf(errWriteOnReadReplica, "op: %s", req.op)
+	}
+	if db := r.recentlyWrittenPrimary(); db != nil {
+		return r.run(ctx, req, RolePrimary, db, fn)
+	}
+	if db := r.shardedRead(req); db != nil {
+		return r.run(ctx, req, RoleRead, db, fn)
+	}
+	if db := r.preferredPrimary(ctx); db != nil {
+		return r.run(ctx, req, RolePrimary, db, fn)
+	}
+
+	candidates, err := r.filterCandidates(ctx, RoleRead, r.readCandidates(ctx))
+	if err != nil {
+		return err
+	}
+	if len(candidates) == 0 {
+		// The read pool can become empty after the construction by RemoveSecondary.
+		if r.strictReadSeparation || len(r.primaries) == 0 {
+			return errNoDBToRead
+		}
+		dbPrimary, err := r.balancePrimary(ctx)
+		if err != nil {
+			return err
+		}
+		return r.run(ctx, req, RolePrimary, dbPrimary, fn)
+	}
+	tried, err := attempt(candidates, r.selectRead(ctx, candidates))
+	for retries := 0; r.connErrClassifier.isConnectionError(err) && retries < r.readRetries; retries++ {
+		for _, db := range tried {
+			candidates = excludeDB(candidates, db)
+		}
+		if len(candidates) == 0 {
+			break
+		}
+		tried, err = attempt(candidates, r.selectRead(ctx, candidates))
+	}
+	if r.connErrClassifier.isConnectionError(err) && r.canFallBack(ctx) {
+		if dbPrimary, filterErr := r.balancePrimary(ctx); filterErr == nil {
+			r.recordFallback(ctx, req, err)
+			err = r.run(ctx, req, RolePrimary, dbPrimary, fn)
+		}
+	}
+	return err
+}
+
+// canFallBack reports whether a read failed with a connection error can fall back to a primary database,
+// i.e. there are primary databases and neither the strict read separation nor WithNoFallback is given.
+func (r *dbResolver) canFallBack(ctx context.Context) bool {
+	return !r.strictReadSeparation && len(r.primaries) > 0 && !noFallbackFromContext(ctx)
+}
+
+// readInto is the same as read but for the methods scanning into the destination.
+// If the read coalescing is enabled, the identical concurrent reads share one read
+// unless the context changes the routing of the read. The reads tagged differently by WithQueryTagFromContext
+// are not identical, so each of them keeps its own tag.
+// The shared read is bounded by the default query timeout instead of the context of the callers.
+// If the hedged reads are enabled, a slow read is hedged with another readable database.
+func (r *dbResolver) readInto(
+	ctx context.Context,
+	req request,
+	dest interface{},
+	fn func(ctx context.Context, db *sqlx.DB, dest interface{}) error,
+) error {
+	if r.coalescer == nil || routedByContext(ctx) {
+		return r.readIntoUncoalesced(ctx, req, dest, fn)
+	}
+
+	return r.coalescer.coalesce(ctx, req.op, dest, r.tagQuery(ctx, req.query), req.args, func(ctx context.Context, dest interface{}) error {
+		ctx, cancel := r.withQueryTimeout(ctx)
+		defer cancel()
+
+		return r.readIntoUncoalesced(ctx, req, dest, fn)
+	})
+}
+
+func (r *dbResolver) readIntoUncoalesced(
+	ctx context.Context,
+	req request,
+	dest interface{},
+	fn func(ctx context.Context, db *sqlx.DB, dest interface{}) error,
+) error {
+	_, err := r.readIntoFrom(ctx, req, dest, fn)
+	return err
+}
+
+// readIntoWithRole reads like readInto and returns the role of the database which served the read.
+// The read is not coalesced, because the database serving the coalesced reads is not known to the callers.
+func (r *dbResolver) readIntoWithRole(
+	ctx context.Context,
+	req request,
+	dest interface{},
+	fn func(ctx context.Context, db *sqlx.DB, dest interface{}) error,
+) (string, error) {
+	served, err := r.readIntoFrom(ctx, req, dest, fn)
+	if err != nil {
+		return "", err
+	}
+
+	if containsDB(r.primaries, served) {
+		return RolePrimary, nil
+	}
+	return RoleReplica, nil
+}
+
+// readIntoFrom reads like readInto and returns the database which served the read.
+// The read is not coalesced, because the database serving the coalesced reads is not known to the callers.
+func (r *dbResolver) readIntoFrom(
+	ctx context.Context,
+	req request,
+	dest interface{},
+	fn func(ctx context.Context, db *sqlx.DB, dest interface{}) error,
+) (*sqlx.DB, error) {
+	if r.hedges(ctx) {
+		served, err := r.readHedged(ctx, req, dest, fn)
+		if err != nil {
+			return nil, err
+		}
+		return served, nil
+	}
+
+	var served *sqlx.DB
+	err := r.read(ctx, req, func(db *sqlx.DB) error {
+		if err := fn(ctx, db, dest); err != nil {
+			return err
+		}
+		served = db
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return served, nil
+}
+
+// selectRead chooses a readable database from the given candidates.
+// If the context has a sticky read, it chooses the stuck database if it is one of the candidates
+// and sticks to the newly chosen database otherwise.
+func (r *dbResolver) selectRead(ctx context.Context, candidates []*sqlx.DB) *sqlx.DB {
+	sticky, ok := stickyReadFromContext(ctx)
+	if !ok {
+		return r.balanceRead(ctx, candidates)
+	}
+
+	sticky.mu.Lock()
+	defer sticky.mu.Unlock()
+
+	if sticky.db == nil || !containsDB(candidates, sticky.db) {
+		sticky.db = r.balanceRead(ctx, candidates)
+	}
+	return sticky.db
+}
+
+// balanceRead chooses a readable database from the given candidates with the load balancer.
+// If the connection validator is enabled, it chooses another database when the chosen one fails validation.
+// If every candidate fails validation, it returns the last chosen database.
+func (r *dbResolver) balanceRead(ctx context.Context, candidates []*sqlx.DB) *sqlx.DB {
+	db := r.choose(ctx, candidates)
+	if r.connValidator == nil {
+		return db
+	}
+
+	for !r.connValidator.validate(ctx, db, r.clock()) {
+		candidates = excludeDB(candidates, db)
+		if len(candidates) == 0 {
+			break
+		}
+		next := r.choose(ctx, candidates)
+		if next == db {
+			break
+		}
+		db = next
+	}
+	return db
+}
+
+// filterCandidates narrows the candidates of the given role by the candidate filter if it is configured.
+// If there are no candidates, it returns them as they are without calling the filter.
+func (r *dbResolver) filterCandidates(ctx context.Context, role string, candidates []*sqlx.DB) ([]*sqlx.DB, error) {
+	if r.candidateFilter == nil || len(candidates) == 0 {
+		return candidates, nil
+	}
+
+	filtered := r.candidateFilter(ctx, role, candidates)
+	if len(filtered) == 0 {
+		return nil, errors.Wrapf(errAllCandidatesFiltered, "role: %s", role)
+	}
+	return filtered, nil
+}
+
+// balancePrimary chooses a primary database for a read with the load balancer.
+func (r *dbResolver) balancePrimary(ctx context.Context) (*sqlx.DB, error) {
+	candidates, err := r.filterCandidates(ctx, RolePrimary, r.primaries)
+	if err != nil {
+		return nil, err
+	}
+	return r.choose(ctx, candidates), nil
+}
+
+// readCandidates returns the readable databases which can be chosen for the given context.
+// If WithReadSubset is given, the readable databases are narrowed to the subset first,
+// so the subset does not change when the other databases become unhealthy.
+// The databases considered unhealthy and the databases whose circuit breaker is open are excluded.
+// If WithReadSampling is given, the candidates are narrowed by the sampling rates.
+func (r *dbResolver) readCandidates(ctx context.Context) []*sqlx.DB {
+	candidates := r.readDBs()
+	if fraction, ok := readSubsetFromContext(ctx); ok {
+		candidates = hashedSubset(candidates, fraction)
+	}
+	candidates = r.shared().health.healthyDBs(candidates)
+	candidates = r.shared().breaker.allowedDBs(candidates)
+	return r.readSampler.sample(candidates)
+}
+
+// begin chooses a primary database and runs begin with it to begin a transaction.
+// If the writes are paused, it waits until the writes are resumed or the context is done.
+// If begin fails with a connection error, it retries with the other primary databases
+// up to the configured number of write retries.
+// It tracks begin as an in-flight query for Shutdown, and fails with errShutdown once Shutdown is called.
+func (r *dbResolver) begin(ctx context.Context, begin func(db *sqlx.DB) error) error {
+	if !r.shared().drain.enter() {
+		return errShutdown
+	}
+	defer r.shared().drain.exit()
+
+	if err := r.shared().writeGate.wait(ctx); err != nil {
+		return err
+	}
+
+	candidates := r.primaries
+	db, err := r.selectPrimary(ctx, candidates)
+	if err != nil {
+		return err
+	}
+	err = begin(db)
+	for retries := 0; r.connErrClassifier.isConnectionError(err) && retries < r.writeRetries; retries++ {
+		candidates = excludeDB(candidates, db)
+		if len(candidates) == 0 {
+			break
+		}
+		next, selectErr := r.selectPrimary(ctx, candidates)
+		if selectErr != nil {
+			break
+		}
+		db = next
+		err = begin(db)
+	}
+	return err
+}
+
+// write chooses a primary database and runs fn with it.
+// If the writes are paused, it waits until the writes are resumed or the context is done.
+func (r *dbResolver) write(ctx context.Context, req request, fn func(db *sqlx.DB) error) error {
+	_, err := r.writeWithMeta(ctx, req, fn)
+	return err
+}
+
+// writeWithMeta is the same as write but also returns how the write was executed.
+// If fn fails with a connection error, it retries with the other primary databases
+// up to the configured number of write retries.
+func (r *dbResolver) writeWithMeta(ctx context.Context, req request, fn func(db *sqlx.DB) error) (ExecMeta, error) {
+	var meta ExecMeta
+	if err := r.shared().writeGate.wait(ctx); err != nil {
+		return meta, err
+	}
+	r.observe(ctx, req)
+	r.shared().routing.countWrite()
+
+	candidates := r.primaries
+	for {
+		var db *sqlx.DB
+		if meta.Attempts == 0 && r.canary.chosen() {
+			db = r.canary.db
+		} else {
+			var err error
+			if db, err = r.selectPrimary(ctx, candidates); err != nil {
+				return meta, err
+			}
+		}
+		meta.Attempts++
+		meta.DB = r.primaryLabel(db)
+		meta.Tried = append(meta.Tried, meta.DB)
+
+		err := r.run(ctx, req, RolePrimary, db, fn)
+		if err == nil && r.readYourWritesWindow > 0 {
+			r.shared().lastWrite.record(db, r.clock())
+		}
+		if !r.connErrClassifier.isConnectionError(err) || meta.Attempts > r.writeRetries {
+			return meta, err
+		}
+
+		candidates = excludeDB(candidates, db)
+		if len(candidates) == 0 {
+			return meta, err
+		}
+	}
+}
+
+// selectPrimary chooses a primary database to write from the given candidates.
+// If the leader selector is set, it chooses the leader with the leader selector instead of the load balancer.
+// If there are no candidates, it returns errNoPrimaryDB.
+func (r *dbResolver) selectPrimary(ctx context.Context, candidates []*sqlx.DB) (*sqlx.DB, error) {
+	if len(candidates) == 0 {
+		return nil, errNoPrimaryDB
+	}
+	candidates, err := r.filterCandidates(ctx, RolePrimary, candidates)
+	if err != nil {
+		return nil, err
+	}
+	if r.leaderSelector == nil {
+		return r.choose(ctx, candidates), nil
+	}
+
+	db, err := r.leaderSelector(ctx, candidates)
+	if err != nil {
+		return nil, err
+	}
+	if db == nil {
+		return nil, errNoLeader
+	}
+	return db, nil
+}
+
+// view returns a view of the resolver which reads from the given databases.
+// The view shares the databases and the state with the resolver.
+func (r *dbResolver) view(reads []*sqlx.DB) *dbResolver {
+	return &dbResolver{
+		primaries:             r.primaries,
+		canary:                r.canary,
+		readSampler:           r.readSampler,
+		candidateFilter:       r.candidateFilter,
+		shardFunc:             r.shardFunc,
+		secondaries:           reads,
+		reads:                 reads,
+		secondaryConfigs:      r.secondaryConfigs,
+		loadBalancer:          r.loadBalancer,
+		root:                  r.shared(),
+		poolChangeListener:    r.poolChangeListener,
+		readPreference:        r.readPreference,
+		preferPrimaryMaxInUse: r.preferPrimaryMaxInUse,
+		writeRetries:          r.writeRetries,
+		readRetries:           r.readRetries,
+		strictReadSeparation:  r.strictReadSeparation,
+		queryRouting:          r.queryRouting,
+		readOnlyEnforcement:   r.readOnlyEnforcement,
+		wrapErrors:            r.wrapErrors,
+		observer:              r.observer,
+		queryTag:              r.queryTag,
+		hedgeDelay:            r.hedgeDelay,
+		leaderSelector:        r.leaderSelector,
+		routeHook:             r.routeHook,
+		effectiveQueryHook:    r.effectiveQueryHook,
+		logger:                r.logger,
+		connErrClassifier:     r.connErrClassifier,
+		coalescer:             r.coalescer,
+		connValidator:         r.connValidator,
+		stmtLimiter:           r.stmtLimiter,
+		tolerantPrepare:       r.tolerantPrepare,
+		fanOutConcurrency:     r.fanOutConcurrency,
+		latencies:             r.latencies,
+		n1Detector:            r.n1Detector,
+		queryTimeout:          r.queryTimeout,
+		defaultContext:        r.defaultContext,
+		readYourWritesWindow:  r.readYourWritesWindow,
+		now:                   r.now,
+	}
+}
+
+// queryRoute returns how the query of the Query methods is routed.
+// It is read unless the query routing is enabled, and then it is decided by routeQuery.
+func (r *dbResolver) queryRoute(query string) func(ctx context.Context, req request, fn func(db *sqlx.DB) error) error {
+	if !r.queryRouting {
+		return r.read
+	}
+	return r.routeQuery(query)
+}
+
+// routeQuery returns how the query which may be a write is routed by the kind of the statement.
+// The writes are routed by write and the reads by read. The statements of the unknown kind are routed by write
+// if the query routing is enabled to be conservative, and by read otherwise.
+func (r *dbResolver) routeQuery(query string) func(ctx context.Context, req request, fn func(db *sqlx.DB) error) error {
+	switch classifyStatement(query) {
+	case stmtKindRead:
+		return r.read
+	case stmtKindWrite:
+		return r.write
+	default:
+		if r.queryRouting {
+			return r.write
+		}
+		return r.read
+	}
+}
+
+// shared returns the resolver holding the state shared with its views.
+func (r *dbResolver) shared() *dbResolver {
+	if r.root != nil {
+		return r.root
+	}
+	return r
+}
+
+// bindTypeCache states.
+const (
+	bindTypeNotCached = 0
+	bindTypeMixed     = -1
+)
+
+// bindType returns the bindvar type of the primary databases without choosing a database.
+// It is computed once and cached, because the drivers of the primary databases do not change.
+// It returns false if the drivers of the primary databases have the different bindvar types,
+// which is allowed by WithAllowMixedDrivers, so the callers should choose a primary database instead.
+func (r *dbResolver) bindType() (int, bool) {
+	root := r.shared()
+	cached := atomic.LoadInt32(&root.bindTypeCache)
+	if cached == bindTypeNotCached {
+		cached = bindTypeMixed
+		if len(r.primaries) > 0 && checkDrivers(r.primaries) == nil {
+			// Plus one to distinguish it from bindTypeNotCached, because sqlx.UNKNOWN is zero.
+			cached = int32(sqlx.BindType(r.primaries[0].DriverName())) + 1
+		}
+		atomic.StoreInt32(&root.bindTypeCache, cached)
+	}
+	if cached == bindTypeMixed {
+		return 0, false
+	}
+	return int(cached) - 1, true
+}
+
+// balancer returns the load balancer in the context if it exists.
+// Otherwise, it returns the configured load balancer.
+func (r *dbResolver) balancer(ctx context.Context) LoadBalancer {
+	if lb, ok := loadBalancerFromContext(ctx); ok {
+		return lb
+	}
+	return r.loadBalancer
+}
+
+// choose chooses a database from the given candidates with the load balancer.
+// A single candidate is chosen without calling the load balancer, which has no choice to make.
+func (r *dbResolver) choose(ctx context.Context, candidates []*sqlx.DB) *sqlx.DB {
+	if len(candidates) == 1 {
+		return candidates[0]
+	}
+	return r.balancer(ctx).Select(ctx, candidates)
+}
+
+// baseContext returns the default context set by WithDefaultContext,
+// which the methods without a context use instead of context.Background.
+func (r *dbResolver) baseContext() context.Context {
+	if r.defaultContext != nil {
+		return r.defaultContext
+	}
+	return context.Background()
+}
+
+// withQueryTimeout returns a copy of the context with the default query timeout
+// if the default query timeout is set and the context has no deadline.
+// Otherwise, it returns the context as it is with a no-op cancel function.
+func (r *dbResolver) withQueryTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if r.queryTimeout <= 0 {
+		return ctx, func() {}
+	}
+	if _, ok := ctx.Deadline(); ok {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, r.queryTimeout)
+}
+
+// tagQuery returns the query prepended with the comment of the tag extracted from the context
+// if WithQueryTagFromContext is given. The tag is escaped not to end the comment early.
+func (r *dbResolver) tagQuery(ctx context.Context, query string) string {
+	if r.queryTag == nil {
+		return query
+	}
+	tag := r.queryTag(ctx)
+	if tag == "" {
+		return query
+	}
+	return "/* " + strings.ReplaceAll(tag, "*/", "* /") + " */ " + query
+}
+
+// tagNamedQuery is the same as tagQuery but for the named query.
+// The colons of the tag are escaped not to be compiled as the named parameters.
+func (r *dbResolver) tagNamedQuery(ctx context.Context, query string) string {
+	tagged := r.tagQuery(ctx, query)
+	if len(tagged) == len(query) {
+		return query
+	}
+	tag := tagged[:len(tagged)-len(query)]
+	return strings.ReplaceAll(tag, ":", "::") + query
+}
+
+// observe notifies the diagnostic hooks of the given request.
+// The N+1 query detector does not observe the default context, which is shared by the methods without a context.
+func (r *dbResolver) observe(ctx context.Context, req request) {
+	if r.n1Detector != nil && ctx != r.defaultContext {
+		r.n1Detector.observe(ctx, req.query, r.clock())
+	}
+}
+
+// run runs fn with the given database and records how long it took.
+// It calls the route hook with the chosen database before running fn if the route hook is set.
+// It tracks fn as an in-flight query for Shutdown, and fails with errShutdown once Shutdown is called.
+// It calls the observer before and after fn if the observer is set.
+func (r *dbResolver) run(ctx context.Context, req request, role string, db *sqlx.DB, fn func(db *sqlx.DB) error) error {
+	if !r.shared().drain.enter() {
+		return errShutdown
+	}
+	defer r.shared().drain.exit()
+
+	r.shared().routing.countQuery(role)
+	if r.routeHook != nil {
+		r.routeHook(ctx, req.op, role, db)
+	}
+	if r.effectiveQueryHook != nil {
+		query, args := r.effectiveQuery(ctx, db, req)
+		r.effectiveQueryHook(ctx, role, query, args)
+	}
+
+	if role == RoleRead {
+		r.shared().breaker.begin(db)
+	}
+	start := r.clock()
+	// errQueryPanicked is reported to the observer if fn panics.
+	err := errQueryPanicked
+	if r.observer != nil {
+		observedCtx, info := r.beforeQuery(ctx, req, role, db)
+		defer func() {
+			info.Duration = r.clock().Sub(start)
+			r.observer.AfterQuery(observedCtx, info, err)
+		}()
+	}
+	err = fn(db)
+	if h, ok := r.latencies[role]; ok {
+		h.record(r.clock().Sub(start))
+	}
+	if role == RoleRead {
+		if ctx.Err() != nil {
+			r.shared().breaker.abort(db)
+		} else {
+			r.shared().breaker.record(db, r.connErrClassifier.isConnectionError(err))
+		}
+	}
+	return r.wrapError(db, err)
+}
+
+// effectiveQuery returns the query and the arguments of the request as sent to the given database.
+// The query is tagged like tagQuery does, and the named query is compiled into the bindvar type of the database
+// like sqlx does.
+func (r *dbResolver) effectiveQuery(ctx context.Context, db *sqlx.DB, req request) (string, []interface{}) {
+	if !req.named {
+		return r.tagQuery(ctx, req.query), req.args
+	}
+
+	tagged := r.tagNamedQuery(ctx, req.query)
+	query, args, err := db.BindNamed(tagged, req.args[0])
+	if err != nil {
+		// The query fails with the same error, so it is reported as is.
+		return tagged, req.args
+	}
+	return query, args
+}
+
+// recordFallback counts the fallback of the read to a primary database
+// and reports it to the logger with the error of the readable database.
+// The event is "read_fallback_" followed by the operation in snake case, e.g. "read_fallback_query_rowx".
+func (r *dbResolver) recordFallback(ctx context.Context, req request, err error) {
+	r.shared().routing.countFallback()
+	if r.logger == nil {
+		return
+	}
+	r.logger(ctx, "read_fallback_"+snakeCase(req.op), err)
+}
+
+// snakeCase converts the camel case name like "QueryRowx" to the snake case like "query_rowx".
+func snakeCase(name string) string {
+	var b strings.Builder
+	for i, c := range name {
+		if unicode.IsUpper(c) {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			c = unicode.ToLower(c)
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+// clock returns the current time.
+func (r *dbResolver) clock() time.Time {
+	if r.now == nil {
+		return time.Now()
+	}
+	return r.now()
+}
+
+// primaryLabel returns the label of the primary database like dbLabel, or "canary" for the canary primary database.
+func (r *dbResolver) primaryLabel(db *sqlx.DB) string {
+	if r.canary != nil && r.canary.db == db && !containsDB(r.primaries, db) {
+		return "canary"
+	}
+	return dbLabel(RolePrimary, r.primaries, db)
+}
+
+// dbLabel returns the label of the given database, which consists of the role and the index in the given databases.
+func dbLabel(role string, dbs []*sqlx.DB, db *sqlx.DB) string {
+	for i, d := range dbs {
+		if d == db {
+			return fmt.Sprintf("%s[%d]", role, i)
+		}
+	}
+	return role
+}
+
+// containsDB reports whether the given database is in the given databases.
+func containsDB(dbs []*sqlx.DB, db *sqlx.DB) bool {
+	for _, d := range dbs {
+		if d == db {
+			return true
+		}
+	}
+	return false
+}
+
+// uniqueDBs returns a new slice of the given databases without the duplicated databases.
+func uniqueDBs(dbs []*sqlx.DB) []*sqlx.DB {
+	result := make([]*sqlx.DB, 0, len(dbs))
+	for _, db := range dbs {
+		if !containsDB(result, db) {
+			result = append(result, db)
+		}
+	}
+	return result
+}
+
+// excludeDB returns a new slice of the given databases without the given database.
+func excludeDB(dbs []*sqlx.DB, db *sqlx.DB) []*sqlx.DB {
+	result := make([]*sqlx.DB, 0, len(dbs))
+	for _, d := range dbs {
+		if d != db {
+			result = append(result, d)
+		}
+	}
+	return result
+}
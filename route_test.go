@@ -0,0 +1,2185 @@
+package dbresolver
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math/rand"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDBResolver_ExecContextDetailed(t *testing.T) {
+	t.Run("without retry", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		connErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+		sqlMock1.ExpectExec(`DELETE FROM person`).
+			WillReturnError(connErr)
+		mockPrimaryDB1 := sqlx.NewDb(mockDB1, "primary1")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		mockPrimaryDB2 := sqlx.NewDb(mockDB2, "primary2")
+		r := &dbResolver{
+			primaries:    []*sqlx.DB{mockPrimaryDB1, mockPrimaryDB2},
+			loadBalancer: &firstLoadBalancer{},
+		}
+
+		result, meta, err := r.ExecContextDetailed(context.Background(), `DELETE FROM person`)
+
+		assert.Nil(t, result)
+		assert.ErrorIs(t, err, connErr)
+		assert.Equal(t, ExecMeta{Attempts: 1, DB: "primary[0]", Tried: []string{"primary[0]"}}, meta)
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
+
+	t.Run("retry with dead first primary", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock1.ExpectExec(`DELETE FROM person`).
+			WillReturnError(&net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")})
+		mockPrimaryDB1 := sqlx.NewDb(mockDB1, "primary1")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock2.ExpectExec(`DELETE FROM person`).
+			WillReturnResult(sqlmock.NewResult(0, 3))
+		mockPrimaryDB2 := sqlx.NewDb(mockDB2, "primary2")
+		r := &dbResolver{
+			primaries:    []*sqlx.DB{mockPrimaryDB1, mockPrimaryDB2},
+			loadBalancer: &firstLoadBalancer{},
+			writeRetries: 1,
+		}
+
+		result, meta, err := r.ExecContextDetailed(context.Background(), `DELETE FROM person`)
+
+		assert.NoError(t, err)
+		rowsAffected, err := result.RowsAffected()
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), rowsAffected)
+		assert.Equal(t, ExecMeta{Attempts: 2, DB: "primary[1]", Tried: []string{"primary[0]", "primary[1]"}}, meta)
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
+
+	t.Run("do not retry non connection error", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		mockError := errors.New("mock error")
+		sqlMock1.ExpectExec(`DELETE FROM person`).
+			WillReturnError(mockError)
+		mockPrimaryDB1 := sqlx.NewDb(mockDB1, "primary1")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		mockPrimaryDB2 := sqlx.NewDb(mockDB2, "primary2")
+		r := &dbResolver{
+			primaries:    []*sqlx.DB{mockPrimaryDB1, mockPrimaryDB2},
+			loadBalancer: &firstLoadBalancer{},
+			writeRetries: 1,
+		}
+
+		_, meta, err := r.ExecContextDetailed(context.Background(), `DELETE FROM person`)
+
+		assert.ErrorIs(t, err, mockError)
+		assert.Equal(t, 1, meta.Attempts)
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
+}
+
+func TestDBResolver_NoDB(t *testing.T) {
+	t.Run("no database to read", func(t *testing.T) {
+		r := &dbResolver{
+			loadBalancer: NewRandomLoadBalancer(),
+		}
+
+		var firstName string
+		assert.ErrorIs(t, r.Get(&firstName, `SELECT first_name FROM person`), errNoDBToRead)
+		var firstNames []string
+		assert.ErrorIs(t, r.SelectContext(context.Background(), &firstNames, `SELECT first_name FROM person`), errNoDBToRead)
+		rows, err := r.Query(`SELECT first_name FROM person`)
+		assert.Nil(t, rows)
+		assert.ErrorIs(t, err, errNoDBToRead)
+		row := r.QueryRowContext(context.Background(), `SELECT first_name FROM person`)
+		assert.ErrorIs(t, row.Err(), errNoDBToRead)
+		assert.ErrorIs(t, row.Scan(&firstName), errNoDBToRead)
+		rowx := r.QueryRowx(`SELECT first_name FROM person`)
+		assert.ErrorIs(t, rowx.Err(), errNoDBToRead)
+		assert.ErrorIs(t, rowx.Scan(&firstName), errNoDBToRead)
+		err = r.WithReplicaConn(context.Background(), func(conn *sqlx.Conn) error {
+			return nil
+		})
+		assert.ErrorIs(t, err, errNoDBToRead)
+	})
+
+	t.Run("no primary database", func(t *testing.T) {
+		r := &dbResolver{
+			loadBalancer: NewRandomLoadBalancer(),
+		}
+
+		result, err := r.Exec(`DELETE FROM person`)
+		assert.Nil(t, result)
+		assert.ErrorIs(t, err, errNoPrimaryDB)
+		result, err = r.NamedExecContext(context.Background(), `DELETE FROM person`, map[string]interface{}{})
+		assert.Nil(t, result)
+		assert.ErrorIs(t, err, errNoPrimaryDB)
+		tx, err := r.BeginTxx(context.Background(), nil)
+		assert.Nil(t, tx)
+		assert.ErrorIs(t, err, errNoPrimaryDB)
+		row := r.QueryRowContext(WithForcePrimary(context.Background()), `SELECT first_name FROM person`)
+		assert.ErrorIs(t, row.Err(), errNoPrimaryDB)
+	})
+}
+
+func TestWithCanaryPrimary(t *testing.T) {
+	t.Run("route fraction of writes to canary", func(t *testing.T) {
+		primaries := newMockDBs(t, 2)
+		canary := newMockDBs(t, 1)[0]
+		r := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: primaries, ReadWritePolicy: ReadWrite},
+			WithCanaryPrimary(canary, 0.1),
+			WithRandSource(rand.NewSource(1)),
+		).(*dbResolver)
+
+		const writes = 10000
+		counts := make(map[*sqlx.DB]int)
+		for i := 0; i < writes; i++ {
+			err := r.write(context.Background(), newRequest("Exec", `DELETE FROM person`), func(db *sqlx.DB) error {
+				counts[db]++
+				return nil
+			})
+			assert.NoError(t, err)
+		}
+
+		assert.InDelta(t, 0.1, float64(counts[canary])/writes, 0.01)
+		assert.Equal(t, writes, counts[canary]+counts[primaries[0]]+counts[primaries[1]])
+	})
+
+	t.Run("retry with primary when canary fails", func(t *testing.T) {
+		connErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+		primaries := newMockDBs(t, 1)
+		canary := newMockDBs(t, 1)[0]
+		r := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: primaries, ReadWritePolicy: ReadWrite},
+			WithCanaryPrimary(canary, 1),
+			WithWriteRetry(1),
+		).(*dbResolver)
+
+		meta, err := r.writeWithMeta(context.Background(), newRequest("Exec", `DELETE FROM person`), func(db *sqlx.DB) error {
+			if db == canary {
+				return connErr
+			}
+			return nil
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"canary", "primary[0]"}, meta.Tried)
+	})
+
+	t.Run("invalid fraction", func(t *testing.T) {
+		primaries := newMockDBs(t, 1)
+		canary := newMockDBs(t, 1)[0]
+
+		r, err := NewDBResolver(
+			&PrimaryDBsConfig{DBs: primaries, ReadWritePolicy: ReadWrite},
+			WithCanaryPrimary(canary, 1.5),
+		)
+
+		assert.Nil(t, r)
+		assert.ErrorIs(t, err, errInvalidCanaryFraction)
+	})
+}
+
+func TestWithCandidateFilter(t *testing.T) {
+	t.Run("restrict to subset", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		mockPrimaryDB1 := sqlx.NewDb(mockDB1, "primary1")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock2.ExpectExec(`DELETE FROM person`).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mockPrimaryDB2 := sqlx.NewDb(mockDB2, "primary2")
+		mockDB3, sqlMock3, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		mockSecondaryDB1 := sqlx.NewDb(mockDB3, "secondary1")
+		mockDB4, sqlMock4, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock4.ExpectQuery(`SELECT first_name FROM person`).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("foo"))
+		mockSecondaryDB2 := sqlx.NewDb(mockDB4, "secondary2")
+		var roles []string
+		r := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB1, mockPrimaryDB2}, ReadWritePolicy: WriteOnly},
+			WithSecondaryDBs(mockSecondaryDB1, mockSecondaryDB2),
+			WithLoadBalancer(&firstLoadBalancer{}),
+			WithCandidateFilter(func(_ context.Context, role string, dbs []*sqlx.DB) []*sqlx.DB {
+				roles = append(roles, role)
+				return dbs[1:]
+			}),
+		)
+
+		_, err := r.ExecContext(context.Background(), `DELETE FROM person`)
+		assert.NoError(t, err)
+		var firstName string
+		err = r.GetContext(context.Background(), &firstName, `SELECT first_name FROM person`)
+		assert.NoError(t, err)
+
+		assert.Equal(t, "foo", firstName)
+		assert.Equal(t, []string{RolePrimary, RoleRead}, roles)
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+		assert.NoError(t, sqlMock3.ExpectationsWereMet())
+		assert.NoError(t, sqlMock4.ExpectationsWereMet())
+	})
+
+	t.Run("return error when filter empties candidates", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		mockPrimaryDB := sqlx.NewDb(mockDB1, "primary")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		mockSecondaryDB := sqlx.NewDb(mockDB2, "secondary")
+		r := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB}, ReadWritePolicy: WriteOnly},
+			WithSecondaryDBs(mockSecondaryDB),
+			WithCandidateFilter(func(_ context.Context, _ string, _ []*sqlx.DB) []*sqlx.DB {
+				return nil
+			}),
+		)
+
+		_, err := r.ExecContext(context.Background(), `DELETE FROM person`)
+		assert.ErrorIs(t, err, errAllCandidatesFiltered)
+		tx, err := r.BeginTxx(context.Background(), nil)
+		assert.Nil(t, tx)
+		assert.ErrorIs(t, err, errAllCandidatesFiltered)
+		var firstName string
+		err = r.GetContext(context.Background(), &firstName, `SELECT first_name FROM person`)
+		assert.ErrorIs(t, err, errAllCandidatesFiltered)
+		err = r.GetContext(WithForcePrimary(context.Background()), &firstName, `SELECT first_name FROM person`)
+		assert.ErrorIs(t, err, errAllCandidatesFiltered)
+		err = r.WithReplicaConn(context.Background(), func(_ *sqlx.Conn) error {
+			return nil
+		})
+		assert.ErrorIs(t, err, errAllCandidatesFiltered)
+
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
+}
+
+func TestWithConnectionErrorClassifier(t *testing.T) {
+	errConnReset := errors.New("connection reset")
+
+	t.Run("fall back to primary for classified error", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock1.ExpectQuery(`SELECT first_name FROM person`).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("foo"))
+		mockPrimaryDB := sqlx.NewDb(mockDB1, "primary")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock2.ExpectQuery(`SELECT first_name FROM person`).
+			WillReturnError(errConnReset)
+		mockSecondaryDB := sqlx.NewDb(mockDB2, "secondary")
+		r := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB}, ReadWritePolicy: WriteOnly},
+			WithSecondaryDBs(mockSecondaryDB),
+			WithConnectionErrorClassifier(func(err error) bool {
+				return errors.Is(err, errConnReset)
+			}),
+		)
+
+		var firstName string
+		err := r.GetContext(context.Background(), &firstName, `SELECT first_name FROM person`)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "foo", firstName)
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
+
+	t.Run("not fall back for unclassified error", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		mockPrimaryDB := sqlx.NewDb(mockDB1, "primary")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		connErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+		sqlMock2.ExpectQuery(`SELECT first_name FROM person`).
+			WillReturnError(connErr)
+		mockSecondaryDB := sqlx.NewDb(mockDB2, "secondary")
+		r := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB}, ReadWritePolicy: WriteOnly},
+			WithSecondaryDBs(mockSecondaryDB),
+			WithConnectionErrorClassifier(func(err error) bool {
+				return errors.Is(err, errConnReset)
+			}),
+		)
+
+		var firstName string
+		err := r.GetContext(context.Background(), &firstName, `SELECT first_name FROM person`)
+
+		assert.ErrorIs(t, err, connErr)
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
+
+	t.Run("retry write for classified error", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock1.ExpectExec(`DELETE FROM person`).
+			WillReturnError(errConnReset)
+		mockPrimaryDB1 := sqlx.NewDb(mockDB1, "primary1")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock2.ExpectExec(`DELETE FROM person`).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mockPrimaryDB2 := sqlx.NewDb(mockDB2, "primary2")
+		r := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB1, mockPrimaryDB2}, ReadWritePolicy: ReadWrite},
+			WithLoadBalancer(&firstLoadBalancer{}),
+			WithWriteRetry(1),
+			WithConnectionErrorClassifier(func(err error) bool {
+				return errors.Is(err, errConnReset)
+			}),
+		)
+
+		_, err := r.ExecContext(context.Background(), `DELETE FROM person`)
+
+		assert.NoError(t, err)
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
+
+	t.Run("retry begin for classified error", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock1.ExpectBegin().
+			WillReturnError(errConnReset)
+		mockPrimaryDB1 := sqlx.NewDb(mockDB1, "primary1")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock2.ExpectBegin()
+		sqlMock2.ExpectRollback()
+		mockPrimaryDB2 := sqlx.NewDb(mockDB2, "primary2")
+		r := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB1, mockPrimaryDB2}, ReadWritePolicy: ReadWrite},
+			WithLoadBalancer(&firstLoadBalancer{}),
+			WithWriteRetry(1),
+			WithConnectionErrorClassifier(func(err error) bool {
+				return errors.Is(err, errConnReset)
+			}),
+		)
+
+		tx, err := r.BeginTxx(context.Background(), nil)
+
+		assert.NoError(t, err)
+		assert.NoError(t, tx.Rollback())
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
+}
+
+func TestWithDefaultContext(t *testing.T) {
+	type traceKey struct{}
+	newResolver := func(t *testing.T, opts ...OptionFunc) (DBResolver, sqlmock.Sqlmock, *[]interface{}) {
+		mockDB, sqlMock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		assert.NoError(t, err)
+		mockPrimaryDB := sqlx.NewDb(mockDB, "primary")
+		var traces []interface{}
+		opts = append(opts, WithRouteHook(func(ctx context.Context, _ string, _ string, _ *sqlx.DB) {
+			traces = append(traces, ctx.Value(traceKey{}))
+		}))
+		resolver := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB}, ReadWritePolicy: ReadWrite},
+			opts...,
+		)
+		return resolver, sqlMock, &traces
+	}
+
+	t.Run("use default context without context", func(t *testing.T) {
+		resolver, sqlMock, traces := newResolver(
+			t, WithDefaultContext(context.WithValue(context.Background(), traceKey{}, "default")),
+		)
+		sqlMock.ExpectQuery(`SELECT first_name FROM person`).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("foo"))
+		sqlMock.ExpectExec(`INSERT INTO person (first_name) VALUES (?)`).
+			WithArgs("foo").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		var firstName string
+		err := resolver.Get(&firstName, `SELECT first_name FROM person`)
+		assert.NoError(t, err)
+		_, err = resolver.Exec(`INSERT INTO person (first_name) VALUES (?)`, "foo")
+		assert.NoError(t, err)
+
+		assert.Equal(t, "foo", firstName)
+		assert.Equal(t, []interface{}{"default", "default"}, *traces)
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("use given context", func(t *testing.T) {
+		resolver, sqlMock, traces := newResolver(
+			t, WithDefaultContext(context.WithValue(context.Background(), traceKey{}, "default")),
+		)
+		sqlMock.ExpectQuery(`SELECT first_name FROM person`).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("foo"))
+
+		var firstName string
+		ctx := context.WithValue(context.Background(), traceKey{}, "given")
+		err := resolver.GetContext(ctx, &firstName, `SELECT first_name FROM person`)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []interface{}{"given"}, *traces)
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("use background context by default", func(t *testing.T) {
+		resolver, sqlMock, traces := newResolver(t)
+		sqlMock.ExpectQuery(`SELECT first_name FROM person`).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("foo"))
+
+		var firstName string
+		err := resolver.Get(&firstName, `SELECT first_name FROM person`)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []interface{}{nil}, *traces)
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+}
+
+func TestWithDefaultQueryTimeout(t *testing.T) {
+	newResolver := func(t *testing.T, timeout time.Duration) (DBResolver, sqlmock.Sqlmock) {
+		mockDB, sqlMock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		assert.NoError(t, err)
+		mockPrimaryDB := sqlx.NewDb(mockDB, "primary")
+		resolver := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB}, ReadWritePolicy: ReadWrite},
+			WithDefaultQueryTimeout(timeout),
+		)
+		return resolver, sqlMock
+	}
+
+	t.Run("slow query exceeds default timeout", func(t *testing.T) {
+		resolver, sqlMock := newResolver(t, 10*time.Millisecond)
+		sqlMock.ExpectQuery(`SELECT first_name FROM person`).
+			WillDelayFor(time.Second).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("foo"))
+
+		start := time.Now()
+		var firstName string
+		err := resolver.GetContext(context.Background(), &firstName, `SELECT first_name FROM person`)
+
+		// sqlmock reports the cancellation by the deadline as ErrCancelled.
+		assert.ErrorIs(t, err, sqlmock.ErrCancelled)
+		assert.Less(t, time.Since(start), time.Second)
+	})
+
+	t.Run("keep deadline of context", func(t *testing.T) {
+		resolver, sqlMock := newResolver(t, 10*time.Millisecond)
+		sqlMock.ExpectQuery(`SELECT first_name FROM person`).
+			WillDelayFor(50 * time.Millisecond).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("foo"))
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+
+		var firstName string
+		err := resolver.GetContext(ctx, &firstName, `SELECT first_name FROM person`)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "foo", firstName)
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("read rows after return", func(t *testing.T) {
+		resolver, sqlMock := newResolver(t, time.Minute)
+		sqlMock.ExpectQuery(`SELECT first_name FROM person`).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("foo").AddRow("bar"))
+
+		rows, err := resolver.QueryxContext(context.Background(), `SELECT first_name FROM person`)
+		assert.NoError(t, err)
+		var firstNames []string
+		for rows.Next() {
+			var firstName string
+			assert.NoError(t, rows.Scan(&firstName))
+			firstNames = append(firstNames, firstName)
+		}
+
+		assert.NoError(t, rows.Err())
+		assert.Equal(t, []string{"foo", "bar"}, firstNames)
+		assert.NoError(t, rows.Close())
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("not bind rows to default timeout", func(t *testing.T) {
+		mockDB, sqlMock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		assert.NoError(t, err)
+		sqlMock.ExpectQuery(`SELECT first_name FROM person`).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("foo"))
+		var routed context.Context
+		resolver := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{sqlx.NewDb(mockDB, "primary")}, ReadWritePolicy: ReadWrite},
+			WithDefaultQueryTimeout(10*time.Millisecond),
+			WithRouteHook(func(ctx context.Context, _ string, _ string, _ *sqlx.DB) {
+				routed = ctx
+			}),
+		)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		rows, err := resolver.QueryxContext(ctx, `SELECT first_name FROM person`)
+		assert.NoError(t, err)
+		// The rows are still readable after the default timeout.
+		time.Sleep(50 * time.Millisecond)
+		var firstNames []string
+		for rows.Next() {
+			var firstName string
+			assert.NoError(t, rows.Scan(&firstName))
+			firstNames = append(firstNames, firstName)
+		}
+
+		assert.NoError(t, rows.Err())
+		assert.Equal(t, []string{"foo"}, firstNames)
+		assert.NoError(t, rows.Close())
+		// No context with a timer is derived, so nothing is left to release after the rows are closed.
+		assert.Same(t, ctx, routed)
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("exec within default timeout", func(t *testing.T) {
+		resolver, sqlMock := newResolver(t, time.Minute)
+		sqlMock.ExpectExec(`DELETE FROM person`).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		_, err := resolver.ExecContext(context.Background(), `DELETE FROM person`)
+
+		assert.NoError(t, err)
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+}
+
+func TestWithEffectiveQueryHook(t *testing.T) {
+	type effectiveQuery struct {
+		role  string
+		query string
+		args  []interface{}
+	}
+
+	t.Run("report compiled queries", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock1.ExpectExec(`INSERT INTO person (first_name) VALUES ($1)`).
+			WithArgs("foo").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mockPrimaryDB := sqlx.NewDb(mockDB1, "postgres")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock2.ExpectQuery(`SELECT first_name FROM person WHERE id=$1`).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("foo"))
+		mockSecondaryDB := sqlx.NewDb(mockDB2, "postgres")
+		var queries []effectiveQuery
+		r := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB}, ReadWritePolicy: WriteOnly},
+			WithSecondaryDBs(mockSecondaryDB),
+			WithEffectiveQueryHook(func(_ context.Context, role string, query string, args []interface{}) {
+				queries = append(queries, effectiveQuery{role: role, query: query, args: args})
+			}),
+		)
+
+		_, err := r.NamedExecContext(
+			context.Background(),
+			`INSERT INTO person (first_name) VALUES (:first_name)`,
+			map[string]interface{}{"first_name": "foo"},
+		)
+		assert.NoError(t, err)
+		var firstName string
+		err = r.GetContext(context.Background(), &firstName, `SELECT first_name FROM person WHERE id=$1`, 1)
+		assert.NoError(t, err)
+
+		assert.Equal(t, []effectiveQuery{
+			{role: RolePrimary, query: `INSERT INTO person (first_name) VALUES ($1)`, args: []interface{}{"foo"}},
+			{role: RoleRead, query: `SELECT first_name FROM person WHERE id=$1`, args: []interface{}{1}},
+		}, queries)
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
+
+	t.Run("report tagged queries", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock1.ExpectExec(`/* req-1 */ UPDATE person SET first_name = $1 WHERE id = $2`).
+			WithArgs("foo", 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mockPrimaryDB := sqlx.NewDb(mockDB1, "postgres")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock2.ExpectQuery(`/* req-1 */ SELECT first_name FROM person WHERE id=$1`).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("foo"))
+		mockSecondaryDB := sqlx.NewDb(mockDB2, "postgres")
+		var queries []effectiveQuery
+		r := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB}, ReadWritePolicy: WriteOnly},
+			WithSecondaryDBs(mockSecondaryDB),
+			WithQueryTagFromContext(func(context.Context) string {
+				return "req-1"
+			}),
+			WithEffectiveQueryHook(func(_ context.Context, role string, query string, args []interface{}) {
+				queries = append(queries, effectiveQuery{role: role, query: query, args: args})
+			}),
+		)
+
+		_, err := r.NamedExecContext(
+			context.Background(),
+			`UPDATE person SET first_name = :first_name WHERE id = :id`,
+			map[string]interface{}{"first_name": "foo", "id": 1},
+		)
+		assert.NoError(t, err)
+		var firstName string
+		err = r.GetContext(context.Background(), &firstName, `SELECT first_name FROM person WHERE id=$1`, 1)
+		assert.NoError(t, err)
+
+		assert.Equal(t, []effectiveQuery{
+			{
+				role:  RolePrimary,
+				query: `/* req-1 */ UPDATE person SET first_name = $1 WHERE id = $2`,
+				args:  []interface{}{"foo", 1},
+			},
+			{role: RoleRead, query: `/* req-1 */ SELECT first_name FROM person WHERE id=$1`, args: []interface{}{1}},
+		}, queries)
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
+}
+
+func TestWithHashShardedReads(t *testing.T) {
+	shardByID := func(args []interface{}) int {
+		if len(args) == 0 {
+			return -1
+		}
+		id, ok := args[0].(int)
+		if !ok {
+			return -1
+		}
+		return id % 2
+	}
+
+	t.Run("route to shard", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock1.ExpectQuery(`SELECT first_name FROM person WHERE id=?`).
+			WithArgs(2).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("foo"))
+		mockSecondaryDB1 := sqlx.NewDb(mockDB1, "secondary1")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock2.ExpectQuery(`SELECT first_name FROM person WHERE id=?`).
+			WithArgs(3).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("bar"))
+		mockSecondaryDB2 := sqlx.NewDb(mockDB2, "secondary2")
+		r := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: newMockDBs(t, 1), ReadWritePolicy: WriteOnly},
+			WithSecondaryDBs(mockSecondaryDB1, mockSecondaryDB2),
+			WithLoadBalancer(&firstLoadBalancer{}),
+			WithHashShardedReads(shardByID),
+		)
+
+		var firstName1, firstName2 string
+		err := r.GetContext(context.Background(), &firstName1, `SELECT first_name FROM person WHERE id=?`, 2)
+		assert.NoError(t, err)
+		err = r.GetContext(context.Background(), &firstName2, `SELECT first_name FROM person WHERE id=?`, 3)
+		assert.NoError(t, err)
+
+		assert.Equal(t, "foo", firstName1)
+		assert.Equal(t, "bar", firstName2)
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
+
+	t.Run("fall back to pool when shard is out of range", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock1.ExpectQuery(`SELECT first_name FROM person`).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("foo"))
+		mockSecondaryDB1 := sqlx.NewDb(mockDB1, "secondary1")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		mockSecondaryDB2 := sqlx.NewDb(mockDB2, "secondary2")
+		r := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: newMockDBs(t, 1), ReadWritePolicy: WriteOnly},
+			WithSecondaryDBs(mockSecondaryDB1, mockSecondaryDB2),
+			WithLoadBalancer(&firstLoadBalancer{}),
+			WithHashShardedReads(shardByID),
+		)
+
+		var firstName string
+		err := r.GetContext(context.Background(), &firstName, `SELECT first_name FROM person`)
+		assert.NoError(t, err)
+
+		assert.Equal(t, "foo", firstName)
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
+
+	t.Run("not hedge read routed to shard", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		mockSecondaryDB1 := sqlx.NewDb(mockDB1, "secondary1")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock2.ExpectQuery(`SELECT first_name FROM person WHERE id=?`).
+			WithArgs(1).
+			WillDelayFor(20 * time.Millisecond).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("foo"))
+		mockSecondaryDB2 := sqlx.NewDb(mockDB2, "secondary2")
+		r := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: newMockDBs(t, 1), ReadWritePolicy: WriteOnly},
+			WithSecondaryDBs(mockSecondaryDB1, mockSecondaryDB2),
+			WithHedgedReads(time.Millisecond),
+			WithHashShardedReads(shardByID),
+		)
+
+		var firstName string
+		err := r.GetContext(context.Background(), &firstName, `SELECT first_name FROM person WHERE id=?`, 1)
+		assert.NoError(t, err)
+
+		assert.Equal(t, "foo", firstName)
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
+}
+
+func TestWithLeaderSelector(t *testing.T) {
+	t.Run("write to leader", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		mockPrimaryDB1 := sqlx.NewDb(mockDB1, "primary1")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock2.ExpectExec(`DELETE FROM person`).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		sqlMock2.ExpectBegin()
+		mockPrimaryDB2 := sqlx.NewDb(mockDB2, "primary2")
+		r := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB1, mockPrimaryDB2}, ReadWritePolicy: ReadWrite},
+			WithLoadBalancer(&firstLoadBalancer{}),
+			WithLeaderSelector(func(ctx context.Context, primaries []*sqlx.DB) (*sqlx.DB, error) {
+				return mockPrimaryDB2, nil
+			}),
+		)
+
+		_, err := r.ExecContext(context.Background(), `DELETE FROM person`)
+		assert.NoError(t, err)
+		_, err = r.BeginTxx(context.Background(), nil)
+		assert.NoError(t, err)
+
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
+
+	t.Run("no leader", func(t *testing.T) {
+		mockDB, sqlMock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		mockPrimaryDB := sqlx.NewDb(mockDB, "primary")
+		mockError := errors.New("no leader elected")
+		r := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB}, ReadWritePolicy: ReadWrite},
+			WithLeaderSelector(func(ctx context.Context, primaries []*sqlx.DB) (*sqlx.DB, error) {
+				return nil, mockError
+			}),
+		)
+
+		result, err := r.ExecContext(context.Background(), `DELETE FROM person`)
+		assert.Nil(t, result)
+		assert.ErrorIs(t, err, mockError)
+		tx, err := r.BeginTxx(context.Background(), nil)
+		assert.Nil(t, tx)
+		assert.ErrorIs(t, err, mockError)
+		assert.PanicsWithValue(t, mockError, func() {
+			r.MustBegin()
+		})
+
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("nil leader", func(t *testing.T) {
+		mockDB, _, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		mockPrimaryDB := sqlx.NewDb(mockDB, "primary")
+		r := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB}, ReadWritePolicy: ReadWrite},
+			WithLeaderSelector(func(ctx context.Context, primaries []*sqlx.DB) (*sqlx.DB, error) {
+				return nil, nil
+			}),
+		)
+
+		_, err := r.ExecContext(context.Background(), `DELETE FROM person`)
+
+		assert.ErrorIs(t, err, errNoLeader)
+	})
+}
+
+func TestWithLogger(t *testing.T) {
+	type logEntry struct {
+		event string
+		err   error
+	}
+	connErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+
+	mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	sqlMock1.ExpectQuery(`SELECT first_name FROM person`).
+		WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("foo"))
+	sqlMock1.ExpectQuery(`SELECT first_name FROM person`).
+		WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("foo"))
+	mockPrimaryDB := sqlx.NewDb(mockDB1, "primary")
+	mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	sqlMock2.ExpectQuery(`SELECT first_name FROM person`).
+		WillReturnError(connErr)
+	sqlMock2.ExpectQuery(`SELECT first_name FROM person`).
+		WillReturnError(connErr)
+	mockSecondaryDB := sqlx.NewDb(mockDB2, "secondary")
+	var entries []logEntry
+	r := MustNewDBResolver(
+		&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB}, ReadWritePolicy: WriteOnly},
+		WithSecondaryDBs(mockSecondaryDB),
+		WithLogger(func(_ context.Context, event string, err error) {
+			entries = append(entries, logEntry{event: event, err: err})
+		}),
+	)
+
+	rows, err := r.QueryContext(context.Background(), `SELECT first_name FROM person`)
+	assert.NoError(t, err)
+	assert.NoError(t, rows.Close())
+	var firstName string
+	err = r.QueryRowxContext(context.Background(), `SELECT first_name FROM person`).Scan(&firstName)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []logEntry{
+		{event: "read_fallback_query", err: connErr},
+		{event: "read_fallback_query_rowx", err: connErr},
+	}, entries)
+	assert.NoError(t, sqlMock1.ExpectationsWereMet())
+	assert.NoError(t, sqlMock2.ExpectationsWereMet())
+}
+
+func TestWithMapperFunc(t *testing.T) {
+	type Person struct {
+		FirstName string
+	}
+
+	t.Run("set mapper to configured databases", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock1.ExpectQuery(`SELECT firstname FROM person`).
+			WillReturnRows(sqlmock.NewRows([]string{"firstname"}).AddRow("foo"))
+		mockPrimaryDB := sqlx.NewDb(mockDB1, "primary")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock2.ExpectQuery(`SELECT firstname FROM person`).
+			WillReturnRows(sqlmock.NewRows([]string{"firstname"}).AddRow("bar"))
+		mockSecondaryDB := sqlx.NewDb(mockDB2, "secondary")
+		r := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB}, ReadWritePolicy: ReadWrite},
+			WithSecondaryDBs(mockSecondaryDB),
+			WithLoadBalancer(&firstLoadBalancer{}),
+			WithMapperFunc(strings.ToLower),
+		)
+
+		var person1, person2 Person
+		err := r.Get(&person1, `SELECT firstname FROM person`)
+		assert.NoError(t, err)
+		err = r.RemoveSecondary(mockSecondaryDB)
+		assert.NoError(t, err)
+		err = r.Get(&person2, `SELECT firstname FROM person`)
+		assert.NoError(t, err)
+
+		assert.Equal(t, "bar", person1.FirstName)
+		assert.Equal(t, "foo", person2.FirstName)
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
+
+	t.Run("set mapper to added secondary", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		mockPrimaryDB := sqlx.NewDb(mockDB1, "primary")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock2.ExpectQuery(`SELECT FIRSTNAME FROM person`).
+			WillReturnRows(sqlmock.NewRows([]string{"FIRSTNAME"}).AddRow("foo"))
+		mockSecondaryDB := sqlx.NewDb(mockDB2, "secondary")
+		r := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB}, ReadWritePolicy: ReadWrite},
+			WithLoadBalancer(&injectedLoadBalancer{db: mockSecondaryDB}),
+			WithMapperFunc(strings.ToUpper),
+		)
+
+		r.AddSecondary(mockSecondaryDB)
+		var person Person
+		err := r.Get(&person, `SELECT FIRSTNAME FROM person`)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "foo", person.FirstName)
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
+}
+
+func TestWithMaxPreparedStatements(t *testing.T) {
+	t.Run("close least recently used statements", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock1.MatchExpectationsInOrder(false)
+		sqlMock1.ExpectPrepare(`SELECT * FROM person WHERE id=?`)
+		sqlMock1.ExpectPrepare(`SELECT * FROM person WHERE first_name=?`).
+			WillBeClosed()
+		sqlMock1.ExpectPrepare(`SELECT * FROM person WHERE last_name=?`)
+		sqlMock1.ExpectExec(`SELECT * FROM person WHERE id=?`).
+			WithArgs(1).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mockPrimaryDB := sqlx.NewDb(mockDB1, "primary")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock2.ExpectPrepare(`SELECT * FROM person WHERE id=?`)
+		sqlMock2.ExpectPrepare(`SELECT * FROM person WHERE first_name=?`).
+			WillBeClosed()
+		sqlMock2.ExpectPrepare(`SELECT * FROM person WHERE last_name=?`)
+		mockSecondaryDB := sqlx.NewDb(mockDB2, "secondary")
+		r := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB}, ReadWritePolicy: WriteOnly},
+			WithSecondaryDBs(mockSecondaryDB),
+			WithMaxPreparedStatements(4),
+		)
+
+		stmt1, err := r.Preparex(`SELECT * FROM person WHERE id=?`)
+		assert.NoError(t, err)
+		stmt2, err := r.Preparex(`SELECT * FROM person WHERE first_name=?`)
+		assert.NoError(t, err)
+		_, err = stmt1.Exec(1)
+		assert.NoError(t, err)
+		_, err = r.Preparex(`SELECT * FROM person WHERE last_name=?`)
+		assert.NoError(t, err)
+
+		_, err = stmt2.Exec("foo")
+		assert.Error(t, err)
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
+
+	t.Run("closed statements free the room", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock1.ExpectPrepare(`SELECT * FROM person WHERE id=?`).
+			WillBeClosed()
+		sqlMock1.ExpectPrepare(`SELECT * FROM person WHERE first_name=?`)
+		mockPrimaryDB := sqlx.NewDb(mockDB1, "primary")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock2.ExpectPrepare(`SELECT * FROM person WHERE id=?`).
+			WillBeClosed()
+		sqlMock2.ExpectPrepare(`SELECT * FROM person WHERE first_name=?`)
+		mockSecondaryDB := sqlx.NewDb(mockDB2, "secondary")
+		r := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB}, ReadWritePolicy: WriteOnly},
+			WithSecondaryDBs(mockSecondaryDB),
+			WithMaxPreparedStatements(2),
+		)
+
+		stmt1, err := r.PrepareNamed(`SELECT * FROM person WHERE id=:id`)
+		assert.NoError(t, err)
+		assert.NoError(t, stmt1.Close())
+		_, err = r.PrepareNamed(`SELECT * FROM person WHERE first_name=:first_name`)
+
+		assert.NoError(t, err)
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
+
+	t.Run("return error when statement exceeds limit alone", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		mockPrimaryDB := sqlx.NewDb(mockDB1, "primary")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		mockSecondaryDB := sqlx.NewDb(mockDB2, "secondary")
+		r := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB}, ReadWritePolicy: WriteOnly},
+			WithSecondaryDBs(mockSecondaryDB),
+			WithMaxPreparedStatements(1),
+		)
+
+		stmt, err := r.Preparex(`SELECT * FROM person WHERE id=?`)
+
+		assert.Nil(t, stmt)
+		assert.ErrorIs(t, err, errTooManyPreparedStatements)
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
+}
+
+type observedQuery struct {
+	info QueryInfo
+	err  error
+	// traced reports whether the context of BeforeQuery was passed to AfterQuery.
+	traced bool
+}
+
+type testObserver struct {
+	mu      sync.Mutex
+	before  []QueryInfo
+	queries []observedQuery
+}
+
+type testObserverKey struct{}
+
+func (o *testObserver) BeforeQuery(ctx context.Context, info QueryInfo) context.Context {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.before = append(o.before, info)
+	return context.WithValue(ctx, testObserverKey{}, true)
+}
+
+func (o *testObserver) AfterQuery(ctx context.Context, info QueryInfo, err error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	traced, _ := ctx.Value(testObserverKey{}).(bool)
+	o.queries = append(o.queries, observedQuery{info: info, err: err, traced: traced})
+}
+
+func TestWithObserver(t *testing.T) {
+	newClock := func() func() time.Time {
+		now := time.Unix(0, 0)
+		return func() time.Time {
+			now = now.Add(10 * time.Millisecond)
+			return now
+		}
+	}
+
+	t.Run("observe exec and get", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock1.ExpectExec(`INSERT INTO person (first_name) VALUES (?)`).
+			WithArgs("foo").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mockPrimaryDB := sqlx.NewDb(mockDB1, "primary")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		mockError := errors.New("mock error")
+		sqlMock2.ExpectQuery(`SELECT first_name FROM person`).
+			WillReturnError(mockError)
+		mockSecondaryDB := sqlx.NewDb(mockDB2, "secondary")
+		observer := &testObserver{}
+		r := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB}, ReadWritePolicy: WriteOnly},
+			WithSecondaryDBConfigs(SecondaryDBConfig{DB: mockSecondaryDB, Name: "replica-a"}),
+			WithObserver(observer),
+			WithClock(newClock()),
+		)
+
+		_, err := r.ExecContext(context.Background(), `INSERT INTO person (first_name) VALUES (?)`, "foo")
+		assert.NoError(t, err)
+		var firstName string
+		err = r.Get(&firstName, `SELECT first_name FROM person`)
+		assert.ErrorIs(t, err, mockError)
+
+		assert.Equal(t, []QueryInfo{
+			{Op: "Exec", Role: RolePrimary, DB: "primary[0]", Query: `INSERT INTO person (first_name) VALUES (?)`},
+			{Op: "Get", Role: RoleRead, DB: "replica-a", Query: `SELECT first_name FROM person`},
+		}, observer.before)
+		assert.Equal(t, []observedQuery{
+			{
+				info: QueryInfo{
+					Op:       "Exec",
+					Role:     RolePrimary,
+					DB:       "primary[0]",
+					Query:    `INSERT INTO person (first_name) VALUES (?)`,
+					Duration: 10 * time.Millisecond,
+				},
+				traced: true,
+			},
+			{
+				info: QueryInfo{
+					Op:       "Get",
+					Role:     RoleRead,
+					DB:       "replica-a",
+					Query:    `SELECT first_name FROM person`,
+					Duration: 10 * time.Millisecond,
+				},
+				err:    mockError,
+				traced: true,
+			},
+		}, observer.queries)
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
+
+	t.Run("observe panicked query", func(t *testing.T) {
+		mockSecondaryDBs := newMockDBs(t, 2)
+		observer := &testObserver{}
+		r := &dbResolver{
+			primaries:   newMockDBs(t, 1),
+			secondaries: mockSecondaryDBs,
+			reads:       mockSecondaryDBs,
+			observer:    observer,
+		}
+
+		assert.Panics(t, func() {
+			_ = r.run(context.Background(), newRequest("Get", `SELECT 1`), RoleRead, mockSecondaryDBs[1], func(*sqlx.DB) error {
+				panic("mock panic")
+			})
+		})
+
+		assert.Len(t, observer.queries, 1)
+		assert.Equal(t, "secondary[1]", observer.queries[0].info.DB)
+		assert.ErrorIs(t, observer.queries[0].err, errQueryPanicked)
+	})
+}
+
+func TestWithPingOnStart(t *testing.T) {
+	t.Run("return error of failed ping", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.MonitorPingsOption(true))
+		sqlMock1.ExpectPing()
+		mockPrimaryDB := sqlx.NewDb(mockDB1, "primary")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.MonitorPingsOption(true))
+		sqlMock2.ExpectPing()
+		mockSecondaryDB1 := sqlx.NewDb(mockDB2, "secondary")
+		mockDB3, sqlMock3, _ := sqlmock.New(sqlmock.MonitorPingsOption(true))
+		mockError := errors.New("mock error")
+		sqlMock3.ExpectPing().
+			WillReturnError(mockError)
+		mockSecondaryDB2 := sqlx.NewDb(mockDB3, "secondary")
+
+		r, err := NewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB}, ReadWritePolicy: WriteOnly},
+			WithSecondaryDBs(mockSecondaryDB1, mockSecondaryDB2),
+			WithPingOnStart(context.Background()),
+		)
+
+		assert.Nil(t, r)
+		assert.ErrorIs(t, err, mockError)
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+		assert.NoError(t, sqlMock3.ExpectationsWereMet())
+	})
+
+	t.Run("return resolver if all pings succeed", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.MonitorPingsOption(true))
+		sqlMock1.ExpectPing()
+		mockPrimaryDB := sqlx.NewDb(mockDB1, "primary")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.MonitorPingsOption(true))
+		sqlMock2.ExpectPing()
+		mockSecondaryDB := sqlx.NewDb(mockDB2, "secondary")
+
+		r, err := NewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB}, ReadWritePolicy: WriteOnly},
+			WithSecondaryDBs(mockSecondaryDB),
+			WithPingOnStart(context.Background()),
+		)
+
+		assert.NoError(t, err)
+		assert.NotNil(t, r)
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
+}
+
+func TestWithPrimaryReadFallbackOnly(t *testing.T) {
+	connErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+
+	t.Run("read from secondaries", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		mockPrimaryDB := sqlx.NewDb(mockDB1, "primary")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		for i := 0; i < 3; i++ {
+			sqlMock2.ExpectQuery(`SELECT first_name FROM person`).
+				WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("foo"))
+		}
+		mockSecondaryDB := sqlx.NewDb(mockDB2, "secondary")
+		r := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB}, ReadWritePolicy: ReadWrite},
+			WithSecondaryDBs(mockSecondaryDB),
+			WithPrimaryReadFallbackOnly(),
+		)
+
+		assert.Equal(t, []*sqlx.DB{mockSecondaryDB}, r.ReadDBs())
+		for i := 0; i < 3; i++ {
+			var firstName string
+			err := r.GetContext(context.Background(), &firstName, `SELECT first_name FROM person`)
+			assert.NoError(t, err)
+			assert.Equal(t, "foo", firstName)
+		}
+		assert.Equal(t, RoutingStats{ReadQueries: 3}, r.RoutingStats())
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
+
+	t.Run("fall back to primary on connection error", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock1.ExpectQuery(`SELECT first_name FROM person`).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("foo"))
+		mockPrimaryDB := sqlx.NewDb(mockDB1, "primary")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock2.ExpectQuery(`SELECT first_name FROM person`).
+			WillReturnError(connErr)
+		mockSecondaryDB := sqlx.NewDb(mockDB2, "secondary")
+		r := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB}, ReadWritePolicy: ReadWrite},
+			WithSecondaryDBs(mockSecondaryDB),
+			WithPrimaryReadFallbackOnly(),
+		)
+
+		rows, err := r.QueryContext(context.Background(), `SELECT first_name FROM person`)
+
+		assert.NoError(t, err)
+		assert.True(t, rows.Next())
+		var firstName string
+		assert.NoError(t, rows.Scan(&firstName))
+		assert.Equal(t, "foo", firstName)
+		assert.NoError(t, rows.Close())
+		assert.Equal(t, RoutingStats{ReadQueries: 1, PrimaryQueries: 1, Fallbacks: 1}, r.RoutingStats())
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
+
+	t.Run("read from primary without secondaries", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock1.ExpectQuery(`SELECT first_name FROM person`).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("foo"))
+		mockPrimaryDB := sqlx.NewDb(mockDB1, "primary")
+		r, err := NewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB}, ReadWritePolicy: ReadWrite},
+			WithPrimaryReadFallbackOnly(),
+		)
+		assert.NoError(t, err)
+
+		var firstName string
+		err = r.GetContext(context.Background(), &firstName, `SELECT first_name FROM person`)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "foo", firstName)
+		assert.Equal(t, []*sqlx.DB{mockPrimaryDB}, r.ReadDBs())
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+	})
+}
+
+func TestWithQueryRouting(t *testing.T) {
+	newDBs := func(t *testing.T) (*sqlx.DB, sqlmock.Sqlmock, *sqlx.DB, sqlmock.Sqlmock) {
+		t.Helper()
+
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		return sqlx.NewDb(mockDB1, "primary"), sqlMock1, sqlx.NewDb(mockDB2, "secondary"), sqlMock2
+	}
+
+	t.Run("route writes to primary", func(t *testing.T) {
+		insertQuery := "/* app: api */ INSERT INTO person (first_name) VALUES (?) RETURNING id"
+		mockPrimaryDB, primaryMock, mockSecondaryDB, secondaryMock := newDBs(t)
+		primaryMock.ExpectQuery(insertQuery).
+			WithArgs("foo").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		primaryMock.ExpectQuery(`CALL find_person()`).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		r := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB}, ReadWritePolicy: WriteOnly},
+			WithSecondaryDBs(mockSecondaryDB),
+			WithQueryRouting(true),
+		)
+
+		var id int
+		err := r.QueryRowContext(context.Background(), insertQuery, "foo").Scan(&id)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, id)
+		rows, err := r.QueryContext(context.Background(), `CALL find_person()`)
+		assert.NoError(t, err)
+		assert.NoError(t, rows.Close())
+
+		assert.Equal(t, RoutingStats{PrimaryQueries: 2, Writes: 2}, r.RoutingStats())
+		assert.NoError(t, primaryMock.ExpectationsWereMet())
+		assert.NoError(t, secondaryMock.ExpectationsWereMet())
+	})
+
+	t.Run("route reads to secondary", func(t *testing.T) {
+		selectQuery := `WITH p AS (SELECT first_name FROM person) SELECT first_name FROM p`
+		mockPrimaryDB, primaryMock, mockSecondaryDB, secondaryMock := newDBs(t)
+		secondaryMock.ExpectQuery(`SELECT first_name FROM person`).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("foo"))
+		secondaryMock.ExpectQuery(selectQuery).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("foo"))
+		r := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB}, ReadWritePolicy: WriteOnly},
+			WithSecondaryDBs(mockSecondaryDB),
+			WithQueryRouting(true),
+		)
+
+		rows, err := r.Queryx(`SELECT first_name FROM person`)
+		assert.NoError(t, err)
+		assert.NoError(t, rows.Close())
+		var firstName string
+		err = r.QueryRowxContext(context.Background(), selectQuery).Scan(&firstName)
+		assert.NoError(t, err)
+		assert.Equal(t, "foo", firstName)
+
+		assert.Equal(t, RoutingStats{ReadQueries: 2}, r.RoutingStats())
+		assert.NoError(t, primaryMock.ExpectationsWereMet())
+		assert.NoError(t, secondaryMock.ExpectationsWereMet())
+	})
+
+	t.Run("read from secondary when disabled", func(t *testing.T) {
+		insertQuery := `INSERT INTO person (first_name) VALUES (?) RETURNING id`
+		mockPrimaryDB, primaryMock, mockSecondaryDB, secondaryMock := newDBs(t)
+		secondaryMock.ExpectQuery(insertQuery).
+			WithArgs("foo").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		r := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB}, ReadWritePolicy: WriteOnly},
+			WithSecondaryDBs(mockSecondaryDB),
+		)
+
+		rows, err := r.QueryContext(context.Background(), insertQuery, "foo")
+		assert.NoError(t, err)
+		assert.NoError(t, rows.Close())
+
+		assert.Equal(t, RoutingStats{ReadQueries: 1}, r.RoutingStats())
+		assert.NoError(t, primaryMock.ExpectationsWereMet())
+		assert.NoError(t, secondaryMock.ExpectationsWereMet())
+	})
+}
+
+func TestWithQueryTagFromContext(t *testing.T) {
+	type tagKey struct{}
+	tagFromContext := func(ctx context.Context) string {
+		tag, _ := ctx.Value(tagKey{}).(string)
+		return tag
+	}
+	newResolver := func(t *testing.T) (DBResolver, sqlmock.Sqlmock, sqlmock.Sqlmock) {
+		t.Helper()
+
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		r := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{sqlx.NewDb(mockDB1, "primary")}, ReadWritePolicy: WriteOnly},
+			WithSecondaryDBs(sqlx.NewDb(mockDB2, "secondary")),
+			WithQueryTagFromContext(tagFromContext),
+		)
+		return r, sqlMock1, sqlMock2
+	}
+
+	t.Run("tag query", func(t *testing.T) {
+		r, primaryMock, secondaryMock := newResolver(t)
+		secondaryMock.ExpectQuery(`/* request_id=42 */ SELECT first_name FROM person WHERE id = ?`).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("foo"))
+		secondaryMock.ExpectQuery(`/* request_id=* /43 */ SELECT first_name FROM person`).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("foo"))
+		ctx := context.WithValue(context.Background(), tagKey{}, "request_id=42")
+
+		rows, err := r.QueryContext(ctx, `SELECT first_name FROM person WHERE id = ?`, 1)
+		assert.NoError(t, err)
+		assert.NoError(t, rows.Close())
+		ctx = context.WithValue(context.Background(), tagKey{}, "request_id=*/43")
+		rows, err = r.QueryContext(ctx, `SELECT first_name FROM person`)
+		assert.NoError(t, err)
+		assert.NoError(t, rows.Close())
+
+		assert.NoError(t, primaryMock.ExpectationsWereMet())
+		assert.NoError(t, secondaryMock.ExpectationsWereMet())
+	})
+
+	t.Run("tag named query", func(t *testing.T) {
+		r, primaryMock, secondaryMock := newResolver(t)
+		primaryMock.ExpectExec(`/* request_id:42 */ INSERT INTO person (first_name) VALUES (?)`).
+			WithArgs("foo").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		ctx := context.WithValue(context.Background(), tagKey{}, "request_id:42")
+
+		_, err := r.NamedExecContext(
+			ctx, `INSERT INTO person (first_name) VALUES (:first_name)`, map[string]interface{}{"first_name": "foo"},
+		)
+		assert.NoError(t, err)
+
+		assert.NoError(t, primaryMock.ExpectationsWereMet())
+		assert.NoError(t, secondaryMock.ExpectationsWereMet())
+	})
+
+	t.Run("not tag query without tag", func(t *testing.T) {
+		r, primaryMock, secondaryMock := newResolver(t)
+		secondaryMock.ExpectQuery(`SELECT first_name FROM person`).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("foo"))
+
+		rows, err := r.QueryContext(context.Background(), `SELECT first_name FROM person`)
+		assert.NoError(t, err)
+		assert.NoError(t, rows.Close())
+
+		assert.NoError(t, primaryMock.ExpectationsWereMet())
+		assert.NoError(t, secondaryMock.ExpectationsWereMet())
+	})
+
+	t.Run("not tag prepared statement", func(t *testing.T) {
+		r, primaryMock, secondaryMock := newResolver(t)
+		primaryMock.ExpectPrepare(`SELECT first_name FROM person`)
+		secondaryMock.ExpectPrepare(`SELECT first_name FROM person`).
+			ExpectQuery().
+			WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("foo"))
+		ctx := context.WithValue(context.Background(), tagKey{}, "request_id=42")
+
+		stmt, err := r.PrepareContext(ctx, `SELECT first_name FROM person`)
+		assert.NoError(t, err)
+		rows, err := stmt.QueryContext(ctx)
+		assert.NoError(t, err)
+		assert.NoError(t, rows.Close())
+
+		assert.NoError(t, primaryMock.ExpectationsWereMet())
+		assert.NoError(t, secondaryMock.ExpectationsWereMet())
+	})
+}
+
+func TestWithReadOnlyEnforcement(t *testing.T) {
+	newDBs := func(t *testing.T) (*sqlx.DB, sqlmock.Sqlmock, *sqlx.DB, sqlmock.Sqlmock) {
+		t.Helper()
+
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		return sqlx.NewDb(mockDB1, "primary"), sqlMock1, sqlx.NewDb(mockDB2, "secondary"), sqlMock2
+	}
+
+	t.Run("reject write on read replica", func(t *testing.T) {
+		mockPrimaryDB, primaryMock, mockSecondaryDB, secondaryMock := newDBs(t)
+		r := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB}, ReadWritePolicy: WriteOnly},
+			WithSecondaryDBs(mockSecondaryDB),
+			WithReadOnlyEnforcement(),
+		)
+
+		rows, err := r.Query(`UPDATE person SET first_name = ? WHERE id = ?`, "foo", 1)
+		assert.Nil(t, rows)
+		assert.ErrorIs(t, err, errWriteOnReadReplica)
+		err = r.QueryRowContext(context.Background(), `/* api */ DELETE FROM person RETURNING id`).Scan(new(int))
+		assert.ErrorIs(t, err, errWriteOnReadReplica)
+
+		assert.Equal(t, RoutingStats{}, r.RoutingStats())
+		assert.NoError(t, primaryMock.ExpectationsWereMet())
+		assert.NoError(t, secondaryMock.ExpectationsWereMet())
+	})
+
+	t.Run("read from secondary", func(t *testing.T) {
+		mockPrimaryDB, primaryMock, mockSecondaryDB, secondaryMock := newDBs(t)
+		secondaryMock.ExpectQuery(`SELECT first_name FROM person`).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("foo"))
+		r := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB}, ReadWritePolicy: WriteOnly},
+			WithSecondaryDBs(mockSecondaryDB),
+			WithReadOnlyEnforcement(),
+		)
+
+		rows, err := r.Query(`SELECT first_name FROM person`)
+		assert.NoError(t, err)
+		assert.NoError(t, rows.Close())
+
+		assert.NoError(t, primaryMock.ExpectationsWereMet())
+		assert.NoError(t, secondaryMock.ExpectationsWereMet())
+	})
+
+	t.Run("write on forced primary", func(t *testing.T) {
+		mockPrimaryDB, primaryMock, mockSecondaryDB, secondaryMock := newDBs(t)
+		primaryMock.ExpectQuery(`UPDATE person SET first_name = ? RETURNING id`).
+			WithArgs("foo").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		r := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB}, ReadWritePolicy: WriteOnly},
+			WithSecondaryDBs(mockSecondaryDB),
+			WithReadOnlyEnforcement(),
+		)
+
+		rows, err := r.QueryContext(
+			WithForcePrimary(context.Background()), `UPDATE person SET first_name = ? RETURNING id`, "foo",
+		)
+		assert.NoError(t, err)
+		assert.NoError(t, rows.Close())
+
+		assert.NoError(t, primaryMock.ExpectationsWereMet())
+		assert.NoError(t, secondaryMock.ExpectationsWereMet())
+	})
+
+	t.Run("route write to primary with query routing", func(t *testing.T) {
+		mockPrimaryDB, primaryMock, mockSecondaryDB, secondaryMock := newDBs(t)
+		primaryMock.ExpectQuery(`UPDATE person SET first_name = ? RETURNING id`).
+			WithArgs("foo").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		r := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB}, ReadWritePolicy: WriteOnly},
+			WithSecondaryDBs(mockSecondaryDB),
+			WithReadOnlyEnforcement(),
+			WithQueryRouting(true),
+		)
+
+		rows, err := r.Query(`UPDATE person SET first_name = ? RETURNING id`, "foo")
+		assert.NoError(t, err)
+		assert.NoError(t, rows.Close())
+
+		assert.NoError(t, primaryMock.ExpectationsWereMet())
+		assert.NoError(t, secondaryMock.ExpectationsWereMet())
+	})
+}
+
+func TestWithReadPreference(t *testing.T) {
+	connErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+	newDBs := func(t *testing.T) (*sqlx.DB, sqlmock.Sqlmock, *sqlx.DB, sqlmock.Sqlmock) {
+		t.Helper()
+
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		return sqlx.NewDb(mockDB1, "primary"), sqlMock1, sqlx.NewDb(mockDB2, "secondary"), sqlMock2
+	}
+	holdConns := func(t *testing.T, db *sqlx.DB, n int) {
+		t.Helper()
+
+		for i := 0; i < n; i++ {
+			conn, err := db.Conn(context.Background())
+			assert.NoError(t, err)
+			t.Cleanup(func() {
+				_ = conn.Close()
+			})
+		}
+	}
+
+	t.Run("read from secondary with prefer replica", func(t *testing.T) {
+		mockPrimaryDB, primaryMock, mockSecondaryDB, secondaryMock := newDBs(t)
+		secondaryMock.ExpectQuery(`SELECT first_name FROM person`).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("foo"))
+		r := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB}, ReadWritePolicy: WriteOnly},
+			WithSecondaryDBs(mockSecondaryDB),
+			WithReadPreference(PreferReplica),
+		)
+
+		var firstName string
+		err := r.GetContext(context.Background(), &firstName, `SELECT first_name FROM person`)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "foo", firstName)
+		assert.NoError(t, primaryMock.ExpectationsWereMet())
+		assert.NoError(t, secondaryMock.ExpectationsWereMet())
+	})
+
+	t.Run("read from primary with prefer primary", func(t *testing.T) {
+		mockPrimaryDB, primaryMock, mockSecondaryDB, secondaryMock := newDBs(t)
+		primaryMock.ExpectQuery(`SELECT first_name FROM person`).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("foo"))
+		r := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB}, ReadWritePolicy: WriteOnly},
+			WithSecondaryDBs(mockSecondaryDB),
+			WithReadPreference(PreferPrimary),
+			WithPreferPrimaryMaxInUse(1),
+		)
+		holdConns(t, mockPrimaryDB, 1)
+
+		var firstName string
+		err := r.GetContext(context.Background(), &firstName, `SELECT first_name FROM person`)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "foo", firstName)
+		assert.NoError(t, primaryMock.ExpectationsWereMet())
+		assert.NoError(t, secondaryMock.ExpectationsWereMet())
+	})
+
+	t.Run("spill to secondary with prefer primary", func(t *testing.T) {
+		mockPrimaryDB, primaryMock, mockSecondaryDB, secondaryMock := newDBs(t)
+		secondaryMock.ExpectQuery(`SELECT first_name FROM person`).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("foo"))
+		r := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB}, ReadWritePolicy: WriteOnly},
+			WithSecondaryDBs(mockSecondaryDB),
+			WithReadPreference(PreferPrimary),
+			WithPreferPrimaryMaxInUse(1),
+		)
+		holdConns(t, mockPrimaryDB, 2)
+
+		var firstName string
+		err := r.GetContext(context.Background(), &firstName, `SELECT first_name FROM person`)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "foo", firstName)
+		assert.NoError(t, primaryMock.ExpectationsWereMet())
+		assert.NoError(t, secondaryMock.ExpectationsWereMet())
+	})
+
+	t.Run("spill to secondary when primary is saturated with prefer primary", func(t *testing.T) {
+		mockPrimaryDB, primaryMock, mockSecondaryDB, secondaryMock := newDBs(t)
+		secondaryMock.ExpectQuery(`SELECT first_name FROM person`).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("foo"))
+		mockPrimaryDB.SetMaxOpenConns(1)
+		r := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB}, ReadWritePolicy: WriteOnly},
+			WithSecondaryDBs(mockSecondaryDB),
+			WithReadPreference(PreferPrimary),
+		)
+		holdConns(t, mockPrimaryDB, 1)
+
+		var firstName string
+		err := r.GetContext(context.Background(), &firstName, `SELECT first_name FROM person`)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "foo", firstName)
+		assert.NoError(t, primaryMock.ExpectationsWereMet())
+		assert.NoError(t, secondaryMock.ExpectationsWereMet())
+	})
+
+	t.Run("read from primary with primary only", func(t *testing.T) {
+		mockPrimaryDB, primaryMock, mockSecondaryDB, secondaryMock := newDBs(t)
+		primaryMock.ExpectQuery(`SELECT first_name FROM person`).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("foo"))
+		r := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB}, ReadWritePolicy: WriteOnly},
+			WithSecondaryDBs(mockSecondaryDB),
+			WithReadPreference(PrimaryOnly),
+		)
+		holdConns(t, mockPrimaryDB, 2)
+
+		var firstName string
+		err := r.GetContext(context.Background(), &firstName, `SELECT first_name FROM person`)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "foo", firstName)
+		assert.NoError(t, primaryMock.ExpectationsWereMet())
+		assert.NoError(t, secondaryMock.ExpectationsWereMet())
+	})
+
+	t.Run("return error of secondary with read replica only", func(t *testing.T) {
+		mockPrimaryDB, primaryMock, mockSecondaryDB, secondaryMock := newDBs(t)
+		secondaryMock.ExpectQuery(`SELECT first_name FROM person`).
+			WillReturnError(connErr)
+		r := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB}, ReadWritePolicy: ReadWrite},
+			WithSecondaryDBs(mockSecondaryDB),
+			WithReadPreference(ReadReplicaOnly),
+		)
+
+		var firstName string
+		err := r.GetContext(context.Background(), &firstName, `SELECT first_name FROM person`)
+
+		assert.ErrorIs(t, err, connErr)
+		assert.Equal(t, []*sqlx.DB{mockSecondaryDB}, r.ReadDBs())
+		assert.NoError(t, primaryMock.ExpectationsWereMet())
+		assert.NoError(t, secondaryMock.ExpectationsWereMet())
+	})
+
+	t.Run("return errNoDBToRead without secondaries with read replica only", func(t *testing.T) {
+		mockPrimaryDB, _, _, _ := newDBs(t)
+
+		r, err := NewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB}, ReadWritePolicy: ReadWrite},
+			WithReadPreference(ReadReplicaOnly),
+		)
+
+		assert.Nil(t, r)
+		assert.ErrorIs(t, err, errNoDBToRead)
+	})
+
+	t.Run("return errInvalidReadPreference", func(t *testing.T) {
+		mockPrimaryDB, _, _, _ := newDBs(t)
+
+		r, err := NewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB}, ReadWritePolicy: ReadWrite},
+			WithReadPreference("unknown"),
+		)
+
+		assert.Nil(t, r)
+		assert.ErrorIs(t, err, errInvalidReadPreference)
+	})
+}
+
+func TestWithReadRetries(t *testing.T) {
+	connErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+
+	t.Run("retry with remaining reads", func(t *testing.T) {
+		mockDB, primaryMock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		mockPrimaryDB := sqlx.NewDb(mockDB, "primary")
+		var reads []*sqlx.DB
+		var readMocks []sqlmock.Sqlmock
+		for i := 0; i < 3; i++ {
+			mockDB, sqlMock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			reads = append(reads, sqlx.NewDb(mockDB, "secondary"))
+			readMocks = append(readMocks, sqlMock)
+		}
+		readMocks[0].ExpectQuery(`SELECT first_name FROM person`).
+			WillReturnError(connErr)
+		readMocks[1].ExpectQuery(`SELECT first_name FROM person`).
+			WillReturnError(connErr)
+		readMocks[2].ExpectQuery(`SELECT first_name FROM person`).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("foo"))
+		r := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB}, ReadWritePolicy: WriteOnly},
+			WithSecondaryDBs(reads...),
+			WithLoadBalancer(&firstLoadBalancer{}),
+			WithReadRetries(2),
+		)
+
+		var firstName string
+		err := r.Get(&firstName, `SELECT first_name FROM person`)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "foo", firstName)
+		assert.NoError(t, primaryMock.ExpectationsWereMet())
+		for _, sqlMock := range readMocks {
+			assert.NoError(t, sqlMock.ExpectationsWereMet())
+		}
+	})
+
+	t.Run("fall back to primary after retries", func(t *testing.T) {
+		mockDB, primaryMock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		primaryMock.ExpectQuery(`SELECT first_name FROM person`).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("bar"))
+		mockPrimaryDB := sqlx.NewDb(mockDB, "primary")
+		var reads []*sqlx.DB
+		var readMocks []sqlmock.Sqlmock
+		for i := 0; i < 3; i++ {
+			mockDB, sqlMock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			reads = append(reads, sqlx.NewDb(mockDB, "secondary"))
+			readMocks = append(readMocks, sqlMock)
+		}
+		readMocks[0].ExpectQuery(`SELECT first_name FROM person`).
+			WillReturnError(connErr)
+		readMocks[1].ExpectQuery(`SELECT first_name FROM person`).
+			WillReturnError(connErr)
+		r := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB}, ReadWritePolicy: WriteOnly},
+			WithSecondaryDBs(reads...),
+			WithLoadBalancer(&firstLoadBalancer{}),
+			WithReadRetries(1),
+		)
+
+		var firstName string
+		err := r.Get(&firstName, `SELECT first_name FROM person`)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "bar", firstName)
+		assert.NoError(t, primaryMock.ExpectationsWereMet())
+		for _, sqlMock := range readMocks {
+			assert.NoError(t, sqlMock.ExpectationsWereMet())
+		}
+	})
+
+	t.Run("retry query row with remaining reads", func(t *testing.T) {
+		mockDB, primaryMock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		mockPrimaryDB := sqlx.NewDb(mockDB, "primary")
+		var reads []*sqlx.DB
+		var readMocks []sqlmock.Sqlmock
+		for i := 0; i < 2; i++ {
+			mockDB, sqlMock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			reads = append(reads, sqlx.NewDb(mockDB, "secondary"))
+			readMocks = append(readMocks, sqlMock)
+		}
+		for i := 0; i < 2; i++ {
+			readMocks[0].ExpectQuery(`SELECT first_name FROM person`).
+				WillReturnError(connErr)
+			readMocks[1].ExpectQuery(`SELECT first_name FROM person`).
+				WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("foo"))
+		}
+		r := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB}, ReadWritePolicy: WriteOnly},
+			WithSecondaryDBs(reads...),
+			WithLoadBalancer(&firstLoadBalancer{}),
+			WithReadRetries(1),
+		)
+
+		var firstName1, firstName2 string
+		err := r.QueryRowx(`SELECT first_name FROM person`).Scan(&firstName1)
+		assert.NoError(t, err)
+		err = r.QueryRowxContext(context.Background(), `SELECT first_name FROM person`).Scan(&firstName2)
+		assert.NoError(t, err)
+
+		assert.Equal(t, "foo", firstName1)
+		assert.Equal(t, "foo", firstName2)
+		assert.NoError(t, primaryMock.ExpectationsWereMet())
+		for _, sqlMock := range readMocks {
+			assert.NoError(t, sqlMock.ExpectationsWereMet())
+		}
+	})
+}
+
+func TestWithReadSampling(t *testing.T) {
+	const selections = 10000
+
+	t.Run("route fraction of reads to sampled db", func(t *testing.T) {
+		mockPrimaryDBs := newMockDBs(t, 1)
+		mockSecondaryDBs := newMockDBs(t, 3)
+		r := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: mockPrimaryDBs, ReadWritePolicy: WriteOnly},
+			WithSecondaryDBs(mockSecondaryDBs...),
+			WithReadSampling(map[*sqlx.DB]float64{mockSecondaryDBs[2]: 0.05}),
+			WithLoadBalancerSeed(1),
+		)
+
+		counts := make(map[*sqlx.DB]int)
+		for i := 0; i < selections; i++ {
+			counts[r.AcquireRead()]++
+		}
+
+		assert.Len(t, counts, 3)
+		assert.InDelta(t, 0.05, float64(counts[mockSecondaryDBs[2]])/selections, 0.01)
+		assert.InDelta(t, 0.475, float64(counts[mockSecondaryDBs[0]])/selections, 0.02)
+		assert.InDelta(t, 0.475, float64(counts[mockSecondaryDBs[1]])/selections, 0.02)
+	})
+
+	t.Run("never route reads to db with zero rate", func(t *testing.T) {
+		mockPrimaryDBs := newMockDBs(t, 1)
+		mockSecondaryDBs := newMockDBs(t, 2)
+		r := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: mockPrimaryDBs, ReadWritePolicy: WriteOnly},
+			WithSecondaryDBs(mockSecondaryDBs...),
+			WithReadSampling(map[*sqlx.DB]float64{mockSecondaryDBs[1]: 0}),
+		)
+
+		for i := 0; i < 100; i++ {
+			assert.Same(t, mockSecondaryDBs[0], r.AcquireRead())
+		}
+	})
+
+	t.Run("route rest of reads to sampled dbs without unsampled db", func(t *testing.T) {
+		mockPrimaryDBs := newMockDBs(t, 1)
+		mockSecondaryDBs := newMockDBs(t, 2)
+		r := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: mockPrimaryDBs, ReadWritePolicy: WriteOnly},
+			WithSecondaryDBs(mockSecondaryDBs...),
+			WithReadSampling(map[*sqlx.DB]float64{mockSecondaryDBs[0]: 0.1, mockSecondaryDBs[1]: 0.1}),
+		)
+
+		for i := 0; i < 100; i++ {
+			assert.Contains(t, mockSecondaryDBs, r.AcquireRead())
+		}
+	})
+
+	t.Run("invalid rate", func(t *testing.T) {
+		mockPrimaryDBs := newMockDBs(t, 1)
+		mockSecondaryDBs := newMockDBs(t, 1)
+
+		r, err := NewDBResolver(
+			&PrimaryDBsConfig{DBs: mockPrimaryDBs, ReadWritePolicy: WriteOnly},
+			WithSecondaryDBs(mockSecondaryDBs...),
+			WithReadSampling(map[*sqlx.DB]float64{mockSecondaryDBs[0]: 1.5}),
+		)
+
+		assert.Nil(t, r)
+		assert.ErrorIs(t, err, errInvalidSamplingRate)
+	})
+}
+
+func TestWithReadYourWrites(t *testing.T) {
+	mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	sqlMock1.ExpectExec(`UPDATE person SET first_name = "foo"`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	sqlMock1.ExpectQuery(`SELECT first_name FROM person`).
+		WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("foo"))
+	mockPrimaryDB := sqlx.NewDb(mockDB1, "primary")
+	mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	sqlMock2.ExpectQuery(`SELECT first_name FROM person`).
+		WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("bar"))
+	mockSecondaryDB := sqlx.NewDb(mockDB2, "secondary")
+	now := time.Now()
+	r := MustNewDBResolver(
+		&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB}, ReadWritePolicy: WriteOnly},
+		WithSecondaryDBs(mockSecondaryDB),
+		WithReadYourWrites(time.Second),
+		WithClock(func() time.Time {
+			return now
+		}),
+	)
+
+	_, err := r.ExecContext(context.Background(), `UPDATE person SET first_name = "foo"`)
+	assert.NoError(t, err)
+	var firstName string
+	err = r.GetContext(context.Background(), &firstName, `SELECT first_name FROM person`)
+	assert.NoError(t, err)
+	assert.Equal(t, "foo", firstName)
+
+	now = now.Add(time.Second)
+	err = r.GetContext(context.Background(), &firstName, `SELECT first_name FROM person`)
+	assert.NoError(t, err)
+	assert.Equal(t, "bar", firstName)
+
+	assert.NoError(t, sqlMock1.ExpectationsWereMet())
+	assert.NoError(t, sqlMock2.ExpectationsWereMet())
+}
+
+func TestWithRouteHook(t *testing.T) {
+	type route struct {
+		op   string
+		role string
+		db   *sqlx.DB
+	}
+	mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	sqlMock1.ExpectExec(`DELETE FROM person`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mockPrimaryDB := sqlx.NewDb(mockDB1, "primary")
+	mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	sqlMock2.ExpectQuery(`SELECT first_name FROM person`).
+		WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("foo"))
+	mockSecondaryDB := sqlx.NewDb(mockDB2, "secondary")
+	var routes []route
+	r := MustNewDBResolver(
+		&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB}, ReadWritePolicy: WriteOnly},
+		WithSecondaryDBs(mockSecondaryDB),
+		WithRouteHook(func(ctx context.Context, op string, role string, db *sqlx.DB) {
+			routes = append(routes, route{op, role, db})
+		}),
+	)
+
+	_, err := r.Exec(`DELETE FROM person`)
+	assert.NoError(t, err)
+	var firstName string
+	err = r.Get(&firstName, `SELECT first_name FROM person`)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "foo", firstName)
+	expected := []route{
+		{"Exec", RolePrimary, mockPrimaryDB},
+		{"Get", RoleRead, mockSecondaryDB},
+	}
+	assert.Equal(t, expected, routes)
+	assert.NoError(t, sqlMock1.ExpectationsWereMet())
+	assert.NoError(t, sqlMock2.ExpectationsWereMet())
+}
+
+func TestWithStrictReadSeparation(t *testing.T) {
+	connErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+
+	t.Run("return error of secondary", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		mockPrimaryDB := sqlx.NewDb(mockDB1, "primary")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock2.ExpectQuery(`SELECT first_name FROM person`).
+			WillReturnError(connErr)
+		mockSecondaryDB1 := sqlx.NewDb(mockDB2, "secondary1")
+		mockDB3, sqlMock3, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock3.ExpectQuery(`SELECT first_name FROM person`).
+			WillReturnError(connErr)
+		mockSecondaryDB2 := sqlx.NewDb(mockDB3, "secondary2")
+		r := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB}, ReadWritePolicy: WriteOnly},
+			WithSecondaryDBs(mockSecondaryDB1, mockSecondaryDB2),
+			WithLoadBalancer(&firstLoadBalancer{}),
+			WithReadRetries(1),
+			WithStrictReadSeparation(),
+		)
+
+		var firstName string
+		err := r.GetContext(context.Background(), &firstName, `SELECT first_name FROM person`)
+
+		assert.ErrorIs(t, err, connErr)
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+		assert.NoError(t, sqlMock3.ExpectationsWereMet())
+	})
+
+	t.Run("read from primary with read-write policy", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock1.ExpectQuery(`SELECT first_name FROM person`).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("foo"))
+		mockPrimaryDB := sqlx.NewDb(mockDB1, "primary")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		mockSecondaryDB := sqlx.NewDb(mockDB2, "secondary")
+		r := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB}, ReadWritePolicy: ReadWrite},
+			WithSecondaryDBs(mockSecondaryDB),
+			WithLoadBalancer(&injectedLoadBalancer{db: mockPrimaryDB}),
+			WithStrictReadSeparation(),
+		)
+
+		var firstName string
+		err := r.GetContext(context.Background(), &firstName, `SELECT first_name FROM person`)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "foo", firstName)
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
+
+	t.Run("return errNoDBToRead after removing all secondaries", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		mockPrimaryDB := sqlx.NewDb(mockDB1, "primary")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		mockSecondaryDB := sqlx.NewDb(mockDB2, "secondary")
+		r := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB}, ReadWritePolicy: WriteOnly},
+			WithSecondaryDBs(mockSecondaryDB),
+			WithStrictReadSeparation(),
+		)
+		assert.NoError(t, r.RemoveSecondary(mockSecondaryDB))
+
+		var firstName string
+		err := r.GetContext(context.Background(), &firstName, `SELECT first_name FROM person`)
+		assert.ErrorIs(t, err, errNoDBToRead)
+		rows, err := r.QueryContext(context.Background(), `SELECT first_name FROM person`)
+		assert.Nil(t, rows)
+		assert.ErrorIs(t, err, errNoDBToRead)
+		err = r.WithReplicaConn(context.Background(), func(_ *sqlx.Conn) error {
+			return nil
+		})
+		assert.ErrorIs(t, err, errNoDBToRead)
+
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
+}
+
+func TestWithTolerantPrepare(t *testing.T) {
+	mockError := errors.New("mock error")
+	newDBs := func(t *testing.T) (*sqlx.DB, []*sqlx.DB, []sqlmock.Sqlmock) {
+		t.Helper()
+
+		mockDB, primaryMock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		primaryMock.ExpectPrepare(`SELECT first_name FROM person WHERE id=?`)
+		var reads []*sqlx.DB
+		var readMocks []sqlmock.Sqlmock
+		for i := 0; i < 2; i++ {
+			mockDB, sqlMock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			reads = append(reads, sqlx.NewDb(mockDB, "secondary"))
+			readMocks = append(readMocks, sqlMock)
+		}
+		return sqlx.NewDb(mockDB, "primary"), reads, readMocks
+	}
+
+	t.Run("skip read failing to prepare", func(t *testing.T) {
+		primaryDB, reads, readMocks := newDBs(t)
+		readMocks[0].ExpectPrepare(`SELECT first_name FROM person WHERE id=?`).
+			WillReturnError(mockError)
+		readMocks[1].ExpectPrepare(`SELECT first_name FROM person WHERE id=?`).
+			ExpectQuery().
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("foo"))
+		var events []string
+		r := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{primaryDB}, ReadWritePolicy: WriteOnly},
+			WithSecondaryDBs(reads...),
+			WithLoadBalancer(&firstLoadBalancer{}),
+			WithTolerantPrepare(),
+			WithLogger(func(_ context.Context, event string, err error) {
+				assert.ErrorIs(t, err, mockError)
+				events = append(events, event)
+			}),
+		)
+
+		s, err := r.PreparexContext(context.Background(), `SELECT first_name FROM person WHERE id=?`)
+		assert.NoError(t, err)
+		var firstName string
+		err = s.Get(&firstName, 1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "foo", firstName)
+		assert.Equal(t, []string{"prepare_skipped"}, events)
+		for _, sqlMock := range readMocks {
+			assert.NoError(t, sqlMock.ExpectationsWereMet())
+		}
+	})
+
+	t.Run("return error when all reads fail to prepare", func(t *testing.T) {
+		primaryDB, reads, readMocks := newDBs(t)
+		for _, sqlMock := range readMocks {
+			sqlMock.ExpectPrepare(`SELECT first_name FROM person WHERE id=?`).
+				WillReturnError(mockError)
+		}
+		r := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{primaryDB}, ReadWritePolicy: WriteOnly},
+			WithSecondaryDBs(reads...),
+			WithTolerantPrepare(),
+		)
+
+		s, err := r.PreparexContext(context.Background(), `SELECT first_name FROM person WHERE id=?`)
+
+		assert.Nil(t, s)
+		assert.ErrorIs(t, err, mockError)
+	})
+
+	t.Run("return error without option", func(t *testing.T) {
+		primaryDB, reads, readMocks := newDBs(t)
+		readMocks[0].ExpectPrepare(`SELECT first_name FROM person WHERE id=?`).
+			WillReturnError(mockError)
+		readMocks[1].ExpectPrepare(`SELECT first_name FROM person WHERE id=?`)
+		r := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{primaryDB}, ReadWritePolicy: WriteOnly},
+			WithSecondaryDBs(reads...),
+		)
+
+		s, err := r.PreparexContext(context.Background(), `SELECT first_name FROM person WHERE id=?`)
+
+		assert.Nil(t, s)
+		assert.ErrorIs(t, err, mockError)
+	})
+}
+
+func TestWithWrappedErrors(t *testing.T) {
+	newDBs := func(t *testing.T) (*sqlx.DB, sqlmock.Sqlmock, []*sqlx.DB, sqlmock.Sqlmock) {
+		t.Helper()
+
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		mockDB2, _, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		mockDB3, sqlMock3, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		mockSecondaryDBs := []*sqlx.DB{sqlx.NewDb(mockDB2, "secondary"), sqlx.NewDb(mockDB3, "secondary")}
+		return sqlx.NewDb(mockDB1, "primary"), sqlMock1, mockSecondaryDBs, sqlMock3
+	}
+
+	t.Run("wrap error of primary", func(t *testing.T) {
+		mockPrimaryDB, primaryMock, mockSecondaryDBs, _ := newDBs(t)
+		mockError := errors.New("mock error")
+		primaryMock.ExpectExec(`INSERT INTO person (first_name) VALUES (?)`).
+			WithArgs("foo").
+			WillReturnError(mockError)
+		r := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB}, ReadWritePolicy: WriteOnly},
+			WithSecondaryDBs(mockSecondaryDBs...),
+			WithWrappedErrors(),
+		)
+
+		_, err := r.Exec(`INSERT INTO person (first_name) VALUES (?)`, "foo")
+
+		assert.ErrorIs(t, err, mockError)
+		var qErr *QueryError
+		assert.ErrorAs(t, err, &qErr)
+		assert.Equal(t, &QueryError{Err: mockError, Role: RolePrimary, DriverName: "primary", Index: 0}, qErr)
+		assert.EqualError(t, err, "primary[0] (primary): mock error")
+		assert.NoError(t, primaryMock.ExpectationsWereMet())
+	})
+
+	t.Run("wrap error of secondary", func(t *testing.T) {
+		mockPrimaryDB, primaryMock, mockSecondaryDBs, secondaryMock := newDBs(t)
+		secondaryMock.ExpectQuery(`SELECT first_name FROM person`).
+			WillReturnError(sql.ErrNoRows)
+		r := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB}, ReadWritePolicy: WriteOnly},
+			WithSecondaryDBs(mockSecondaryDBs...),
+			WithLoadBalancer(&injectedLoadBalancer{db: mockSecondaryDBs[1]}),
+			WithWrappedErrors(),
+		)
+
+		var firstName string
+		err := r.GetContext(context.Background(), &firstName, `SELECT first_name FROM person`)
+
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+		var qErr *QueryError
+		assert.ErrorAs(t, err, &qErr)
+		assert.Equal(t, RoleReplica, qErr.Role)
+		assert.Equal(t, "secondary", qErr.DriverName)
+		assert.Equal(t, 1, qErr.Index)
+		assert.NoError(t, primaryMock.ExpectationsWereMet())
+		assert.NoError(t, secondaryMock.ExpectationsWereMet())
+	})
+
+	t.Run("wrap error of primary after fallback", func(t *testing.T) {
+		mockPrimaryDB, primaryMock, mockSecondaryDBs, secondaryMock := newDBs(t)
+		connErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+		secondaryMock.ExpectQuery(`SELECT first_name FROM person`).
+			WillReturnError(connErr)
+		mockError := errors.New("mock error")
+		primaryMock.ExpectQuery(`SELECT first_name FROM person`).
+			WillReturnError(mockError)
+		r := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB}, ReadWritePolicy: WriteOnly},
+			WithSecondaryDBs(mockSecondaryDBs[1]),
+			WithWrappedErrors(),
+		)
+
+		rows, err := r.QueryContext(context.Background(), `SELECT first_name FROM person`)
+
+		assert.Nil(t, rows)
+		assert.ErrorIs(t, err, mockError)
+		var qErr *QueryError
+		assert.ErrorAs(t, err, &qErr)
+		assert.Equal(t, RolePrimary, qErr.Role)
+		assert.Equal(t, RoutingStats{ReadQueries: 1, PrimaryQueries: 1, Fallbacks: 1}, r.RoutingStats())
+		assert.NoError(t, primaryMock.ExpectationsWereMet())
+		assert.NoError(t, secondaryMock.ExpectationsWereMet())
+	})
+
+	t.Run("not wrap error without option", func(t *testing.T) {
+		mockPrimaryDB, primaryMock, mockSecondaryDBs, _ := newDBs(t)
+		mockError := errors.New("mock error")
+		primaryMock.ExpectExec(`INSERT INTO person (first_name) VALUES (?)`).
+			WithArgs("foo").
+			WillReturnError(mockError)
+		r := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB}, ReadWritePolicy: WriteOnly},
+			WithSecondaryDBs(mockSecondaryDBs...),
+		)
+
+		_, err := r.Exec(`INSERT INTO person (first_name) VALUES (?)`, "foo")
+
+		assert.Equal(t, mockError, err)
+		assert.NoError(t, primaryMock.ExpectationsWereMet())
+	})
+}
+
+func TestWithWriteRetry(t *testing.T) {
+	mockDB, _, _ := sqlmock.New()
+	primaryDBsCfg := &PrimaryDBsConfig{
+		DBs: []*sqlx.DB{sqlx.NewDb(mockDB, "primary")},
+	}
+
+	r, err := NewDBResolver(primaryDBsCfg, WithWriteRetry(2))
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, r.(*dbResolver).writeRetries)
+}
@@ -0,0 +1,51 @@
+package dbresolver
+
+import (
+	"sync/atomic"
+)
+
+// RoutingStats is the numbers of the queries routed by the resolver.
+type RoutingStats struct {
+	// PrimaryQueries is the number of the queries run on the primary databases, including the writes.
+	PrimaryQueries uint64
+	// ReadQueries is the number of the queries run on the readable databases.
+	ReadQueries uint64
+	// Fallbacks is the number of the reads falling back to a primary database because of a connection error.
+	Fallbacks uint64
+	// Writes is the number of the writes. A retried write is counted once.
+	Writes uint64
+}
+
+// routingCounters counts the queries routed by the resolver. It is safe for concurrent use.
+type routingCounters struct {
+	primaryQueries uint64
+	readQueries    uint64
+	fallbacks      uint64
+	writes         uint64
+}
+
+// countQuery counts a query run on a database of the given role.
+func (c *routingCounters) countQuery(role string) {
+	if role == RolePrimary {
+		atomic.AddUint64(&c.primaryQueries, 1)
+		return
+	}
+	atomic.AddUint64(&c.readQueries, 1)
+}
+
+func (c *routingCounters) countFallback() {
+	atomic.AddUint64(&c.fallbacks, 1)
+}
+
+func (c *routingCounters) countWrite() {
+	atomic.AddUint64(&c.writes, 1)
+}
+
+func (c *routingCounters) stats() RoutingStats {
+	return RoutingStats{
+		PrimaryQueries: atomic.LoadUint64(&c.primaryQueries),
+		ReadQueries:    atomic.LoadUint64(&c.readQueries),
+		Fallbacks:      atomic.LoadUint64(&c.fallbacks),
+		Writes:         atomic.LoadUint64(&c.writes),
+	}
+}
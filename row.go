@@ -0,0 +1,203 @@
+package dbresolver
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Row is the result of QueryRowFallback, which is a wrapper around sql.Row.
+// The connection errors often surface only when the row is scanned, so if Scan fails with a connection error
+// of the readable database which ran the query, it runs the query again on a primary database and scans from it.
+type Row struct {
+	row *sql.Row
+	// fallback runs the query on a primary database if the error is a connection error, or returns nil otherwise.
+	// It is nil if the row cannot fall back.
+	fallback func(err error) *sql.Row
+}
+
+// Err returns the error of the query if any.
+// Err is a wrapper around sql.Row.Err.
+func (r *Row) Err() error {
+	return r.row.Err()
+}
+
+// Scan copies the columns of the row into the values pointed at by dest.
+// Scan is a wrapper around sql.Row.Scan.
+func (r *Row) Scan(dest ...interface{}) error {
+	err := r.row.Scan(dest...)
+	if err == nil || r.fallback == nil {
+		return err
+	}
+
+	row := r.fallback(err)
+	r.fallback = nil
+	if row == nil {
+		return err
+	}
+	r.row = row
+	return r.row.Scan(dest...)
+}
+
+// Rowx is the result of QueryRowxFallback, which is a wrapper around sqlx.Row.
+// Like Row, if the row fails to scan with a connection error of the readable database which ran the query,
+// it runs the query again on a primary database and scans from it.
+type Rowx struct {
+	row *sqlx.Row
+	// fallback runs the query on a primary database if the error is a connection error, or returns nil otherwise.
+	// It is nil if the row cannot fall back.
+	fallback func(err error) *sqlx.Row
+}
+
+// ColumnTypes returns the column types of the row.
+// ColumnTypes is a wrapper around sqlx.Row.ColumnTypes.
+func (r *Rowx) ColumnTypes() ([]*sql.ColumnType, error) {
+	return r.row.ColumnTypes()
+}
+
+// Columns returns the column names of the row.
+// Columns is a wrapper around sqlx.Row.Columns.
+func (r *Rowx) Columns() ([]string, error) {
+	return r.row.Columns()
+}
+
+// Err returns the error of the query if any.
+// Err is a wrapper around sqlx.Row.Err.
+func (r *Rowx) Err() error {
+	return r.row.Err()
+}
+
+// MapScan scans the row into the map.
+// MapScan is a wrapper around sqlx.Row.MapScan.
+func (r *Rowx) MapScan(dest map[string]interface{}) error {
+	return r.scan(func(row *sqlx.Row) error {
+		return row.MapScan(dest)
+	})
+}
+
+// Scan copies the columns of the row into the values pointed at by dest.
+// Scan is a wrapper around sqlx.Row.Scan.
+func (r *Rowx) Scan(dest ...interface{}) error {
+	return r.scan(func(row *sqlx.Row) error {
+		return row.Scan(dest...)
+	})
+}
+
+// SliceScan scans the row into a slice.
+// SliceScan is a wrapper around sqlx.Row.SliceScan.
+func (r *Rowx) SliceScan() ([]interface{}, error) {
+	var result []interface{}
+	err := r.scan(func(row *sqlx.Row) error {
+		var err error
+		result, err = row.SliceScan()
+		return err
+	})
+	return result, err
+}
+
+// StructScan scans the row into the struct.
+// StructScan is a wrapper around sqlx.Row.StructScan.
+func (r *Rowx) StructScan(dest interface{}) error {
+	return r.scan(func(row *sqlx.Row) error {
+		return row.StructScan(dest)
+	})
+}
+
+// scan runs scan with the row, and runs it again with the row of a primary database
+// if it fails with a connection error.
+func (r *Rowx) scan(scan func(row *sqlx.Row) error) error {
+	err := scan(r.row)
+	if err == nil || r.fallback == nil {
+		return err
+	}
+
+	row := r.fallback(err)
+	r.fallback = nil
+	if row == nil {
+		return err
+	}
+	r.row = row
+	return scan(r.row)
+}
+
+// queryRow routes the query of QueryRowFallback and returns the row of fn,
+// which falls back to a primary database on a connection error while scanning it.
+func (r *dbResolver) queryRow(ctx context.Context, req request, fn func(db *sqlx.DB) *sql.Row) (*Row, error) {
+	var (
+		row    *sql.Row
+		served *sqlx.DB
+	)
+	err := r.queryRoute(req.query)(ctx, req, func(db *sqlx.DB) error {
+		row = fn(db)
+		served = db
+		return row.Err()
+	})
+	if row == nil {
+		return &Row{row: errRow(err)}, err
+	}
+
+	result := &Row{row: row}
+	if r.canFallBackScan(ctx, served) {
+		result.fallback = func(scanErr error) *sql.Row {
+			if !r.connErrClassifier.isConnectionError(scanErr) {
+				return nil
+			}
+			r.recordFallback(ctx, req, scanErr)
+
+			var row *sql.Row
+			err := r.read(WithForcePrimary(ctx), req, func(db *sqlx.DB) error {
+				row = fn(db)
+				return row.Err()
+			})
+			if row == nil {
+				row = errRow(err)
+			}
+			return row
+		}
+	}
+	return result, err
+}
+
+// queryRowx is the same as queryRow but for QueryRowxFallback.
+func (r *dbResolver) queryRowx(ctx context.Context, req request, fn func(db *sqlx.DB) *sqlx.Row) (*Rowx, error) {
+	var (
+		row    *sqlx.Row
+		served *sqlx.DB
+	)
+	err := r.queryRoute(req.query)(ctx, req, func(db *sqlx.DB) error {
+		row = fn(db)
+		served = db
+		return row.Err()
+	})
+	if row == nil {
+		return &Rowx{row: errRowx(err)}, err
+	}
+
+	result := &Rowx{row: row}
+	if r.canFallBackScan(ctx, served) {
+		result.fallback = func(scanErr error) *sqlx.Row {
+			if !r.connErrClassifier.isConnectionError(scanErr) {
+				return nil
+			}
+			r.recordFallback(ctx, req, scanErr)
+
+			var row *sqlx.Row
+			err := r.read(WithForcePrimary(ctx), req, func(db *sqlx.DB) error {
+				row = fn(db)
+				return row.Err()
+			})
+			if row == nil {
+				row = errRowx(err)
+			}
+			return row
+		}
+	}
+	return result, err
+}
+
+// canFallBackScan reports whether the row of the given database can fall back to a primary database
+// when it fails to scan, i.e. the database is not a primary database and the fallback is allowed.
+func (r *dbResolver) canFallBackScan(ctx context.Context, served *sqlx.DB) bool {
+	return served != nil && r.canFallBack(ctx) && !containsDB(r.primaries, served)
+}
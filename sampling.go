@@ -0,0 +1,53 @@
+package dbresolver
+
+import (
+	"math/rand"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// readSampler routes a fraction of the reads to each of the readable databases being rolled out.
+type readSampler struct {
+	rates  map[*sqlx.DB]float64
+	random *rand.Rand
+}
+
+var _ randomized = (*readSampler)(nil)
+
+// sample narrows the candidates of a read by the sampling rates.
+// A sampled database is the only candidate for the fraction of the reads of its rate,
+// and it is excluded from the candidates for the rest of the reads,
+// which choose from the databases without a rate, or from the sampled ones if there are none of them.
+// A database whose rate is zero is never a candidate.
+func (s *readSampler) sample(candidates []*sqlx.DB) []*sqlx.DB {
+	if s == nil || len(candidates) == 0 {
+		return candidates
+	}
+
+	u := randFloat64(s.random)
+	cumulative := 0.0
+	var sampled, unsampled []*sqlx.DB
+	for _, db := range candidates {
+		rate, ok := s.rates[db]
+		if !ok {
+			unsampled = append(unsampled, db)
+			continue
+		}
+		if rate <= 0 {
+			continue
+		}
+		cumulative += rate
+		if u < cumulative {
+			return []*sqlx.DB{db}
+		}
+		sampled = append(sampled, db)
+	}
+	if len(unsampled) > 0 {
+		return unsampled
+	}
+	return sampled
+}
+
+func (s *readSampler) setRandom(random *rand.Rand) {
+	s.random = random
+}
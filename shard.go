@@ -0,0 +1,20 @@
+package dbresolver
+
+import (
+	"github.com/jmoiron/sqlx"
+)
+
+// shardedRead returns the readable database owning the shard of the args if the hash-sharded reads are enabled.
+// It returns nil if they are not enabled or the shard is out of range, so that the read falls back to the whole pool.
+func (r *dbResolver) shardedRead(req request) *sqlx.DB {
+	if r.shardFunc == nil {
+		return nil
+	}
+
+	reads := r.readDBs()
+	shard := r.shardFunc(req.args)
+	if shard < 0 || shard >= len(reads) {
+		return nil
+	}
+	return reads[shard]
+}
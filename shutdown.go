@@ -0,0 +1,60 @@
+package dbresolver
+
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// errors.
+var (
+	errShutdown = errors.New("dbresolver: resolver is shut down")
+)
+
+// drainGate tracks the in-flight queries and refuses the new ones once it is closed.
+// The zero value is open, and a nil gate never refuses the queries.
+type drainGate struct {
+	mu       sync.Mutex
+	closed   bool
+	inFlight sync.WaitGroup
+}
+
+// enter registers a new query. It returns false if the gate is closed.
+// The registered query must be finished by exit.
+func (g *drainGate) enter() bool {
+	if g == nil {
+		return true
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.closed {
+		return false
+	}
+	g.inFlight.Add(1)
+	return true
+}
+
+// exit finishes the query registered by enter.
+func (g *drainGate) exit() {
+	if g == nil {
+		return
+	}
+
+	g.inFlight.Done()
+}
+
+// close closes the gate and returns a channel which is closed when all the in-flight queries finish.
+func (g *drainGate) close() <-chan struct{} {
+	g.mu.Lock()
+	g.closed = true
+	g.mu.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		g.inFlight.Wait()
+		close(drained)
+	}()
+	return drained
+}
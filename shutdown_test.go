@@ -0,0 +1,196 @@
+package dbresolver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDBResolver_Shutdown(t *testing.T) {
+	t.Run("wait for in-flight query", func(t *testing.T) {
+		mockDB, sqlMock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock.ExpectExec(`INSERT INTO person (first_name) VALUES (?)`).
+			WithArgs("foo").
+			WillDelayFor(200 * time.Millisecond).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		sqlMock.ExpectClose()
+		mockPrimaryDB := sqlx.NewDb(mockDB, "mock")
+		r := &dbResolver{
+			primaries: []*sqlx.DB{mockPrimaryDB},
+			loadBalancer: &injectedLoadBalancer{
+				db: mockPrimaryDB,
+			},
+		}
+
+		done := make(chan error, 1)
+		go func() {
+			_, err := r.ExecContext(context.Background(), `INSERT INTO person (first_name) VALUES (?)`, "foo")
+			done <- err
+		}()
+		time.Sleep(50 * time.Millisecond)
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		err := r.Shutdown(ctx)
+
+		assert.NoError(t, err)
+		select {
+		case err := <-done:
+			assert.NoError(t, err)
+		default:
+			t.Fatal("in-flight query must finish before shutdown returns")
+		}
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("return errShutdown for new query", func(t *testing.T) {
+		mockDB, sqlMock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock.ExpectClose()
+		mockPrimaryDB := sqlx.NewDb(mockDB, "mock")
+		r := &dbResolver{
+			primaries: []*sqlx.DB{mockPrimaryDB},
+			reads:     []*sqlx.DB{mockPrimaryDB},
+			loadBalancer: &injectedLoadBalancer{
+				db: mockPrimaryDB,
+			},
+		}
+
+		err := r.Shutdown(context.Background())
+		assert.NoError(t, err)
+
+		_, err = r.ExecContext(context.Background(), `INSERT INTO person (first_name) VALUES (?)`, "foo")
+		assert.ErrorIs(t, err, errShutdown)
+		var firstName string
+		err = r.GetContext(context.Background(), &firstName, `SELECT first_name FROM person`)
+		assert.ErrorIs(t, err, errShutdown)
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("wait for in-flight transaction", func(t *testing.T) {
+		mockDB, sqlMock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock.ExpectBegin()
+		sqlMock.ExpectExec(`INSERT INTO person (first_name) VALUES (?)`).
+			WithArgs("foo").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		sqlMock.ExpectCommit()
+		sqlMock.ExpectClose()
+		mockPrimaryDB := sqlx.NewDb(mockDB, "mock")
+		r := &dbResolver{
+			primaries: []*sqlx.DB{mockPrimaryDB},
+			loadBalancer: &injectedLoadBalancer{
+				db: mockPrimaryDB,
+			},
+		}
+
+		begun := make(chan struct{})
+		done := make(chan error, 1)
+		go func() {
+			done <- r.Transaction(context.Background(), nil, func(tx *sqlx.Tx) error {
+				close(begun)
+				time.Sleep(200 * time.Millisecond)
+				_, err := tx.Exec(`INSERT INTO person (first_name) VALUES (?)`, "foo")
+				return err
+			})
+		}()
+		<-begun
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		err := r.Shutdown(ctx)
+
+		assert.NoError(t, err)
+		select {
+		case err := <-done:
+			assert.NoError(t, err)
+		default:
+			t.Fatal("in-flight transaction must finish before shutdown returns")
+		}
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("return errShutdown for new transaction, statement and connection", func(t *testing.T) {
+		mockDB, sqlMock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock.MatchExpectationsInOrder(false)
+		for i := 0; i < 2; i++ {
+			sqlMock.ExpectPrepare(`INSERT INTO person (first_name) VALUES (?)`).
+				WillBeClosed()
+			sqlMock.ExpectPrepare(`SELECT first_name FROM person WHERE id = ?`).
+				WillBeClosed()
+		}
+		sqlMock.ExpectClose()
+		mockPrimaryDB := sqlx.NewDb(mockDB, "mock")
+		r := MustNewDBResolver(&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB}})
+		stmt, err := r.Preparex(`INSERT INTO person (first_name) VALUES (?)`)
+		assert.NoError(t, err)
+		namedStmt, err := r.PrepareNamed(`SELECT first_name FROM person WHERE id = :id`)
+		assert.NoError(t, err)
+
+		err = r.Shutdown(context.Background())
+		assert.NoError(t, err)
+
+		_, err = r.BeginTxx(context.Background(), nil)
+		assert.ErrorIs(t, err, errShutdown)
+		err = r.Transaction(context.Background(), nil, func(*sqlx.Tx) error {
+			t.Fatal("transaction must not begin after shutdown")
+			return nil
+		})
+		assert.ErrorIs(t, err, errShutdown)
+		_, err = r.Connx(context.Background())
+		assert.ErrorIs(t, err, errShutdown)
+		err = r.WithReplicaConn(context.Background(), func(*sqlx.Conn) error {
+			t.Fatal("connection must not be given after shutdown")
+			return nil
+		})
+		assert.ErrorIs(t, err, errShutdown)
+		_, err = r.ExecOnAllPrimaries(context.Background(), `INSERT INTO person (first_name) VALUES (?)`, "foo")
+		assert.ErrorIs(t, err, errShutdown)
+		_, err = r.QueryEachRead(context.Background(), `SELECT first_name FROM person`)
+		assert.ErrorIs(t, err, errShutdown)
+		errs := r.GetEachRead(context.Background(), func(*sqlx.DB) interface{} {
+			return new(string)
+		}, `SELECT first_name FROM person`)
+		assert.Equal(t, map[*sqlx.DB]error{mockPrimaryDB: errShutdown}, errs)
+		_, err = stmt.Exec("foo")
+		assert.ErrorIs(t, err, errShutdown)
+		var firstName string
+		err = stmt.QueryRowx().Scan(&firstName)
+		assert.ErrorIs(t, err, errShutdown)
+		err = namedStmt.Get(&firstName, map[string]interface{}{"id": 1})
+		assert.ErrorIs(t, err, errShutdown)
+		err = namedStmt.QueryRowx(map[string]interface{}{"id": 1}).Scan(&firstName)
+		assert.ErrorIs(t, err, errShutdown)
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("close databases after context is done", func(t *testing.T) {
+		mockDB, sqlMock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock.ExpectExec(`INSERT INTO person (first_name) VALUES (?)`).
+			WithArgs("foo").
+			WillDelayFor(time.Second).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		sqlMock.ExpectClose()
+		mockPrimaryDB := sqlx.NewDb(mockDB, "mock")
+		r := &dbResolver{
+			primaries: []*sqlx.DB{mockPrimaryDB},
+			loadBalancer: &injectedLoadBalancer{
+				db: mockPrimaryDB,
+			},
+		}
+
+		done := make(chan error, 1)
+		go func() {
+			_, err := r.ExecContext(context.Background(), `INSERT INTO person (first_name) VALUES (?)`, "foo")
+			done <- err
+		}()
+		time.Sleep(50 * time.Millisecond)
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		err := r.Shutdown(ctx)
+
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.NoError(t, <-done)
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+}
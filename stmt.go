@@ -43,7 +43,11 @@ type stmt struct {
 	primaryStmts map[*sqlx.DB]*sqlx.Stmt
 	readStmts    map[*sqlx.DB]*sqlx.Stmt
 
-	loadBalancer LoadBalancer
+	loadBalancer      LoadBalancer
+	limiter           *stmtLimiter
+	registry          *stmtRegistry
+	drain             *drainGate
+	connErrClassifier connErrorClassifier
 }
 
 var _ Stmt = (*stmt)(nil)
@@ -51,6 +55,9 @@ var _ Stmt = (*stmt)(nil)
 // Close closes all statements.
 // Close is a wrapper around sqlx.Stmt.Close.
 func (s *stmt) Close() error {
+	s.limiter.remove(s)
+	s.registry.remove(s)
+
 	var errs error
 	for _, stmt := range s.primaryStmts {
 		if err := stmt.Close(); err != nil {
@@ -68,7 +75,13 @@ func (s *stmt) Close() error {
 // Exec chooses a primary database's statement and executes using chosen statement.
 // Exec is a wrapper around sqlx.Stmt.Exec.
 func (s *stmt) Exec(args ...interface{}) (sql.Result, error) {
-	db := s.loadBalancer.Select(context.Background(), s.primaries)
+	s.limiter.touch(s)
+	if !s.drain.enter() {
+		return nil, errShutdown
+	}
+	defer s.drain.exit()
+
+	db := s.selectPrimary(context.Background())
 	stmt, ok := s.primaryStmts[db]
 	if !ok {
 		// Should not happen.
@@ -80,7 +93,13 @@ func (s *stmt) Exec(args ...interface{}) (sql.Result, error) {
 // ExecContext chooses a primary database's statement and executes using chosen statement.
 // ExecContext is a wrapper around sqlx.Stmt.ExecContext.
 func (s *stmt) ExecContext(ctx context.Context, args ...interface{}) (sql.Result, error) {
-	db := s.loadBalancer.Select(ctx, s.primaries)
+	s.limiter.touch(s)
+	if !s.drain.enter() {
+		return nil, errShutdown
+	}
+	defer s.drain.exit()
+
+	db := s.selectPrimary(ctx)
 	stmt, ok := s.primaryStmts[db]
 	if !ok {
 		// Should not happen.
@@ -92,7 +111,13 @@ func (s *stmt) ExecContext(ctx context.Context, args ...interface{}) (sql.Result
 // Get chooses a readable database's statement and Get using chosen statement.
 // Get is a wrapper around sqlx.Stmt.Get.
 func (s *stmt) Get(dest interface{}, args ...interface{}) error {
-	db := s.loadBalancer.Select(context.Background(), s.reads)
+	s.limiter.touch(s)
+	if !s.drain.enter() {
+		return errShutdown
+	}
+	defer s.drain.exit()
+
+	db := s.selectRead(context.Background())
 	stmt, ok := s.readStmts[db]
 	if !ok {
 		// Should not happen.
@@ -100,12 +125,12 @@ func (s *stmt) Get(dest interface{}, args ...interface{}) error {
 	}
 	err := stmt.Get(dest, args...)
 
-	if isDBConnectionError(err) {
-		dbPrimary := s.loadBalancer.Select(context.Background(), s.primaries)
-		stmtPrimary, ok := s.readStmts[dbPrimary]
+	if s.connErrClassifier.isConnectionError(err) {
+		dbPrimary := s.selectPrimary(context.Background())
+		stmtPrimary, ok := s.primaryStmts[dbPrimary]
 		if !ok {
 			// Should not happen.
-			return errors.Wrapf(errSelectedNamedStmtNotFound, "readable db: %v", db)
+			return errors.Wrapf(errSelectedStmtNotFound, "primary db: %v", dbPrimary)
 		}
 		err = stmtPrimary.Get(dest, args...)
 	}
@@ -115,7 +140,13 @@ func (s *stmt) Get(dest interface{}, args ...interface{}) error {
 // GetContext chooses a readable database's statement and Get using chosen statement.
 // GetContext is a wrapper around sqlx.Stmt.GetContext.
 func (s *stmt) GetContext(ctx context.Context, dest interface{}, args ...interface{}) error {
-	db := s.loadBalancer.Select(ctx, s.reads)
+	s.limiter.touch(s)
+	if !s.drain.enter() {
+		return errShutdown
+	}
+	defer s.drain.exit()
+
+	db := s.selectRead(ctx)
 	stmt, ok := s.readStmts[db]
 	if !ok {
 		// Should not happen.
@@ -123,12 +154,12 @@ func (s *stmt) GetContext(ctx context.Context, dest interface{}, args ...interfa
 	}
 	err := stmt.GetContext(ctx, dest, args...)
 
-	if isDBConnectionError(err) {
-		dbPrimary := s.loadBalancer.Select(ctx, s.primaries)
-		stmtPrimary, ok := s.readStmts[dbPrimary]
+	if s.connErrClassifier.isConnectionError(err) {
+		dbPrimary := s.selectPrimary(ctx)
+		stmtPrimary, ok := s.primaryStmts[dbPrimary]
 		if !ok {
 			// Should not happen.
-			return errors.Wrapf(errSelectedNamedStmtNotFound, "readable db: %v", db)
+			return errors.Wrapf(errSelectedStmtNotFound, "primary db: %v", dbPrimary)
 		}
 		err = stmtPrimary.GetContext(ctx, dest, args...)
 	}
@@ -138,7 +169,13 @@ func (s *stmt) GetContext(ctx context.Context, dest interface{}, args ...interfa
 // MustExec chooses a primary database's statement and executes using chosen statement or panic.
 // MustExec is a wrapper around sqlx.Stmt.MustExec.
 func (s *stmt) MustExec(args ...interface{}) sql.Result {
-	db := s.loadBalancer.Select(context.Background(), s.primaries)
+	s.limiter.touch(s)
+	if !s.drain.enter() {
+		panic(errShutdown)
+	}
+	defer s.drain.exit()
+
+	db := s.selectPrimary(context.Background())
 	stmt, ok := s.primaryStmts[db]
 	if !ok {
 		// Should not happen.
@@ -150,7 +187,13 @@ func (s *stmt) MustExec(args ...interface{}) sql.Result {
 // MustExecContext chooses a primary database's statement and executes using chosen statement or panic.
 // MustExecContext is a wrapper around sqlx.Stmt.MustExecContext.
 func (s *stmt) MustExecContext(ctx context.Context, args ...interface{}) sql.Result {
-	db := s.loadBalancer.Select(ctx, s.primaries)
+	s.limiter.touch(s)
+	if !s.drain.enter() {
+		panic(errShutdown)
+	}
+	defer s.drain.exit()
+
+	db := s.selectPrimary(ctx)
 	stmt, ok := s.primaryStmts[db]
 	if !ok {
 		// Should not happen.
@@ -162,7 +205,13 @@ func (s *stmt) MustExecContext(ctx context.Context, args ...interface{}) sql.Res
 // Query chooses a readable database's statement and executes using chosen statement.
 // Query is a wrapper around sqlx.Stmt.Query.
 func (s *stmt) Query(args ...interface{}) (*sql.Rows, error) {
-	db := s.loadBalancer.Select(context.Background(), s.reads)
+	s.limiter.touch(s)
+	if !s.drain.enter() {
+		return nil, errShutdown
+	}
+	defer s.drain.exit()
+
+	db := s.selectRead(context.Background())
 	stmt, ok := s.readStmts[db]
 	if !ok {
 		// Should not happen.
@@ -170,12 +219,12 @@ func (s *stmt) Query(args ...interface{}) (*sql.Rows, error) {
 	}
 	rows, err := stmt.Query(args...)
 
-	if isDBConnectionError(err) {
-		dbPrimary := s.loadBalancer.Select(context.Background(), s.primaries)
-		stmtPrimary, ok := s.readStmts[dbPrimary]
+	if s.connErrClassifier.isConnectionError(err) {
+		dbPrimary := s.selectPrimary(context.Background())
+		stmtPrimary, ok := s.primaryStmts[dbPrimary]
 		if !ok {
 			// Should not happen.
-			return nil, errors.Wrapf(errSelectedNamedStmtNotFound, "readable db: %v", db)
+			return nil, errors.Wrapf(errSelectedStmtNotFound, "primary db: %v", dbPrimary)
 		}
 		rows, err = stmtPrimary.Query(args...)
 	}
@@ -185,7 +234,13 @@ func (s *stmt) Query(args ...interface{}) (*sql.Rows, error) {
 // QueryContext chooses a readable database's statement and executes using chosen statement.
 // QueryContext is a wrapper around sqlx.Stmt.QueryContext.
 func (s *stmt) QueryContext(ctx context.Context, args ...interface{}) (*sql.Rows, error) {
-	db := s.loadBalancer.Select(ctx, s.reads)
+	s.limiter.touch(s)
+	if !s.drain.enter() {
+		return nil, errShutdown
+	}
+	defer s.drain.exit()
+
+	db := s.selectRead(ctx)
 	stmt, ok := s.readStmts[db]
 	if !ok {
 		// Should not happen.
@@ -193,12 +248,12 @@ func (s *stmt) QueryContext(ctx context.Context, args ...interface{}) (*sql.Rows
 	}
 	rows, err := stmt.QueryContext(ctx, args...)
 
-	if isDBConnectionError(err) {
-		dbPrimary := s.loadBalancer.Select(ctx, s.primaries)
-		stmtPrimary, ok := s.readStmts[dbPrimary]
+	if s.connErrClassifier.isConnectionError(err) {
+		dbPrimary := s.selectPrimary(ctx)
+		stmtPrimary, ok := s.primaryStmts[dbPrimary]
 		if !ok {
 			// Should not happen.
-			return nil, errors.Wrapf(errSelectedNamedStmtNotFound, "readable db: %v", db)
+			return nil, errors.Wrapf(errSelectedStmtNotFound, "primary db: %v", dbPrimary)
 		}
 		rows, err = stmtPrimary.QueryContext(ctx, args...)
 	}
@@ -209,7 +264,13 @@ func (s *stmt) QueryContext(ctx context.Context, args ...interface{}) (*sql.Rows
 // If selected statement is not found, returns nil.
 // QueryRow is a wrapper around sqlx.Stmt.QueryRow.
 func (s *stmt) QueryRow(args ...interface{}) *sql.Row {
-	db := s.loadBalancer.Select(context.Background(), s.reads)
+	s.limiter.touch(s)
+	if !s.drain.enter() {
+		return errRow(errShutdown)
+	}
+	defer s.drain.exit()
+
+	db := s.selectRead(context.Background())
 	stmt, ok := s.readStmts[db]
 	if !ok {
 		// Should not happen.
@@ -217,9 +278,9 @@ func (s *stmt) QueryRow(args ...interface{}) *sql.Row {
 	}
 	row := stmt.QueryRow(args...)
 
-	if isDBConnectionError(row.Err()) {
-		dbPrimary := s.loadBalancer.Select(context.Background(), s.primaries)
-		stmtPrimary, ok := s.readStmts[dbPrimary]
+	if s.connErrClassifier.isConnectionError(row.Err()) {
+		dbPrimary := s.selectPrimary(context.Background())
+		stmtPrimary, ok := s.primaryStmts[dbPrimary]
 		if !ok {
 			// Should not happen.
 			return nil
@@ -233,7 +294,13 @@ func (s *stmt) QueryRow(args ...interface{}) *sql.Row {
 // If selected statement is not found, returns nil.
 // QueryRowContext is a wrapper around sqlx.Stmt.QueryRowContext.
 func (s *stmt) QueryRowContext(ctx context.Context, args ...interface{}) *sql.Row {
-	db := s.loadBalancer.Select(ctx, s.reads)
+	s.limiter.touch(s)
+	if !s.drain.enter() {
+		return errRow(errShutdown)
+	}
+	defer s.drain.exit()
+
+	db := s.selectRead(ctx)
 	stmt, ok := s.readStmts[db]
 	if !ok {
 		// Should not happen.
@@ -241,9 +308,9 @@ func (s *stmt) QueryRowContext(ctx context.Context, args ...interface{}) *sql.Ro
 	}
 	row := stmt.QueryRowContext(ctx, args...)
 
-	if isDBConnectionError(row.Err()) {
-		dbPrimary := s.loadBalancer.Select(ctx, s.primaries)
-		stmtPrimary, ok := s.readStmts[dbPrimary]
+	if s.connErrClassifier.isConnectionError(row.Err()) {
+		dbPrimary := s.selectPrimary(ctx)
+		stmtPrimary, ok := s.primaryStmts[dbPrimary]
 		if !ok {
 			// Should not happen.
 			return nil
@@ -257,7 +324,13 @@ func (s *stmt) QueryRowContext(ctx context.Context, args ...interface{}) *sql.Ro
 // If selected statement is not found, returns nil.
 // QueryRowx is a wrapper around sqlx.Stmt.QueryRowx.
 func (s *stmt) QueryRowx(args ...interface{}) *sqlx.Row {
-	db := s.loadBalancer.Select(context.Background(), s.reads)
+	s.limiter.touch(s)
+	if !s.drain.enter() {
+		return errRowx(errShutdown)
+	}
+	defer s.drain.exit()
+
+	db := s.selectRead(context.Background())
 	stmt, ok := s.readStmts[db]
 	if !ok {
 		// Should not happen.
@@ -265,9 +338,9 @@ func (s *stmt) QueryRowx(args ...interface{}) *sqlx.Row {
 	}
 	row := stmt.QueryRowx(args...)
 
-	if isDBConnectionError(row.Err()) {
-		dbPrimary := s.loadBalancer.Select(context.Background(), s.primaries)
-		stmtPrimary, ok := s.readStmts[dbPrimary]
+	if s.connErrClassifier.isConnectionError(row.Err()) {
+		dbPrimary := s.selectPrimary(context.Background())
+		stmtPrimary, ok := s.primaryStmts[dbPrimary]
 		if !ok {
 			// Should not happen.
 			return nil
@@ -281,7 +354,13 @@ func (s *stmt) QueryRowx(args ...interface{}) *sqlx.Row {
 // If selected statement is not found, returns nil.
 // QueryRowxContext is a wrapper around sqlx.Stmt.QueryRowxContext.
 func (s *stmt) QueryRowxContext(ctx context.Context, args ...interface{}) *sqlx.Row {
-	db := s.loadBalancer.Select(ctx, s.reads)
+	s.limiter.touch(s)
+	if !s.drain.enter() {
+		return errRowx(errShutdown)
+	}
+	defer s.drain.exit()
+
+	db := s.selectRead(ctx)
 	stmt, ok := s.readStmts[db]
 	if !ok {
 		// Should not happen.
@@ -289,9 +368,9 @@ func (s *stmt) QueryRowxContext(ctx context.Context, args ...interface{}) *sqlx.
 	}
 	row := stmt.QueryRowxContext(ctx, args...)
 
-	if isDBConnectionError(row.Err()) {
-		dbPrimary := s.loadBalancer.Select(ctx, s.primaries)
-		stmtPrimary, ok := s.readStmts[dbPrimary]
+	if s.connErrClassifier.isConnectionError(row.Err()) {
+		dbPrimary := s.selectPrimary(ctx)
+		stmtPrimary, ok := s.primaryStmts[dbPrimary]
 		if !ok {
 			// Should not happen.
 			return nil
@@ -304,7 +383,13 @@ func (s *stmt) QueryRowxContext(ctx context.Context, args ...interface{}) *sqlx.
 // Queryx chooses a readable database's statement, executes using chosen statement and returns *sqlx.Rows.
 // Queryx is a wrapper around sqlx.Stmt.Queryx.
 func (s *stmt) Queryx(args ...interface{}) (*sqlx.Rows, error) {
-	db := s.loadBalancer.Select(context.Background(), s.reads)
+	s.limiter.touch(s)
+	if !s.drain.enter() {
+		return nil, errShutdown
+	}
+	defer s.drain.exit()
+
+	db := s.selectRead(context.Background())
 	stmt, ok := s.readStmts[db]
 	if !ok {
 		// Should not happen.
@@ -312,12 +397,12 @@ func (s *stmt) Queryx(args ...interface{}) (*sqlx.Rows, error) {
 	}
 	rows, err := stmt.Queryx(args...)
 
-	if isDBConnectionError(err) {
-		dbPrimary := s.loadBalancer.Select(context.Background(), s.primaries)
-		stmtPrimary, ok := s.readStmts[dbPrimary]
+	if s.connErrClassifier.isConnectionError(err) {
+		dbPrimary := s.selectPrimary(context.Background())
+		stmtPrimary, ok := s.primaryStmts[dbPrimary]
 		if !ok {
 			// Should not happen.
-			return nil, errors.Wrapf(errSelectedNamedStmtNotFound, "readable db: %v", db)
+			return nil, errors.Wrapf(errSelectedStmtNotFound, "primary db: %v", dbPrimary)
 		}
 		rows, err = stmtPrimary.Queryx(args...)
 	}
@@ -327,7 +412,13 @@ func (s *stmt) Queryx(args ...interface{}) (*sqlx.Rows, error) {
 // QueryxContext chooses a readable database's statement, executes using chosen statement and returns *sqlx.Rows.
 // QueryxContext is a wrapper around sqlx.Stmt.QueryxContext.
 func (s *stmt) QueryxContext(ctx context.Context, args ...interface{}) (*sqlx.Rows, error) {
-	db := s.loadBalancer.Select(ctx, s.reads)
+	s.limiter.touch(s)
+	if !s.drain.enter() {
+		return nil, errShutdown
+	}
+	defer s.drain.exit()
+
+	db := s.selectRead(ctx)
 	stmt, ok := s.readStmts[db]
 	if !ok {
 		// Should not happen.
@@ -335,12 +426,12 @@ func (s *stmt) QueryxContext(ctx context.Context, args ...interface{}) (*sqlx.Ro
 	}
 	rows, err := stmt.QueryxContext(ctx, args...)
 
-	if isDBConnectionError(err) {
-		dbPrimary := s.loadBalancer.Select(ctx, s.primaries)
-		stmtPrimary, ok := s.readStmts[dbPrimary]
+	if s.connErrClassifier.isConnectionError(err) {
+		dbPrimary := s.selectPrimary(ctx)
+		stmtPrimary, ok := s.primaryStmts[dbPrimary]
 		if !ok {
 			// Should not happen.
-			return nil, errors.Wrapf(errSelectedNamedStmtNotFound, "readable db: %v", db)
+			return nil, errors.Wrapf(errSelectedStmtNotFound, "primary db: %v", dbPrimary)
 		}
 		rows, err = stmtPrimary.QueryxContext(ctx, args...)
 	}
@@ -350,7 +441,13 @@ func (s *stmt) QueryxContext(ctx context.Context, args ...interface{}) (*sqlx.Ro
 // Select chooses a readable database's statement, executes using chosen statement.
 // Select is a wrapper around sqlx.Stmt.Select.
 func (s *stmt) Select(dest interface{}, args ...interface{}) error {
-	db := s.loadBalancer.Select(context.Background(), s.reads)
+	s.limiter.touch(s)
+	if !s.drain.enter() {
+		return errShutdown
+	}
+	defer s.drain.exit()
+
+	db := s.selectRead(context.Background())
 	stmt, ok := s.readStmts[db]
 	if !ok {
 		// Should not happen.
@@ -358,12 +455,12 @@ func (s *stmt) Select(dest interface{}, args ...interface{}) error {
 	}
 	err := stmt.Select(dest, args...)
 
-	if isDBConnectionError(err) {
-		dbPrimary := s.loadBalancer.Select(context.Background(), s.primaries)
-		stmtPrimary, ok := s.readStmts[dbPrimary]
+	if s.connErrClassifier.isConnectionError(err) {
+		dbPrimary := s.selectPrimary(context.Background())
+		stmtPrimary, ok := s.primaryStmts[dbPrimary]
 		if !ok {
 			// Should not happen.
-			return errors.Wrapf(errSelectedNamedStmtNotFound, "readable db: %v", db)
+			return errors.Wrapf(errSelectedStmtNotFound, "primary db: %v", dbPrimary)
 		}
 		err = stmtPrimary.Select(dest, args...)
 	}
@@ -373,7 +470,13 @@ func (s *stmt) Select(dest interface{}, args ...interface{}) error {
 // SelectContext chooses a readable database's statement, executes using chosen statement.
 // SelectContext is a wrapper around sqlx.Stmt.SelectContext.
 func (s *stmt) SelectContext(ctx context.Context, dest interface{}, args ...interface{}) error {
-	db := s.loadBalancer.Select(ctx, s.reads)
+	s.limiter.touch(s)
+	if !s.drain.enter() {
+		return errShutdown
+	}
+	defer s.drain.exit()
+
+	db := s.selectRead(ctx)
 	stmt, ok := s.readStmts[db]
 	if !ok {
 		// Should not happen.
@@ -381,12 +484,12 @@ func (s *stmt) SelectContext(ctx context.Context, dest interface{}, args ...inte
 	}
 	err := stmt.SelectContext(ctx, dest, args...)
 
-	if isDBConnectionError(err) {
-		dbPrimary := s.loadBalancer.Select(ctx, s.primaries)
-		stmtPrimary, ok := s.readStmts[dbPrimary]
+	if s.connErrClassifier.isConnectionError(err) {
+		dbPrimary := s.selectPrimary(ctx)
+		stmtPrimary, ok := s.primaryStmts[dbPrimary]
 		if !ok {
 			// Should not happen.
-			return errors.Wrapf(errSelectedNamedStmtNotFound, "readable db: %v", db)
+			return errors.Wrapf(errSelectedStmtNotFound, "primary db: %v", dbPrimary)
 		}
 		err = stmtPrimary.SelectContext(ctx, dest, args...)
 	}
@@ -397,7 +500,9 @@ func (s *stmt) SelectContext(ctx context.Context, dest interface{}, args ...inte
 // If selected statement is not found, returns nil.
 // Unsafe wraps sqlx.Stmt.Unsafe.
 func (s *stmt) Unsafe() *sqlx.Stmt {
-	db := s.loadBalancer.Select(context.Background(), s.primaries)
+	s.limiter.touch(s)
+
+	db := s.selectPrimary(context.Background())
 	stmt, ok := s.primaryStmts[db]
 	if !ok {
 		// Should not happen.
@@ -405,3 +510,61 @@ func (s *stmt) Unsafe() *sqlx.Stmt {
 	}
 	return stmt.Unsafe()
 }
+
+// selectPrimary chooses a primary database on which the statement is prepared.
+func (s *stmt) selectPrimary(ctx context.Context) *sqlx.DB {
+	return selectPrepared(ctx, s.loadBalancer, s.primaries, func(db *sqlx.DB) bool {
+		_, ok := s.primaryStmts[db]
+		return ok
+	})
+}
+
+// selectRead chooses a readable database on which the statement is prepared.
+func (s *stmt) selectRead(ctx context.Context) *sqlx.DB {
+	return selectPrepared(ctx, s.loadBalancer, s.reads, func(db *sqlx.DB) bool {
+		_, ok := s.readStmts[db]
+		return ok
+	})
+}
+
+// selectPrepared chooses a database from the given databases with the load balancer.
+// If the statement is not prepared on the chosen database, e.g. by WithTolerantPrepare,
+// it chooses again from the rest of the databases. It returns nil if no database can be chosen.
+func selectPrepared(ctx context.Context, lb LoadBalancer, dbs []*sqlx.DB, prepared func(db *sqlx.DB) bool) *sqlx.DB {
+	for len(dbs) > 0 {
+		db := dbs[0]
+		if len(dbs) > 1 {
+			db = lb.Select(ctx, dbs)
+		}
+		if prepared(db) {
+			return db
+		}
+
+		rest := excludeDB(dbs, db)
+		if len(rest) == len(dbs) {
+			// The load balancer chose a database which is not one of the given databases.
+			return nil
+		}
+		dbs = rest
+	}
+	return nil
+}
+
+// toleratePrepareErrors reports whether the statements prepared despite the errors can be used,
+// which requires WithTolerantPrepare and the statements prepared on at least one primary and one readable database.
+// If so, it reports the errors to the logger.
+func (r *dbResolver) toleratePrepareErrors(ctx context.Context, errs error, primaries, reads int) bool {
+	if !r.tolerantPrepare || primaries == 0 || reads == 0 {
+		return false
+	}
+
+	if r.logger != nil {
+		var merr *multierror.Error
+		if errors.As(errs, &merr) {
+			for _, err := range merr.Errors {
+				r.logger(ctx, "prepare_skipped", err)
+			}
+		}
+	}
+	return true
+}
@@ -3,6 +3,7 @@ package dbresolver
 import (
 	"context"
 	"database/sql/driver"
+	"net"
 	"testing"
 
 	"github.com/DATA-DOG/go-sqlmock"
@@ -297,6 +298,41 @@ func TestStmt_Get(t *testing.T) {
 		assert.ErrorIs(t, err, errSelectedStmtNotFound)
 	})
 
+	t.Run("fall back to primary on connection error", func(t *testing.T) {
+		type Person struct {
+			FirstName string `db:"first_name"`
+			LastName  string `db:"last_name"`
+		}
+		connErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock1.ExpectPrepare(`SELECT * FROM person WHERE first_name=?`).
+			ExpectQuery().
+			WithArgs(driver.Value("foo")).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name", "last_name"}).
+				AddRow("foo", "bar"))
+		mockPrimaryDB := sqlx.NewDb(mockDB1, "mock1")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock2.ExpectPrepare(`SELECT * FROM person WHERE first_name=?`).
+			ExpectQuery().
+			WithArgs(driver.Value("foo")).
+			WillReturnError(connErr)
+		mockSecondaryDB := sqlx.NewDb(mockDB2, "mock2")
+		r := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB}, ReadWritePolicy: WriteOnly},
+			WithSecondaryDBs(mockSecondaryDB),
+		)
+		stmt, err := r.Preparex(`SELECT * FROM person WHERE first_name=?`)
+		assert.NoError(t, err)
+
+		result := &Person{}
+		err = stmt.Get(result, "foo")
+
+		assert.NoError(t, err)
+		assert.Equal(t, &Person{FirstName: "foo", LastName: "bar"}, result)
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
+
 	t.Run("failed to execute query", func(t *testing.T) {
 		type Person struct {
 			FirstName string `db:"first_name"`
@@ -1200,6 +1236,41 @@ func TestStmt_QueryRowxContext(t *testing.T) {
 		assert.Nil(t, result)
 	})
 
+	t.Run("fall back to primary on connection error", func(t *testing.T) {
+		type Person struct {
+			FirstName string `db:"first_name"`
+			LastName  string `db:"last_name"`
+		}
+		connErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock1.ExpectPrepare(`SELECT * FROM person WHERE first_name=?`).
+			ExpectQuery().
+			WithArgs(driver.Value("foo")).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name", "last_name"}).
+				AddRow("foo", "bar"))
+		mockPrimaryDB := sqlx.NewDb(mockDB1, "mock1")
+		mockDB2, sqlMock2, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		sqlMock2.ExpectPrepare(`SELECT * FROM person WHERE first_name=?`).
+			ExpectQuery().
+			WithArgs(driver.Value("foo")).
+			WillReturnError(connErr)
+		mockSecondaryDB := sqlx.NewDb(mockDB2, "mock2")
+		r := MustNewDBResolver(
+			&PrimaryDBsConfig{DBs: []*sqlx.DB{mockPrimaryDB}, ReadWritePolicy: WriteOnly},
+			WithSecondaryDBs(mockSecondaryDB),
+		)
+		stmt, err := r.Preparex(`SELECT * FROM person WHERE first_name=?`)
+		assert.NoError(t, err)
+
+		result := &Person{}
+		err = stmt.QueryRowxContext(context.Background(), "foo").StructScan(result)
+
+		assert.NoError(t, err)
+		assert.Equal(t, &Person{FirstName: "foo", LastName: "bar"}, result)
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
+
 	t.Run("failed to execute query", func(t *testing.T) {
 		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
 		mockError := errors.New("mock error")
@@ -0,0 +1,138 @@
+package dbresolver
+
+import (
+	"container/list"
+	"io"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// errors.
+var (
+	errTooManyPreparedStatements = errors.New("dbresolver: too many prepared statements")
+)
+
+// stmtLimiter limits the number of the outstanding prepared statements across all the databases.
+// A Stmt or a NamedStmt counts as many statements as the databases it is prepared on.
+// When the limit would be exceeded, the least recently used ones are closed to make room.
+type stmtLimiter struct {
+	max int
+
+	mu    sync.Mutex
+	count int
+	// lru has the statements in order of use, the most recently used at the front.
+	lru     *list.List
+	entries map[io.Closer]*list.Element
+}
+
+type stmtLimiterEntry struct {
+	stmt io.Closer
+	size int
+}
+
+func newStmtLimiter(max int) *stmtLimiter {
+	return &stmtLimiter{
+		max:     max,
+		lru:     list.New(),
+		entries: make(map[io.Closer]*list.Element),
+	}
+}
+
+// reserve makes room for size statements, closing the least recently used ones if needed.
+// The reserved room must be given back by release if the statements are not prepared.
+func (l *stmtLimiter) reserve(size int) error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	if size > l.max {
+		l.mu.Unlock()
+		return errors.Wrapf(errTooManyPreparedStatements, "need: %d, max: %d", size, l.max)
+	}
+
+	var evicted []io.Closer
+	for l.count+size > l.max {
+		elem := l.lru.Back()
+		if elem == nil {
+			break
+		}
+		entry := l.lru.Remove(elem).(*stmtLimiterEntry)
+		delete(l.entries, entry.stmt)
+		l.count -= entry.size
+		evicted = append(evicted, entry.stmt)
+	}
+	if l.count+size > l.max {
+		// The statements being prepared concurrently hold the rest of the room.
+		l.mu.Unlock()
+		for _, stmt := range evicted {
+			_ = stmt.Close()
+		}
+		return errors.Wrapf(errTooManyPreparedStatements, "need: %d, max: %d", size, l.max)
+	}
+	l.count += size
+	l.mu.Unlock()
+
+	// Closing outside the lock, because Close of the statements removes them from the limiter.
+	for _, stmt := range evicted {
+		_ = stmt.Close()
+	}
+	return nil
+}
+
+// release gives back the room reserved for size statements which are not prepared.
+func (l *stmtLimiter) release(size int) {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.count -= size
+}
+
+// add tracks the statements prepared in the reserved room as the most recently used.
+func (l *stmtLimiter) add(stmt io.Closer, size int) {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.entries[stmt] = l.lru.PushFront(&stmtLimiterEntry{stmt: stmt, size: size})
+}
+
+// touch marks the statements as the most recently used.
+func (l *stmtLimiter) touch(stmt io.Closer) {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if elem, ok := l.entries[stmt]; ok {
+		l.lru.MoveToFront(elem)
+	}
+}
+
+// remove stops tracking the closed statements and frees their room.
+func (l *stmtLimiter) remove(stmt io.Closer) {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	elem, ok := l.entries[stmt]
+	if !ok {
+		return
+	}
+	entry := l.lru.Remove(elem).(*stmtLimiterEntry)
+	delete(l.entries, stmt)
+	l.count -= entry.size
+}
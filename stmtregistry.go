@@ -0,0 +1,75 @@
+package dbresolver
+
+import (
+	"io"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// errors.
+var (
+	errStatementLeaked = errors.New("dbresolver: prepared statement not closed")
+)
+
+// stmtRegistry tracks the open prepared statements of the resolver, i.e. the Stmts and the NamedStmts
+// which are prepared but not closed yet, with their queries. The zero value is ready to use.
+type stmtRegistry struct {
+	mu    sync.Mutex
+	stmts map[io.Closer]string
+}
+
+// add tracks the prepared statements of the given query.
+func (g *stmtRegistry) add(stmt io.Closer, query string) {
+	if g == nil {
+		return
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.stmts == nil {
+		g.stmts = make(map[io.Closer]string)
+	}
+	g.stmts[stmt] = query
+}
+
+// remove stops tracking the closed statements.
+func (g *stmtRegistry) remove(stmt io.Closer) {
+	if g == nil {
+		return
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	delete(g.stmts, stmt)
+}
+
+// len returns the number of the open statements.
+func (g *stmtRegistry) len() int {
+	if g == nil {
+		return 0
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return len(g.stmts)
+}
+
+// open returns the open statements with their queries.
+func (g *stmtRegistry) open() map[io.Closer]string {
+	if g == nil {
+		return nil
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	open := make(map[io.Closer]string, len(g.stmts))
+	for stmt, query := range g.stmts {
+		open[stmt] = query
+	}
+	return open
+}
@@ -0,0 +1,48 @@
+package dbresolver
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"sort"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// hashedSubset returns a stable subset of the given databases of the given fraction.
+// The databases are ranked by the hash of their identity, so the same databases are chosen across calls.
+// The order of the given databases is kept in the subset.
+func hashedSubset(dbs []*sqlx.DB, fraction float64) []*sqlx.DB {
+	if fraction <= 0 || fraction >= 1 || len(dbs) <= 1 {
+		return dbs
+	}
+
+	size := int(math.Ceil(fraction * float64(len(dbs))))
+	if size >= len(dbs) {
+		return dbs
+	}
+
+	type rankedDB struct {
+		index int
+		hash  uint64
+	}
+	ranked := make([]rankedDB, len(dbs))
+	for i, db := range dbs {
+		h := fnv.New64a()
+		_, _ = fmt.Fprintf(h, "%p", db)
+		ranked[i] = rankedDB{index: i, hash: h.Sum64()}
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		return ranked[i].hash < ranked[j].hash
+	})
+
+	chosen := ranked[:size]
+	sort.Slice(chosen, func(i, j int) bool {
+		return chosen[i].index < chosen[j].index
+	})
+	subset := make([]*sqlx.DB, size)
+	for i, c := range chosen {
+		subset[i] = dbs[c.index]
+	}
+	return subset
+}
@@ -0,0 +1,96 @@
+package dbresolver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func newMockDBs(t *testing.T, n int) []*sqlx.DB {
+	t.Helper()
+
+	dbs := make([]*sqlx.DB, n)
+	for i := range dbs {
+		mockDB, _, err := sqlmock.New()
+		assert.NoError(t, err)
+		dbs[i] = sqlx.NewDb(mockDB, "mock")
+	}
+	return dbs
+}
+
+func TestHashedSubset(t *testing.T) {
+	t.Run("fraction out of range", func(t *testing.T) {
+		dbs := newMockDBs(t, 10)
+
+		assert.Equal(t, dbs, hashedSubset(dbs, 0))
+		assert.Equal(t, dbs, hashedSubset(dbs, 1))
+		assert.Equal(t, dbs, hashedSubset(dbs, -0.5))
+	})
+
+	t.Run("sized correctly", func(t *testing.T) {
+		dbs := newMockDBs(t, 10)
+
+		assert.Len(t, hashedSubset(dbs, 0.1), 1)
+		assert.Len(t, hashedSubset(dbs, 0.25), 3)
+		assert.Len(t, hashedSubset(dbs, 0.01), 1)
+		assert.Len(t, hashedSubset(dbs, 0.99), 10)
+	})
+
+	t.Run("stable across calls", func(t *testing.T) {
+		dbs := newMockDBs(t, 10)
+
+		expected := hashedSubset(dbs, 0.3)
+		for i := 0; i < 10; i++ {
+			result := hashedSubset(dbs, 0.3)
+
+			assert.Equal(t, expected, result)
+		}
+		for _, db := range expected {
+			assert.Contains(t, dbs, db)
+		}
+	})
+}
+
+func TestDBResolver_WithReadSubset(t *testing.T) {
+	t.Run("narrow reads to subset", func(t *testing.T) {
+		reads := newMockDBs(t, 10)
+		r := &dbResolver{
+			reads:        reads,
+			loadBalancer: NewRandomLoadBalancer(),
+		}
+		ctx := WithReadSubset(context.Background(), 0.2)
+
+		subset := r.readCandidates(ctx)
+		for i := 0; i < 100; i++ {
+			db := r.loadBalancer.Select(ctx, r.readCandidates(ctx))
+
+			assert.Contains(t, subset, db)
+		}
+		assert.Len(t, subset, 2)
+		assert.Equal(t, reads, r.readCandidates(context.Background()))
+	})
+
+	t.Run("keep subset when other reads are unhealthy", func(t *testing.T) {
+		reads := newMockDBs(t, 10)
+		r := &dbResolver{
+			reads:        reads,
+			loadBalancer: NewRandomLoadBalancer(),
+		}
+		ctx := WithReadSubset(context.Background(), 0.3)
+		subset := r.readCandidates(ctx)
+
+		for _, db := range reads {
+			if !containsDB(subset, db) {
+				r.health.markUnhealthy(db)
+				break
+			}
+		}
+		assert.Equal(t, subset, r.readCandidates(ctx))
+
+		r.health.markUnhealthy(subset[0])
+		assert.Equal(t, subset[1:], r.readCandidates(ctx))
+	})
+}
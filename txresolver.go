@@ -0,0 +1,109 @@
+package dbresolver
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// TxResolver is a wrapper around sqlx.Tx with the ergonomics of DBResolver.
+// All the queries run in the transaction, so there is no read/write splitting.
+type TxResolver interface {
+	Commit() error
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	Get(dest interface{}, query string, args ...interface{}) error
+	GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	NamedExec(query string, arg interface{}) (sql.Result, error)
+	NamedExecContext(ctx context.Context, query string, arg interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	Rollback() error
+	Select(dest interface{}, query string, args ...interface{}) error
+	SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	Tx() *sqlx.Tx
+}
+
+type txResolver struct {
+	tx *sqlx.Tx
+}
+
+var _ TxResolver = (*txResolver)(nil)
+
+// Commit commits the transaction.
+// Commit is a wrapper around sqlx.Tx.Commit.
+func (t *txResolver) Commit() error {
+	return t.tx.Commit()
+}
+
+// Exec executes a query without returning any rows in the transaction.
+// Exec is a wrapper around sqlx.Tx.Exec.
+func (t *txResolver) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return t.tx.Exec(query, args...)
+}
+
+// ExecContext executes a query without returning any rows in the transaction.
+// ExecContext is a wrapper around sqlx.Tx.ExecContext.
+func (t *txResolver) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return t.tx.ExecContext(ctx, query, args...)
+}
+
+// Get executes a query in the transaction and scans the row into dest.
+// Get is a wrapper around sqlx.Tx.Get.
+func (t *txResolver) Get(dest interface{}, query string, args ...interface{}) error {
+	return t.tx.Get(dest, query, args...)
+}
+
+// GetContext executes a query in the transaction and scans the row into dest.
+// GetContext is a wrapper around sqlx.Tx.GetContext.
+func (t *txResolver) GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	return t.tx.GetContext(ctx, dest, query, args...)
+}
+
+// NamedExec executes a named query in the transaction.
+// NamedExec is a wrapper around sqlx.Tx.NamedExec.
+func (t *txResolver) NamedExec(query string, arg interface{}) (sql.Result, error) {
+	return t.tx.NamedExec(query, arg)
+}
+
+// NamedExecContext executes a named query in the transaction.
+// NamedExecContext is a wrapper around sqlx.Tx.NamedExecContext.
+func (t *txResolver) NamedExecContext(ctx context.Context, query string, arg interface{}) (sql.Result, error) {
+	return t.tx.NamedExecContext(ctx, query, arg)
+}
+
+// Query executes a query that returns rows in the transaction.
+// Query is a wrapper around sqlx.Tx.Query.
+func (t *txResolver) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return t.tx.Query(query, args...)
+}
+
+// QueryContext executes a query that returns rows in the transaction.
+// QueryContext is a wrapper around sqlx.Tx.QueryContext.
+func (t *txResolver) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return t.tx.QueryContext(ctx, query, args...)
+}
+
+// Rollback aborts the transaction.
+// Rollback is a wrapper around sqlx.Tx.Rollback.
+func (t *txResolver) Rollback() error {
+	return t.tx.Rollback()
+}
+
+// Select executes a query in the transaction and scans the rows into dest.
+// Select is a wrapper around sqlx.Tx.Select.
+func (t *txResolver) Select(dest interface{}, query string, args ...interface{}) error {
+	return t.tx.Select(dest, query, args...)
+}
+
+// SelectContext executes a query in the transaction and scans the rows into dest.
+// SelectContext is a wrapper around sqlx.Tx.SelectContext.
+func (t *txResolver) SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	return t.tx.SelectContext(ctx, dest, query, args...)
+}
+
+// Tx returns the underlying transaction.
+func (t *txResolver) Tx() *sqlx.Tx {
+	return t.tx
+}
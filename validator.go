@@ -0,0 +1,44 @@
+package dbresolver
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// connValidator validates a database which has been idle for a long time before using it.
+type connValidator struct {
+	maxIdle time.Duration
+
+	mu       sync.Mutex
+	lastUsed map[*sqlx.DB]time.Time
+}
+
+func newConnValidator(maxIdle time.Duration) *connValidator {
+	return &connValidator{
+		maxIdle:  maxIdle,
+		lastUsed: make(map[*sqlx.DB]time.Time),
+	}
+}
+
+// validate reports whether the given database can be used.
+// If the database has been idle beyond the threshold, it pings the database.
+// A database which has never been used is considered idle.
+func (v *connValidator) validate(ctx context.Context, db *sqlx.DB, now time.Time) bool {
+	v.mu.Lock()
+	lastUsed, ok := v.lastUsed[db]
+	v.mu.Unlock()
+
+	if !ok || now.Sub(lastUsed) > v.maxIdle {
+		if err := db.PingContext(ctx); err != nil {
+			return false
+		}
+	}
+
+	v.mu.Lock()
+	v.lastUsed[db] = now
+	v.mu.Unlock()
+	return true
+}
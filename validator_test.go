@@ -0,0 +1,82 @@
+package dbresolver
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConnValidator_Validate(t *testing.T) {
+	t.Run("skip ping when recently used", func(t *testing.T) {
+		mockDB, sqlMock, _ := sqlmock.New(sqlmock.MonitorPingsOption(true))
+		sqlMock.ExpectPing()
+		db := sqlx.NewDb(mockDB, "mock")
+		v := newConnValidator(time.Minute)
+		now := time.Now()
+
+		assert.True(t, v.validate(context.Background(), db, now))
+		assert.True(t, v.validate(context.Background(), db, now.Add(30*time.Second)))
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("ping when idle too long", func(t *testing.T) {
+		mockDB, sqlMock, _ := sqlmock.New(sqlmock.MonitorPingsOption(true))
+		sqlMock.ExpectPing()
+		sqlMock.ExpectPing().
+			WillReturnError(errors.New("mock error"))
+		db := sqlx.NewDb(mockDB, "mock")
+		v := newConnValidator(time.Minute)
+		now := time.Now()
+
+		assert.True(t, v.validate(context.Background(), db, now))
+		assert.False(t, v.validate(context.Background(), db, now.Add(2*time.Minute)))
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+}
+
+func TestDBResolver_WithConnValidator(t *testing.T) {
+	t.Run("choose another db when validation fails", func(t *testing.T) {
+		mockDB1, sqlMock1, _ := sqlmock.New(sqlmock.MonitorPingsOption(true))
+		sqlMock1.ExpectPing().
+			WillReturnError(errors.New("mock error"))
+		mockReadDB1 := sqlx.NewDb(mockDB1, "read1")
+		mockDB2, sqlMock2, _ := sqlmock.New(
+			sqlmock.MonitorPingsOption(true),
+			sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual),
+		)
+		sqlMock2.ExpectPing()
+		sqlMock2.ExpectQuery(`SELECT first_name FROM person`).
+			WillReturnRows(sqlmock.NewRows([]string{"first_name"}).AddRow("foo"))
+		mockReadDB2 := sqlx.NewDb(mockDB2, "read2")
+		r := &dbResolver{
+			reads:         []*sqlx.DB{mockReadDB1, mockReadDB2},
+			loadBalancer:  &firstLoadBalancer{},
+			connValidator: newConnValidator(time.Minute),
+		}
+
+		var firstName string
+		err := r.GetContext(context.Background(), &firstName, `SELECT first_name FROM person`)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "foo", firstName)
+		assert.NoError(t, sqlMock1.ExpectationsWereMet())
+		assert.NoError(t, sqlMock2.ExpectationsWereMet())
+	})
+
+	t.Run("enabled by option", func(t *testing.T) {
+		mockDB, _, _ := sqlmock.New()
+		primaryDBsCfg := &PrimaryDBsConfig{
+			DBs: []*sqlx.DB{sqlx.NewDb(mockDB, "primary")},
+		}
+
+		r, err := NewDBResolver(primaryDBsCfg, WithConnValidator(time.Minute))
+
+		assert.NoError(t, err)
+		assert.Equal(t, time.Minute, r.(*dbResolver).connValidator.maxIdle)
+	})
+}